@@ -0,0 +1,74 @@
+package main
+
+import (
+	"fmt"
+	"os"
+
+	"agentic-poc/internal/config"
+	"agentic-poc/internal/provider"
+)
+
+// Response cache modes of the -cache flag.
+const (
+	cacheOff   = "off"   // Every request goes to the provider
+	cacheOn    = "on"    // Deterministic requests are cached
+	cacheForce = "force" // Every request is cached
+)
+
+// cacheFlag is the value of -cache. It works as a boolean flag, so -cache
+// alone turns the cache on, and also accepts -cache=force.
+type cacheFlag string
+
+func (f *cacheFlag) String() string {
+	if f == nil || *f == "" {
+		return cacheOff
+	}
+	return string(*f)
+}
+
+func (f *cacheFlag) Set(value string) error {
+	switch value {
+	case "true", cacheOn:
+		*f = cacheOn
+	case "false", cacheOff:
+		*f = cacheOff
+	case cacheForce:
+		*f = cacheForce
+	default:
+		return fmt.Errorf("must be on, off or force")
+	}
+	return nil
+}
+
+// IsBoolFlag lets -cache be given without a value.
+func (f *cacheFlag) IsBoolFlag() bool {
+	return true
+}
+
+// withResponseCache wraps p in a response cache in the default cache
+// directory, unless mode is cacheOff. It warns if the cache is on but the
+// configured temperature is not 0, since then only -cache=force caches
+// anything.
+func withResponseCache(p provider.LLMProvider, mode cacheFlag, cfg *config.Config) (provider.LLMProvider, error) {
+	if mode.String() == cacheOff {
+		return p, nil
+	}
+
+	dir, err := provider.DefaultResponseCacheDir()
+	if err != nil {
+		return nil, fmt.Errorf("-cache: %w", err)
+	}
+	var opts []provider.CacheOption
+	t := cfg.Provider.Temperature
+	if t != nil {
+		opts = append(opts, provider.WithProviderTemperature(*t))
+	}
+	if mode == cacheForce {
+		opts = append(opts, provider.WithCacheNonDeterministic())
+	} else if t == nil || *t != 0 {
+		// The provider's own default temperature is not 0, so nothing
+		// would be cached
+		fmt.Fprintln(os.Stderr, "Warning: -cache only caches requests with temperature 0; set -temperature 0 or provider.temperature, or use -cache=force")
+	}
+	return provider.NewCachingProvider(p, dir, opts...)
+}
@@ -0,0 +1,225 @@
+package main
+
+import (
+	"errors"
+	"flag"
+	"fmt"
+	"io"
+	"os"
+	"strings"
+	"text/tabwriter"
+	"time"
+
+	"agentic-poc/internal/config"
+	"agentic-poc/internal/provider"
+)
+
+// configFlagKeys maps the flags that override config settings to their keys.
+var configFlagKeys = map[string]string{
+	"provider":    "provider.name",
+	"model":       "provider.model",
+	"max-tokens":  "provider.maxTokens",
+	"temperature": "provider.temperature",
+	"run-timeout": "runTimeout",
+}
+
+// showValueLength is the number of characters of long values, such as
+// system prompts, shown by "config show".
+const showValueLength = 50
+
+// defineConfigFlags adds -config and the setting override flags to fs and
+// returns the -config flag.
+func defineConfigFlags(fs *flag.FlagSet) *string {
+	path := fs.String("config", config.ProjectFile, "Path to the project config file")
+	fs.String("provider", "", "LLM provider, claude or ollama (overrides provider.name)")
+	fs.String("model", "", "Model name (overrides provider.model)")
+	fs.Int("max-tokens", 0, "Output token limit per request (overrides provider.maxTokens)")
+	fs.Float64("temperature", 0, "Sampling temperature, 0 to 1 (overrides provider.temperature)")
+	fs.Duration("run-timeout", 0, "Time limit for each run or workflow, e.g. 5m (overrides runTimeout)")
+	return path
+}
+
+// loadConfig loads the layered config and applies the override flags that
+// were set on fs. A -config file given explicitly must exist.
+func loadConfig(fs *flag.FlagSet, path string) (*config.Config, error) {
+	explicit := false
+	fs.Visit(func(f *flag.Flag) {
+		if f.Name == "config" {
+			explicit = true
+		}
+	})
+	if explicit {
+		if _, err := os.Stat(path); err != nil {
+			return nil, fmt.Errorf("-config: %w", err)
+		}
+	}
+
+	cfg, err := config.Load(path, os.Getenv)
+	if err != nil {
+		return nil, err
+	}
+
+	var setErr error
+	fs.Visit(func(f *flag.Flag) {
+		key, ok := configFlagKeys[f.Name]
+		if !ok || setErr != nil {
+			return
+		}
+		if err := cfg.Set(key, f.Value.String(), "flag -"+f.Name); err != nil {
+			setErr = fmt.Errorf("-%s: %w", f.Name, err)
+		}
+	})
+	if setErr != nil {
+		return nil, setErr
+	}
+
+	if err := cfg.Validate(); err != nil {
+		return nil, err
+	}
+	return cfg, nil
+}
+
+// newProvider creates the LLM provider selected by the config. If an agent
+// uses a model of its own, or a fallback model is set, it returns a
+// provider.Router that sends each agent's requests to its model and
+// retries transient failures on the fallback model.
+func newProvider(cfg *config.Config) (provider.LLMProvider, error) {
+	def, err := newModelProvider(cfg, cfg.Provider.Model)
+	if err != nil {
+		return nil, err
+	}
+
+	var opts []provider.RouterOption
+	for _, name := range []string{config.AgentSingle, config.AgentArchitect, config.AgentCoder} {
+		model := cfg.Agents[name].Model
+		if model == "" || model == cfg.Provider.Model {
+			continue
+		}
+		p, err := newModelProvider(cfg, model)
+		if err != nil {
+			return nil, err
+		}
+		opts = append(opts, provider.WithRoleRoute(name, p))
+	}
+	if model := cfg.Provider.FallbackModel; model != "" {
+		p, err := newModelProvider(cfg, model)
+		if err != nil {
+			return nil, err
+		}
+		opts = append(opts, provider.WithFallback(p))
+	}
+
+	if len(opts) == 0 {
+		return def, nil
+	}
+	return provider.NewRouter(def, opts...), nil
+}
+
+// newModelProvider creates a provider of the configured kind, "claude" or
+// "ollama", for model.
+func newModelProvider(cfg *config.Config, model string) (provider.LLMProvider, error) {
+	p := cfg.Provider
+	switch p.Name {
+	case "claude":
+		opts := []provider.ClaudeOption{
+			provider.WithModel(model),
+			provider.WithMaxTokens(p.MaxTokens),
+			provider.WithTimeout(time.Duration(p.Timeout)),
+		}
+		if p.Temperature != nil {
+			opts = append(opts, provider.WithTemperature(*p.Temperature))
+		}
+		return provider.NewClaudeProvider(opts...)
+	case "ollama":
+		// The built-in default model is a Claude model
+		if cfg.Source("provider.model") == config.SourceDefault && model == p.Model {
+			model = provider.DefaultOllamaModel
+		}
+		opts := []provider.OllamaOption{
+			provider.WithOllamaModel(model),
+			provider.WithOllamaMaxTokens(p.MaxTokens),
+			provider.WithOllamaTimeout(time.Duration(p.Timeout)),
+		}
+		if p.Temperature != nil {
+			opts = append(opts, provider.WithOllamaTemperature(*p.Temperature))
+		}
+		return provider.NewOllamaProvider(opts...), nil
+	default:
+		return nil, fmt.Errorf("unknown provider %q (set by %s)", p.Name, cfg.Source("provider.name"))
+	}
+}
+
+// runConfigCommand runs "agent config <subcommand>" and returns the exit
+// code.
+func runConfigCommand(args []string) int {
+	if len(args) == 0 || args[0] != "show" {
+		fmt.Fprintln(os.Stderr, "Usage: agent config show [-config file] [setting flags]")
+		return exitUsage
+	}
+
+	fs := flag.NewFlagSet("config show", flag.ContinueOnError)
+	path := defineConfigFlags(fs)
+	if err := fs.Parse(args[1:]); err != nil {
+		if errors.Is(err, flag.ErrHelp) {
+			return exitSuccess
+		}
+		return exitUsage
+	}
+
+	cfg, err := loadConfig(fs, *path)
+	if err != nil {
+		fmt.Fprintf(os.Stderr, "Error: %v\n", err)
+		return exitUsage
+	}
+
+	showConfig(os.Stdout, cfg, *path)
+	return exitSuccess
+}
+
+// showConfig prints the config files consulted and every effective setting
+// with its source.
+func showConfig(w io.Writer, cfg *config.Config, projectPath string) {
+	userPath, _ := config.UserConfigPath()
+
+	fmt.Fprintln(w, "Config files:")
+	tw := tabwriter.NewWriter(w, 0, 0, 2, ' ', 0)
+	for _, file := range []struct{ name, path string }{{"user", userPath}, {"project", projectPath}} {
+		status := ""
+		if _, err := os.Stat(file.path); err != nil {
+			status = " (not found)"
+		}
+		fmt.Fprintf(tw, "  %s\t%s%s\n", file.name, file.path, status)
+	}
+	tw.Flush()
+
+	fmt.Fprintln(w)
+	tw = tabwriter.NewWriter(w, 0, 0, 2, ' ', 0)
+	fmt.Fprintln(tw, "SETTING\tVALUE\tSOURCE")
+	for _, s := range cfg.Settings() {
+		fmt.Fprintf(tw, "%s\t%s\t%s\n", s.Key, displayValue(s.Key, s.Value), s.Source)
+	}
+	tw.Flush()
+}
+
+// displayValue shortens a setting's value to one line for "config show".
+func displayValue(key, value string) string {
+	switch {
+	case value == "" && key == "provider.temperature":
+		return "(provider default)"
+	case value == "" && key == "provider.fallbackModel":
+		return "(none)"
+	case value == "" && strings.HasSuffix(key, ".model"):
+		return "(provider.model)"
+	case value == "" && strings.HasSuffix(key, ".tools"):
+		return "(none)"
+	case value == "":
+		return `""`
+	}
+
+	line := strings.Join(strings.Fields(value), " ")
+	runes := []rune(line)
+	if len(runes) <= showValueLength {
+		return line
+	}
+	return fmt.Sprintf("%s... (%d chars)", string(runes[:showValueLength]), len(value))
+}
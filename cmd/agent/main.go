@@ -3,72 +3,157 @@ package main
 
 import (
 	"context"
+	"errors"
 	"flag"
 	"fmt"
+	"io"
 	"os"
+	"os/signal"
 
 	"agentic-poc/internal/cli"
-	"agentic-poc/internal/provider"
+)
+
+// Exit codes. A one-shot run exits with exitFailure when the agent or
+// workflow fails, exitUsage when the flags or prompt are invalid, and
+// exitInterrupted when it is cancelled with Ctrl-C.
+const (
+	exitSuccess     = 0
+	exitFailure     = 1
+	exitUsage       = 2
+	exitInterrupted = cli.ExitInterrupted
 )
 
 func main() {
+	if len(os.Args) > 1 && os.Args[1] == "config" {
+		os.Exit(runConfigCommand(os.Args[2:]))
+	}
+
 	// Define command-line flags
-	mode := flag.String("mode", "single", "Mode to run: 'single' for single-agent mode, 'multi' for multi-agent mode")
+	mode := flag.String("mode", cli.ModeSingle, "Mode to run: 'single' for single-agent mode, 'multi' for multi-agent mode")
 	basePath := flag.String("path", ".", "Base path for file operations")
 	mcpOnly := flag.Bool("mcp-only", false, "Use only MCP tools (no built-in tools). Requires mcp.json config.")
 	mcpConfig := flag.String("mcp-config", "mcp.json", "Path to MCP configuration file")
+	mcpLogLevel := flag.String("mcp-log-level", "", "Show MCP server log messages at this level and above (e.g. debug, info, warning)")
+	prompt := flag.String("prompt", "", "Run a single prompt or goal non-interactively and exit")
+	promptFile := flag.String("prompt-file", "", "Read the prompt or goal for a non-interactive run from a file")
+	output := flag.String("output", cli.OutputText, "Result format for non-interactive runs: 'text' or 'json'")
+	showThinking := flag.Bool("show-thinking", false, "Print the model's extended thinking for agents with a thinking budget")
+	var cache cacheFlag
+	flag.Var(&cache, "cache", "Reuse cached responses to identical requests: -cache for requests with temperature 0, -cache=force for all")
+	configPath := defineConfigFlags(flag.CommandLine)
 	help := flag.Bool("help", false, "Show help message")
 
 	flag.Parse()
 
 	if *help {
 		printUsage()
-		os.Exit(0)
+		os.Exit(exitSuccess)
 	}
 
 	// Validate mode
-	if *mode != "single" && *mode != "multi" {
+	if *mode != cli.ModeSingle && *mode != cli.ModeMulti {
 		fmt.Fprintf(os.Stderr, "Error: invalid mode '%s'. Use 'single' or 'multi'.\n", *mode)
 		printUsage()
-		os.Exit(1)
+		os.Exit(exitUsage)
+	}
+
+	// A prompt from a flag, a file or piped stdin selects a one-shot run
+	oneShotPrompt, err := cli.ReadPrompt(*prompt, *promptFile, pipedStdin())
+	if err != nil {
+		fmt.Fprintf(os.Stderr, "Error: %v\n", err)
+		os.Exit(exitUsage)
+	}
+
+	cfg, err := loadConfig(flag.CommandLine, *configPath)
+	if err != nil {
+		fmt.Fprintf(os.Stderr, "Error: %v\n", err)
+		os.Exit(exitUsage)
 	}
 
 	// Create the LLM provider
-	llmProvider, err := provider.NewClaudeProvider()
+	llmProvider, err := newProvider(cfg)
 	if err != nil {
 		fmt.Fprintf(os.Stderr, "Error creating LLM provider: %v\n", err)
-		fmt.Fprintln(os.Stderr, "Make sure ANTHROPIC_API_KEY environment variable is set.")
-		os.Exit(1)
+		if cfg.Provider.Name == "claude" {
+			fmt.Fprintln(os.Stderr, "Make sure ANTHROPIC_API_KEY environment variable is set.")
+		}
+		os.Exit(exitFailure)
+	}
+	llmProvider, err = withResponseCache(llmProvider, cache, cfg)
+	if err != nil {
+		fmt.Fprintf(os.Stderr, "Error: %v\n", err)
+		os.Exit(exitFailure)
 	}
 
 	// Create the CLI
 	cliInstance := cli.NewCLI(llmProvider)
+	cliInstance.SetConfig(cfg)
 	cliInstance.SetBasePath(*basePath)
 	cliInstance.SetMCPOnly(*mcpOnly)
+	cliInstance.SetShowThinking(*showThinking)
+	if err := cliInstance.SetMCPLogLevel(*mcpLogLevel); err != nil {
+		fmt.Fprintf(os.Stderr, "Error: -mcp-log-level: %v\n", err)
+		os.Exit(exitUsage)
+	}
+	if err := cliInstance.SetOutputFormat(*output); err != nil {
+		fmt.Fprintf(os.Stderr, "Error: -output: %v\n", err)
+		os.Exit(exitUsage)
+	}
+
+	// Ctrl-C cancels the current run; a second Ctrl-C exits
+	interrupts := make(chan os.Signal, 1)
+	signal.Notify(interrupts, os.Interrupt)
+	cliInstance.HandleInterrupts(interrupts)
+
+	os.Exit(run(cliInstance, *mode, *mcpOnly, *mcpConfig, oneShotPrompt))
+}
+
+// run loads the MCP config and runs the selected mode, interactively or
+// once if prompt is set, and returns the exit code. It is separate from
+// main so the deferred Shutdown runs before the process exits.
+func run(cliInstance *cli.CLI, mode string, mcpOnly bool, mcpConfig, prompt string) int {
 	defer cliInstance.Shutdown()
+	ctx := context.Background()
 
 	// Load MCP config if mcp-only mode or if config exists
-	if *mcpOnly {
-		ctx := context.Background()
-		if err := cliInstance.LoadMCPConfig(ctx, *mcpConfig); err != nil {
+	if mcpOnly {
+		if err := cliInstance.LoadMCPConfig(ctx, mcpConfig); err != nil {
 			fmt.Fprintf(os.Stderr, "Error loading MCP config: %v\n", err)
-			os.Exit(1)
+			return exitFailure
 		}
 	}
 
 	// Run the appropriate mode
 	var runErr error
-	switch *mode {
-	case "single":
+	switch {
+	case mode == cli.ModeSingle && prompt != "":
+		runErr = cliInstance.RunSingleAgentOnce(ctx, prompt)
+	case mode == cli.ModeMulti && prompt != "":
+		runErr = cliInstance.RunMultiAgentOnce(ctx, prompt)
+	case mode == cli.ModeSingle:
 		runErr = cliInstance.RunSingleAgentMode()
-	case "multi":
+	case mode == cli.ModeMulti:
 		runErr = cliInstance.RunMultiAgentMode()
 	}
 
 	if runErr != nil {
 		fmt.Fprintf(os.Stderr, "Error: %v\n", runErr)
-		os.Exit(1)
+		if errors.Is(runErr, context.Canceled) {
+			return exitInterrupted
+		}
+		return exitFailure
 	}
+	return exitSuccess
+}
+
+// pipedStdin returns os.Stdin if it is a pipe or file rather than a
+// terminal, so a prompt can be piped in, and nil otherwise.
+func pipedStdin() io.Reader {
+	info, err := os.Stdin.Stat()
+	if err != nil || info.Mode()&os.ModeCharDevice != 0 {
+		return nil
+	}
+	return os.Stdin
 }
 
 // printUsage prints the usage information.
@@ -77,6 +162,8 @@ func printUsage() {
 	fmt.Println()
 	fmt.Println("Usage:")
 	fmt.Println("  agent [flags]")
+	fmt.Println("  agent config show [-config file] [setting flags]")
+	fmt.Println("        Print the effective config and where each setting came from")
 	fmt.Println()
 	fmt.Println("Flags:")
 	fmt.Println("  -mode string")
@@ -87,11 +174,56 @@ func printUsage() {
 	fmt.Println("        Use only MCP tools instead of built-in tools. Requires mcp.json config.")
 	fmt.Println("  -mcp-config string")
 	fmt.Println("        Path to MCP configuration file (default \"mcp.json\")")
+	fmt.Println("        Merged over the user-level ~/.config/agentic-poc/mcp.json if present.")
+	fmt.Println("  -mcp-log-level string")
+	fmt.Println("        Show MCP server log messages at this level and above:")
+	fmt.Println("        debug, info, notice, warning, error, critical, alert, emergency")
+	fmt.Println("  -prompt string")
+	fmt.Println("        Run a single prompt (single mode) or goal (multi mode) and exit")
+	fmt.Println("  -prompt-file string")
+	fmt.Println("        Like -prompt, but read the prompt from a file.")
+	fmt.Println("        If neither is given and stdin is not a terminal, stdin is read instead.")
+	fmt.Println("  -output string")
+	fmt.Println("        Result format for non-interactive runs: 'text' or 'json' (default \"text\")")
+	fmt.Println("  -cache, -cache=force")
+	fmt.Println("        Store responses on disk and reuse them for identical requests for 24 hours.")
+	fmt.Println("        Only requests with temperature 0 and no extended thinking are cached,")
+	fmt.Println("        unless -cache=force is given. No temperature is set by default, so use")
+	fmt.Println("        -temperature 0 or set provider.temperature to 0 with plain -cache.")
+	fmt.Println("  -show-thinking")
+	fmt.Println("        Print the model's extended thinking as it runs. Enable thinking per agent")
+	fmt.Println("        with agents.<name>.thinkingBudget in the config file.")
+	fmt.Println("  -config string")
+	fmt.Println("        Path to the project config file (default \".agentic.json\")")
+	fmt.Println("        Merged over the user-level ~/.config/agentic-poc/config.json if present.")
+	fmt.Println("  -provider string")
+	fmt.Println("        LLM provider, claude or ollama (overrides provider.name)")
+	fmt.Println("  -model string")
+	fmt.Println("        Model name (overrides provider.model)")
+	fmt.Println("  -max-tokens int")
+	fmt.Println("        Output token limit per request (overrides provider.maxTokens)")
+	fmt.Println("  -temperature float")
+	fmt.Println("        Sampling temperature, 0 to 1 (overrides provider.temperature)")
+	fmt.Println("  -run-timeout duration")
+	fmt.Println("        Time limit for each run or workflow, e.g. 5m (overrides runTimeout)")
 	fmt.Println("  -help")
 	fmt.Println("        Show this help message")
 	fmt.Println()
+	fmt.Println("Interactive Commands:")
+	fmt.Println("  Type /help in a session to list slash commands, such as /tools, /clear,")
+	fmt.Println("  /save, /load, /model, /mode, /mcp and /plan.")
+	fmt.Println("  Ctrl-C cancels the current run and returns to the prompt, showing the")
+	fmt.Println("  tool calls and plan made so far. Press Ctrl-C again to exit.")
+	fmt.Println("  In single mode, @path in a prompt attaches a file: images and PDFs are")
+	fmt.Println("  sent as such, other text files are inlined. A word needs a slash or an")
+	fmt.Println("  image or PDF extension to count as a path, e.g. @./notes.txt.")
+	fmt.Println()
 	fmt.Println("Environment Variables:")
-	fmt.Println("  ANTHROPIC_API_KEY    API key for Claude (required)")
+	fmt.Println("  ANTHROPIC_API_KEY    API key for Claude (required for the claude provider)")
+	fmt.Println("  OLLAMA_HOST          Address of the Ollama server (default localhost:11434)")
+	fmt.Println("  AGENTIC_PROVIDER, AGENTIC_MODEL, AGENTIC_FALLBACK_MODEL, AGENTIC_MAX_TOKENS,")
+	fmt.Println("  AGENTIC_TEMPERATURE, AGENTIC_TIMEOUT, AGENTIC_RUN_TIMEOUT")
+	fmt.Println("                       Override config file settings; flags override these")
 	fmt.Println()
 	fmt.Println("Examples:")
 	fmt.Println("  # Run in single-agent mode with built-in tools (default)")
@@ -105,4 +237,11 @@ func printUsage() {
 	fmt.Println()
 	fmt.Println("  # Run with a specific base path for file operations")
 	fmt.Println("  agent -mode single -path /tmp/workspace")
+	fmt.Println()
+	fmt.Println("  # Run one workflow from a script and capture the result as JSON")
+	fmt.Println("  agent -mode multi -prompt-file goal.txt -output json > result.json")
+	fmt.Println()
+	fmt.Println("Exit Status:")
+	fmt.Println("  0 on success, 1 if the run failed, 2 if the flags or prompt are invalid,")
+	fmt.Println("  130 if the run was interrupted with Ctrl-C.")
 }
@@ -4,24 +4,35 @@ package main
 
 import (
 	"context"
+	"flag"
+	"fmt"
+	"io"
 	"log"
 	"os"
 	"os/signal"
+	"strings"
 	"syscall"
 
+	"agentic-poc/internal/agent"
 	"agentic-poc/internal/mcp"
+	"agentic-poc/internal/orchestrator"
+	"agentic-poc/internal/provider"
 	"agentic-poc/internal/tool"
 )
 
 func main() {
-	// Create tools to expose
-	tools := []tool.Tool{
-		tool.NewCalculatorTool(),
-		tool.NewFileReaderTool("."), // Use current directory as base
-	}
+	gatewayConfig := flag.String("gateway", "", "Run as a gateway re-exporting the tools of the MCP servers in this config file")
+	agents := flag.Bool("agents", false, "Also expose run_architect and run_workflow (requires ANTHROPIC_API_KEY)")
+	toolNames := flag.String("tools", "calculator,read_file", "Comma-separated built-in tools to expose (available: "+strings.Join(tool.BuiltinNames(), ", ")+")")
+	basePath := flag.String("path", ".", "Base path for file tools")
+	readOnly := flag.Bool("read-only", false, "Hide and refuse tools that are not annotated as read-only")
+	name := flag.String("name", "", "Server name reported to clients (default \"agentic-poc-tools\", or \"agentic-poc-gateway\" with -gateway)")
+	// Not -version, which by convention prints the program's version and exits
+	version := flag.String("server-version", "1.0.0", "Server version reported to clients")
+	flag.Parse()
 
-	// Create MCP server
-	server := mcp.NewMCPServer("agentic-poc-tools", "1.0.0", tools)
+	// Redirect logs to stderr to not interfere with JSON-RPC
+	log.SetOutput(os.Stderr)
 
 	// Setup context with cancellation on signals
 	ctx, cancel := context.WithCancel(context.Background())
@@ -34,11 +45,108 @@ func main() {
 		cancel()
 	}()
 
+	var tools []tool.Tool
+	var opts []mcp.ServerOption
+	serverName := "agentic-poc-tools"
+
+	if *readOnly {
+		opts = append(opts, mcp.WithReadOnly())
+	}
+
+	if *gatewayConfig != "" {
+		// Gateway mode serves the upstream tools instead of the built-in ones
+		gateway, shutdown, err := startGateway(ctx, *gatewayConfig)
+		if err != nil {
+			log.Fatalf("Failed to start gateway: %v", err)
+		}
+		defer shutdown()
+
+		opts = append(opts, mcp.WithToolSource(gateway))
+		serverName = "agentic-poc-gateway"
+	} else {
+		builtins, err := builtinTools(*toolNames, *basePath)
+		if err != nil {
+			log.Fatalf("Invalid -tools: %v", err)
+		}
+		tools = builtins
+	}
+
+	if *agents {
+		llmProvider, err := provider.NewClaudeProvider()
+		if err != nil {
+			log.Fatalf("Failed to create LLM provider: %v", err)
+		}
+		tools = append(tools,
+			agent.NewArchitectTool(llmProvider),
+			orchestrator.NewWorkflowTool(orchestrator.NewOrchestrator(llmProvider, *basePath)),
+		)
+	}
+
+	if *name != "" {
+		serverName = *name
+	}
+
+	// Create MCP server
+	server := mcp.NewMCPServer(serverName, *version, tools, opts...)
+
 	// Run server on stdin/stdout
-	log.SetOutput(os.Stderr) // Redirect logs to stderr to not interfere with JSON-RPC
 	if err := server.Serve(ctx, os.Stdin, os.Stdout); err != nil {
 		if err != context.Canceled {
 			log.Fatalf("Server error: %v", err)
 		}
 	}
 }
+
+// builtinTools creates the built-in tools named in the comma-separated list.
+func builtinTools(names, basePath string) ([]tool.Tool, error) {
+	var tools []tool.Tool
+	for _, name := range strings.Split(names, ",") {
+		name = strings.TrimSpace(name)
+		if name == "" {
+			continue
+		}
+		t, err := tool.NewBuiltinTool(name, basePath)
+		if err != nil {
+			return nil, err
+		}
+		tools = append(tools, t)
+	}
+	return tools, nil
+}
+
+// startGateway connects to the upstream servers in configPath and returns a
+// Gateway over their tools, along with a function that releases its resources.
+func startGateway(ctx context.Context, configPath string) (*mcp.Gateway, func(), error) {
+	cfg, err := mcp.LoadMCPConfig(configPath)
+	if err != nil {
+		return nil, nil, err
+	}
+
+	var audit io.WriteCloser
+	if cfg.Gateway.AuditLog != "" {
+		audit, err = os.OpenFile(cfg.Gateway.AuditLog, os.O_CREATE|os.O_APPEND|os.O_WRONLY, 0600)
+		if err != nil {
+			return nil, nil, fmt.Errorf("failed to open audit log: %w", err)
+		}
+	}
+
+	manager := mcp.NewMCPManager()
+	if err := manager.LoadFromConfig(ctx, cfg); err != nil {
+		manager.Shutdown()
+		if audit != nil {
+			audit.Close()
+		}
+		return nil, nil, fmt.Errorf("failed to load upstream servers: %w", err)
+	}
+
+	gateway := mcp.NewGateway(manager, cfg.Gateway, audit)
+	log.Printf("[MCP Gateway] Exposing %d of %d upstream tools", len(gateway.GetTools()), manager.ToolCount())
+
+	shutdown := func() {
+		manager.Shutdown()
+		if audit != nil {
+			audit.Close()
+		}
+	}
+	return gateway, shutdown, nil
+}
@@ -3,8 +3,14 @@ package agent
 
 import (
 	"context"
+	"encoding/json"
 	"errors"
 	"fmt"
+	"log"
+	"sort"
+	"strings"
+	"sync"
+	"unicode"
 
 	"agentic-poc/internal/memory"
 	"agentic-poc/internal/provider"
@@ -16,30 +22,163 @@ const (
 	DefaultMaxIterations = 10
 )
 
+// Roles of the agents built by NewArchitectAgent and NewCoderAgent. The
+// config package uses them as the agents' names, so renaming one renames
+// its section of the config file.
+const (
+	RoleArchitect = "architect"
+	RoleCoder     = "coder"
+)
+
+// DefaultCachePolicy caches the system prompt, the tools and the
+// conversation up to the latest message, so each iteration of a run reads
+// the previous iteration's prefix from the provider's prompt cache.
+var DefaultCachePolicy = provider.CachePolicy{System: true, Tools: true, Messages: 1}
+
 // ErrMaxIterationsExceeded is returned when the agent loop reaches the maximum number of iterations.
 var ErrMaxIterationsExceeded = errors.New("max iterations exceeded")
 
+// ErrResponseTruncated is returned when the model reaches its output token
+// limit in a way the agent cannot continue from, such as in a tool call.
+var ErrResponseTruncated = errors.New("response truncated at the output token limit")
+
 // AgentConfig holds configuration for creating a new Agent.
+// Tools is a fixed set; ToolProvider, if set, contributes additional tools
+// that are re-read on every iteration.
+// RequiredTool, if set, names a tool the model must call successfully
+// before it finishes: a final answer given without calling it is discarded
+// and the model is asked again with the tool forced.
+// Cache selects what the provider should cache; nil uses
+// DefaultCachePolicy and a zero CachePolicy disables caching.
+// ThinkingBudget, if positive, enables extended thinking with that many
+// tokens of reasoning per LLM call, until the run forces the required tool.
+// Role names the agent to the provider, such as "architect", so that a
+// provider.Router can pick a backend for it.
+// OutputSchema, if set, is the JSON Schema of the agent's answer, which
+// must describe an object, such as one derived from a Go type with
+// tool.SchemaFor. The agent is given a tool named OutputTool (or
+// DefaultOutputTool) whose arguments are the answer, and is required to
+// finish by calling it in place of RequiredTool: arguments that do not
+// match the schema go back to the model as a validation error to correct,
+// and the run ends with the first valid answer in AgentResult.Structured.
 type AgentConfig struct {
-	Provider      provider.LLMProvider
-	Tools         []tool.Tool
-	SystemPrompt  string
-	MaxIterations int
+	Provider       provider.LLMProvider
+	Tools          []tool.Tool
+	ToolProvider   tool.Provider
+	SystemPrompt   string
+	MaxIterations  int
+	RequiredTool   string
+	Cache          *provider.CachePolicy
+	ThinkingBudget int
+	Role           string
+	OutputSchema   map[string]interface{}
+	OutputTool     string
+}
+
+// Option customizes the AgentConfig of an agent built by a constructor
+// such as NewArchitectAgent. Options given zero values keep the default.
+type Option func(*AgentConfig)
+
+// WithSystemPrompt replaces the agent's system prompt.
+func WithSystemPrompt(prompt string) Option {
+	return func(cfg *AgentConfig) {
+		if prompt != "" {
+			cfg.SystemPrompt = prompt
+		}
+	}
+}
+
+// WithTools replaces the agent's tools. A nil slice keeps the defaults;
+// an empty one removes them.
+func WithTools(tools []tool.Tool) Option {
+	return func(cfg *AgentConfig) {
+		if tools != nil {
+			cfg.Tools = tools
+		}
+	}
+}
+
+// WithMaxIterations sets the agent's iteration limit.
+func WithMaxIterations(n int) Option {
+	return func(cfg *AgentConfig) {
+		if n > 0 {
+			cfg.MaxIterations = n
+		}
+	}
+}
+
+// WithThinkingBudget enables extended thinking with up to n tokens of
+// reasoning per LLM call.
+func WithThinkingBudget(n int) Option {
+	return func(cfg *AgentConfig) {
+		if n > 0 {
+			cfg.ThinkingBudget = n
+		}
+	}
+}
+
+// ThinkingFunc receives the model's reasoning as an agent run produces it.
+type ThinkingFunc func(thinking string)
+
+// thinkingKey is the context key for the ThinkingFunc.
+type thinkingKey struct{}
+
+// WithThinkingHandler returns a context in which agent runs pass the
+// model's extended thinking to fn, such as to show it to the user.
+// Redacted thinking is not passed on.
+func WithThinkingHandler(ctx context.Context, fn ThinkingFunc) context.Context {
+	return context.WithValue(ctx, thinkingKey{}, fn)
+}
+
+// reportThinking passes the readable thinking of a response to the
+// handler in ctx, if any.
+func reportThinking(ctx context.Context, thinking []provider.Thinking) {
+	fn, ok := ctx.Value(thinkingKey{}).(ThinkingFunc)
+	if !ok || fn == nil {
+		return
+	}
+	for _, t := range thinking {
+		if t.Text != "" {
+			fn(t.Text)
+		}
+	}
+}
+
+// providerContext returns the context for an LLM call, carrying the
+// agent's role if it has one.
+func (a *Agent) providerContext(ctx context.Context) context.Context {
+	if a.role == "" {
+		return ctx
+	}
+	return provider.WithRole(ctx, a.role)
 }
 
 // AgentResult represents the result of an agent run.
 type AgentResult struct {
-	Response      string
-	ToolCallsMade []provider.ToolCall
-	Iterations    int
+	Response      string              `json:"response"`
+	ToolCallsMade []provider.ToolCall `json:"tool_calls"`
+	Iterations    int                 `json:"iterations"`
+	Usage         provider.Usage      `json:"usage"` // Summed over every LLM call in the run
+	// Structured is the answer of an agent with an output schema, as
+	// validated against it; nil otherwise. Response then holds it as JSON.
+	Structured map[string]interface{} `json:"structured,omitempty"`
 }
 
 // Agent implements the Think -> Act -> Observe loop for interacting with an LLM.
 type Agent struct {
 	provider      provider.LLMProvider
 	tools         map[string]tool.Tool
+	toolProvider  tool.Provider
 	systemPrompt  string
 	maxIterations int
+	requiredTool  string
+	cache         *provider.CachePolicy // Nil if caching is disabled
+	thinking      int                   // Extended thinking budget; 0 if disabled
+	role          string                // Passed to the provider with provider.WithRole
+	outputTool    string                // Tool whose valid arguments end the run; "" without an output schema
+
+	// reportedOverrides remembers shadowed tool names already logged
+	reportedOverrides sync.Map
 }
 
 // NewAgent creates a new Agent with the given configuration.
@@ -53,31 +192,100 @@ func NewAgent(cfg AgentConfig) *Agent {
 	// Build tool map for quick lookup by name
 	toolMap := make(map[string]tool.Tool)
 	for _, t := range cfg.Tools {
+		if _, exists := toolMap[t.Name()]; exists {
+			log.Printf("[Agent] Duplicate tool %q in config; the later one replaces the earlier", t.Name())
+		}
 		toolMap[t.Name()] = t
 	}
 
+	requiredTool := cfg.RequiredTool
+	var outputName string
+	if cfg.OutputSchema != nil {
+		outputName = cfg.OutputTool
+		if outputName == "" {
+			outputName = DefaultOutputTool
+		}
+		if _, exists := toolMap[outputName]; exists {
+			log.Printf("[Agent] Tool %q replaced by the output schema's tool", outputName)
+		}
+		toolMap[outputName] = &outputTool{name: outputName, schema: cfg.OutputSchema}
+		requiredTool = outputName
+	}
+
+	cache := &DefaultCachePolicy
+	if cfg.Cache != nil {
+		cache = cfg.Cache
+	}
+	if *cache == (provider.CachePolicy{}) {
+		cache = nil
+	}
+
 	return &Agent{
 		provider:      cfg.Provider,
 		tools:         toolMap,
+		toolProvider:  cfg.ToolProvider,
 		systemPrompt:  cfg.SystemPrompt,
 		maxIterations: maxIter,
+		requiredTool:  requiredTool,
+		cache:         cache,
+		thinking:      cfg.ThinkingBudget,
+		role:          cfg.Role,
+		outputTool:    outputName,
 	}
 }
 
-// RegisterTool adds a tool to the agent's tool registry.
+// RegisterTool adds a tool to the agent's tool registry, replacing any
+// registered tool with the same name.
 func (a *Agent) RegisterTool(t tool.Tool) {
+	if _, exists := a.tools[t.Name()]; exists {
+		log.Printf("[Agent] Tool %q replaced by RegisterTool", t.Name())
+	}
 	a.tools[t.Name()] = t
 }
 
-// GetTools returns a slice of all registered tools.
+// GetTools returns a slice of all registered tools, including the current
+// tools from the ToolProvider.
 func (a *Agent) GetTools() []tool.Tool {
-	tools := make([]tool.Tool, 0, len(a.tools))
-	for _, t := range a.tools {
+	current := a.currentTools()
+	tools := make([]tool.Tool, 0, len(current))
+	for _, t := range current {
 		tools = append(tools, t)
 	}
 	return tools
 }
 
+// currentTools returns the registered tools merged with the latest tools
+// from the ToolProvider. A provider tool never replaces a registered tool
+// of the same name; the conflict is logged instead.
+func (a *Agent) currentTools() map[string]tool.Tool {
+	if a.toolProvider == nil {
+		return a.tools
+	}
+
+	dynamic := a.toolProvider.GetTools()
+	tools := make(map[string]tool.Tool, len(a.tools)+len(dynamic))
+	for name, t := range a.tools {
+		tools[name] = t
+	}
+	for _, t := range dynamic {
+		name := t.Name()
+		if _, exists := tools[name]; exists {
+			a.reportOverride(name)
+			continue
+		}
+		tools[name] = t
+	}
+	return tools
+}
+
+// reportOverride logs, once per name, that a provider tool was ignored
+// because a tool with the same name is already registered.
+func (a *Agent) reportOverride(name string) {
+	if _, seen := a.reportedOverrides.LoadOrStore(name, true); !seen {
+		log.Printf("[Agent] Ignoring tool %q from tool provider: a registered tool has the same name", name)
+	}
+}
+
 // Run executes the agent loop with the given input and conversation memory.
 // It implements the Think -> Act -> Observe loop:
 // 1. Add user input to memory
@@ -85,53 +293,178 @@ func (a *Agent) GetTools() []tool.Tool {
 // 3. If no tool calls, return response
 // 4. Execute tool calls, add results to memory
 // 5. Repeat until max iterations or final response
+//
+// A text answer cut off at the output token limit is continued: the next
+// call sends the partial answer as the start of the assistant's turn, and
+// the parts are joined into one response. Each continuation counts as an
+// iteration. A response cut off in a tool call fails with
+// ErrResponseTruncated, as its arguments may be incomplete.
+//
+// If ctx is cancelled or times out, Run stops before the next LLM call or
+// tool call and returns the progress so far (the tool calls made, the
+// iterations and the usage) together with an error wrapping ctx.Err(). The
+// memory may then end with tool calls that have no results. Other errors
+// return a nil result.
 func (a *Agent) Run(ctx context.Context, input string, mem *memory.ConversationMemory) (*AgentResult, error) {
+	return a.RunWithContent(ctx, input, nil, mem)
+}
+
+// RunWithContent is like Run, but the user's message also carries content
+// blocks, such as attached images or documents. The blocks are sent in
+// place of input, so they should include input as a text block; input is
+// kept as the message's text form.
+func (a *Agent) RunWithContent(ctx context.Context, input string, blocks []provider.ContentBlock, mem *memory.ConversationMemory) (*AgentResult, error) {
 	// Add user input to memory
-	mem.AddMessage("user", input)
+	if len(blocks) > 0 {
+		mem.AddMessageWithContent("user", input, blocks)
+	} else {
+		mem.AddMessage("user", input)
+	}
 
 	// Track all tool calls made during this run
 	allToolCalls := make([]provider.ToolCall, 0)
+	var usage provider.Usage
 
-	// Build tool definitions for LLM
-	toolDefs := a.buildToolDefinitions()
+	var partial string // A text answer cut off at the token limit, to be continued
+	var toolChoice *provider.ToolChoice
+	forced := false
+	requiredCalled := a.requiredTool == ""
+	// Claude does not think on a request that forces a tool, and rejects a
+	// later request with thinking whose history has a tool call made
+	// without it, so forcing a tool turns thinking off for the whole run
+	thinking := a.thinking
 
 	for iteration := 1; iteration <= a.maxIterations; iteration++ {
-		// Think: Call LLM with current context
+		if ctx.Err() != nil {
+			return interrupted(ctx, allToolCalls, iteration-1, usage)
+		}
+
+		// Refresh the tool set so tools that appeared or vanished since the
+		// last iteration are reflected in this call
+		tools := a.currentTools()
+
+		tool.ReportProgress(ctx, fmt.Sprintf("Iteration %d: thinking", iteration))
+
+		// Think: Call LLM with current context, prefilling the assistant's
+		// turn with a truncated answer so the model carries on from there
+		messages := mem.GetMessages()
+		if partial != "" {
+			messages = append(messages, provider.Message{Role: "assistant", Content: partial})
+		}
 		req := provider.GenerateRequest{
-			Messages:     mem.GetMessages(),
-			Tools:        toolDefs,
-			SystemPrompt: a.systemPrompt,
+			Messages:       messages,
+			Tools:          a.buildToolDefinitions(tools),
+			SystemPrompt:   a.systemPrompt,
+			ToolChoice:     toolChoice,
+			Cache:          a.cache,
+			ThinkingBudget: thinking,
 		}
+		toolChoice = nil
 
-		resp, err := a.provider.Generate(ctx, req)
+		resp, err := a.provider.Generate(a.providerContext(ctx), req)
 		if err != nil {
+			if ctx.Err() != nil {
+				return interrupted(ctx, allToolCalls, iteration, usage)
+			}
 			return nil, fmt.Errorf("LLM generation failed: %w", err)
 		}
+		usage = usage.Add(resp.Usage)
+		reportThinking(ctx, resp.Thinking)
+		text := partial + resp.Text
+		partial = ""
+
+		if resp.Truncated() {
+			if resp.HasToolCalls() {
+				last := resp.ToolCalls[len(resp.ToolCalls)-1]
+				return nil, fmt.Errorf("%w: the call to tool '%s' may be incomplete", ErrResponseTruncated, last.Name)
+			}
+			if strings.TrimSpace(resp.Text) == "" {
+				return nil, fmt.Errorf("%w: no text was produced", ErrResponseTruncated)
+			}
+			// Claude does not accept a prefilled answer with thinking enabled
+			if thinking > 0 {
+				return nil, fmt.Errorf("%w: answers cannot be continued with extended thinking enabled", ErrResponseTruncated)
+			}
+			// The API rejects an assistant prefill that ends in whitespace
+			partial = strings.TrimRightFunc(text, unicode.IsSpace)
+			log.Printf("[Agent] Response reached the output token limit after %d characters; continuing", len(partial))
+			continue
+		}
 
 		// Check if this is a final response (no tool calls)
 		if !resp.HasToolCalls() {
+			if !requiredCalled && !forced {
+				if _, ok := tools[a.requiredTool]; ok {
+					log.Printf("[Agent] Model answered without calling %s; asking again with the tool required", a.requiredTool)
+					toolChoice = provider.ForceTool(a.requiredTool)
+					forced = true
+					thinking = 0
+					continue
+				}
+			}
+
 			// Add assistant response to memory
-			mem.AddMessage("assistant", resp.Text)
+			mem.AddMessage("assistant", text)
 
 			return &AgentResult{
-				Response:      resp.Text,
+				Response:      text,
 				ToolCallsMade: allToolCalls,
 				Iterations:    iteration,
+				Usage:         usage,
 			}, nil
 		}
 
 		// Store the assistant's response with tool calls BEFORE executing tools
-		// This is required by Claude API - tool_result must follow tool_use in the same conversation
-		mem.AddAssistantMessageWithToolCalls(resp.Text, resp.ToolCalls)
+		// This is required by Claude API - tool_result must follow tool_use in the same conversation,
+		// and any thinking before the tool_use must be sent back unchanged with it
+		mem.AddAssistantMessageWithThinking(text, resp.ToolCalls, resp.Thinking)
 
 		// Act: Execute tool calls
+		var structured map[string]interface{}
 		for _, tc := range resp.ToolCalls {
+			if ctx.Err() != nil {
+				return interrupted(ctx, allToolCalls, iteration, usage)
+			}
 			allToolCalls = append(allToolCalls, tc)
+			tool.ReportProgress(ctx, fmt.Sprintf("Running tool %s", tc.Name))
+
+			result := a.executeTool(ctx, tools, tc)
 
-			result := a.executeTool(ctx, tc)
+			// A failed call, such as an answer that does not match the
+			// output schema, does not count: the model is told why and
+			// has to call the tool again
+			if tc.Name == a.requiredTool && result.Success {
+				requiredCalled = true
+				if tc.Name == a.outputTool && structured == nil {
+					structured = tc.Arguments
+					if structured == nil {
+						structured = map[string]interface{}{}
+					}
+				}
+			}
 
-			// Observe: Add tool result to memory
-			mem.AddToolResult(tc.ID, tc.Name, result)
+			// Observe: Add tool result to memory, flagging failures so the
+			// provider can mark them as errors for the model
+			if toolErr := result.Err(); toolErr != nil {
+				mem.AddToolError(tc.ID, tc.Name, toolErr)
+			} else {
+				mem.AddToolResultWithContent(tc.ID, tc.Name, result.Output, result.Content)
+			}
+		}
+
+		// A valid answer ends the run without asking the model again
+		if structured != nil {
+			response, err := json.MarshalIndent(structured, "", "  ")
+			if err != nil {
+				return nil, fmt.Errorf("failed to encode structured answer: %w", err)
+			}
+			return &AgentResult{
+				Response:      string(response),
+				ToolCallsMade: allToolCalls,
+				Iterations:    iteration,
+				Usage:         usage,
+				Structured:    structured,
+			}, nil
 		}
 	}
 
@@ -139,31 +472,47 @@ func (a *Agent) Run(ctx context.Context, input string, mem *memory.ConversationM
 	return nil, fmt.Errorf("%w: reached %d iterations without final response", ErrMaxIterationsExceeded, a.maxIterations)
 }
 
-// buildToolDefinitions converts registered tools to ToolDefinitions for LLM requests.
-func (a *Agent) buildToolDefinitions() []provider.ToolDefinition {
-	defs := make([]provider.ToolDefinition, 0, len(a.tools))
-	for _, t := range a.tools {
+// interrupted returns the partial result and the error for a run whose
+// context ended.
+func interrupted(ctx context.Context, toolCalls []provider.ToolCall, iterations int, usage provider.Usage) (*AgentResult, error) {
+	return &AgentResult{
+		ToolCallsMade: toolCalls,
+		Iterations:    iterations,
+		Usage:         usage,
+	}, fmt.Errorf("agent run interrupted: %w", ctx.Err())
+}
+
+// buildToolDefinitions converts tools to ToolDefinitions for LLM requests,
+// sorted by name so that the same tools always produce the same request
+// prefix and the provider's prompt cache can match it.
+func (a *Agent) buildToolDefinitions(tools map[string]tool.Tool) []provider.ToolDefinition {
+	defs := make([]provider.ToolDefinition, 0, len(tools))
+	for _, t := range tools {
 		defs = append(defs, tool.ToDefinition(t))
 	}
+	sort.Slice(defs, func(i, j int) bool { return defs[i].Name < defs[j].Name })
 	return defs
 }
 
-// executeTool dispatches a tool call to the correct tool and returns the result as a string.
-// If the tool is not found or execution fails, it returns an error message instead of panicking.
-func (a *Agent) executeTool(ctx context.Context, tc provider.ToolCall) string {
-	t, exists := a.tools[tc.Name]
+// executeTool dispatches a tool call to the correct tool and returns its
+// result. Failures, including unknown tools, invalid arguments and Go errors
+// from the tool, are returned as unsuccessful results with an error kind
+// rather than causing a panic or aborting the run.
+func (a *Agent) executeTool(ctx context.Context, tools map[string]tool.Tool, tc provider.ToolCall) *provider.ToolResult {
+	t, exists := tools[tc.Name]
 	if !exists {
-		return fmt.Sprintf("error: unknown tool '%s'", tc.Name)
+		return tool.ErrorResult(tool.Errorf(provider.ToolErrorNotFound, "unknown tool '%s'", tc.Name))
 	}
 
-	result, err := t.Execute(ctx, tc.Arguments)
-	if err != nil {
-		return fmt.Sprintf("error: tool execution failed: %v", err)
+	// Reject malformed arguments before the tool sees them, with enough
+	// detail for the model to correct its call
+	if err := tool.ValidateArguments(t.Parameters(), tc.Arguments); err != nil {
+		return tool.ErrorResult(tool.Errorf(provider.ToolErrorValidation, "invalid arguments for tool '%s': %v", tc.Name, err))
 	}
 
-	if !result.Success {
-		return fmt.Sprintf("error: %s", result.Error)
+	result, err := t.Execute(ctx, tc.Arguments)
+	if err != nil {
+		return tool.ErrorResult(fmt.Errorf("tool execution failed: %w", err))
 	}
-
-	return result.Output
+	return result
 }
@@ -3,6 +3,7 @@ package agent
 import (
 	"context"
 	"errors"
+	"strings"
 	"testing"
 
 	"agentic-poc/internal/memory"
@@ -527,6 +528,94 @@ func TestAgent_Run_ToolDefinitionsIncluded(t *testing.T) {
 	}
 }
 
+func TestAgent_Run_ToolDefinitionsSorted(t *testing.T) {
+	names := []string{"write_file", "calculator", "read_file", "list_directory", "finish_plan"}
+	tools := make([]tool.Tool, 0, len(names))
+	for _, name := range names {
+		tools = append(tools, &mockTool{name: name})
+	}
+	dynamic := &mockToolProvider{tools: []tool.Tool{&mockTool{name: "mcp_search"}, &mockTool{name: "bash"}}}
+
+	mockProvider := &mockLLMProvider{}
+	agent := NewAgent(AgentConfig{Provider: mockProvider, Tools: tools, ToolProvider: dynamic})
+
+	// Map iteration order varies, so repeat to catch an unstable order
+	for i := 0; i < 20; i++ {
+		if _, err := agent.Run(context.Background(), "Hello", memory.NewConversationMemory()); err != nil {
+			t.Fatal(err)
+		}
+	}
+
+	want := []string{"bash", "calculator", "finish_plan", "list_directory", "mcp_search", "read_file", "write_file"}
+	for i, req := range mockProvider.requests {
+		got := make([]string, len(req.Tools))
+		for j, def := range req.Tools {
+			got[j] = def.Name
+		}
+		if strings.Join(got, ",") != strings.Join(want, ",") {
+			t.Fatalf("request %d tools = %v, want %v", i, got, want)
+		}
+	}
+}
+
+func TestAgent_Run_CachePolicy(t *testing.T) {
+	custom := provider.CachePolicy{Tools: true}
+	tests := []struct {
+		name  string
+		cache *provider.CachePolicy
+		want  *provider.CachePolicy
+	}{
+		{name: "default", cache: nil, want: &DefaultCachePolicy},
+		{name: "custom", cache: &custom, want: &custom},
+		{name: "disabled", cache: &provider.CachePolicy{}, want: nil},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			mockProvider := &mockLLMProvider{}
+			agent := NewAgent(AgentConfig{Provider: mockProvider, Cache: tt.cache})
+
+			if _, err := agent.Run(context.Background(), "Hello", memory.NewConversationMemory()); err != nil {
+				t.Fatal(err)
+			}
+
+			got := mockProvider.requests[0].Cache
+			if (got == nil) != (tt.want == nil) || got != nil && *got != *tt.want {
+				t.Errorf("cache = %+v, want %+v", got, tt.want)
+			}
+		})
+	}
+}
+
+// roleProvider records the agent role of each request.
+type roleProvider struct {
+	mockLLMProvider
+	roles []string
+}
+
+func (p *roleProvider) Generate(ctx context.Context, req provider.GenerateRequest) (*provider.LLMResponse, error) {
+	p.roles = append(p.roles, provider.RoleFromContext(ctx))
+	return p.mockLLMProvider.Generate(ctx, req)
+}
+
+func TestAgent_Run_PassesRole(t *testing.T) {
+	for _, role := range []string{"", "coder"} {
+		p := &roleProvider{}
+		agent := NewAgent(AgentConfig{Provider: p, Role: role})
+		if _, err := agent.Run(context.Background(), "Hi", memory.NewConversationMemory()); err != nil {
+			t.Fatal(err)
+		}
+		if len(p.roles) != 1 || p.roles[0] != role {
+			t.Errorf("roles = %q, want [%q]", p.roles, role)
+		}
+	}
+
+	architect := NewArchitectAgent(&mockLLMProvider{})
+	if architect.role != RoleArchitect {
+		t.Errorf("architect role = %q", architect.role)
+	}
+}
+
 func TestAgent_Run_ConversationMemoryUpdated(t *testing.T) {
 	mockProvider := &mockLLMProvider{
 		responses: []provider.LLMResponse{
@@ -616,3 +705,672 @@ func TestAgent_GetTools(t *testing.T) {
 		t.Errorf("tool count = %d, want 2", len(tools))
 	}
 }
+
+// mockToolProvider is a test double for ToolProvider whose tool set can change.
+type mockToolProvider struct {
+	tools []tool.Tool
+	calls int
+}
+
+func (p *mockToolProvider) GetTools() []tool.Tool {
+	p.calls++
+	return p.tools
+}
+
+func TestAgent_Run_ToolProviderRefreshedEachIteration(t *testing.T) {
+	addedLater := &mockTool{name: "added_later"}
+	toolProvider := &mockToolProvider{}
+
+	mockProvider := &mockLLMProvider{
+		responses: []provider.LLMResponse{
+			{ToolCalls: []provider.ToolCall{{ID: "call_1", Name: "static", Arguments: map[string]interface{}{}}}},
+			{ToolCalls: []provider.ToolCall{{ID: "call_2", Name: "added_later", Arguments: map[string]interface{}{}}}},
+			{Text: "done"},
+		},
+	}
+
+	staticTool := &mockTool{name: "static"}
+	staticTool.result = &provider.ToolResult{Success: true, Output: "ok"}
+
+	agent := NewAgent(AgentConfig{
+		Provider:     mockProvider,
+		Tools:        []tool.Tool{staticTool},
+		ToolProvider: toolProvider,
+	})
+
+	// Simulate a server announcing a new tool after the first call
+	wrapped := &toolAddingProvider{mockLLMProvider: mockProvider, onCall: 1, add: func() {
+		toolProvider.tools = []tool.Tool{addedLater}
+	}}
+	agent.provider = wrapped
+
+	result, err := agent.Run(context.Background(), "go", memory.NewConversationMemory())
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+
+	if result.Response != "done" {
+		t.Errorf("response = %q, want %q", result.Response, "done")
+	}
+	if addedLater.callCount != 1 {
+		t.Errorf("dynamically added tool call count = %d, want 1", addedLater.callCount)
+	}
+	if got := len(mockProvider.requests[0].Tools); got != 1 {
+		t.Errorf("first request tool count = %d, want 1", got)
+	}
+	if got := len(mockProvider.requests[1].Tools); got != 2 {
+		t.Errorf("second request tool count = %d, want 2", got)
+	}
+}
+
+// toolAddingProvider runs a hook after a given number of Generate calls.
+type toolAddingProvider struct {
+	*mockLLMProvider
+	onCall int
+	add    func()
+}
+
+func (p *toolAddingProvider) Generate(ctx context.Context, req provider.GenerateRequest) (*provider.LLMResponse, error) {
+	resp, err := p.mockLLMProvider.Generate(ctx, req)
+	if p.mockLLMProvider.callCount == p.onCall {
+		p.add()
+	}
+	return resp, err
+}
+
+func TestAgent_GetTools_IncludesToolProvider(t *testing.T) {
+	agent := NewAgent(AgentConfig{
+		Provider:     &mockLLMProvider{},
+		Tools:        []tool.Tool{&mockTool{name: "static"}},
+		ToolProvider: &mockToolProvider{tools: []tool.Tool{&mockTool{name: "dynamic"}}},
+	})
+
+	if got := len(agent.GetTools()); got != 2 {
+		t.Errorf("tool count = %d, want 2", got)
+	}
+}
+
+func TestAgent_ToolProviderCannotOverrideRegisteredTool(t *testing.T) {
+	builtin := &mockTool{name: "read_file", result: &provider.ToolResult{Success: true, Output: "builtin"}}
+	impostor := &mockTool{name: "read_file", result: &provider.ToolResult{Success: true, Output: "impostor"}}
+
+	mockProvider := &mockLLMProvider{
+		responses: []provider.LLMResponse{
+			{ToolCalls: []provider.ToolCall{{ID: "call_1", Name: "read_file", Arguments: map[string]interface{}{}}}},
+			{Text: "done"},
+		},
+	}
+
+	agent := NewAgent(AgentConfig{
+		Provider:     mockProvider,
+		Tools:        []tool.Tool{builtin},
+		ToolProvider: &mockToolProvider{tools: []tool.Tool{impostor}},
+	})
+
+	if _, err := agent.Run(context.Background(), "read", memory.NewConversationMemory()); err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+
+	if builtin.callCount != 1 || impostor.callCount != 0 {
+		t.Errorf("builtin calls = %d, impostor calls = %d; registered tool should win", builtin.callCount, impostor.callCount)
+	}
+	if got := len(mockProvider.requests[0].Tools); got != 1 {
+		t.Errorf("tool definitions = %d, want 1", got)
+	}
+}
+
+func TestAgent_Run_ToolResultContentStoredInMemory(t *testing.T) {
+	image := provider.ImageBlock("image/png", "iVBORw0=")
+	screenshot := &mockTool{
+		name:   "screenshot",
+		result: &provider.ToolResult{Success: true, Output: "captured", Content: []provider.ContentBlock{image}},
+	}
+
+	mockProvider := &mockLLMProvider{
+		responses: []provider.LLMResponse{
+			{ToolCalls: []provider.ToolCall{{ID: "call_1", Name: "screenshot", Arguments: map[string]interface{}{}}}},
+			{Text: "I see it"},
+		},
+	}
+
+	agent := NewAgent(AgentConfig{Provider: mockProvider, Tools: []tool.Tool{screenshot}})
+	mem := memory.NewConversationMemory()
+
+	if _, err := agent.Run(context.Background(), "look", mem); err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+
+	// The second request must carry the image in the tool result message
+	msgs := mockProvider.requests[1].Messages
+	toolMsg := msgs[len(msgs)-1]
+	if toolMsg.Content != "captured" {
+		t.Errorf("tool result text = %q, want %q", toolMsg.Content, "captured")
+	}
+	if len(toolMsg.Blocks) != 1 || toolMsg.Blocks[0] != image {
+		t.Errorf("tool result blocks = %+v, want the image", toolMsg.Blocks)
+	}
+}
+
+func TestAgent_Run_InvalidArgumentsNotExecuted(t *testing.T) {
+	strict := &mockTool{
+		name: "strict",
+		params: map[string]interface{}{
+			"type": "object",
+			"properties": map[string]interface{}{
+				"count": map[string]interface{}{"type": "integer", "minimum": 1},
+			},
+			"required": []string{"count"},
+		},
+	}
+
+	mockProvider := &mockLLMProvider{
+		responses: []provider.LLMResponse{
+			{ToolCalls: []provider.ToolCall{{ID: "call_1", Name: "strict", Arguments: map[string]interface{}{"count": 0.0}}}},
+			{Text: "fixed"},
+		},
+	}
+
+	agent := NewAgent(AgentConfig{Provider: mockProvider, Tools: []tool.Tool{strict}})
+	if _, err := agent.Run(context.Background(), "go", memory.NewConversationMemory()); err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+
+	if strict.callCount != 0 {
+		t.Errorf("tool executed %d times with invalid arguments", strict.callCount)
+	}
+
+	msgs := mockProvider.requests[1].Messages
+	toolMsg := msgs[len(msgs)-1]
+	want := "invalid arguments for tool 'strict': count: must be >= 1, got 0"
+	if toolMsg.Content != want {
+		t.Errorf("tool result = %q, want %q", toolMsg.Content, want)
+	}
+	if toolMsg.ToolError == nil || toolMsg.ToolError.Kind != provider.ToolErrorValidation || !toolMsg.ToolError.Retryable {
+		t.Errorf("expected a retryable validation error, got %+v", toolMsg.ToolError)
+	}
+}
+
+func TestAgent_Run_ToolErrorsAreStructured(t *testing.T) {
+	tests := []struct {
+		name      string
+		tool      *mockTool
+		call      string
+		wantKind  provider.ToolErrorKind
+		wantRetry bool
+	}{
+		{
+			name:     "unknown tool",
+			tool:     &mockTool{name: "real"},
+			call:     "imaginary",
+			wantKind: provider.ToolErrorNotFound,
+		},
+		{
+			name:     "failed result without a kind",
+			tool:     &mockTool{name: "tool", result: &provider.ToolResult{Success: false, Error: "boom"}},
+			call:     "tool",
+			wantKind: provider.ToolErrorInternal,
+		},
+		{
+			name: "classified failure",
+			tool: &mockTool{name: "tool", result: &provider.ToolResult{
+				Success: false, Error: "slow", ErrorKind: provider.ToolErrorTimeout, Retryable: true,
+			}},
+			call:      "tool",
+			wantKind:  provider.ToolErrorTimeout,
+			wantRetry: true,
+		},
+		{
+			name:      "Go error from the tool",
+			tool:      &mockTool{name: "tool", err: context.DeadlineExceeded},
+			call:      "tool",
+			wantKind:  provider.ToolErrorTimeout,
+			wantRetry: true,
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			mockProvider := &mockLLMProvider{
+				responses: []provider.LLMResponse{
+					{ToolCalls: []provider.ToolCall{{ID: "call_1", Name: tt.call, Arguments: map[string]interface{}{}}}},
+					{Text: "done"},
+				},
+			}
+			agent := NewAgent(AgentConfig{Provider: mockProvider, Tools: []tool.Tool{tt.tool}})
+			if _, err := agent.Run(context.Background(), "go", memory.NewConversationMemory()); err != nil {
+				t.Fatalf("unexpected error: %v", err)
+			}
+
+			msgs := mockProvider.requests[1].Messages
+			toolErr := msgs[len(msgs)-1].ToolError
+			if toolErr == nil {
+				t.Fatal("expected the tool result to carry an error")
+			}
+			if toolErr.Kind != tt.wantKind || toolErr.Retryable != tt.wantRetry {
+				t.Errorf("got kind %q retryable %v, want %q %v", toolErr.Kind, toolErr.Retryable, tt.wantKind, tt.wantRetry)
+			}
+		})
+	}
+}
+
+func TestAgent_Run_SuccessfulToolResultHasNoError(t *testing.T) {
+	mockProvider := &mockLLMProvider{
+		responses: []provider.LLMResponse{
+			{ToolCalls: []provider.ToolCall{{ID: "call_1", Name: "tool", Arguments: map[string]interface{}{}}}},
+			{Text: "done"},
+		},
+	}
+	agent := NewAgent(AgentConfig{Provider: mockProvider, Tools: []tool.Tool{&mockTool{name: "tool"}}})
+	if _, err := agent.Run(context.Background(), "go", memory.NewConversationMemory()); err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+
+	msgs := mockProvider.requests[1].Messages
+	if toolMsg := msgs[len(msgs)-1]; toolMsg.ToolError != nil || toolMsg.Content != "mock result" {
+		t.Errorf("expected a plain successful result, got %+v", toolMsg)
+	}
+}
+
+func TestAgent_Run_UsageSummedAcrossIterations(t *testing.T) {
+	mockTool := &mockTool{
+		name:   "calculator",
+		result: &provider.ToolResult{Success: true, Output: "3"},
+	}
+
+	mockProvider := &mockLLMProvider{
+		responses: []provider.LLMResponse{
+			{
+				ToolCalls: []provider.ToolCall{
+					{ID: "call_1", Name: "calculator", Arguments: map[string]interface{}{}},
+				},
+				Usage: provider.Usage{InputTokens: 100, OutputTokens: 20},
+			},
+			{
+				Text:  "The result is 3",
+				Usage: provider.Usage{InputTokens: 130, OutputTokens: 5},
+			},
+		},
+	}
+
+	agent := NewAgent(AgentConfig{
+		Provider: mockProvider,
+		Tools:    []tool.Tool{mockTool},
+	})
+
+	result, err := agent.Run(context.Background(), "What is 1 + 2?", memory.NewConversationMemory())
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+
+	want := provider.Usage{InputTokens: 230, OutputTokens: 25}
+	if result.Usage != want {
+		t.Errorf("usage = %+v, want %+v", result.Usage, want)
+	}
+}
+
+// cancellingTool cancels the run's context when executed, like a user
+// pressing Ctrl-C while the tool runs.
+type cancellingTool struct {
+	mockTool
+	cancel context.CancelFunc
+}
+
+func (t *cancellingTool) Execute(ctx context.Context, args map[string]interface{}) (*provider.ToolResult, error) {
+	t.cancel()
+	return t.mockTool.Execute(ctx, args)
+}
+
+func TestAgent_Run_CancelledBeforeStart(t *testing.T) {
+	mockProvider := &mockLLMProvider{}
+	agent := NewAgent(AgentConfig{Provider: mockProvider})
+
+	ctx, cancel := context.WithCancel(context.Background())
+	cancel()
+
+	result, err := agent.Run(ctx, "Hello", memory.NewConversationMemory())
+
+	if !errors.Is(err, context.Canceled) {
+		t.Fatalf("error = %v, want context.Canceled", err)
+	}
+	if result == nil || result.Iterations != 0 || len(result.ToolCallsMade) != 0 {
+		t.Errorf("expected an empty partial result, got %+v", result)
+	}
+	if mockProvider.callCount != 0 {
+		t.Errorf("LLM called %d times after cancellation", mockProvider.callCount)
+	}
+}
+
+func TestAgent_Run_CancelledDuringToolReturnsPartialResult(t *testing.T) {
+	ctx, cancel := context.WithCancel(context.Background())
+	defer cancel()
+
+	first := &cancellingTool{mockTool: mockTool{name: "first"}, cancel: cancel}
+	second := &mockTool{name: "second"}
+
+	mockProvider := &mockLLMProvider{
+		responses: []provider.LLMResponse{
+			{
+				ToolCalls: []provider.ToolCall{
+					{ID: "call_1", Name: "first", Arguments: map[string]interface{}{}},
+					{ID: "call_2", Name: "second", Arguments: map[string]interface{}{}},
+				},
+				Usage: provider.Usage{InputTokens: 12, OutputTokens: 3},
+			},
+		},
+	}
+
+	agent := NewAgent(AgentConfig{
+		Provider: mockProvider,
+		Tools:    []tool.Tool{first, second},
+	})
+
+	result, err := agent.Run(ctx, "Run both tools", memory.NewConversationMemory())
+
+	if !errors.Is(err, context.Canceled) {
+		t.Fatalf("error = %v, want context.Canceled", err)
+	}
+	if result == nil {
+		t.Fatal("expected a partial result")
+	}
+	if len(result.ToolCallsMade) != 1 || result.ToolCallsMade[0].Name != "first" {
+		t.Errorf("tool calls made = %+v, want only the first", result.ToolCallsMade)
+	}
+	if result.Iterations != 1 {
+		t.Errorf("iterations = %d, want 1", result.Iterations)
+	}
+	if result.Usage.InputTokens != 12 {
+		t.Errorf("usage = %+v, want the first response's usage", result.Usage)
+	}
+	if second.callCount != 0 {
+		t.Error("tool calls after the cancellation should not run")
+	}
+	if mockProvider.callCount != 1 {
+		t.Errorf("LLM called %d times, want 1", mockProvider.callCount)
+	}
+}
+
+func TestAgent_Run_ContinuesTruncatedAnswer(t *testing.T) {
+	mockProvider := &mockLLMProvider{
+		responses: []provider.LLMResponse{
+			{Text: "The first half, ", StopReason: provider.StopMaxTokens, Usage: provider.Usage{OutputTokens: 4}},
+			{Text: " and the second half.", StopReason: provider.StopEndTurn, Usage: provider.Usage{OutputTokens: 5}},
+		},
+	}
+	agent := NewAgent(AgentConfig{Provider: mockProvider})
+	mem := memory.NewConversationMemory()
+
+	result, err := agent.Run(context.Background(), "Write a long answer", mem)
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+
+	if want := "The first half, and the second half."; result.Response != want {
+		t.Errorf("response = %q, want %q", result.Response, want)
+	}
+	if result.Iterations != 2 || result.Usage.OutputTokens != 9 {
+		t.Errorf("iterations = %d, usage = %+v", result.Iterations, result.Usage)
+	}
+
+	// The continuation request prefills the partial answer without trailing
+	// whitespace
+	msgs := mockProvider.requests[1].Messages
+	if last := msgs[len(msgs)-1]; last.Role != "assistant" || last.Content != "The first half," {
+		t.Errorf("continuation prefill = %+v", last)
+	}
+
+	// Memory holds the joined answer once
+	history := mem.GetMessages()
+	if len(history) != 2 || history[1].Content != result.Response {
+		t.Errorf("memory = %+v, want the user input and the joined answer", history)
+	}
+}
+
+func TestAgent_Run_TruncatedResponseErrors(t *testing.T) {
+	tests := []struct {
+		name    string
+		resp    provider.LLMResponse
+		wantErr string
+	}{
+		{
+			name: "truncated tool call",
+			resp: provider.LLMResponse{
+				StopReason: provider.StopMaxTokens,
+				ToolCalls:  []provider.ToolCall{{ID: "call_1", Name: "write_file", Arguments: map[string]interface{}{}}},
+			},
+			wantErr: "'write_file' may be incomplete",
+		},
+		{
+			name:    "no text",
+			resp:    provider.LLMResponse{StopReason: provider.StopMaxTokens},
+			wantErr: "no text was produced",
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			writeTool := &mockTool{name: "write_file"}
+			agent := NewAgent(AgentConfig{
+				Provider: &mockLLMProvider{responses: []provider.LLMResponse{tt.resp}},
+				Tools:    []tool.Tool{writeTool},
+			})
+
+			_, err := agent.Run(context.Background(), "Go", memory.NewConversationMemory())
+			if !errors.Is(err, ErrResponseTruncated) {
+				t.Fatalf("error = %v, want ErrResponseTruncated", err)
+			}
+			if !strings.Contains(err.Error(), tt.wantErr) {
+				t.Errorf("error = %v, want one containing %q", err, tt.wantErr)
+			}
+			if writeTool.callCount != 0 {
+				t.Error("a truncated tool call should not run")
+			}
+		})
+	}
+}
+
+func TestAgent_Run_ExtendedThinking(t *testing.T) {
+	thinking := []provider.Thinking{{Text: "I should read the file first.", Signature: "sig"}, {Redacted: "opaque"}}
+	mockProvider := &mockLLMProvider{
+		responses: []provider.LLMResponse{
+			{
+				Thinking:  thinking,
+				ToolCalls: []provider.ToolCall{{ID: "call_1", Name: "read_file", Arguments: map[string]interface{}{}}},
+			},
+			{Text: "Done.", Thinking: []provider.Thinking{{Text: "The file answers it.", Signature: "sig2"}}},
+		},
+	}
+	agent := NewAgent(AgentConfig{
+		Provider:       mockProvider,
+		Tools:          []tool.Tool{&mockTool{name: "read_file"}},
+		ThinkingBudget: 2048,
+	})
+
+	var reported []string
+	ctx := WithThinkingHandler(context.Background(), func(text string) { reported = append(reported, text) })
+	mem := memory.NewConversationMemory()
+	if _, err := agent.Run(ctx, "Read it", mem); err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+
+	for i, req := range mockProvider.requests {
+		if req.ThinkingBudget != 2048 {
+			t.Errorf("request %d thinking budget = %d, want 2048", i, req.ThinkingBudget)
+		}
+	}
+
+	// The thinking before a tool call is sent back with it, unchanged
+	msgs := mockProvider.requests[1].Messages
+	if got := msgs[1].Thinking; len(got) != 2 || got[0] != thinking[0] || got[1] != thinking[1] {
+		t.Errorf("tool call message thinking = %+v, want %+v", got, thinking)
+	}
+
+	// Redacted thinking is not reported
+	if want := []string{"I should read the file first.", "The file answers it."}; strings.Join(reported, "|") != strings.Join(want, "|") {
+		t.Errorf("reported thinking = %q, want %q", reported, want)
+	}
+}
+
+func TestAgent_Run_ThinkingOffAfterForcedTool(t *testing.T) {
+	// The forced call fails validation, so the run goes on after it: the
+	// retry must not turn thinking back on behind a call made without it
+	mockProvider := &mockLLMProvider{
+		responses: []provider.LLMResponse{
+			{Text: "It is positive.", Thinking: []provider.Thinking{{Text: "Easy.", Signature: "sig"}}},
+			answerCall("call_1", "final_answer", map[string]interface{}{"label": "neutral", "confidence": 0.5}),
+			answerCall("call_2", "final_answer", map[string]interface{}{"label": "positive", "confidence": 0.5}),
+		},
+	}
+	agent := NewAgent(AgentConfig{
+		Provider:       mockProvider,
+		OutputSchema:   tool.SchemaFor[verdict](),
+		ThinkingBudget: 2048,
+	})
+
+	result, err := agent.Run(context.Background(), "Classify: great product", memory.NewConversationMemory())
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	if result.Structured == nil {
+		t.Fatal("expected the corrected answer")
+	}
+
+	want := []int{2048, 0, 0}
+	if len(mockProvider.requests) != len(want) {
+		t.Fatalf("LLM called %d times, want %d", len(mockProvider.requests), len(want))
+	}
+	for i, req := range mockProvider.requests {
+		if req.ThinkingBudget != want[i] {
+			t.Errorf("request %d thinking budget = %d, want %d", i, req.ThinkingBudget, want[i])
+		}
+	}
+	if mockProvider.requests[2].ToolChoice != nil {
+		t.Errorf("retry tool choice = %+v, want none", mockProvider.requests[2].ToolChoice)
+	}
+}
+
+func TestAgent_Run_TruncatedAnswerWithThinkingErrors(t *testing.T) {
+	mockProvider := &mockLLMProvider{
+		responses: []provider.LLMResponse{{Text: "The first half", StopReason: provider.StopMaxTokens}},
+	}
+	agent := NewAgent(AgentConfig{Provider: mockProvider, ThinkingBudget: 1024})
+
+	_, err := agent.Run(context.Background(), "Write a long answer", memory.NewConversationMemory())
+	if !errors.Is(err, ErrResponseTruncated) || !strings.Contains(err.Error(), "extended thinking") {
+		t.Errorf("error = %v, want ErrResponseTruncated about extended thinking", err)
+	}
+	if mockProvider.callCount != 1 {
+		t.Errorf("provider called %d times, want no continuation", mockProvider.callCount)
+	}
+}
+
+func TestAgent_Run_RequiredTool(t *testing.T) {
+	tests := []struct {
+		name        string
+		responses   []provider.LLMResponse
+		tools       []string
+		wantForced  bool
+		wantCalls   int
+		wantRequest int
+	}{
+		{
+			name: "forced after a text answer",
+			responses: []provider.LLMResponse{
+				{Text: "Here is my plan in prose."},
+				{ToolCalls: []provider.ToolCall{{ID: "call_1", Name: "submit", Arguments: map[string]interface{}{}}}},
+				{Text: "Submitted."},
+			},
+			tools:       []string{"submit"},
+			wantForced:  true,
+			wantCalls:   1,
+			wantRequest: 3,
+		},
+		{
+			name: "called without forcing",
+			responses: []provider.LLMResponse{
+				{ToolCalls: []provider.ToolCall{{ID: "call_1", Name: "submit", Arguments: map[string]interface{}{}}}},
+				{Text: "Submitted."},
+			},
+			tools:       []string{"submit"},
+			wantCalls:   1,
+			wantRequest: 2,
+		},
+		{
+			name:        "forced only once",
+			responses:   []provider.LLMResponse{{Text: "No."}, {Text: "Still no."}},
+			tools:       []string{"submit"},
+			wantForced:  true,
+			wantRequest: 2,
+		},
+		{
+			name:        "not available",
+			responses:   []provider.LLMResponse{{Text: "Done."}},
+			wantRequest: 1,
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			mockProvider := &mockLLMProvider{responses: tt.responses}
+			tools := make([]tool.Tool, 0, len(tt.tools))
+			for _, name := range tt.tools {
+				tools = append(tools, &mockTool{name: name})
+			}
+			agent := NewAgent(AgentConfig{Provider: mockProvider, Tools: tools, RequiredTool: "submit"})
+			mem := memory.NewConversationMemory()
+
+			result, err := agent.Run(context.Background(), "Make a plan", mem)
+			if err != nil {
+				t.Fatalf("unexpected error: %v", err)
+			}
+
+			if len(mockProvider.requests) != tt.wantRequest {
+				t.Fatalf("LLM called %d times, want %d", len(mockProvider.requests), tt.wantRequest)
+			}
+			forced := false
+			for i, req := range mockProvider.requests {
+				if req.ToolChoice == nil {
+					continue
+				}
+				if i != 1 || *req.ToolChoice != *provider.ForceTool("submit") {
+					t.Errorf("request %d tool choice = %+v", i, req.ToolChoice)
+				}
+				forced = true
+			}
+			if forced != tt.wantForced {
+				t.Errorf("forced = %v, want %v", forced, tt.wantForced)
+			}
+			if len(result.ToolCallsMade) != tt.wantCalls {
+				t.Errorf("tool calls = %d, want %d", len(result.ToolCallsMade), tt.wantCalls)
+			}
+			// A discarded answer is not kept in memory
+			for _, msg := range mem.GetMessages() {
+				if msg.Content == "Here is my plan in prose." || msg.Content == "No." {
+					t.Errorf("discarded answer %q stored in memory", msg.Content)
+				}
+			}
+		})
+	}
+}
+
+func TestAgent_RunWithContent(t *testing.T) {
+	mockProvider := &mockLLMProvider{responses: []provider.LLMResponse{{Text: "A bar chart."}}}
+	agent := NewAgent(AgentConfig{Provider: mockProvider})
+	mem := memory.NewConversationMemory()
+
+	blocks := []provider.ContentBlock{provider.ImageBlock("image/png", "iVBORw0="), provider.TextBlock("What is this?")}
+	result, err := agent.RunWithContent(context.Background(), "What is this?", blocks, mem)
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	if result.Response != "A bar chart." {
+		t.Errorf("response = %q", result.Response)
+	}
+
+	sent := mockProvider.requests[0].Messages[0]
+	if sent.Content != "What is this?" || len(sent.Blocks) != 2 || sent.Blocks[0].Type != provider.ContentImage {
+		t.Errorf("user message = %+v, want the text form and the blocks", sent)
+	}
+	if stored := mem.GetMessages()[0]; len(stored.Blocks) != 2 {
+		t.Errorf("memory should keep the blocks, got %+v", stored)
+	}
+}
@@ -0,0 +1,191 @@
+// Package agent implements the core agent loop and specialized agents.
+package agent
+
+import (
+	"context"
+	"errors"
+	"fmt"
+
+	"agentic-poc/internal/memory"
+	"agentic-poc/internal/provider"
+	"agentic-poc/internal/tool"
+)
+
+// AgentTool exposes an Agent as a tool, so that other agents or MCP hosts
+// can delegate a task to it. Each call runs with a fresh conversation.
+type AgentTool struct {
+	name        string
+	description string
+	agent       *Agent
+}
+
+// NewAgentTool creates a tool that runs a with the caller's input.
+func NewAgentTool(name, description string, a *Agent) *AgentTool {
+	return &AgentTool{name: name, description: description, agent: a}
+}
+
+// Name returns the tool's identifier.
+func (t *AgentTool) Name() string {
+	return t.name
+}
+
+// Description returns what the tool does.
+func (t *AgentTool) Description() string {
+	return t.description
+}
+
+// Parameters returns the JSON Schema for the tool's input.
+func (t *AgentTool) Parameters() map[string]interface{} {
+	return map[string]interface{}{
+		"type": "object",
+		"properties": map[string]interface{}{
+			"input": map[string]interface{}{
+				"type":        "string",
+				"description": "The task or question for the agent",
+			},
+		},
+		"required": []string{"input"},
+	}
+}
+
+// Execute runs the agent and returns its final response. The structured
+// content also lists the tool calls the agent made, and holds its answer
+// if it has an output schema.
+func (t *AgentTool) Execute(ctx context.Context, args map[string]interface{}) (*provider.ToolResult, error) {
+	input, ok := args["input"].(string)
+	if !ok || input == "" {
+		return &provider.ToolResult{
+			Success: false,
+			Error:   "missing or invalid 'input' argument",
+		}, nil
+	}
+
+	result, err := t.agent.Run(ctx, input, memory.NewConversationMemory())
+	if err != nil {
+		return &provider.ToolResult{
+			Success: false,
+			Error:   fmt.Sprintf("agent run failed: %v", err),
+		}, nil
+	}
+
+	structured := map[string]interface{}{
+		"response":   result.Response,
+		"actions":    DescribeToolCalls(result.ToolCallsMade),
+		"iterations": result.Iterations,
+	}
+	if result.Structured != nil {
+		structured["answer"] = result.Structured
+	}
+
+	return &provider.ToolResult{
+		Success: true,
+		Output:  result.Response,
+		Content: []provider.ContentBlock{
+			provider.TextBlock(result.Response),
+			{Type: provider.ContentStructured, Structured: structured},
+		},
+	}, nil
+}
+
+// ArchitectTool exposes the Architect agent as the run_architect tool.
+// A new Architect is created for every call, so concurrent calls do not
+// share any state.
+type ArchitectTool struct {
+	provider provider.LLMProvider
+}
+
+// NewArchitectTool creates a run_architect tool backed by llmProvider.
+func NewArchitectTool(llmProvider provider.LLMProvider) *ArchitectTool {
+	return &ArchitectTool{provider: llmProvider}
+}
+
+// Name returns the tool's identifier.
+func (t *ArchitectTool) Name() string {
+	return "run_architect"
+}
+
+// Description returns what the tool does.
+func (t *ArchitectTool) Description() string {
+	return "Breaks a high-level goal down into an ordered implementation plan. Returns the plan as structured content."
+}
+
+// Annotations describes the tool's behavior for MCP clients.
+func (t *ArchitectTool) Annotations() tool.Annotations {
+	return tool.Annotations{ReadOnlyHint: true, OpenWorldHint: true}
+}
+
+// Parameters returns the JSON Schema for the tool's input.
+func (t *ArchitectTool) Parameters() map[string]interface{} {
+	return map[string]interface{}{
+		"type": "object",
+		"properties": map[string]interface{}{
+			"goal": map[string]interface{}{
+				"type":        "string",
+				"description": "The goal to plan for",
+			},
+		},
+		"required": []string{"goal"},
+	}
+}
+
+// Execute runs the Architect agent and returns the plan it produced.
+func (t *ArchitectTool) Execute(ctx context.Context, args map[string]interface{}) (*provider.ToolResult, error) {
+	goal, ok := args["goal"].(string)
+	if !ok || goal == "" {
+		return &provider.ToolResult{
+			Success: false,
+			Error:   "missing or invalid 'goal' argument",
+		}, nil
+	}
+
+	result, err := NewArchitectAgent(t.provider).Run(ctx, goal, memory.NewConversationMemory())
+	if err != nil {
+		return &provider.ToolResult{
+			Success: false,
+			Error:   fmt.Sprintf("architect agent failed: %v", err),
+		}, nil
+	}
+
+	plan, err := PlanFromResult(result)
+	if errors.Is(err, ErrNoStructuredOutput) {
+		return &provider.ToolResult{
+			Success: false,
+			Error:   "architect agent did not produce a plan",
+		}, nil
+	}
+	if err != nil {
+		return &provider.ToolResult{
+			Success: false,
+			Error:   fmt.Sprintf("failed to parse architect plan: %v", err),
+		}, nil
+	}
+
+	planJSON, err := plan.ToJSON()
+	if err != nil {
+		return &provider.ToolResult{
+			Success: false,
+			Error:   err.Error(),
+		}, nil
+	}
+
+	tool.ReportProgress(ctx, fmt.Sprintf("Plan ready with %d steps", len(plan.Steps)))
+
+	return &provider.ToolResult{
+		Success: true,
+		Output:  planJSON,
+		Content: []provider.ContentBlock{
+			provider.TextBlock(planJSON),
+			{Type: provider.ContentStructured, Structured: plan},
+		},
+	}, nil
+}
+
+// DescribeToolCalls renders tool calls as "name: arguments" strings, the
+// form used for the actions taken by an agent.
+func DescribeToolCalls(calls []provider.ToolCall) []string {
+	actions := make([]string, 0, len(calls))
+	for _, tc := range calls {
+		actions = append(actions, fmt.Sprintf("%s: %v", tc.Name, tc.Arguments))
+	}
+	return actions
+}
@@ -0,0 +1,115 @@
+package agent
+
+import (
+	"context"
+	"strings"
+	"testing"
+
+	"agentic-poc/internal/provider"
+	"agentic-poc/internal/tool"
+)
+
+func TestAgentTool_Execute(t *testing.T) {
+	mockProvider := &mockLLMProvider{
+		responses: []provider.LLMResponse{
+			{ToolCalls: []provider.ToolCall{{ID: "1", Name: "helper", Arguments: map[string]interface{}{"x": "y"}}}},
+			{Text: "all done"},
+		},
+	}
+	a := NewAgent(AgentConfig{
+		Provider: mockProvider,
+		Tools:    []tool.Tool{&mockTool{name: "helper"}},
+	})
+	at := NewAgentTool("run_helper", "Runs the helper agent", a)
+
+	var progress []string
+	ctx := tool.WithProgress(context.Background(), func(m string) { progress = append(progress, m) })
+
+	result, err := at.Execute(ctx, map[string]interface{}{"input": "do it"})
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	if !result.Success || result.Output != "all done" {
+		t.Fatalf("unexpected result: %+v", result)
+	}
+
+	structured := findStructured(t, result.Content).(map[string]interface{})
+	actions := structured["actions"].([]string)
+	if len(actions) != 1 || !strings.HasPrefix(actions[0], "helper:") {
+		t.Errorf("expected helper action, got %v", actions)
+	}
+
+	if len(progress) == 0 || !strings.Contains(strings.Join(progress, "\n"), "Running tool helper") {
+		t.Errorf("expected progress for tool call, got %v", progress)
+	}
+}
+
+func TestAgentTool_MissingInput(t *testing.T) {
+	at := NewAgentTool("run_x", "", NewAgent(AgentConfig{Provider: &mockLLMProvider{}}))
+
+	result, err := at.Execute(context.Background(), map[string]interface{}{})
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	if result.Success {
+		t.Error("expected failure without input")
+	}
+}
+
+func TestArchitectTool_ReturnsPlan(t *testing.T) {
+	mockProvider := &mockLLMProvider{
+		responses: []provider.LLMResponse{
+			{ToolCalls: []provider.ToolCall{{
+				ID:   "1",
+				Name: "finish_plan",
+				Arguments: map[string]interface{}{
+					"goal": "Build it",
+					"steps": []interface{}{
+						map[string]interface{}{"description": "Write main.go", "action": "write_file"},
+					},
+				},
+			}}},
+			{Text: "Plan complete"},
+		},
+	}
+
+	result, err := NewArchitectTool(mockProvider).Execute(context.Background(), map[string]interface{}{"goal": "Build it"})
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	if !result.Success {
+		t.Fatalf("expected success, got error %q", result.Error)
+	}
+
+	plan, ok := findStructured(t, result.Content).(*Plan)
+	if !ok {
+		t.Fatalf("expected *Plan structured content")
+	}
+	if plan.Goal != "Build it" || len(plan.Steps) != 1 {
+		t.Errorf("unexpected plan: %+v", plan)
+	}
+	if !strings.Contains(result.Output, "Write main.go") {
+		t.Errorf("text output should contain the plan: %s", result.Output)
+	}
+}
+
+func TestArchitectTool_NoPlan(t *testing.T) {
+	mockProvider := &mockLLMProvider{responses: []provider.LLMResponse{{Text: "I refuse to plan"}}}
+
+	result, _ := NewArchitectTool(mockProvider).Execute(context.Background(), map[string]interface{}{"goal": "x"})
+	if result.Success || !strings.Contains(result.Error, "did not produce a plan") {
+		t.Errorf("expected missing plan error, got %+v", result)
+	}
+}
+
+// findStructured returns the structured content block's value.
+func findStructured(t *testing.T, blocks []provider.ContentBlock) interface{} {
+	t.Helper()
+	for _, b := range blocks {
+		if b.Type == provider.ContentStructured {
+			return b.Structured
+		}
+	}
+	t.Fatal("no structured content")
+	return nil
+}
@@ -6,6 +6,9 @@ import (
 	"agentic-poc/internal/tool"
 )
 
+// ArchitectOutputTool is the tool the Architect agent submits its plan with.
+const ArchitectOutputTool = "finish_plan"
+
 // ArchitectSystemPrompt is the system prompt for the Architect agent.
 // It instructs the agent to create detailed implementation plans.
 const ArchitectSystemPrompt = `You are an Architect agent responsible for breaking down high-level goals into detailed implementation plans.
@@ -34,18 +37,24 @@ Always call finish_plan when your plan is complete. Do not provide the plan as t
 
 // NewArchitectAgent creates a new Agent configured as an Architect.
 // The Architect agent is responsible for breaking down high-level goals into detailed plans.
-// It returns both the Agent and the FinishPlanTool so the caller can retrieve the captured plan.
+// It answers with a Plan through its output schema, submitted with the
+// finish_plan tool; use PlanFromResult to get the plan of a run.
+// Options may change its prompt, tools and iteration limit; the model is
+// made to call finish_plan if it answers without a plan.
 //
 // Validates: Requirements 5.1, 5.2, 5.3
-func NewArchitectAgent(llmProvider provider.LLMProvider) (*Agent, *tool.FinishPlanTool) {
-	finishPlanTool := tool.NewFinishPlanTool()
-
-	agent := NewAgent(AgentConfig{
+func NewArchitectAgent(llmProvider provider.LLMProvider, opts ...Option) *Agent {
+	cfg := AgentConfig{
 		Provider:      llmProvider,
-		Tools:         []tool.Tool{finishPlanTool},
 		SystemPrompt:  ArchitectSystemPrompt,
 		MaxIterations: DefaultMaxIterations,
-	})
-
-	return agent, finishPlanTool
+		Role:          RoleArchitect,
+	}
+	for _, opt := range opts {
+		opt(&cfg)
+	}
+	cfg.OutputSchema = tool.SchemaFor[Plan]()
+	cfg.OutputTool = ArchitectOutputTool
+
+	return NewAgent(cfg)
 }
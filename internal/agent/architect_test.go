@@ -2,25 +2,28 @@ package agent
 
 import (
 	"context"
+	"errors"
+	"strings"
 	"testing"
 
 	"agentic-poc/internal/memory"
 	"agentic-poc/internal/provider"
+	"agentic-poc/internal/tool"
 )
 
 func TestNewArchitectAgent(t *testing.T) {
 	mockProvider := &mockLLMProvider{}
 
-	agent, finishPlanTool := NewArchitectAgent(mockProvider)
+	agent := NewArchitectAgent(mockProvider)
 
 	// Verify agent is created
 	if agent == nil {
 		t.Fatal("expected agent to be created, got nil")
 	}
 
-	// Verify finish_plan tool is returned
-	if finishPlanTool == nil {
-		t.Fatal("expected finishPlanTool to be returned, got nil")
+	// Verify the plan is submitted with finish_plan
+	if agent.outputTool != "finish_plan" || agent.requiredTool != "finish_plan" {
+		t.Errorf("output tool = %q, required tool = %q; want finish_plan", agent.outputTool, agent.requiredTool)
 	}
 
 	// Verify system prompt is set
@@ -46,6 +49,31 @@ func TestNewArchitectAgent(t *testing.T) {
 	}
 }
 
+func TestNewArchitectAgent_Options(t *testing.T) {
+	agent := NewArchitectAgent(&mockLLMProvider{},
+		WithSystemPrompt("Plan carefully."),
+		WithTools([]tool.Tool{tool.NewFileReaderTool(".")}),
+		WithMaxIterations(3),
+	)
+
+	if agent.systemPrompt != "Plan carefully." {
+		t.Errorf("system prompt = %q", agent.systemPrompt)
+	}
+	if agent.maxIterations != 3 {
+		t.Errorf("max iterations = %d, want 3", agent.maxIterations)
+	}
+	// finish_plan is kept next to the configured tools
+	if _, ok := agent.tools["finish_plan"]; !ok || len(agent.tools) != 2 {
+		t.Errorf("tools = %v, want read_file and finish_plan", agent.tools)
+	}
+
+	// Zero values keep the defaults
+	agent = NewArchitectAgent(&mockLLMProvider{}, WithSystemPrompt(""), WithTools(nil), WithMaxIterations(0))
+	if agent.systemPrompt != ArchitectSystemPrompt || agent.maxIterations != DefaultMaxIterations || len(agent.tools) != 1 {
+		t.Error("zero-valued options should keep the defaults")
+	}
+}
+
 func TestArchitectAgent_GeneratesPlan(t *testing.T) {
 	// Test that the architect agent can generate a plan using the finish_plan tool
 	mockProvider := &mockLLMProvider{
@@ -71,11 +99,10 @@ func TestArchitectAgent_GeneratesPlan(t *testing.T) {
 					},
 				},
 			},
-			{Text: "I have created a plan to build a hello world program."},
 		},
 	}
 
-	agent, finishPlanTool := NewArchitectAgent(mockProvider)
+	agent := NewArchitectAgent(mockProvider)
 	mem := memory.NewConversationMemory()
 
 	result, err := agent.Run(context.Background(), "Create a hello world program", mem)
@@ -98,14 +125,16 @@ func TestArchitectAgent_GeneratesPlan(t *testing.T) {
 		t.Errorf("expected finish_plan tool call, got %s", result.ToolCallsMade[0].Name)
 	}
 
-	// Verify plan was captured
-	if !finishPlanTool.HasCapturedPlan() {
-		t.Error("expected plan to be captured")
+	// Verify the plan is the run's answer
+	plan, err := PlanFromResult(result)
+	if err != nil {
+		t.Fatalf("expected a plan: %v", err)
 	}
-
-	capturedPlan := finishPlanTool.GetCapturedPlan()
-	if capturedPlan == "" {
-		t.Error("captured plan is empty")
+	if plan.Goal != "Create a hello world program" || len(plan.Steps) != 1 || plan.Steps[0].Parameters["path"] != "main.go" {
+		t.Errorf("plan = %+v", plan)
+	}
+	if len(mockProvider.requests) != 1 {
+		t.Errorf("LLM called %d times, want the plan to end the run", len(mockProvider.requests))
 	}
 }
 
@@ -117,7 +146,7 @@ func TestArchitectAgent_SystemPromptIncluded(t *testing.T) {
 		},
 	}
 
-	agent, _ := NewArchitectAgent(mockProvider)
+	agent := NewArchitectAgent(mockProvider)
 	mem := memory.NewConversationMemory()
 
 	_, err := agent.Run(context.Background(), "Build a web server", mem)
@@ -144,7 +173,7 @@ func TestArchitectAgent_ToolDefinitionIncluded(t *testing.T) {
 		},
 	}
 
-	agent, _ := NewArchitectAgent(mockProvider)
+	agent := NewArchitectAgent(mockProvider)
 	mem := memory.NewConversationMemory()
 
 	_, err := agent.Run(context.Background(), "Build a calculator", mem)
@@ -209,11 +238,10 @@ func TestArchitectAgent_MultiStepPlan(t *testing.T) {
 					},
 				},
 			},
-			{Text: "I have created a comprehensive plan for the REST API."},
 		},
 	}
 
-	agent, finishPlanTool := NewArchitectAgent(mockProvider)
+	agent := NewArchitectAgent(mockProvider)
 	mem := memory.NewConversationMemory()
 
 	result, err := agent.Run(context.Background(), "Create a REST API", mem)
@@ -226,9 +254,9 @@ func TestArchitectAgent_MultiStepPlan(t *testing.T) {
 		t.Fatal("expected result, got nil")
 	}
 
-	// Verify plan was captured
-	if !finishPlanTool.HasCapturedPlan() {
-		t.Error("expected plan to be captured")
+	// Verify the plan is the run's answer
+	if plan, err := PlanFromResult(result); err != nil || len(plan.Steps) != 3 {
+		t.Errorf("plan = %+v, err = %v; want 3 steps", plan, err)
 	}
 }
 
@@ -252,7 +280,7 @@ func TestArchitectAgent_InvalidPlanRejected(t *testing.T) {
 		},
 	}
 
-	agent, finishPlanTool := NewArchitectAgent(mockProvider)
+	agent := NewArchitectAgent(mockProvider)
 	mem := memory.NewConversationMemory()
 
 	result, err := agent.Run(context.Background(), "Create something", mem)
@@ -265,9 +293,15 @@ func TestArchitectAgent_InvalidPlanRejected(t *testing.T) {
 		t.Fatal("expected result, got nil")
 	}
 
-	// Plan should NOT be captured because it was invalid
-	if finishPlanTool.HasCapturedPlan() {
-		t.Error("expected invalid plan to be rejected")
+	// There is no plan, because the only one submitted was invalid
+	if _, err := PlanFromResult(result); !errors.Is(err, ErrNoStructuredOutput) {
+		t.Errorf("PlanFromResult error = %v, want ErrNoStructuredOutput", err)
+	}
+
+	// The model was told why the plan was rejected
+	feedback := mockProvider.requests[1].Messages[2]
+	if feedback.ToolError == nil || feedback.ToolError.Kind != provider.ToolErrorValidation || !strings.Contains(feedback.Content, "steps") {
+		t.Errorf("feedback = %+v, want a validation error about steps", feedback)
 	}
 }
 
@@ -303,11 +337,10 @@ func TestArchitectAgent_PlanWithReadFileAction(t *testing.T) {
 					},
 				},
 			},
-			{Text: "Plan created for refactoring."},
 		},
 	}
 
-	agent, finishPlanTool := NewArchitectAgent(mockProvider)
+	agent := NewArchitectAgent(mockProvider)
 	mem := memory.NewConversationMemory()
 
 	result, err := agent.Run(context.Background(), "Refactor existing code", mem)
@@ -320,8 +353,40 @@ func TestArchitectAgent_PlanWithReadFileAction(t *testing.T) {
 		t.Fatal("expected result, got nil")
 	}
 
-	// Verify plan was captured
-	if !finishPlanTool.HasCapturedPlan() {
-		t.Error("expected plan to be captured")
+	// Verify the plan is the run's answer
+	if plan, err := PlanFromResult(result); err != nil || plan.Steps[0].Action != "read_file" {
+		t.Errorf("plan = %+v, err = %v", plan, err)
+	}
+}
+
+func TestArchitectAgent_ForcesFinishPlan(t *testing.T) {
+	// An architect that answers in prose is made to call finish_plan
+	mockProvider := &mockLLMProvider{
+		responses: []provider.LLMResponse{
+			{Text: "Step 1: write main.go."},
+			{
+				ToolCalls: []provider.ToolCall{{
+					ID:   "call_1",
+					Name: "finish_plan",
+					Arguments: map[string]interface{}{
+						"goal":  "Hello world",
+						"steps": []interface{}{map[string]interface{}{"description": "Write main.go", "action": "write_file"}},
+					},
+				}},
+			},
+		},
+	}
+
+	agent := NewArchitectAgent(mockProvider)
+	result, err := agent.Run(context.Background(), "Hello world", memory.NewConversationMemory())
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+
+	if choice := mockProvider.requests[1].ToolChoice; choice == nil || choice.Name != "finish_plan" {
+		t.Errorf("second request tool choice = %+v, want finish_plan forced", choice)
+	}
+	if result.Structured == nil {
+		t.Error("expected the forced call to produce a plan")
 	}
 }
@@ -33,18 +33,23 @@ When you have completed all steps in the plan, provide a summary of what was acc
 // NewCoderAgent creates a new Agent configured as a Coder.
 // The Coder agent is responsible for executing plans by reading and writing files.
 // The basePath parameter specifies the root directory for file operations.
+// Options may change its prompt, tools and iteration limit.
 //
 // Validates: Requirements 6.1, 6.2, 6.3, 6.4
-func NewCoderAgent(llmProvider provider.LLMProvider, basePath string) *Agent {
+func NewCoderAgent(llmProvider provider.LLMProvider, basePath string, opts ...Option) *Agent {
 	fileReader := tool.NewFileReaderTool(basePath)
 	fileWriter := tool.NewFileWriterTool(basePath)
 
-	agent := NewAgent(AgentConfig{
+	cfg := AgentConfig{
 		Provider:      llmProvider,
 		Tools:         []tool.Tool{fileReader, fileWriter},
 		SystemPrompt:  CoderSystemPrompt,
 		MaxIterations: DefaultMaxIterations,
-	})
+		Role:          RoleCoder,
+	}
+	for _, opt := range opts {
+		opt(&cfg)
+	}
 
-	return agent
+	return NewAgent(cfg)
 }
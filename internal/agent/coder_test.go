@@ -8,6 +8,7 @@ import (
 
 	"agentic-poc/internal/memory"
 	"agentic-poc/internal/provider"
+	"agentic-poc/internal/tool"
 )
 
 func TestNewCoderAgent(t *testing.T) {
@@ -46,6 +47,31 @@ func TestNewCoderAgent(t *testing.T) {
 	}
 }
 
+func TestNewCoderAgent_Options(t *testing.T) {
+	agent := NewCoderAgent(&mockLLMProvider{}, "/tmp/test",
+		WithSystemPrompt("Only read."),
+		WithTools([]tool.Tool{tool.NewFileReaderTool("/tmp/test")}),
+		WithMaxIterations(25),
+		WithThinkingBudget(4096),
+	)
+
+	if agent.systemPrompt != "Only read." || agent.maxIterations != 25 {
+		t.Errorf("prompt = %q, max iterations = %d", agent.systemPrompt, agent.maxIterations)
+	}
+	if agent.thinking != 4096 {
+		t.Errorf("thinking budget = %d, want 4096", agent.thinking)
+	}
+	if _, ok := agent.tools["write_file"]; ok || len(agent.tools) != 1 {
+		t.Errorf("tools = %v, want only read_file", agent.tools)
+	}
+
+	// An empty tool list removes the default tools
+	agent = NewCoderAgent(&mockLLMProvider{}, "/tmp/test", WithTools([]tool.Tool{}))
+	if len(agent.tools) != 0 {
+		t.Errorf("tools = %v, want none", agent.tools)
+	}
+}
+
 func TestCoderAgent_SystemPromptIncluded(t *testing.T) {
 	mockProvider := &mockLLMProvider{
 		responses: []provider.LLMResponse{
@@ -0,0 +1,75 @@
+// Package agent implements the core agent loop and specialized agents.
+package agent
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+
+	"agentic-poc/internal/provider"
+)
+
+// DefaultOutputTool is the name of the tool an agent with an output schema
+// calls to give its answer, unless AgentConfig.OutputTool names another.
+const DefaultOutputTool = "final_answer"
+
+// ErrNoStructuredOutput is returned when decoding the structured answer of
+// a run that finished without one.
+var ErrNoStructuredOutput = errors.New("agent did not produce a structured answer")
+
+// WithOutputSchema makes the agent finish by calling the final_answer tool
+// with arguments matching schema, such as one derived from a Go type with
+// tool.SchemaFor. See AgentConfig.OutputSchema.
+func WithOutputSchema(schema map[string]interface{}) Option {
+	return func(cfg *AgentConfig) {
+		if schema != nil {
+			cfg.OutputSchema = schema
+		}
+	}
+}
+
+// Decode stores the run's structured answer in the value pointed to by v,
+// by way of JSON, so the usual json tags and types apply. It returns
+// ErrNoStructuredOutput if the run has no structured answer.
+func (r *AgentResult) Decode(v interface{}) error {
+	if r == nil || r.Structured == nil {
+		return ErrNoStructuredOutput
+	}
+	data, err := json.Marshal(r.Structured)
+	if err != nil {
+		return fmt.Errorf("failed to encode structured answer: %w", err)
+	}
+	if err := json.Unmarshal(data, v); err != nil {
+		return fmt.Errorf("failed to decode structured answer: %w", err)
+	}
+	return nil
+}
+
+// outputTool is the tool generated for an agent's output schema. Its
+// arguments are the answer: the agent validates them against the schema
+// before the tool runs, so the tool only has to accept them.
+type outputTool struct {
+	name   string
+	schema map[string]interface{}
+}
+
+// Name returns the tool's identifier.
+func (t *outputTool) Name() string {
+	return t.name
+}
+
+// Description returns what the tool does.
+func (t *outputTool) Description() string {
+	return "Submits your final answer. Call this once you are done, with the answer as the arguments; do not give the answer as text."
+}
+
+// Parameters returns the output schema.
+func (t *outputTool) Parameters() map[string]interface{} {
+	return t.schema
+}
+
+// Execute accepts the answer.
+func (t *outputTool) Execute(ctx context.Context, args map[string]interface{}) (*provider.ToolResult, error) {
+	return &provider.ToolResult{Success: true, Output: "Answer accepted."}, nil
+}
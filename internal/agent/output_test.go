@@ -0,0 +1,193 @@
+package agent
+
+import (
+	"context"
+	"errors"
+	"strings"
+	"testing"
+
+	"agentic-poc/internal/memory"
+	"agentic-poc/internal/provider"
+	"agentic-poc/internal/tool"
+)
+
+// verdict is the structured answer used by the output schema tests.
+type verdict struct {
+	Label      string  `json:"label" jsonschema:"enum=positive,enum=negative"`
+	Confidence float64 `json:"confidence" jsonschema:"minimum=0,maximum=1"`
+}
+
+func answerCall(id, name string, args map[string]interface{}) provider.LLMResponse {
+	return provider.LLMResponse{ToolCalls: []provider.ToolCall{{ID: id, Name: name, Arguments: args}}}
+}
+
+func TestAgent_Run_OutputSchema(t *testing.T) {
+	valid := map[string]interface{}{"label": "positive", "confidence": 0.9}
+	invalid := map[string]interface{}{"label": "neutral", "confidence": 2.0}
+
+	tests := []struct {
+		name         string
+		outputTool   string
+		responses    []provider.LLMResponse
+		wantRequests int
+		wantAnswer   bool
+		wantForced   bool
+		wantFeedback []string // Messages of the validation errors sent back
+	}{
+		{
+			name:         "valid answer ends the run",
+			responses:    []provider.LLMResponse{answerCall("call_1", "final_answer", valid)},
+			wantRequests: 1,
+			wantAnswer:   true,
+		},
+		{
+			name: "invalid answer is corrected",
+			responses: []provider.LLMResponse{
+				answerCall("call_1", "final_answer", invalid),
+				answerCall("call_2", "final_answer", valid),
+			},
+			wantRequests: 2,
+			wantAnswer:   true,
+			wantFeedback: []string{"label: must be one of", "confidence: must be <= 1"},
+		},
+		{
+			name: "text answer is forced into the tool",
+			responses: []provider.LLMResponse{
+				{Text: "It is positive."},
+				answerCall("call_1", "final_answer", valid),
+			},
+			wantRequests: 2,
+			wantAnswer:   true,
+			wantForced:   true,
+		},
+		{
+			name: "invalid answer then text is forced",
+			responses: []provider.LLMResponse{
+				answerCall("call_1", "final_answer", invalid),
+				{Text: "Fine, it is positive."},
+				answerCall("call_2", "final_answer", valid),
+			},
+			wantRequests: 3,
+			wantAnswer:   true,
+			wantForced:   true,
+		},
+		{
+			name:         "no answer after forcing",
+			responses:    []provider.LLMResponse{{Text: "No."}, {Text: "Still no."}},
+			wantRequests: 2,
+			wantForced:   true,
+		},
+		{
+			name:         "custom tool name",
+			outputTool:   "submit_verdict",
+			responses:    []provider.LLMResponse{answerCall("call_1", "submit_verdict", valid)},
+			wantRequests: 1,
+			wantAnswer:   true,
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			mockProvider := &mockLLMProvider{responses: tt.responses}
+			agent := NewAgent(AgentConfig{
+				Provider:     mockProvider,
+				OutputSchema: tool.SchemaFor[verdict](),
+				OutputTool:   tt.outputTool,
+			})
+			mem := memory.NewConversationMemory()
+
+			result, err := agent.Run(context.Background(), "Classify: great product", mem)
+			if err != nil {
+				t.Fatalf("unexpected error: %v", err)
+			}
+
+			if len(mockProvider.requests) != tt.wantRequests {
+				t.Errorf("LLM called %d times, want %d", len(mockProvider.requests), tt.wantRequests)
+			}
+			forced := false
+			for _, req := range mockProvider.requests {
+				if req.ToolChoice != nil {
+					forced = true
+				}
+			}
+			if forced != tt.wantForced {
+				t.Errorf("forced = %v, want %v", forced, tt.wantForced)
+			}
+
+			var got verdict
+			err = result.Decode(&got)
+			if !tt.wantAnswer {
+				if !errors.Is(err, ErrNoStructuredOutput) {
+					t.Errorf("Decode error = %v, want ErrNoStructuredOutput", err)
+				}
+				return
+			}
+			if err != nil {
+				t.Fatalf("Decode error: %v", err)
+			}
+			if got != (verdict{Label: "positive", Confidence: 0.9}) {
+				t.Errorf("answer = %+v", got)
+			}
+			if !strings.Contains(result.Response, `"label": "positive"`) {
+				t.Errorf("response = %q, want the answer as JSON", result.Response)
+			}
+
+			var feedback []string
+			for _, msg := range mem.GetMessages() {
+				if msg.ToolError != nil && msg.ToolError.Kind == provider.ToolErrorValidation {
+					feedback = append(feedback, msg.Content)
+				}
+			}
+			for _, want := range tt.wantFeedback {
+				if len(feedback) == 0 || !strings.Contains(feedback[0], want) {
+					t.Errorf("feedback = %q, want it to mention %s", feedback, want)
+				}
+			}
+
+			// The accepted answer has a result, so the conversation can go on
+			messages := mem.GetMessages()
+			if last := messages[len(messages)-1]; last.Role != "tool" || last.ToolError != nil {
+				t.Errorf("last message = %+v, want the accepted answer's result", last)
+			}
+		})
+	}
+}
+
+func TestAgent_OutputSchemaTool(t *testing.T) {
+	schema := tool.SchemaFor[verdict]()
+	agent := NewAgent(AgentConfig{
+		Provider:     &mockLLMProvider{},
+		Tools:        []tool.Tool{&mockTool{name: "final_answer"}, &mockTool{name: "lookup"}},
+		RequiredTool: "lookup",
+		OutputSchema: schema,
+	})
+
+	// The generated tool replaces a configured one of the same name and is
+	// required in place of RequiredTool
+	if agent.requiredTool != DefaultOutputTool {
+		t.Errorf("required tool = %q, want %q", agent.requiredTool, DefaultOutputTool)
+	}
+	answer, ok := agent.tools[DefaultOutputTool].(*outputTool)
+	if !ok || len(agent.tools) != 2 {
+		t.Fatalf("tools = %v, want lookup and the generated final_answer", agent.tools)
+	}
+	if answer.Parameters()["required"] == nil {
+		t.Errorf("parameters = %v, want the output schema", answer.Parameters())
+	}
+}
+
+func TestAgentTool_StructuredAnswer(t *testing.T) {
+	mockProvider := &mockLLMProvider{responses: []provider.LLMResponse{
+		answerCall("call_1", "final_answer", map[string]interface{}{"label": "negative", "confidence": 0.2}),
+	}}
+	a := NewAgent(AgentConfig{Provider: mockProvider, OutputSchema: tool.SchemaFor[verdict]()})
+
+	result, err := NewAgentTool("classify", "Classifies text", a).Execute(context.Background(), map[string]interface{}{"input": "meh"})
+	if err != nil || !result.Success {
+		t.Fatalf("result = %+v, err = %v", result, err)
+	}
+	structured, _ := result.Content[1].Structured.(map[string]interface{})
+	if answer, _ := structured["answer"].(map[string]interface{}); answer["label"] != "negative" {
+		t.Errorf("structured content = %v, want the answer", structured)
+	}
+}
@@ -9,15 +9,16 @@ import (
 
 // PlanStep represents a single step in a plan.
 type PlanStep struct {
-	Description string                 `json:"description"`
-	Action      string                 `json:"action"`
-	Parameters  map[string]interface{} `json:"parameters,omitempty"`
+	Description string                 `json:"description" jsonschema:"description=A description of what this step accomplishes,minLength=1"`
+	Action      string                 `json:"action" jsonschema:"description=The action to perform (e.g., 'write_file', 'read_file'),minLength=1"`
+	Parameters  map[string]interface{} `json:"parameters,omitempty" jsonschema:"description=Parameters for the action"`
 }
 
-// Plan represents a structured plan created by the Architect agent.
+// Plan represents a structured plan created by the Architect agent. Its
+// JSON Schema, from tool.SchemaFor, is the Architect's output schema.
 type Plan struct {
-	Goal  string     `json:"goal"`
-	Steps []PlanStep `json:"steps"`
+	Goal  string     `json:"goal" jsonschema:"description=The high-level goal this plan addresses,minLength=1"`
+	Steps []PlanStep `json:"steps" jsonschema:"description=The ordered list of steps to execute,minItems=1"`
 }
 
 // ToJSON serializes the Plan to a JSON string.
@@ -45,27 +46,49 @@ func ParsePlan(jsonStr string) (*Plan, error) {
 		return nil, fmt.Errorf("failed to parse plan JSON: %w", err)
 	}
 
-	// Validate required fields
-	if plan.Goal == "" {
-		return nil, errors.New("plan is missing required field: goal")
+	if err := plan.normalize(); err != nil {
+		return nil, err
 	}
+	return &plan, nil
+}
 
-	if len(plan.Steps) == 0 {
-		return nil, errors.New("plan must have at least one step")
+// PlanFromResult returns the plan produced by an Architect run, taken from
+// its structured answer. It returns ErrNoStructuredOutput if the Architect
+// finished without a plan.
+func PlanFromResult(result *AgentResult) (*Plan, error) {
+	var plan Plan
+	if err := result.Decode(&plan); err != nil {
+		return nil, err
 	}
 
-	for i, step := range plan.Steps {
+	if err := plan.normalize(); err != nil {
+		return nil, err
+	}
+	return &plan, nil
+}
+
+// normalize checks the plan's required fields and gives every step a
+// non-nil parameter map.
+func (p *Plan) normalize() error {
+	if p.Goal == "" {
+		return errors.New("plan is missing required field: goal")
+	}
+
+	if len(p.Steps) == 0 {
+		return errors.New("plan must have at least one step")
+	}
+
+	for i, step := range p.Steps {
 		if step.Description == "" {
-			return nil, fmt.Errorf("step %d is missing required field: description", i+1)
+			return fmt.Errorf("step %d is missing required field: description", i+1)
 		}
 		if step.Action == "" {
-			return nil, fmt.Errorf("step %d is missing required field: action", i+1)
+			return fmt.Errorf("step %d is missing required field: action", i+1)
 		}
 		// Normalize nil parameters to empty map for consistency
-		if plan.Steps[i].Parameters == nil {
-			plan.Steps[i].Parameters = make(map[string]interface{})
+		if p.Steps[i].Parameters == nil {
+			p.Steps[i].Parameters = make(map[string]interface{})
 		}
 	}
-
-	return &plan, nil
+	return nil
 }
@@ -0,0 +1,129 @@
+package cli
+
+import (
+	"errors"
+	"fmt"
+	"io/fs"
+	"net/http"
+	"os"
+	"path/filepath"
+	"strings"
+
+	"agentic-poc/internal/provider"
+)
+
+// attachment is a file attached to a prompt with @path.
+type attachment struct {
+	path  string
+	block provider.ContentBlock
+}
+
+// mediaExtensions are the file extensions that mark an @word as a path
+// even without a slash, such as "@diagram.png".
+var mediaExtensions = map[string]bool{
+	".png": true, ".jpg": true, ".jpeg": true, ".gif": true, ".webp": true, ".pdf": true,
+}
+
+// parseAttachments loads the files named by @path words in input, such as
+// "@docs/diagram.png". Paths are relative to the working directory, and
+// trailing punctuation is ignored if the path does not exist without it.
+// Only a word that looks like a path, with a slash or an image or PDF
+// extension, is an attachment, so "@alice" and "@example.com" stay text;
+// write "@./notes.txt" to attach another file from the working directory.
+// A path that does not exist is left as text too and returned in missing,
+// so the caller can warn about it. Each file is attached once.
+func parseAttachments(input string) (attachments []attachment, missing []string, err error) {
+	seen := make(map[string]bool)
+
+	for _, word := range strings.Fields(input) {
+		if len(word) < 2 || word[0] != '@' {
+			continue
+		}
+		path := word[1:]
+		if _, err := os.Stat(path); err != nil {
+			path = strings.TrimRight(path, ".,;:!?)\"'")
+		}
+		if !strings.ContainsRune(path, '/') && !mediaExtensions[strings.ToLower(filepath.Ext(path))] {
+			continue
+		}
+		if seen[path] {
+			continue
+		}
+		seen[path] = true
+
+		info, err := os.Stat(path)
+		if errors.Is(err, fs.ErrNotExist) {
+			missing = append(missing, path)
+			continue
+		}
+		if err != nil {
+			return nil, nil, fmt.Errorf("attachment @%s: %w", path, err)
+		}
+		if info.IsDir() {
+			return nil, nil, fmt.Errorf("attachment @%s: is a directory", path)
+		}
+
+		block, err := loadAttachment(path)
+		if err != nil {
+			return nil, nil, fmt.Errorf("attachment @%s: %w", path, err)
+		}
+		attachments = append(attachments, attachment{path: path, block: block})
+	}
+	return attachments, missing, nil
+}
+
+// rejectAttachments returns an error if a goal for the multi-agent
+// workflow attaches files, which only single-agent mode supports.
+func rejectAttachments(goal string) error {
+	attachments, _, err := parseAttachments(goal)
+	if err != nil {
+		return err
+	}
+	if len(attachments) > 0 {
+		return fmt.Errorf("attachments such as @%s are only supported in single-agent mode", attachments[0].path)
+	}
+	return nil
+}
+
+// loadAttachment reads a file as an image or document block, or as text.
+func loadAttachment(path string) (provider.ContentBlock, error) {
+	data, err := os.ReadFile(path)
+	if err != nil {
+		return provider.ContentBlock{}, err
+	}
+
+	if block, ok := provider.MediaBlock(path, data); ok {
+		if len(data) > provider.MaxMediaSize {
+			return provider.ContentBlock{}, fmt.Errorf("file is too large (%d bytes, limit %d)", len(data), provider.MaxMediaSize)
+		}
+		return block, nil
+	}
+	if http.DetectContentType(data) == "application/octet-stream" {
+		return provider.ContentBlock{}, fmt.Errorf("unsupported binary file; attach text, images or PDFs")
+	}
+	return provider.TextBlock(fmt.Sprintf("Contents of %s:\n%s", path, data)), nil
+}
+
+// promptBlocks returns the content blocks of a prompt with attachments:
+// the attached files first, then the prompt text. It returns nil if there
+// are no attachments, so the prompt is sent as plain text.
+func promptBlocks(input string, attachments []attachment) []provider.ContentBlock {
+	if len(attachments) == 0 {
+		return nil
+	}
+	blocks := make([]provider.ContentBlock, 0, len(attachments)+1)
+	for _, a := range attachments {
+		blocks = append(blocks, a.block)
+	}
+	return append(blocks, provider.TextBlock(input))
+}
+
+// describeAttachment returns a short description of an attachment, such as
+// "diagram.png (image/png)".
+func describeAttachment(a attachment) string {
+	kind := a.block.MimeType
+	if kind == "" {
+		kind = "text"
+	}
+	return fmt.Sprintf("%s (%s)", a.path, kind)
+}
@@ -0,0 +1,161 @@
+package cli
+
+import (
+	"bytes"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+
+	"agentic-poc/internal/provider"
+)
+
+// pngHeader is enough of a PNG file for content type detection.
+var pngHeader = []byte("\x89PNG\r\n\x1a\n\x00\x00\x00\rIHDR")
+
+// writeAttachments writes sample files to a temporary directory and
+// returns it.
+func writeAttachments(t *testing.T) string {
+	t.Helper()
+	dir := t.TempDir()
+	files := map[string][]byte{
+		"diagram.png": pngHeader,
+		"spec.pdf":    []byte("%PDF-1.4\n%\xe2\xe3\xcf\xd3\n"),
+		"notes.txt":   []byte("remember the milk"),
+		"blob.bin":    {0x00, 0x01, 0x02, 0x03, 0xff},
+	}
+	for name, data := range files {
+		if err := os.WriteFile(filepath.Join(dir, name), data, 0644); err != nil {
+			t.Fatal(err)
+		}
+	}
+	if err := os.Mkdir(filepath.Join(dir, "sub"), 0755); err != nil {
+		t.Fatal(err)
+	}
+	return dir
+}
+
+func TestParseAttachments(t *testing.T) {
+	dir := writeAttachments(t)
+	path := func(name string) string { return filepath.Join(dir, name) }
+
+	tests := []struct {
+		name        string
+		input       string
+		wantPaths   []string
+		wantTypes   []provider.ContentType
+		wantMissing []string
+		wantErr     string
+	}{
+		{name: "no attachments", input: "hello there"},
+		{name: "mention is text", input: "ask @alice about it"},
+		{name: "dotted mention is text", input: "email @john.doe"},
+		{name: "domain is text", input: "ping @example.com."},
+		{
+			name:      "image",
+			input:     "what is in @" + path("diagram.png") + "?",
+			wantPaths: []string{path("diagram.png")},
+			wantTypes: []provider.ContentType{provider.ContentImage},
+		},
+		{
+			name:      "document and text file",
+			input:     "compare @" + path("spec.pdf") + " with @" + path("notes.txt") + ".",
+			wantPaths: []string{path("spec.pdf"), path("notes.txt")},
+			wantTypes: []provider.ContentType{provider.ContentDocument, provider.ContentText},
+		},
+		{
+			name:      "attached once",
+			input:     "@" + path("diagram.png") + " and again @" + path("diagram.png"),
+			wantPaths: []string{path("diagram.png")},
+			wantTypes: []provider.ContentType{provider.ContentImage},
+		},
+		{name: "missing file", input: "see @" + path("missing.png"), wantMissing: []string{path("missing.png")}},
+		{name: "missing image name", input: "see @missing.png?", wantMissing: []string{"missing.png"}},
+		{name: "directory", input: "see @" + path("sub"), wantErr: "is a directory"},
+		{name: "binary file", input: "see @" + path("blob.bin"), wantErr: "unsupported binary file"},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			attachments, missing, err := parseAttachments(tt.input)
+			if tt.wantErr != "" {
+				if err == nil || !strings.Contains(err.Error(), tt.wantErr) {
+					t.Errorf("error = %v, want one containing %q", err, tt.wantErr)
+				}
+				return
+			}
+			if err != nil {
+				t.Fatalf("unexpected error: %v", err)
+			}
+			if strings.Join(missing, ",") != strings.Join(tt.wantMissing, ",") {
+				t.Errorf("missing = %q, want %q", missing, tt.wantMissing)
+			}
+			if len(attachments) != len(tt.wantPaths) {
+				t.Fatalf("got %d attachments, want %d", len(attachments), len(tt.wantPaths))
+			}
+			for i, a := range attachments {
+				if a.path != tt.wantPaths[i] || a.block.Type != tt.wantTypes[i] {
+					t.Errorf("attachment %d = %s (%s), want %s (%s)", i, a.path, a.block.Type, tt.wantPaths[i], tt.wantTypes[i])
+				}
+			}
+		})
+	}
+}
+
+func TestSingleAgentMode_MissingAttachment(t *testing.T) {
+	mock := newMockProvider(&provider.LLMResponse{Text: "Done."})
+	output := &bytes.Buffer{}
+
+	cli := NewCLIWithIO(mock, strings.NewReader("Summarize @./missing.txt for @john.doe\nexit\n"), output)
+	if err := cli.RunSingleAgentMode(); err != nil {
+		t.Fatal(err)
+	}
+
+	if !strings.Contains(output.String(), "Warning: @./missing.txt not found, sent as text") {
+		t.Errorf("output should warn about the missing file, got: %s", output.String())
+	}
+	if len(mock.calls) != 1 || mock.calls[0].Messages[0].Content != "Summarize @./missing.txt for @john.doe" {
+		t.Errorf("the prompt should be sent as text, got calls: %+v", mock.calls)
+	}
+}
+
+func TestSingleAgentMode_Attachment(t *testing.T) {
+	dir := writeAttachments(t)
+	image := filepath.Join(dir, "diagram.png")
+	mock := newMockProvider(&provider.LLMResponse{Text: "A diagram."})
+	output := &bytes.Buffer{}
+
+	cli := NewCLIWithIO(mock, strings.NewReader("Describe @"+image+"\nexit\n"), output)
+	if err := cli.RunSingleAgentMode(); err != nil {
+		t.Fatal(err)
+	}
+
+	if !strings.Contains(output.String(), "Attached "+image+" (image/png)") {
+		t.Errorf("output should list the attachment, got: %s", output.String())
+	}
+	msg := mock.calls[0].Messages[0]
+	if len(msg.Blocks) != 2 || msg.Blocks[0].Type != provider.ContentImage || msg.Blocks[1].Text != "Describe @"+image {
+		t.Errorf("user message blocks = %+v, want the image then the prompt", msg.Blocks)
+	}
+	if msg.Content != "Describe @"+image {
+		t.Errorf("text form = %q, want the prompt", msg.Content)
+	}
+}
+
+func TestMultiAgentMode_RejectsAttachments(t *testing.T) {
+	dir := writeAttachments(t)
+	mock := newMockProvider()
+	output := &bytes.Buffer{}
+
+	cli := NewCLIWithIO(mock, strings.NewReader("Build @"+filepath.Join(dir, "diagram.png")+"\nexit\n"), output)
+	if err := cli.RunMultiAgentMode(); err != nil {
+		t.Fatal(err)
+	}
+
+	if !strings.Contains(output.String(), "only supported in single-agent mode") {
+		t.Errorf("expected an attachment error, got: %s", output.String())
+	}
+	if len(mock.calls) != 0 {
+		t.Error("the workflow should not run")
+	}
+}
@@ -0,0 +1,301 @@
+package cli
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"os"
+	"sort"
+	"strings"
+
+	"agentic-poc/internal/agent"
+	"agentic-poc/internal/config"
+	"agentic-poc/internal/provider"
+	"agentic-poc/internal/tool"
+)
+
+// historyPreviewLength is the number of characters of each message shown
+// by /history.
+const historyPreviewLength = 120
+
+// registerBuiltinCommands registers the slash commands every CLI has.
+func (c *CLI) registerBuiltinCommands() {
+	for _, cmd := range []Command{
+		{Name: "help", Description: "List commands", Run: cmdHelp},
+		{Name: "tools", Args: "[name]", Description: "List the available tools and their input schemas", Run: cmdTools},
+		{Name: "clear", Description: "Start a new conversation", Run: cmdClear},
+		{Name: "history", Description: "Show the conversation so far", Run: cmdHistory},
+		{Name: "save", Args: "<file>", Description: "Save the conversation and last plan to a file", Run: cmdSave},
+		{Name: "load", Args: "<file>", Description: "Restore a conversation saved with /save", Run: cmdLoad},
+		{Name: "model", Args: "[name]", Description: "Show or switch the LLM model", Run: cmdModel},
+		{Name: "mode", Args: "[single|multi]", Description: "Show or switch the agent mode", Run: cmdMode},
+		{Name: "mcp", Args: "[reconnect <server>]", Description: "Show MCP server status or reconnect a server", Run: cmdMCP},
+		{Name: "plan", Description: "Show the last plan from multi-agent mode", Run: cmdPlan},
+	} {
+		c.RegisterCommand(cmd)
+	}
+}
+
+// cmdHelp lists the registered commands.
+func cmdHelp(ctx context.Context, c *CLI, args []string) error {
+	commands := c.Commands()
+
+	usages := make([]string, len(commands))
+	width := 0
+	for i, cmd := range commands {
+		usages[i] = "/" + cmd.Name
+		if cmd.Args != "" {
+			usages[i] += " " + cmd.Args
+		}
+		if len(usages[i]) > width {
+			width = len(usages[i])
+		}
+	}
+
+	c.println("Commands:")
+	for i, cmd := range commands {
+		c.printf("  %-*s  %s\n", width, usages[i], cmd.Description)
+	}
+	c.println("Type 'exit' or 'quit' to exit.")
+	return nil
+}
+
+// cmdTools lists the tools of the current mode with their input schemas,
+// or only the named tool.
+func cmdTools(ctx context.Context, c *CLI, args []string) error {
+	tools, err := c.modeTools()
+	if err != nil {
+		return err
+	}
+
+	if len(args) > 0 {
+		var match []tool.Tool
+		for _, t := range tools {
+			if t.Name() == args[0] {
+				match = append(match, t)
+			}
+		}
+		if len(match) == 0 {
+			return fmt.Errorf("unknown tool %q", args[0])
+		}
+		tools = match
+	}
+
+	for _, t := range tools {
+		c.printf("%s: %s\n", t.Name(), t.Description())
+		schema, err := json.MarshalIndent(t.Parameters(), "    ", "  ")
+		if err != nil {
+			return fmt.Errorf("failed to encode schema of %s: %w", t.Name(), err)
+		}
+		c.printf("    %s\n", schema)
+	}
+	return nil
+}
+
+// modeTools returns the tools available in the current mode sorted by
+// name: the single agent's tools, or the architect's and coder's.
+func (c *CLI) modeTools() ([]tool.Tool, error) {
+	var tools []tool.Tool
+	if c.mode == ModeMulti {
+		architectOpts, err := c.agentOptions(config.AgentArchitect)
+		if err != nil {
+			return nil, err
+		}
+		coderOpts, err := c.agentOptions(config.AgentCoder)
+		if err != nil {
+			return nil, err
+		}
+		architect := agent.NewArchitectAgent(c.provider, architectOpts...)
+		tools = append(architect.GetTools(), agent.NewCoderAgent(c.provider, c.basePath, coderOpts...).GetTools()...)
+	} else {
+		if c.agent == nil {
+			agentInstance, err := c.newSingleAgent()
+			if err != nil {
+				return nil, err
+			}
+			c.agent = agentInstance
+		}
+		tools = c.agent.GetTools()
+	}
+
+	sort.Slice(tools, func(i, j int) bool { return tools[i].Name() < tools[j].Name() })
+	return tools, nil
+}
+
+// cmdClear starts a new single-agent conversation.
+func cmdClear(ctx context.Context, c *CLI, args []string) error {
+	c.session.Clear()
+	c.println("Conversation cleared.")
+	return nil
+}
+
+// cmdHistory prints a one-line summary of each message in the conversation.
+func cmdHistory(ctx context.Context, c *CLI, args []string) error {
+	messages := c.session.GetMessages()
+	if len(messages) == 0 {
+		c.println("No conversation yet.")
+		return nil
+	}
+
+	for i, msg := range messages {
+		c.printf("%3d. %s\n", i+1, describeMessage(msg))
+	}
+	return nil
+}
+
+// describeMessage summarizes a message on one line.
+func describeMessage(msg provider.Message) string {
+	text := previewText(msg.Content)
+
+	switch {
+	case msg.ToolCallID != "" && msg.ToolError != nil:
+		return fmt.Sprintf("tool %s failed: %s", msg.ToolName, text)
+	case msg.ToolCallID != "":
+		return fmt.Sprintf("tool %s: %s", msg.ToolName, text)
+	case len(msg.ToolCalls) > 0:
+		names := make([]string, len(msg.ToolCalls))
+		for i, tc := range msg.ToolCalls {
+			names[i] = tc.Name
+		}
+		calls := "calls " + strings.Join(names, ", ")
+		if text == "" {
+			return fmt.Sprintf("%s: [%s]", msg.Role, calls)
+		}
+		return fmt.Sprintf("%s: %s [%s]", msg.Role, text, calls)
+	default:
+		return fmt.Sprintf("%s: %s", msg.Role, text)
+	}
+}
+
+// previewText flattens text to one line and shortens it to
+// historyPreviewLength characters.
+func previewText(text string) string {
+	text = strings.Join(strings.Fields(text), " ")
+	runes := []rune(text)
+	if len(runes) <= historyPreviewLength {
+		return text
+	}
+	return string(runes[:historyPreviewLength]) + "..."
+}
+
+// sessionFile is the format of files written by /save.
+type sessionFile struct {
+	Messages []provider.Message `json:"messages"`
+	Plan     *agent.Plan        `json:"plan,omitempty"`
+}
+
+// cmdSave writes the conversation and the last plan to a file.
+func cmdSave(ctx context.Context, c *CLI, args []string) error {
+	if len(args) != 1 {
+		return fmt.Errorf("usage: /save <file>")
+	}
+
+	session := sessionFile{Messages: c.session.GetMessages(), Plan: c.lastPlan}
+	data, err := json.MarshalIndent(session, "", "  ")
+	if err != nil {
+		return fmt.Errorf("failed to encode session: %w", err)
+	}
+	if err := os.WriteFile(args[0], data, 0644); err != nil {
+		return fmt.Errorf("failed to save session: %w", err)
+	}
+
+	c.printf("Saved %d messages to %s\n", len(session.Messages), args[0])
+	return nil
+}
+
+// cmdLoad replaces the conversation and the last plan with those in a
+// file written by /save.
+func cmdLoad(ctx context.Context, c *CLI, args []string) error {
+	if len(args) != 1 {
+		return fmt.Errorf("usage: /load <file>")
+	}
+
+	data, err := os.ReadFile(args[0])
+	if err != nil {
+		return fmt.Errorf("failed to load session: %w", err)
+	}
+	var session sessionFile
+	if err := json.Unmarshal(data, &session); err != nil {
+		return fmt.Errorf("failed to parse session file %s: %w", args[0], err)
+	}
+
+	c.session.SetMessages(session.Messages)
+	c.lastPlan = session.Plan
+	c.printf("Loaded %d messages from %s\n", len(session.Messages), args[0])
+	return nil
+}
+
+// cmdModel shows or changes the provider's model.
+func cmdModel(ctx context.Context, c *CLI, args []string) error {
+	switcher, ok := c.provider.(provider.ModelSwitcher)
+	if !ok {
+		return fmt.Errorf("provider %s does not support switching models", c.provider.Name())
+	}
+
+	if len(args) == 0 {
+		c.printf("Model: %s\n", switcher.Model())
+		return nil
+	}
+	switcher.SetModel(args[0])
+	c.printf("Model set to %s\n", args[0])
+	return nil
+}
+
+// cmdMode shows or switches between single-agent and multi-agent mode.
+func cmdMode(ctx context.Context, c *CLI, args []string) error {
+	if len(args) == 0 {
+		c.printf("Mode: %s\n", c.mode)
+		return nil
+	}
+	if args[0] == c.mode {
+		c.printf("Already in %s mode.\n", c.mode)
+		return nil
+	}
+	c.println()
+	return c.enterMode(args[0])
+}
+
+// cmdMCP lists the MCP servers with their status, or reconnects one.
+func cmdMCP(ctx context.Context, c *CLI, args []string) error {
+	if c.mcpManager == nil {
+		c.println("No MCP servers loaded.")
+		return nil
+	}
+
+	switch {
+	case len(args) == 0:
+		servers := c.mcpManager.Servers()
+		if len(servers) == 0 {
+			c.println("No MCP servers loaded.")
+			return nil
+		}
+		c.println("MCP servers:")
+		for _, s := range servers {
+			if s.Connected {
+				c.printf("  %s: connected, %d tools\n", s.Name, s.ToolCount)
+			} else {
+				c.printf("  %s: disconnected\n", s.Name)
+			}
+		}
+		return nil
+	case len(args) == 2 && args[0] == "reconnect":
+		if err := c.mcpManager.Reconnect(ctx, args[1]); err != nil {
+			return err
+		}
+		c.printf("Reconnected %s\n", args[1])
+		c.printToolConflicts()
+		return nil
+	default:
+		return fmt.Errorf("usage: /mcp [reconnect <server>]")
+	}
+}
+
+// cmdPlan shows the most recent plan from multi-agent mode.
+func cmdPlan(ctx context.Context, c *CLI, args []string) error {
+	if c.lastPlan == nil {
+		c.println("No plan yet. Run a goal in multi-agent mode first.")
+		return nil
+	}
+	c.printPlan(c.lastPlan)
+	return nil
+}
@@ -4,12 +4,15 @@ package cli
 import (
 	"bufio"
 	"context"
+	"errors"
 	"fmt"
 	"io"
 	"os"
 	"strings"
+	"sync"
 
 	"agentic-poc/internal/agent"
+	"agentic-poc/internal/config"
 	"agentic-poc/internal/mcp"
 	"agentic-poc/internal/memory"
 	"agentic-poc/internal/orchestrator"
@@ -17,6 +20,12 @@ import (
 	"agentic-poc/internal/tool"
 )
 
+// Interactive modes.
+const (
+	ModeSingle = "single"
+	ModeMulti  = "multi"
+)
+
 // CLI provides the command-line interface for interacting with the agentic system.
 // It supports both single-agent mode (for testing tool use) and multi-agent mode
 // (for the Architect/Coder workflow).
@@ -24,33 +33,60 @@ import (
 // Validates: Requirement 9.1
 type CLI struct {
 	provider   provider.LLMProvider
+	config     *config.Config
 	output     io.Writer
 	input      *bufio.Scanner
+	lines      chan string // Lines of input, read on a goroutine by readLine
+	linesOnce  sync.Once
 	basePath   string
 	mcpManager *mcp.MCPManager
 	mcpOnly    bool // If true, only use MCP tools (no built-in tools)
+
+	mode     string                     // ModeSingle or ModeMulti, once a REPL has started
+	agent    *agent.Agent               // Single-agent mode agent, created on first use
+	session  *memory.ConversationMemory // Single-agent mode conversation
+	lastPlan *agent.Plan                // Most recent plan from multi-agent mode
+	commands map[string]Command         // Slash commands by name
+
+	showThinking bool       // Print the model's extended thinking during runs
+	mcpLogLevel  string     // Minimum MCP server log level shown, "" for none
+	outputMu     sync.Mutex // MCP events are printed from client read loops
+	outputFormat string     // OutputText or OutputJSON, for one-shot runs
+	errOutput    io.Writer  // Receives MCP events when the output is JSON
+
+	approveMu sync.Mutex // Asks about one sampling request at a time
+
+	runMu     sync.Mutex
+	runCancel context.CancelFunc // Cancels the run in progress, nil if none
+	runCtx    context.Context    // Context of the run in progress, nil if none
+	exit      func(code int)     // Called after an interrupt with no run to cancel
 }
 
 // NewCLI creates a new CLI instance with the given LLM provider.
 // By default, it uses os.Stdout for output and os.Stdin for input.
 func NewCLI(llmProvider provider.LLMProvider) *CLI {
-	return &CLI{
-		provider: llmProvider,
-		output:   os.Stdout,
-		input:    bufio.NewScanner(os.Stdin),
-		basePath: ".",
-	}
+	c := NewCLIWithIO(llmProvider, os.Stdin, os.Stdout)
+	c.errOutput = os.Stderr
+	return c
 }
 
 // NewCLIWithIO creates a new CLI instance with custom input/output streams.
 // This is useful for testing.
 func NewCLIWithIO(llmProvider provider.LLMProvider, input io.Reader, output io.Writer) *CLI {
-	return &CLI{
-		provider: llmProvider,
-		output:   output,
-		input:    bufio.NewScanner(input),
-		basePath: ".",
+	c := &CLI{
+		provider:     llmProvider,
+		config:       config.Default(),
+		output:       output,
+		input:        bufio.NewScanner(input),
+		basePath:     ".",
+		outputFormat: OutputText,
+		errOutput:    output,
+		session:      memory.NewConversationMemory(),
+		commands:     make(map[string]Command),
+		exit:         os.Exit,
 	}
+	c.registerBuiltinCommands()
+	return c
 }
 
 // SetBasePath sets the base path for file operations.
@@ -58,6 +94,19 @@ func (c *CLI) SetBasePath(path string) {
 	c.basePath = path
 }
 
+// SetConfig sets the agents' system prompts, tools and iteration limits,
+// and the run timeout. The default is config.Default().
+func (c *CLI) SetConfig(cfg *config.Config) {
+	c.config = cfg
+}
+
+// SetShowThinking sets whether the model's extended thinking is printed as
+// runs produce it. Thinking is only produced for agents with a thinking
+// budget in the config.
+func (c *CLI) SetShowThinking(show bool) {
+	c.showThinking = show
+}
+
 // SetMCPOnly sets whether to use only MCP tools (no built-in tools).
 func (c *CLI) SetMCPOnly(mcpOnly bool) {
 	c.mcpOnly = mcpOnly
@@ -66,16 +115,30 @@ func (c *CLI) SetMCPOnly(mcpOnly bool) {
 // LoadMCPConfig loads MCP servers from the given config file path.
 // Returns an error if mcpOnly is true and config cannot be loaded.
 func (c *CLI) LoadMCPConfig(ctx context.Context, configPath string) error {
-	cfg, err := mcp.LoadMCPConfig(configPath)
+	cfg, err := mcp.LoadLayeredMCPConfig(configPath)
 	if err != nil {
 		if c.mcpOnly {
 			return fmt.Errorf("mcp-only mode requires valid config: %w", err)
 		}
-		// Config file not found is not an error in normal mode
+		// Config file not found is not an error in normal mode, but an
+		// invalid config should not be ignored silently
+		if !errors.Is(err, mcp.ErrConfigNotFound) {
+			c.diagf("Warning: MCP servers not loaded: %v\n", err)
+		}
 		return nil
 	}
 
 	c.mcpManager = mcp.NewMCPManager()
+	sampling := mcp.NewSamplingHandler(c.provider)
+	sampling.SetApprover(c.approveSampling)
+	c.mcpManager.SetSamplingHandler(sampling)
+	c.mcpManager.OnProgress(c.printProgress)
+	if c.mcpLogLevel != "" {
+		c.mcpManager.OnLogMessage(c.printLogMessage)
+		if err := c.mcpManager.SetLogLevel(ctx, c.mcpLogLevel); err != nil {
+			return err
+		}
+	}
 	if err := c.mcpManager.LoadFromConfig(ctx, cfg); err != nil {
 		return fmt.Errorf("failed to load MCP servers: %w", err)
 	}
@@ -96,14 +159,30 @@ func (c *CLI) Shutdown() {
 
 // printf is a helper to write formatted output.
 func (c *CLI) printf(format string, args ...interface{}) {
+	c.outputMu.Lock()
+	defer c.outputMu.Unlock()
 	fmt.Fprintf(c.output, format, args...)
 }
 
 // println is a helper to write a line of output.
 func (c *CLI) println(args ...interface{}) {
+	c.outputMu.Lock()
+	defer c.outputMu.Unlock()
 	fmt.Fprintln(c.output, args...)
 }
 
+// diagf writes diagnostic output such as MCP events and warnings. When the
+// output is JSON it goes to errOutput instead, keeping the result parseable.
+func (c *CLI) diagf(format string, args ...interface{}) {
+	c.outputMu.Lock()
+	defer c.outputMu.Unlock()
+	w := c.output
+	if c.outputFormat == OutputJSON {
+		w = c.errOutput
+	}
+	fmt.Fprintf(w, format, args...)
+}
+
 // printToolCall displays information about a tool call.
 // Validates: Requirement 9.5
 func (c *CLI) printToolCall(tc provider.ToolCall) {
@@ -113,6 +192,27 @@ func (c *CLI) printToolCall(tc provider.ToolCall) {
 	}
 }
 
+// printThinking displays a block of the model's reasoning, indented.
+func (c *CLI) printThinking(thinking string) {
+	var b strings.Builder
+	b.WriteString("\n[Thinking]\n")
+	for _, line := range strings.Split(strings.TrimSpace(thinking), "\n") {
+		b.WriteString("  " + line + "\n")
+	}
+	c.diagf("%s", b.String())
+}
+
+// printToolConflicts warns about MCP tools that were renamed because their
+// name was already taken by another server's tool.
+func (c *CLI) printToolConflicts() {
+	if c.mcpManager == nil {
+		return
+	}
+	for _, conflict := range c.mcpManager.NameConflicts() {
+		c.printf("Warning: %s\n", conflict)
+	}
+}
+
 // printAgentTransition displays information about an agent transition.
 // Validates: Requirement 9.5
 func (c *CLI) printAgentTransition(from, to string) {
@@ -126,40 +226,105 @@ func isExitCommand(input string) bool {
 	return lower == "exit" || lower == "quit"
 }
 
-// RunSingleAgentMode runs the CLI in single-agent mode with an interactive loop.
-// The agent has access to Calculator and FileReader tools, plus any MCP tools.
-// If mcpOnly is true, only MCP tools are used.
-//
-// Validates: Requirement 9.2
-func (c *CLI) RunSingleAgentMode() error {
-	c.println("=== Single Agent Mode ===")
-
+// newSingleAgent creates the single-agent mode agent. It has the built-in
+// tools from the config plus any MCP tools, or only MCP tools if mcpOnly is
+// true.
+func (c *CLI) newSingleAgent() (*agent.Agent, error) {
 	var tools []tool.Tool
+	cfg := c.config.Agents[config.AgentSingle]
+
+	// MCP tools are supplied through a tool.Provider so that servers adding or
+	// removing tools mid-session are picked up on the next LLM call
+	var toolProvider tool.Provider
+	if c.mcpManager != nil {
+		toolProvider = c.mcpManager
+	}
 
 	if c.mcpOnly {
-		// MCP-only mode: use only tools from MCP servers
-		c.println("Mode: MCP-only (tools loaded from MCP servers)")
 		if c.mcpManager == nil {
-			return fmt.Errorf("mcp-only mode but no MCP manager configured")
+			return nil, fmt.Errorf("mcp-only mode but no MCP manager configured")
 		}
-		tools = c.mcpManager.GetTools()
-		if len(tools) == 0 {
-			return fmt.Errorf("mcp-only mode but no MCP tools available")
+		if len(c.mcpManager.GetTools()) == 0 {
+			return nil, fmt.Errorf("mcp-only mode but no MCP tools available")
 		}
+	} else {
+		builtins, err := c.builtinTools(cfg.Tools)
+		if err != nil {
+			return nil, err
+		}
+		tools = builtins
+	}
+
+	return agent.NewAgent(agent.AgentConfig{
+		Provider:       c.provider,
+		Tools:          tools,
+		ToolProvider:   toolProvider,
+		SystemPrompt:   cfg.SystemPrompt,
+		MaxIterations:  cfg.MaxIterations,
+		ThinkingBudget: cfg.ThinkingBudget,
+		Role:           config.AgentSingle,
+	}), nil
+}
+
+// builtinTools creates the named built-in tools, rooted at the base path.
+func (c *CLI) builtinTools(names []string) ([]tool.Tool, error) {
+	tools := make([]tool.Tool, 0, len(names))
+	for _, name := range names {
+		t, err := tool.NewBuiltinTool(name, c.basePath)
+		if err != nil {
+			return nil, err
+		}
+		tools = append(tools, t)
+	}
+	return tools, nil
+}
+
+// agentOptions returns the options for the architect or coder from the
+// config.
+func (c *CLI) agentOptions(name string) ([]agent.Option, error) {
+	cfg := c.config.Agents[name]
+	tools, err := c.builtinTools(cfg.Tools)
+	if err != nil {
+		return nil, fmt.Errorf("%s agent: %w", name, err)
+	}
+	return []agent.Option{
+		agent.WithSystemPrompt(cfg.SystemPrompt),
+		agent.WithTools(tools),
+		agent.WithMaxIterations(cfg.MaxIterations),
+		agent.WithThinkingBudget(cfg.ThinkingBudget),
+	}, nil
+}
+
+// newOrchestrator creates the multi-agent mode orchestrator with the
+// architect and coder configured from the config.
+func (c *CLI) newOrchestrator() (*orchestrator.Orchestrator, error) {
+	architectOpts, err := c.agentOptions(config.AgentArchitect)
+	if err != nil {
+		return nil, err
+	}
+	coderOpts, err := c.agentOptions(config.AgentCoder)
+	if err != nil {
+		return nil, err
+	}
+	return orchestrator.NewOrchestrator(c.provider, c.basePath,
+		orchestrator.WithArchitectOptions(architectOpts...),
+		orchestrator.WithCoderOptions(coderOpts...),
+	), nil
+}
+
+// printSingleAgentTools lists the tools available in single-agent mode.
+func (c *CLI) printSingleAgentTools() {
+	if c.mcpOnly {
+		c.println("Mode: MCP-only (tools loaded from MCP servers)")
 		c.println("Available MCP tools:")
-		for _, t := range tools {
+		for _, t := range c.mcpManager.GetTools() {
 			c.printf("  - %s: %s\n", t.Name(), t.Description())
 		}
 	} else {
-		// Normal mode: use built-in tools
 		c.println("Mode: Built-in tools")
-		tools = []tool.Tool{
-			tool.NewCalculatorTool(),
-			tool.NewFileReaderTool(c.basePath),
-		}
-		c.println("Available tools: calculator, read_file")
+		c.printf("Available tools: %s\n", strings.Join(c.config.Agents[config.AgentSingle].Tools, ", "))
 
-		// Also add MCP tools if available
+		// Also list MCP tools if available
 		if c.mcpManager != nil {
 			mcpTools := c.mcpManager.GetTools()
 			if len(mcpTools) > 0 {
@@ -167,42 +332,87 @@ func (c *CLI) RunSingleAgentMode() error {
 				for _, t := range mcpTools {
 					c.printf("  - %s\n", t.Name())
 				}
-				tools = append(tools, mcpTools...)
 			}
 		}
 	}
 
-	c.println("Type 'exit' or 'quit' to exit.")
+	c.printToolConflicts()
+}
+
+// RunSingleAgentMode runs the CLI in single-agent mode with an interactive loop.
+// The agent has access to Calculator and FileReader tools, plus any MCP tools.
+// If mcpOnly is true, only MCP tools are used. The conversation is kept
+// across prompts until /clear.
+//
+// Validates: Requirement 9.2
+func (c *CLI) RunSingleAgentMode() error {
+	if err := c.enterMode(ModeSingle); err != nil {
+		return err
+	}
+	return c.repl()
+}
+
+// RunMultiAgentMode runs the CLI in multi-agent mode with the Architect/Coder workflow.
+// The user provides a goal, and the orchestrator coordinates the agents.
+//
+// Validates: Requirement 9.3
+func (c *CLI) RunMultiAgentMode() error {
+	if err := c.enterMode(ModeMulti); err != nil {
+		return err
+	}
+	return c.repl()
+}
+
+// enterMode switches to mode and prints its banner. The single-agent mode
+// agent is created on first use.
+func (c *CLI) enterMode(mode string) error {
+	switch mode {
+	case ModeSingle:
+		c.println("=== Single Agent Mode ===")
+		if c.agent == nil {
+			agentInstance, err := c.newSingleAgent()
+			if err != nil {
+				return err
+			}
+			c.agent = agentInstance
+		}
+		c.printSingleAgentTools()
+	case ModeMulti:
+		c.println("=== Multi-Agent Mode (Architect/Coder) ===")
+		c.println("Enter a goal for the system to accomplish.")
+		c.println("The Architect will create a plan, and the Coder will execute it.")
+	default:
+		return fmt.Errorf("unknown mode %q (expected %q or %q)", mode, ModeSingle, ModeMulti)
+	}
+
+	c.mode = mode
+	c.println("Type 'exit' or 'quit' to exit, or /help for commands.")
 	c.println()
+	return nil
+}
 
-	// Create the agent with a clear system prompt
-	agentInstance := agent.NewAgent(agent.AgentConfig{
-		Provider: c.provider,
-		Tools:    tools,
-		SystemPrompt: `You are a helpful assistant with access to two tools:
-1. calculator - Use this for ANY math operations (add, subtract, multiply, divide). Always use the calculator tool for arithmetic.
-2. read_file - Use this to read file contents when asked about files.
-
-When the user asks a math question, use the calculator tool. Do not try to calculate in your head.
-When the user asks to read a file, use the read_file tool.
-Keep responses concise and helpful.`,
-		MaxIterations: 10,
-	})
+// repl reads prompts or goals, depending on the current mode, and slash
+// commands until exit or end of input.
+func (c *CLI) repl() error {
+	ctx := context.Background()
 
-	// Interactive loop - fresh memory for each prompt
 	for {
-		c.printf("You: ")
+		if c.mode == ModeMulti {
+			c.printf("Goal: ")
+		} else {
+			c.printf("You: ")
+		}
 
-		if !c.input.Scan() {
-			// EOF or error
-			if err := c.input.Err(); err != nil {
-				return fmt.Errorf("input error: %w", err)
-			}
+		line, err := c.readLine(ctx)
+		if err == io.EOF {
 			c.println("\nGoodbye!")
 			return nil
 		}
+		if err != nil {
+			return fmt.Errorf("input error: %w", err)
+		}
 
-		input := strings.TrimSpace(c.input.Text())
+		input := strings.TrimSpace(line)
 		if input == "" {
 			continue
 		}
@@ -213,117 +423,195 @@ Keep responses concise and helpful.`,
 			return nil
 		}
 
-		// Create fresh memory for each prompt to avoid token accumulation
-		mem := memory.NewConversationMemory()
-
-		// Run the agent
-		ctx := context.Background()
-		result, err := agentInstance.Run(ctx, input, mem)
-		if err != nil {
-			c.printf("Error: %v\n\n", err)
+		if isSlashCommand(input) {
+			c.runCommand(ctx, input)
 			continue
 		}
 
-		// Display tool calls made (intermediate steps)
-		if len(result.ToolCallsMade) > 0 {
-			c.println("\n--- Intermediate Steps ---")
-			for _, tc := range result.ToolCallsMade {
-				c.printToolCall(tc)
-			}
-			c.printf("  Iterations: %d\n", result.Iterations)
-			c.println("---------------------------")
+		if c.mode == ModeMulti {
+			c.runWorkflow(ctx, input)
+		} else {
+			c.runSingleTurn(ctx, input)
 		}
+	}
+}
+
+// readLine returns the next line of input, or io.EOF at the end of the
+// input. The input is read on a goroutine, so that a read can give up with
+// ctx.Err() when ctx is cancelled; the line it was waiting for then goes
+// to the next read.
+func (c *CLI) readLine(ctx context.Context) (string, error) {
+	c.linesOnce.Do(func() {
+		c.lines = make(chan string)
+		go func() {
+			defer close(c.lines)
+			for c.input.Scan() {
+				c.lines <- c.input.Text()
+			}
+		}()
+	})
 
-		// Display the response
-		c.printf("\nAssistant: %s\n\n", result.Response)
+	select {
+	case line, ok := <-c.lines:
+		if !ok {
+			if err := c.input.Err(); err != nil {
+				return "", err
+			}
+			return "", io.EOF
+		}
+		return line, nil
+	case <-ctx.Done():
+		return "", ctx.Err()
 	}
 }
 
-// RunMultiAgentMode runs the CLI in multi-agent mode with the Architect/Coder workflow.
-// The user provides a goal, and the orchestrator coordinates the agents.
-//
-// Validates: Requirement 9.3
-func (c *CLI) RunMultiAgentMode() error {
-	c.println("=== Multi-Agent Mode (Architect/Coder) ===")
-	c.println("Enter a goal for the system to accomplish.")
-	c.println("The Architect will create a plan, and the Coder will execute it.")
-	c.println("Type 'exit' or 'quit' to exit.")
-	c.println()
+// runSingleTurn sends a prompt, with any @path attachments, to the
+// single-agent mode agent and prints the intermediate steps and the
+// response. A failed or interrupted turn is removed from the conversation
+// so it cannot leave it in an inconsistent state; an interrupted turn
+// still shows the tool calls it made.
+func (c *CLI) runSingleTurn(ctx context.Context, input string) {
+	attachments, missing, err := parseAttachments(input)
+	if err != nil {
+		c.printf("Error: %v\n\n", err)
+		return
+	}
+	for _, path := range missing {
+		c.printf("Warning: @%s not found, sent as text\n", path)
+	}
+	for _, a := range attachments {
+		c.printf("Attached %s\n", describeAttachment(a))
+	}
 
-	// Create the orchestrator
-	orch := orchestrator.NewOrchestrator(c.provider, c.basePath)
+	before := c.session.GetMessages()
 
-	// Interactive loop
-	for {
-		c.printf("Goal: ")
+	ctx, endRun := c.beginRun(ctx)
+	result, err := c.agent.RunWithContent(ctx, input, promptBlocks(input, attachments), c.session)
+	endRun()
 
-		if !c.input.Scan() {
-			// EOF or error
-			if err := c.input.Err(); err != nil {
-				return fmt.Errorf("input error: %w", err)
+	if err != nil {
+		c.session.SetMessages(before)
+		if errors.Is(err, context.Canceled) {
+			if result != nil {
+				c.printIntermediateSteps(result)
 			}
-			c.println("\nGoodbye!")
-			return nil
+			c.println("\nRun cancelled.")
+			c.println()
+			return
 		}
+		c.printf("Error: %v\n\n", err)
+		return
+	}
 
-		input := strings.TrimSpace(c.input.Text())
-		if input == "" {
-			continue
-		}
+	c.printIntermediateSteps(result)
 
-		// Check for exit command
-		if isExitCommand(input) {
-			c.println("Goodbye!")
-			return nil
-		}
+	// Display the response
+	c.printf("\nAssistant: %s\n\n", result.Response)
+}
 
-		// Display agent transition
-		c.printAgentTransition("user", "architect")
+// printIntermediateSteps displays the tool calls an agent run made.
+func (c *CLI) printIntermediateSteps(result *agent.AgentResult) {
+	if len(result.ToolCallsMade) == 0 {
+		return
+	}
+	c.println("\n--- Intermediate Steps ---")
+	for _, tc := range result.ToolCallsMade {
+		c.printToolCall(tc)
+	}
+	c.printf("  Iterations: %d\n", result.Iterations)
+	c.println("---------------------------")
+}
 
-		// Run the orchestrator
-		ctx := context.Background()
+// runWorkflow runs the Architect/Coder workflow for a goal and prints the
+// result. The plan is kept for /plan.
+func (c *CLI) runWorkflow(ctx context.Context, goal string) {
+	if err := rejectAttachments(goal); err != nil {
+		c.printf("Error: %v\n\n", err)
+		return
+	}
 
-		// Create a callback to display state changes
-		c.println("Starting workflow...")
+	orch, err := c.newOrchestrator()
+	if err != nil {
+		c.printf("Error: %v\n\n", err)
+		return
+	}
 
-		result, err := orch.Run(ctx, input)
+	// Display agent transition
+	c.printAgentTransition("user", "architect")
+	c.println("Starting workflow...")
 
-		// Display final state
-		state := orch.State()
-		c.printf("\nWorkflow Phase: %s\n", state.Phase)
+	ctx, endRun := c.beginRun(ctx)
+	result, err := orch.Run(ctx, goal)
+	endRun()
 
-		if err != nil {
-			c.printf("Error: %v\n\n", err)
-			continue
-		}
+	if result != nil && result.Plan != nil {
+		c.lastPlan = result.Plan
+	}
+
+	// Display final state
+	state := orch.State()
+	c.printf("\nWorkflow Phase: %s\n", state.Phase)
 
-		// Display the plan
-		if result.Plan != nil {
-			c.println("\n--- Plan ---")
-			c.printf("Goal: %s\n", result.Plan.Goal)
-			c.println("Steps:")
-			for i, step := range result.Plan.Steps {
-				c.printf("  %d. %s (action: %s)\n", i+1, step.Description, step.Action)
+	if err != nil {
+		// Show how far an interrupted workflow got
+		if state.Phase == orchestrator.PhaseCancelled && result != nil {
+			if result.Plan != nil {
+				c.println()
+				c.printPlan(result.Plan)
 			}
-			c.println("------------")
+			c.printActions(result.ActionsTaken)
+			c.println("\nWorkflow cancelled.")
+			c.println()
+			return
 		}
+		c.printf("Error: %v\n\n", err)
+		return
+	}
 
-		// Display agent transition
-		if state.Phase == orchestrator.PhaseComplete {
-			c.printAgentTransition("architect", "coder")
-		}
+	c.printWorkflowResult(result, state.Phase)
+	c.println()
+}
 
-		// Display actions taken
-		if len(result.ActionsTaken) > 0 {
-			c.println("\n--- Actions Taken ---")
-			for _, action := range result.ActionsTaken {
-				c.printf("  • %s\n", action)
-			}
-			c.println("---------------------")
-		}
+// printWorkflowResult displays the plan, actions and summary of a workflow
+// that ended in phase.
+func (c *CLI) printWorkflowResult(result *orchestrator.OrchestratorResult, phase orchestrator.WorkflowPhase) {
+	// Display the plan
+	if result.Plan != nil {
+		c.println()
+		c.printPlan(result.Plan)
+	}
+
+	// Display agent transition
+	if phase == orchestrator.PhaseComplete {
+		c.printAgentTransition("architect", "coder")
+	}
+
+	c.printActions(result.ActionsTaken)
+
+	// Display summary
+	c.printf("\nSummary: %s\n", result.Summary)
+	c.printf("Success: %v\n", result.Success)
+}
+
+// printActions displays the actions a workflow took.
+func (c *CLI) printActions(actions []string) {
+	if len(actions) == 0 {
+		return
+	}
+	c.println("\n--- Actions Taken ---")
+	for _, action := range actions {
+		c.printf("  • %s\n", action)
+	}
+	c.println("---------------------")
+}
 
-		// Display summary
-		c.printf("\nSummary: %s\n", result.Summary)
-		c.printf("Success: %v\n\n", result.Success)
+// printPlan displays a plan's goal and steps.
+func (c *CLI) printPlan(plan *agent.Plan) {
+	c.println("--- Plan ---")
+	c.printf("Goal: %s\n", plan.Goal)
+	c.println("Steps:")
+	for i, step := range plan.Steps {
+		c.printf("  %d. %s (action: %s)\n", i+1, step.Description, step.Action)
 	}
+	c.println("------------")
 }
@@ -4,9 +4,12 @@ package cli
 import (
 	"bytes"
 	"context"
+	"errors"
 	"strings"
 	"testing"
+	"time"
 
+	"agentic-poc/internal/config"
 	"agentic-poc/internal/provider"
 )
 
@@ -82,6 +85,99 @@ func TestSetBasePath(t *testing.T) {
 	}
 }
 
+func TestSetConfig_Agents(t *testing.T) {
+	cfg := config.Default()
+	for key, value := range map[string]string{
+		"agents.single.systemPrompt":    "Be brief.",
+		"agents.single.tools":           "calculator",
+		"agents.architect.systemPrompt": "Plan briefly.",
+	} {
+		if err := cfg.Set(key, value, "test"); err != nil {
+			t.Fatal(err)
+		}
+	}
+
+	mock := newMockProvider(&provider.LLMResponse{Text: "Hi"})
+	output := &bytes.Buffer{}
+	cli := NewCLIWithIO(mock, strings.NewReader("Hello\nexit\n"), output)
+	cli.SetConfig(cfg)
+
+	if err := cli.RunSingleAgentMode(); err != nil {
+		t.Fatal(err)
+	}
+	if got := mock.calls[0].SystemPrompt; got != "Be brief." {
+		t.Errorf("single agent system prompt = %q", got)
+	}
+	if tools := mock.calls[0].Tools; len(tools) != 1 || tools[0].Name != "calculator" {
+		t.Errorf("single agent tools = %+v, want only the calculator", tools)
+	}
+	if !strings.Contains(output.String(), "Available tools: calculator\n") {
+		t.Errorf("banner should list the configured tools, got: %s", output.String())
+	}
+
+	mock = newMockProvider(&provider.LLMResponse{Text: "No plan"})
+	cli = NewCLIWithIO(mock, strings.NewReader(""), &bytes.Buffer{})
+	cli.SetConfig(cfg)
+	cli.RunMultiAgentOnce(context.Background(), "Do something")
+	if got := mock.calls[0].SystemPrompt; got != "Plan briefly." {
+		t.Errorf("architect system prompt = %q", got)
+	}
+}
+
+func TestShowThinking(t *testing.T) {
+	cfg := config.Default()
+	if err := cfg.Set("agents.single.thinkingBudget", "2048", "test"); err != nil {
+		t.Fatal(err)
+	}
+
+	for _, show := range []bool{true, false} {
+		mock := newMockProvider(&provider.LLMResponse{
+			Text:     "42",
+			Thinking: []provider.Thinking{{Text: "Six times seven.\nThat is 42.", Signature: "sig"}},
+		})
+		output := &bytes.Buffer{}
+		cli := NewCLIWithIO(mock, strings.NewReader(""), output)
+		cli.SetConfig(cfg)
+		cli.SetShowThinking(show)
+
+		if err := cli.RunSingleAgentOnce(context.Background(), "What is 6 x 7?"); err != nil {
+			t.Fatal(err)
+		}
+		if got := mock.calls[0].ThinkingBudget; got != 2048 {
+			t.Errorf("thinking budget = %d, want 2048", got)
+		}
+		shown := strings.Contains(output.String(), "[Thinking]\n  Six times seven.\n  That is 42.\n")
+		if shown != show {
+			t.Errorf("show thinking %v: output = %q", show, output.String())
+		}
+	}
+}
+
+// blockingProvider waits until the request's context ends.
+type blockingProvider struct{}
+
+func (blockingProvider) Generate(ctx context.Context, req provider.GenerateRequest) (*provider.LLMResponse, error) {
+	<-ctx.Done()
+	return nil, ctx.Err()
+}
+
+func (blockingProvider) Name() string {
+	return "blocking"
+}
+
+func TestSetConfig_RunTimeout(t *testing.T) {
+	cfg := config.Default()
+	cfg.RunTimeout = config.Duration(10 * time.Millisecond)
+
+	cli := NewCLIWithIO(blockingProvider{}, strings.NewReader(""), &bytes.Buffer{})
+	cli.SetConfig(cfg)
+
+	err := cli.RunSingleAgentOnce(context.Background(), "Hi")
+	if !errors.Is(err, context.DeadlineExceeded) {
+		t.Errorf("error = %v, want the run timeout to expire", err)
+	}
+}
+
 func TestIsExitCommand(t *testing.T) {
 	tests := []struct {
 		input    string
@@ -0,0 +1,89 @@
+package cli
+
+import (
+	"context"
+	"fmt"
+	"sort"
+	"strings"
+
+	"agentic-poc/internal/mcp"
+	"agentic-poc/internal/memory"
+)
+
+// Command is a slash command in the interactive CLI, such as /help.
+type Command struct {
+	// Name is what follows the slash, e.g. "tools" for /tools.
+	Name string
+
+	// Args describes the arguments for /help, e.g. "<file>" or "[name]".
+	Args string
+
+	// Description is a one-line summary for /help.
+	Description string
+
+	// Run executes the command with the whitespace-separated words after
+	// its name. A returned error is printed and the session continues.
+	Run func(ctx context.Context, c *CLI, args []string) error
+}
+
+// RegisterCommand adds a slash command, replacing any command with the
+// same name. Subsystems use it to add their own commands next to the
+// built-in ones.
+func (c *CLI) RegisterCommand(cmd Command) {
+	if cmd.Name == "" || strings.ContainsAny(cmd.Name, " \t/") {
+		panic(fmt.Sprintf("cli: invalid command name %q", cmd.Name))
+	}
+	if cmd.Run == nil {
+		panic(fmt.Sprintf("cli: command %q has no Run function", cmd.Name))
+	}
+	c.commands[cmd.Name] = cmd
+}
+
+// Commands returns the registered slash commands sorted by name.
+func (c *CLI) Commands() []Command {
+	commands := make([]Command, 0, len(c.commands))
+	for _, cmd := range c.commands {
+		commands = append(commands, cmd)
+	}
+	sort.Slice(commands, func(i, j int) bool { return commands[i].Name < commands[j].Name })
+	return commands
+}
+
+// Printf writes formatted output to the CLI, for use by commands.
+func (c *CLI) Printf(format string, args ...interface{}) {
+	c.printf(format, args...)
+}
+
+// Session returns the single-agent mode conversation.
+func (c *CLI) Session() *memory.ConversationMemory {
+	return c.session
+}
+
+// MCPManager returns the MCP manager, or nil if no MCP servers are loaded.
+func (c *CLI) MCPManager() *mcp.MCPManager {
+	return c.mcpManager
+}
+
+// isSlashCommand reports whether a line of input is a slash command.
+func isSlashCommand(input string) bool {
+	return strings.HasPrefix(input, "/")
+}
+
+// runCommand parses and runs a slash command, printing any error.
+func (c *CLI) runCommand(ctx context.Context, input string) {
+	fields := strings.Fields(strings.TrimPrefix(input, "/"))
+	if len(fields) == 0 {
+		c.println("Type /help to list commands.")
+		return
+	}
+
+	cmd, ok := c.commands[fields[0]]
+	if !ok {
+		c.printf("Unknown command: /%s. Type /help to list commands.\n", fields[0])
+		return
+	}
+
+	if err := cmd.Run(ctx, c, fields[1:]); err != nil {
+		c.printf("Error: /%s: %v\n", cmd.Name, err)
+	}
+}
@@ -0,0 +1,319 @@
+package cli
+
+import (
+	"bytes"
+	"context"
+	"path/filepath"
+	"strings"
+	"testing"
+
+	"agentic-poc/internal/mcp"
+	"agentic-poc/internal/provider"
+)
+
+// switchableProvider is a mockProvider whose model can be changed.
+type switchableProvider struct {
+	*mockProvider
+	model string
+}
+
+func (p *switchableProvider) Model() string {
+	return p.model
+}
+
+func (p *switchableProvider) SetModel(model string) {
+	p.model = model
+}
+
+// fakeMCPClient is a minimal mcp.MCPClient with a single tool.
+type fakeMCPClient struct {
+	connects int
+}
+
+func (f *fakeMCPClient) Connect(ctx context.Context) error {
+	f.connects++
+	return nil
+}
+
+func (f *fakeMCPClient) ListTools(ctx context.Context) ([]mcp.MCPToolInfo, error) {
+	return []mcp.MCPToolInfo{{Name: "search", Description: "Search things"}}, nil
+}
+
+func (f *fakeMCPClient) CallTool(ctx context.Context, name string, args map[string]interface{}) (*provider.ToolResult, error) {
+	return &provider.ToolResult{Success: true}, nil
+}
+
+func (f *fakeMCPClient) Close() error {
+	return nil
+}
+
+// runSession runs single-agent mode until the CLI's input ends and
+// returns the output.
+func runSession(t *testing.T, cli *CLI, output *bytes.Buffer) string {
+	t.Helper()
+	if err := cli.RunSingleAgentMode(); err != nil {
+		t.Fatalf("RunSingleAgentMode returned error: %v", err)
+	}
+	return output.String()
+}
+
+func TestSlashCommands_HelpAndUnknown(t *testing.T) {
+	output := &bytes.Buffer{}
+	cli := NewCLIWithIO(newMockProvider(), strings.NewReader("/help\n/nope\n/\nexit\n"), output)
+
+	out := runSession(t, cli, output)
+
+	for _, want := range []string{
+		"/clear", "/history", "/load <file>", "/mcp [reconnect <server>]",
+		"/mode [single|multi]", "/model [name]", "/plan", "/save <file>", "/tools [name]",
+		"Unknown command: /nope",
+	} {
+		if !strings.Contains(out, want) {
+			t.Errorf("output should contain %q, got: %s", want, out)
+		}
+	}
+}
+
+func TestSlashCommands_RegisterCommand(t *testing.T) {
+	output := &bytes.Buffer{}
+	mock := newMockProvider()
+	cli := NewCLIWithIO(mock, strings.NewReader("/greet Ada Lovelace\n/help\nexit\n"), output)
+
+	var gotArgs []string
+	cli.RegisterCommand(Command{
+		Name:        "greet",
+		Args:        "<name>",
+		Description: "Say hello",
+		Run: func(ctx context.Context, c *CLI, args []string) error {
+			gotArgs = args
+			c.Printf("Hello, %s!\n", strings.Join(args, " "))
+			return nil
+		},
+	})
+
+	out := runSession(t, cli, output)
+
+	if strings.Join(gotArgs, "|") != "Ada|Lovelace" {
+		t.Errorf("args = %q, want [Ada Lovelace]", gotArgs)
+	}
+	if !strings.Contains(out, "Hello, Ada Lovelace!") {
+		t.Errorf("command output missing, got: %s", out)
+	}
+	if !strings.Contains(out, "/greet <name>") {
+		t.Errorf("/help should list the registered command, got: %s", out)
+	}
+	if len(mock.calls) != 0 {
+		t.Error("slash commands should not be sent to the provider")
+	}
+}
+
+func TestSlashCommands_RegisterCommandInvalid(t *testing.T) {
+	cli := NewCLIWithIO(newMockProvider(), strings.NewReader(""), &bytes.Buffer{})
+	run := func(ctx context.Context, c *CLI, args []string) error { return nil }
+
+	for _, cmd := range []Command{
+		{Name: "", Run: run},
+		{Name: "two words", Run: run},
+		{Name: "/slash", Run: run},
+		{Name: "norun"},
+	} {
+		func() {
+			defer func() {
+				if recover() == nil {
+					t.Errorf("RegisterCommand(%q) should panic", cmd.Name)
+				}
+			}()
+			cli.RegisterCommand(cmd)
+		}()
+	}
+}
+
+func TestSlashCommands_ConversationHistoryAndClear(t *testing.T) {
+	mock := newMockProvider(
+		&provider.LLMResponse{Text: "Nice to meet you, Ada."},
+		&provider.LLMResponse{Text: "Your name is Ada."},
+		&provider.LLMResponse{Text: "I don't know your name."},
+	)
+	input := "My name is Ada\nWhat is my name?\n/history\n/clear\n/history\nWhat is my name?\nexit\n"
+	output := &bytes.Buffer{}
+	cli := NewCLIWithIO(mock, strings.NewReader(input), output)
+
+	out := runSession(t, cli, output)
+
+	// The second prompt is sent with the first exchange
+	if n := len(mock.calls[1].Messages); n != 3 {
+		t.Errorf("second prompt sent with %d messages, want 3", n)
+	}
+	for _, want := range []string{
+		"1. user: My name is Ada",
+		"2. assistant: Nice to meet you, Ada.",
+		"4. assistant: Your name is Ada.",
+		"Conversation cleared.",
+		"No conversation yet.",
+	} {
+		if !strings.Contains(out, want) {
+			t.Errorf("output should contain %q, got: %s", want, out)
+		}
+	}
+	// After /clear the conversation starts over
+	if n := len(mock.calls[2].Messages); n != 1 {
+		t.Errorf("prompt after /clear sent with %d messages, want 1", n)
+	}
+}
+
+func TestSlashCommands_FailedTurnIsRolledBack(t *testing.T) {
+	output := &bytes.Buffer{}
+	cli := NewCLIWithIO(failingProvider{}, strings.NewReader("Hi\nexit\n"), output)
+
+	out := runSession(t, cli, output)
+
+	if !strings.Contains(out, "service unavailable") {
+		t.Errorf("error should be shown, got: %s", out)
+	}
+	if cli.Session().Len() != 0 {
+		t.Errorf("failed turn left %d messages in the conversation", cli.Session().Len())
+	}
+}
+
+func TestSlashCommands_SaveAndLoad(t *testing.T) {
+	file := filepath.Join(t.TempDir(), "session.json")
+
+	mock := newMockProvider(&provider.LLMResponse{Text: "Hello!"})
+	output := &bytes.Buffer{}
+	cli := NewCLIWithIO(mock, strings.NewReader("Hi\n/save "+file+"\nexit\n"), output)
+	out := runSession(t, cli, output)
+	if !strings.Contains(out, "Saved 2 messages") {
+		t.Fatalf("save failed, got: %s", out)
+	}
+
+	mock = newMockProvider(&provider.LLMResponse{Text: "Welcome back."})
+	output = &bytes.Buffer{}
+	cli = NewCLIWithIO(mock, strings.NewReader("/load "+file+"\nAgain\n/load missing.json\nexit\n"), output)
+	out = runSession(t, cli, output)
+
+	if !strings.Contains(out, "Loaded 2 messages") {
+		t.Errorf("load failed, got: %s", out)
+	}
+	messages := mock.calls[0].Messages
+	if len(messages) != 3 || messages[0].Content != "Hi" || messages[1].Content != "Hello!" {
+		t.Errorf("loaded conversation not sent with the next prompt: %+v", messages)
+	}
+	if !strings.Contains(out, "Error: /load:") {
+		t.Errorf("loading a missing file should report an error, got: %s", out)
+	}
+}
+
+func TestSlashCommands_Model(t *testing.T) {
+	p := &switchableProvider{mockProvider: newMockProvider(), model: "model-a"}
+	output := &bytes.Buffer{}
+	cli := NewCLIWithIO(p, strings.NewReader("/model\n/model model-b\nexit\n"), output)
+
+	out := runSession(t, cli, output)
+
+	if !strings.Contains(out, "Model: model-a") || !strings.Contains(out, "Model set to model-b") {
+		t.Errorf("unexpected output: %s", out)
+	}
+	if p.model != "model-b" {
+		t.Errorf("model = %q, want model-b", p.model)
+	}
+
+	output = &bytes.Buffer{}
+	cli = NewCLIWithIO(newMockProvider(), strings.NewReader("/model other\nexit\n"), output)
+	out = runSession(t, cli, output)
+	if !strings.Contains(out, "does not support switching models") {
+		t.Errorf("expected an error for a provider without model switching, got: %s", out)
+	}
+}
+
+func TestSlashCommands_ModeAndPlan(t *testing.T) {
+	mock := newMockProvider(
+		&provider.LLMResponse{
+			ToolCalls: []provider.ToolCall{{
+				ID:   "call_1",
+				Name: "finish_plan",
+				Arguments: map[string]interface{}{
+					"goal": "Say hello",
+					"steps": []interface{}{
+						map[string]interface{}{"description": "Greet the user", "action": "respond"},
+					},
+				},
+			}},
+		},
+		&provider.LLMResponse{Text: "Said hello"},
+	)
+	input := "/plan\n/mode multi\n/mode\nSay hello\n/plan\n/mode single\n/mode bogus\nexit\n"
+	output := &bytes.Buffer{}
+	cli := NewCLIWithIO(mock, strings.NewReader(input), output)
+	cli.SetBasePath(t.TempDir())
+
+	out := runSession(t, cli, output)
+
+	for _, want := range []string{
+		"No plan yet",
+		"=== Multi-Agent Mode (Architect/Coder) ===",
+		"Mode: multi",
+		"Goal: ",
+		"Summary: Said hello",
+		"1. Greet the user (action: respond)",
+		"Error: /mode: unknown mode",
+	} {
+		if !strings.Contains(out, want) {
+			t.Errorf("output should contain %q, got: %s", want, out)
+		}
+	}
+	if strings.Count(out, "=== Single Agent Mode ===") != 2 {
+		t.Errorf("expected to switch back to single-agent mode, got: %s", out)
+	}
+}
+
+func TestSlashCommands_Tools(t *testing.T) {
+	output := &bytes.Buffer{}
+	cli := NewCLIWithIO(newMockProvider(), strings.NewReader("/tools calculator\n/tools missing\nexit\n"), output)
+
+	out := runSession(t, cli, output)
+
+	for _, want := range []string{
+		"calculator: Performs basic arithmetic",
+		`"operation": {`,
+		`Error: /tools: unknown tool "missing"`,
+	} {
+		if !strings.Contains(out, want) {
+			t.Errorf("output should contain %q, got: %s", want, out)
+		}
+	}
+	if strings.Contains(out, "read_file: ") {
+		t.Error("/tools calculator should only show the calculator")
+	}
+}
+
+func TestSlashCommands_MCP(t *testing.T) {
+	output := &bytes.Buffer{}
+	cli := NewCLIWithIO(newMockProvider(), strings.NewReader("/mcp\nexit\n"), output)
+	if out := runSession(t, cli, output); !strings.Contains(out, "No MCP servers loaded.") {
+		t.Errorf("expected no servers, got: %s", out)
+	}
+
+	client := &fakeMCPClient{}
+	manager := mcp.NewMCPManager()
+	if err := manager.AddClient(context.Background(), "docs", client); err != nil {
+		t.Fatal(err)
+	}
+
+	output = &bytes.Buffer{}
+	cli = NewCLIWithIO(newMockProvider(), strings.NewReader("/mcp\n/mcp reconnect docs\n/mcp reconnect\nexit\n"), output)
+	cli.mcpManager = manager
+	out := runSession(t, cli, output)
+
+	for _, want := range []string{
+		"docs: connected, 1 tools",
+		"Reconnected docs",
+		"usage: /mcp [reconnect <server>]",
+	} {
+		if !strings.Contains(out, want) {
+			t.Errorf("output should contain %q, got: %s", want, out)
+		}
+	}
+	if client.connects != 2 {
+		t.Errorf("client connected %d times, want 2", client.connects)
+	}
+}
@@ -0,0 +1,79 @@
+package cli
+
+import (
+	"context"
+	"os"
+	"time"
+
+	"agentic-poc/internal/agent"
+)
+
+// ExitInterrupted is the exit code after Ctrl-C, following the shell
+// convention of 128 plus the signal number of SIGINT.
+const ExitInterrupted = 130
+
+// HandleInterrupts handles the signals received on interrupts, typically
+// registered with signal.Notify for os.Interrupt. The first interrupt
+// cancels the run in progress, which stops at its next LLM or tool call
+// and reports what it did so far, and the CLI returns to the prompt. An
+// interrupt when no run is in progress, such as a second Ctrl-C while a
+// run is stopping, shuts the CLI down and exits with ExitInterrupted.
+func (c *CLI) HandleInterrupts(interrupts <-chan os.Signal) {
+	go func() {
+		for range interrupts {
+			if c.cancelRun() {
+				continue
+			}
+			c.diagf("\nExiting.\n")
+			c.Shutdown()
+			c.exit(ExitInterrupted)
+			return
+		}
+	}()
+}
+
+// beginRun returns a context for an agent run or workflow that is
+// cancelled by the next interrupt, or when the configured run timeout
+// expires, and that shows the model's thinking if enabled. The returned
+// function must be called when the run ends.
+func (c *CLI) beginRun(ctx context.Context) (context.Context, func()) {
+	if c.showThinking {
+		ctx = agent.WithThinkingHandler(ctx, c.printThinking)
+	}
+
+	var cancel context.CancelFunc
+	if timeout := time.Duration(c.config.RunTimeout); timeout > 0 {
+		ctx, cancel = context.WithTimeout(ctx, timeout)
+	} else {
+		ctx, cancel = context.WithCancel(ctx)
+	}
+
+	c.runMu.Lock()
+	c.runCancel = cancel
+	c.runCtx = ctx
+	c.runMu.Unlock()
+
+	return ctx, func() {
+		c.runMu.Lock()
+		c.runCancel = nil
+		c.runCtx = nil
+		c.runMu.Unlock()
+		cancel()
+	}
+}
+
+// cancelRun cancels the run in progress, if any, and reports whether there
+// was one. A run is only cancelled once, so the next interrupt exits.
+func (c *CLI) cancelRun() bool {
+	c.runMu.Lock()
+	cancel := c.runCancel
+	c.runCancel = nil
+	c.runMu.Unlock()
+
+	if cancel == nil {
+		return false
+	}
+	c.diagf("\nInterrupted: cancelling the current run. Press Ctrl-C again to exit.\n")
+	cancel()
+	return true
+}
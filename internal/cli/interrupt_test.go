@@ -0,0 +1,121 @@
+package cli
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"errors"
+	"os"
+	"strings"
+	"testing"
+	"time"
+
+	"agentic-poc/internal/provider"
+)
+
+// interruptingProvider makes a calculator call, then sends an interrupt
+// during the next Generate and waits for the run to be cancelled.
+type interruptingProvider struct {
+	interrupts chan os.Signal
+	calls      int
+}
+
+func (p *interruptingProvider) Generate(ctx context.Context, req provider.GenerateRequest) (*provider.LLMResponse, error) {
+	p.calls++
+	if p.calls == 1 {
+		return &provider.LLMResponse{
+			ToolCalls: []provider.ToolCall{
+				{ID: "call_1", Name: "calculator", Arguments: map[string]interface{}{"operation": "add", "a": 1.0, "b": 2.0}},
+			},
+		}, nil
+	}
+	p.interrupts <- os.Interrupt
+	<-ctx.Done()
+	return nil, ctx.Err()
+}
+
+func (p *interruptingProvider) Name() string {
+	return "interrupting"
+}
+
+func TestInterrupt_CancelsRunAndReturnsToPrompt(t *testing.T) {
+	interrupts := make(chan os.Signal)
+	p := &interruptingProvider{interrupts: interrupts}
+	output := &bytes.Buffer{}
+	cli := NewCLIWithIO(p, strings.NewReader("Add 1 and 2\n/history\nexit\n"), output)
+	cli.exit = func(code int) { t.Errorf("CLI exited with %d after the first interrupt", code) }
+	cli.HandleInterrupts(interrupts)
+
+	out := runSession(t, cli, output)
+
+	for _, want := range []string{
+		"Interrupted: cancelling the current run",
+		"[Tool Call] calculator",
+		"Run cancelled.",
+		"No conversation yet.",
+		"Goodbye!",
+	} {
+		if !strings.Contains(out, want) {
+			t.Errorf("output should contain %q, got: %s", want, out)
+		}
+	}
+}
+
+func TestInterrupt_ExitsWithoutRun(t *testing.T) {
+	interrupts := make(chan os.Signal)
+	output := &bytes.Buffer{}
+	cli := NewCLIWithIO(newMockProvider(), strings.NewReader(""), output)
+	exited := make(chan int, 1)
+	cli.exit = func(code int) { exited <- code }
+	cli.HandleInterrupts(interrupts)
+
+	interrupts <- os.Interrupt
+
+	select {
+	case code := <-exited:
+		if code != ExitInterrupted {
+			t.Errorf("exit code = %d, want %d", code, ExitInterrupted)
+		}
+	case <-time.After(2 * time.Second):
+		t.Fatal("CLI did not exit")
+	}
+	if !strings.Contains(output.String(), "Exiting.") {
+		t.Errorf("output = %q, want an exit message", output.String())
+	}
+}
+
+func TestInterrupt_OneShotReportsPartialResult(t *testing.T) {
+	interrupts := make(chan os.Signal)
+	p := &interruptingProvider{interrupts: interrupts}
+	output := &bytes.Buffer{}
+	errOutput := &bytes.Buffer{}
+	cli := NewCLIWithIO(p, strings.NewReader(""), output)
+	cli.errOutput = errOutput
+	if err := cli.SetOutputFormat(OutputJSON); err != nil {
+		t.Fatal(err)
+	}
+	cli.HandleInterrupts(interrupts)
+
+	err := cli.RunSingleAgentOnce(context.Background(), "Add 1 and 2")
+	if !errors.Is(err, ErrRunFailed) || !errors.Is(err, context.Canceled) {
+		t.Fatalf("error = %v, want ErrRunFailed wrapping context.Canceled", err)
+	}
+
+	var got struct {
+		Success   bool                `json:"success"`
+		ToolCalls []provider.ToolCall `json:"tool_calls"`
+		Error     string              `json:"error"`
+	}
+	if err := json.Unmarshal(output.Bytes(), &got); err != nil {
+		t.Fatalf("output is not JSON: %v\n%s", err, output.String())
+	}
+	if got.Success || !strings.Contains(got.Error, "interrupted") {
+		t.Errorf("success = %v, error = %q; want an interrupted failure", got.Success, got.Error)
+	}
+	if len(got.ToolCalls) != 1 || got.ToolCalls[0].Name != "calculator" {
+		t.Errorf("tool calls = %+v, want the calculator call made before the interrupt", got.ToolCalls)
+	}
+	if !strings.Contains(errOutput.String(), "Interrupted") {
+		t.Errorf("interrupt notice should go to stderr in JSON mode, got: %q", errOutput.String())
+	}
+}
@@ -0,0 +1,119 @@
+package cli
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"strings"
+
+	"agentic-poc/internal/mcp"
+)
+
+// progressBarWidth is the number of cells in a rendered progress bar.
+const progressBarWidth = 20
+
+// SetMCPLogLevel sets the minimum level of MCP server log messages to show.
+// An empty level, the default, shows none. It must be called before
+// LoadMCPConfig.
+func (c *CLI) SetMCPLogLevel(level string) error {
+	if level != "" {
+		if err := mcp.ValidateLogLevel(level); err != nil {
+			return err
+		}
+	}
+	c.mcpLogLevel = level
+	return nil
+}
+
+// printProgress displays a progress update for an MCP tool call.
+func (c *CLI) printProgress(e mcp.ProgressEvent) {
+	c.diagf("  [mcp:%s] %s\n", e.Server, formatProgress(e))
+}
+
+// formatProgress renders a progress event as a bar when the total is
+// known, and as a counter otherwise.
+func formatProgress(e mcp.ProgressEvent) string {
+	var b strings.Builder
+	b.WriteString(e.Tool)
+
+	if e.Total > 0 {
+		ratio := e.Progress / e.Total
+		if ratio > 1 {
+			ratio = 1
+		}
+		filled := int(ratio * progressBarWidth)
+		fmt.Fprintf(&b, " [%s%s] %3.0f%%",
+			strings.Repeat("#", filled), strings.Repeat("-", progressBarWidth-filled), ratio*100)
+	} else {
+		fmt.Fprintf(&b, " (%g)", e.Progress)
+	}
+
+	if e.Message != "" {
+		b.WriteString(" ")
+		b.WriteString(e.Message)
+	}
+	return b.String()
+}
+
+// printLogMessage displays a log message from an MCP server.
+func (c *CLI) printLogMessage(msg mcp.LogMessage) {
+	source := msg.Server
+	if msg.Logger != "" {
+		source += "/" + msg.Logger
+	}
+	c.diagf("  [mcp:%s] %s: %s\n", source, msg.Level, formatLogData(msg.Data))
+}
+
+// formatLogData renders log data, which may be any JSON value, as text.
+func formatLogData(data interface{}) string {
+	if s, ok := data.(string); ok {
+		return s
+	}
+	encoded, err := json.Marshal(data)
+	if err != nil {
+		return fmt.Sprint(data)
+	}
+	return string(encoded)
+}
+
+// approveSampling asks the user whether an MCP server may send a sampling
+// request to the LLM, for servers without sampling.autoApprove. Sampling
+// happens while a tool call is waiting on the server, so the prompt reads
+// the answer from the CLI's input while the REPL is not reading it.
+// Requests that arrive outside a run, or that get no answer because the
+// input has ended or the run was interrupted, are denied.
+func (c *CLI) approveSampling(ctx context.Context, server string, req *mcp.SamplingRequest) error {
+	c.approveMu.Lock()
+	defer c.approveMu.Unlock()
+
+	c.runMu.Lock()
+	running := c.runCancel != nil
+	runCtx := c.runCtx
+	c.runMu.Unlock()
+	if !running {
+		return errors.New("no run in progress to ask the user from")
+	}
+
+	// Ctrl-C cancels the run, and with it the question
+	ctx, cancel := context.WithCancel(ctx)
+	defer cancel()
+	stop := context.AfterFunc(runCtx, cancel)
+	defer stop()
+
+	c.diagf("\n  [mcp:%s] wants to use the LLM: %d messages, up to %d tokens. Allow? [y/N] ", server, len(req.Messages), req.MaxTokens)
+	answer, err := c.readLine(ctx)
+	if err != nil {
+		c.diagf("\n")
+		if ctx.Err() != nil {
+			return errors.New("interrupted before the user answered")
+		}
+		return errors.New("no answer from the user")
+	}
+	switch strings.ToLower(strings.TrimSpace(answer)) {
+	case "y", "yes":
+		return nil
+	default:
+		return errors.New("denied by the user")
+	}
+}
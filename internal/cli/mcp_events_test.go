@@ -0,0 +1,150 @@
+package cli
+
+import (
+	"bytes"
+	"context"
+	"io"
+	"strings"
+	"sync"
+	"testing"
+	"time"
+
+	"agentic-poc/internal/mcp"
+)
+
+func TestFormatProgress(t *testing.T) {
+	tests := []struct {
+		name  string
+		event mcp.ProgressEvent
+		want  string
+	}{
+		{
+			name:  "known total",
+			event: mcp.ProgressEvent{Tool: "build", Progress: 1, Total: 4, Message: "compiling"},
+			want:  "build [#####---------------]  25% compiling",
+		},
+		{
+			name:  "unknown total",
+			event: mcp.ProgressEvent{Tool: "search", Progress: 3},
+			want:  "search (3)",
+		},
+		{
+			name:  "progress past total is capped",
+			event: mcp.ProgressEvent{Tool: "sync", Progress: 5, Total: 2},
+			want:  "sync [####################] 100%",
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if got := formatProgress(tt.event); got != tt.want {
+				t.Errorf("formatProgress() = %q, want %q", got, tt.want)
+			}
+		})
+	}
+}
+
+func TestPrintLogMessage(t *testing.T) {
+	output := &bytes.Buffer{}
+	cli := NewCLIWithIO(newMockProvider(), strings.NewReader(""), output)
+
+	cli.printLogMessage(mcp.LogMessage{Server: "db", Logger: "pool", Level: "warning", Data: map[string]interface{}{"open": 9}})
+
+	if got, want := output.String(), "  [mcp:db/pool] warning: {\"open\":9}\n"; got != want {
+		t.Errorf("printLogMessage() wrote %q, want %q", got, want)
+	}
+}
+
+func TestSetMCPLogLevel(t *testing.T) {
+	cli := NewCLIWithIO(newMockProvider(), strings.NewReader(""), &bytes.Buffer{})
+
+	if err := cli.SetMCPLogLevel("info"); err != nil {
+		t.Errorf("SetMCPLogLevel(info) error = %v", err)
+	}
+	if err := cli.SetMCPLogLevel(""); err != nil {
+		t.Errorf("SetMCPLogLevel(\"\") error = %v", err)
+	}
+	if err := cli.SetMCPLogLevel("chatty"); err == nil {
+		t.Error("expected error for invalid level")
+	}
+}
+
+func TestApproveSampling(t *testing.T) {
+	req := &mcp.SamplingRequest{Messages: []mcp.SamplingMessage{{Role: "user"}}, MaxTokens: 100}
+	tests := []struct {
+		name    string
+		input   string
+		running bool
+		wantErr string
+	}{
+		{name: "approved", input: "y\n", running: true},
+		{name: "approved in full", input: "Yes\n", running: true},
+		{name: "denied", input: "n\n", running: true, wantErr: "denied by the user"},
+		{name: "empty answer", input: "\n", running: true, wantErr: "denied by the user"},
+		{name: "end of input", input: "", running: true, wantErr: "no answer"},
+		{name: "no run in progress", input: "y\n", wantErr: "no run in progress"},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			output := &bytes.Buffer{}
+			cli := NewCLIWithIO(newMockProvider(), strings.NewReader(tt.input), output)
+			if tt.running {
+				_, endRun := cli.beginRun(context.Background())
+				defer endRun()
+			}
+
+			err := cli.approveSampling(context.Background(), "docs", req)
+			if tt.wantErr == "" {
+				if err != nil {
+					t.Errorf("unexpected error: %v", err)
+				}
+			} else if err == nil || !strings.Contains(err.Error(), tt.wantErr) {
+				t.Errorf("error = %v, want %q", err, tt.wantErr)
+			}
+
+			if prompted := strings.Contains(output.String(), `[mcp:docs] wants to use the LLM: 1 messages, up to 100 tokens`); prompted != tt.running {
+				t.Errorf("prompted = %v, output %q", prompted, output.String())
+			}
+		})
+	}
+}
+
+// waitingReader is input that never arrives. It closes reading on the
+// first Read, then blocks until the test ends.
+type waitingReader struct {
+	reading chan struct{}
+	once    sync.Once
+	done    chan struct{}
+}
+
+func (r *waitingReader) Read(p []byte) (int, error) {
+	r.once.Do(func() { close(r.reading) })
+	<-r.done
+	return 0, io.EOF
+}
+
+func TestApproveSampling_Interrupted(t *testing.T) {
+	input := &waitingReader{reading: make(chan struct{}), done: make(chan struct{})}
+	defer close(input.done)
+	cli := NewCLIWithIO(newMockProvider(), input, &bytes.Buffer{})
+	_, endRun := cli.beginRun(context.Background())
+	defer endRun()
+
+	errCh := make(chan error, 1)
+	go func() {
+		errCh <- cli.approveSampling(context.Background(), "docs", &mcp.SamplingRequest{MaxTokens: 100})
+	}()
+
+	// Ctrl-C while the prompt waits for an answer denies the request
+	<-input.reading
+	cli.cancelRun()
+	select {
+	case err := <-errCh:
+		if err == nil || !strings.Contains(err.Error(), "interrupted") {
+			t.Errorf("error = %v, want the request denied as interrupted", err)
+		}
+	case <-time.After(2 * time.Second):
+		t.Fatal("approveSampling kept waiting for input after the run was cancelled")
+	}
+}
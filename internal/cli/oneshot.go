@@ -0,0 +1,176 @@
+package cli
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"os"
+	"strings"
+
+	"agentic-poc/internal/agent"
+	"agentic-poc/internal/memory"
+	"agentic-poc/internal/orchestrator"
+)
+
+// Output formats for one-shot runs.
+const (
+	OutputText = "text"
+	OutputJSON = "json"
+)
+
+// ErrRunFailed is returned by the one-shot runs when the agent or workflow
+// did not succeed. The failure has already been reported in the output.
+var ErrRunFailed = errors.New("run failed")
+
+// SetOutputFormat sets how one-shot runs report their result: OutputText
+// (the default) for people, or OutputJSON for scripts. In JSON mode MCP
+// progress and log messages go to stderr so stdout holds only the result.
+func (c *CLI) SetOutputFormat(format string) error {
+	switch format {
+	case "", OutputText:
+		c.outputFormat = OutputText
+	case OutputJSON:
+		c.outputFormat = OutputJSON
+	default:
+		return fmt.Errorf("invalid output format %q (expected %q or %q)", format, OutputText, OutputJSON)
+	}
+	return nil
+}
+
+// ReadPrompt returns the prompt for a one-shot run from, in order of
+// preference, the prompt argument, the file at promptFile, or all of
+// stdin. Pass a nil stdin when it is a terminal. It returns "" when no
+// source is given, meaning the CLI should run interactively.
+func ReadPrompt(prompt, promptFile string, stdin io.Reader) (string, error) {
+	if prompt != "" && promptFile != "" {
+		return "", fmt.Errorf("-prompt and -prompt-file cannot be used together")
+	}
+
+	var source string
+	switch {
+	case prompt != "":
+		source = "-prompt"
+	case promptFile != "":
+		data, err := os.ReadFile(promptFile)
+		if err != nil {
+			return "", fmt.Errorf("failed to read prompt file: %w", err)
+		}
+		prompt, source = string(data), promptFile
+	case stdin != nil:
+		data, err := io.ReadAll(stdin)
+		if err != nil {
+			return "", fmt.Errorf("failed to read prompt from stdin: %w", err)
+		}
+		prompt, source = string(data), "stdin"
+	default:
+		return "", nil
+	}
+
+	prompt = strings.TrimSpace(prompt)
+	if prompt == "" {
+		return "", fmt.Errorf("prompt from %s is empty", source)
+	}
+	return prompt, nil
+}
+
+// singleAgentOutput is the JSON document written by RunSingleAgentOnce.
+// The result fields are omitted when the agent failed.
+type singleAgentOutput struct {
+	Success bool `json:"success"`
+	*agent.AgentResult
+	Error string `json:"error,omitempty"`
+}
+
+// RunSingleAgentOnce runs the single-agent mode agent on one prompt, with
+// any @path attachments, and writes its result, then returns. It returns
+// ErrRunFailed if the agent failed, and other errors if the agent could
+// not be set up. An interrupted run also wraps context.Canceled, and its
+// JSON result holds the tool calls made before the interrupt.
+func (c *CLI) RunSingleAgentOnce(ctx context.Context, prompt string) error {
+	attachments, missing, err := parseAttachments(prompt)
+	if err != nil {
+		return err
+	}
+	for _, path := range missing {
+		c.diagf("Warning: @%s not found, sent as text\n", path)
+	}
+	for _, a := range attachments {
+		c.diagf("Attached %s\n", describeAttachment(a))
+	}
+
+	agentInstance, err := c.newSingleAgent()
+	if err != nil {
+		return err
+	}
+
+	ctx, endRun := c.beginRun(ctx)
+	result, runErr := agentInstance.RunWithContent(ctx, prompt, promptBlocks(prompt, attachments), memory.NewConversationMemory())
+	endRun()
+
+	if c.outputFormat == OutputJSON {
+		out := singleAgentOutput{Success: runErr == nil, AgentResult: result}
+		if runErr != nil {
+			out.Error = runErr.Error()
+		}
+		if err := c.writeJSON(out); err != nil {
+			return err
+		}
+	} else if runErr == nil {
+		c.println(result.Response)
+	}
+
+	if runErr != nil {
+		return fmt.Errorf("%w: %w", ErrRunFailed, runErr)
+	}
+	return nil
+}
+
+// RunMultiAgentOnce runs the Architect/Coder workflow for one goal and
+// writes its result, then returns. It returns ErrRunFailed if the workflow
+// did not succeed, also wrapping context.Canceled if it was interrupted.
+func (c *CLI) RunMultiAgentOnce(ctx context.Context, goal string) error {
+	if err := rejectAttachments(goal); err != nil {
+		return err
+	}
+	orch, err := c.newOrchestrator()
+	if err != nil {
+		return err
+	}
+	ctx, endRun := c.beginRun(ctx)
+	result, runErr := orch.Run(ctx, goal)
+	endRun()
+	if result == nil {
+		result = &orchestrator.OrchestratorResult{}
+		if runErr != nil {
+			result.Error = runErr.Error()
+		}
+	}
+
+	if c.outputFormat == OutputJSON {
+		if err := c.writeJSON(result); err != nil {
+			return err
+		}
+	} else if runErr == nil {
+		c.printWorkflowResult(result, orch.State().Phase)
+	}
+
+	if runErr != nil {
+		return fmt.Errorf("%w: %w", ErrRunFailed, runErr)
+	}
+	if !result.Success {
+		return fmt.Errorf("%w: %s", ErrRunFailed, result.Error)
+	}
+	return nil
+}
+
+// writeJSON writes v to the output as indented JSON.
+func (c *CLI) writeJSON(v interface{}) error {
+	data, err := json.MarshalIndent(v, "", "  ")
+	if err != nil {
+		return fmt.Errorf("failed to encode result: %w", err)
+	}
+	c.println(string(data))
+	return nil
+}
@@ -0,0 +1,271 @@
+package cli
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"errors"
+	"io"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+
+	"agentic-poc/internal/provider"
+)
+
+// failingProvider implements provider.LLMProvider and always fails.
+type failingProvider struct{}
+
+func (failingProvider) Generate(ctx context.Context, req provider.GenerateRequest) (*provider.LLMResponse, error) {
+	return nil, errors.New("service unavailable")
+}
+
+func (failingProvider) Name() string {
+	return "failing"
+}
+
+func TestReadPrompt(t *testing.T) {
+	dir := t.TempDir()
+	promptFile := filepath.Join(dir, "prompt.txt")
+	if err := os.WriteFile(promptFile, []byte("  from file\n"), 0644); err != nil {
+		t.Fatal(err)
+	}
+	blankFile := filepath.Join(dir, "blank.txt")
+	if err := os.WriteFile(blankFile, []byte("\n\n"), 0644); err != nil {
+		t.Fatal(err)
+	}
+
+	tests := []struct {
+		name       string
+		prompt     string
+		promptFile string
+		stdin      string
+		noStdin    bool
+		want       string
+		wantErr    string
+	}{
+		{name: "flag", prompt: "from flag", noStdin: true, want: "from flag"},
+		{name: "flag wins over stdin", prompt: "from flag", stdin: "from stdin", want: "from flag"},
+		{name: "file", promptFile: promptFile, noStdin: true, want: "from file"},
+		{name: "stdin", stdin: "line one\nline two\n", want: "line one\nline two"},
+		{name: "interactive", noStdin: true, want: ""},
+		{name: "both flags", prompt: "a", promptFile: promptFile, noStdin: true, wantErr: "cannot be used together"},
+		{name: "missing file", promptFile: filepath.Join(dir, "missing.txt"), noStdin: true, wantErr: "failed to read prompt file"},
+		{name: "blank file", promptFile: blankFile, noStdin: true, wantErr: "is empty"},
+		{name: "empty stdin", stdin: "", wantErr: "prompt from stdin is empty"},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			var stdin io.Reader
+			if !tt.noStdin {
+				stdin = strings.NewReader(tt.stdin)
+			}
+
+			got, err := ReadPrompt(tt.prompt, tt.promptFile, stdin)
+
+			if tt.wantErr != "" {
+				if err == nil || !strings.Contains(err.Error(), tt.wantErr) {
+					t.Fatalf("error = %v, want one containing %q", err, tt.wantErr)
+				}
+				return
+			}
+			if err != nil {
+				t.Fatalf("unexpected error: %v", err)
+			}
+			if got != tt.want {
+				t.Errorf("prompt = %q, want %q", got, tt.want)
+			}
+		})
+	}
+}
+
+func TestSetOutputFormat(t *testing.T) {
+	cli := NewCLIWithIO(newMockProvider(), strings.NewReader(""), &bytes.Buffer{})
+
+	for _, format := range []string{"", OutputText, OutputJSON} {
+		if err := cli.SetOutputFormat(format); err != nil {
+			t.Errorf("SetOutputFormat(%q) returned error: %v", format, err)
+		}
+	}
+	if err := cli.SetOutputFormat("yaml"); err == nil {
+		t.Error("expected error for unknown format")
+	}
+}
+
+func TestRunSingleAgentOnce_Text(t *testing.T) {
+	mock := newMockProvider(&provider.LLMResponse{Text: "Hello there"})
+	output := &bytes.Buffer{}
+	cli := NewCLIWithIO(mock, strings.NewReader(""), output)
+
+	if err := cli.RunSingleAgentOnce(context.Background(), "Hi"); err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+
+	if got := output.String(); got != "Hello there\n" {
+		t.Errorf("output = %q, want only the response", got)
+	}
+}
+
+func TestRunSingleAgentOnce_JSON(t *testing.T) {
+	mock := newMockProvider(
+		&provider.LLMResponse{
+			ToolCalls: []provider.ToolCall{
+				{ID: "call_1", Name: "calculator", Arguments: map[string]interface{}{"operation": "add", "a": 2.0, "b": 3.0}},
+			},
+			Usage: provider.Usage{InputTokens: 50, OutputTokens: 10},
+		},
+		&provider.LLMResponse{
+			Text:  "The answer is 5",
+			Usage: provider.Usage{InputTokens: 70, OutputTokens: 6},
+		},
+	)
+	output := &bytes.Buffer{}
+	cli := NewCLIWithIO(mock, strings.NewReader(""), output)
+	if err := cli.SetOutputFormat(OutputJSON); err != nil {
+		t.Fatal(err)
+	}
+
+	if err := cli.RunSingleAgentOnce(context.Background(), "What is 2 + 3?"); err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+
+	var got struct {
+		Success   bool                `json:"success"`
+		Response  string              `json:"response"`
+		ToolCalls []provider.ToolCall `json:"tool_calls"`
+		Usage     provider.Usage      `json:"usage"`
+		Error     string              `json:"error"`
+	}
+	if err := json.Unmarshal(output.Bytes(), &got); err != nil {
+		t.Fatalf("output is not JSON: %v\n%s", err, output.String())
+	}
+
+	if !got.Success || got.Error != "" {
+		t.Errorf("success = %v, error = %q; want success", got.Success, got.Error)
+	}
+	if got.Response != "The answer is 5" {
+		t.Errorf("response = %q", got.Response)
+	}
+	if len(got.ToolCalls) != 1 || got.ToolCalls[0].Name != "calculator" {
+		t.Errorf("tool calls = %+v, want one calculator call", got.ToolCalls)
+	}
+	if want := (provider.Usage{InputTokens: 120, OutputTokens: 16}); got.Usage != want {
+		t.Errorf("usage = %+v, want %+v", got.Usage, want)
+	}
+}
+
+func TestRunSingleAgentOnce_Failure(t *testing.T) {
+	output := &bytes.Buffer{}
+	cli := NewCLIWithIO(failingProvider{}, strings.NewReader(""), output)
+	if err := cli.SetOutputFormat(OutputJSON); err != nil {
+		t.Fatal(err)
+	}
+
+	err := cli.RunSingleAgentOnce(context.Background(), "Hi")
+	if !errors.Is(err, ErrRunFailed) {
+		t.Fatalf("error = %v, want ErrRunFailed", err)
+	}
+
+	var got map[string]interface{}
+	if err := json.Unmarshal(output.Bytes(), &got); err != nil {
+		t.Fatalf("output is not JSON: %v\n%s", err, output.String())
+	}
+	if got["success"] != false {
+		t.Errorf("success = %v, want false", got["success"])
+	}
+	if msg, _ := got["error"].(string); !strings.Contains(msg, "service unavailable") {
+		t.Errorf("error = %q, want the provider error", msg)
+	}
+	if _, ok := got["response"]; ok {
+		t.Error("failed run should not include a response")
+	}
+}
+
+func TestRunMultiAgentOnce_JSON(t *testing.T) {
+	mock := newMockProvider(
+		&provider.LLMResponse{
+			ToolCalls: []provider.ToolCall{{
+				ID:   "call_1",
+				Name: "finish_plan",
+				Arguments: map[string]interface{}{
+					"goal": "Say hello",
+					"steps": []interface{}{
+						map[string]interface{}{"description": "Greet", "action": "respond"},
+					},
+				},
+			}},
+			Usage: provider.Usage{InputTokens: 40, OutputTokens: 30},
+		},
+		&provider.LLMResponse{Text: "Said hello", Usage: provider.Usage{InputTokens: 20, OutputTokens: 4}},
+	)
+	output := &bytes.Buffer{}
+	cli := NewCLIWithIO(mock, strings.NewReader(""), output)
+	cli.SetBasePath(t.TempDir())
+	if err := cli.SetOutputFormat(OutputJSON); err != nil {
+		t.Fatal(err)
+	}
+
+	if err := cli.RunMultiAgentOnce(context.Background(), "Say hello"); err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+
+	var got struct {
+		Success bool `json:"success"`
+		Plan    struct {
+			Goal  string `json:"goal"`
+			Steps []struct {
+				Action string `json:"action"`
+			} `json:"steps"`
+		} `json:"plan"`
+		ActionsTaken []string       `json:"actions_taken"`
+		Summary      string         `json:"summary"`
+		Usage        provider.Usage `json:"usage"`
+	}
+	if err := json.Unmarshal(output.Bytes(), &got); err != nil {
+		t.Fatalf("output is not JSON: %v\n%s", err, output.String())
+	}
+
+	if !got.Success {
+		t.Error("expected success")
+	}
+	if got.Plan.Goal != "Say hello" || len(got.Plan.Steps) != 1 {
+		t.Errorf("plan = %+v", got.Plan)
+	}
+	if len(got.ActionsTaken) != 1 {
+		t.Errorf("actions taken = %v, want the finish_plan call", got.ActionsTaken)
+	}
+	if got.Summary != "Said hello" {
+		t.Errorf("summary = %q", got.Summary)
+	}
+	if want := (provider.Usage{InputTokens: 60, OutputTokens: 34}); got.Usage != want {
+		t.Errorf("usage = %+v, want %+v", got.Usage, want)
+	}
+}
+
+func TestRunMultiAgentOnce_Failure(t *testing.T) {
+	// The architect answers without calling finish_plan
+	mock := newMockProvider(&provider.LLMResponse{Text: "I have no plan"})
+	output := &bytes.Buffer{}
+	cli := NewCLIWithIO(mock, strings.NewReader(""), output)
+	if err := cli.SetOutputFormat(OutputJSON); err != nil {
+		t.Fatal(err)
+	}
+
+	err := cli.RunMultiAgentOnce(context.Background(), "Do something")
+	if !errors.Is(err, ErrRunFailed) {
+		t.Fatalf("error = %v, want ErrRunFailed", err)
+	}
+
+	var got map[string]interface{}
+	if err := json.Unmarshal(output.Bytes(), &got); err != nil {
+		t.Fatalf("output is not JSON: %v\n%s", err, output.String())
+	}
+	if got["success"] != false {
+		t.Errorf("success = %v, want false", got["success"])
+	}
+	if msg, _ := got["error"].(string); !strings.Contains(msg, "did not produce a plan") {
+		t.Errorf("error = %q", msg)
+	}
+}
@@ -0,0 +1,503 @@
+// Package config loads the settings of the agent CLI: the LLM provider,
+// its model and fallback model, sampling and timeout limits, and each
+// agent's model, system prompt, tools, iteration limit and thinking budget.
+//
+// Settings are layered. Built-in defaults are overridden by the user config
+// file, then the project config file, then environment variables, and
+// finally command-line flags. The effective config remembers which layer
+// each value came from.
+package config
+
+import (
+	"bytes"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"os"
+	"path/filepath"
+	"sort"
+	"strconv"
+	"strings"
+	"time"
+
+	"agentic-poc/internal/agent"
+	"agentic-poc/internal/provider"
+	"agentic-poc/internal/tool"
+)
+
+// ProjectFile is the default name of the project config file.
+const ProjectFile = ".agentic.json"
+
+// userConfigSubpath is the location of the user config relative to the
+// user config directory (e.g. ~/.config on Linux).
+const userConfigSubpath = "agentic-poc/config.json"
+
+// Agents that can be configured in the agents section. Each name is also
+// the agent's role, which per-agent model routing matches on, so the
+// multi-agent names are the agent package's roles.
+const (
+	AgentSingle    = "single"            // The single-agent mode assistant
+	AgentArchitect = agent.RoleArchitect // Plans in multi-agent mode
+	AgentCoder     = agent.RoleCoder     // Executes plans in multi-agent mode
+)
+
+// agentNames lists the configurable agents in display order.
+var agentNames = []string{AgentSingle, AgentArchitect, AgentCoder}
+
+// SourceDefault is the source of a setting no layer overrides.
+const SourceDefault = "default"
+
+// envVars maps the environment variables that override settings to their
+// keys.
+var envVars = []struct{ name, key string }{
+	{"AGENTIC_PROVIDER", "provider.name"},
+	{"AGENTIC_MODEL", "provider.model"},
+	{"AGENTIC_FALLBACK_MODEL", "provider.fallbackModel"},
+	{"AGENTIC_MAX_TOKENS", "provider.maxTokens"},
+	{"AGENTIC_TEMPERATURE", "provider.temperature"},
+	{"AGENTIC_TIMEOUT", "provider.timeout"},
+	{"AGENTIC_RUN_TIMEOUT", "runTimeout"},
+}
+
+// Config is the effective configuration of the CLI.
+type Config struct {
+	Provider   ProviderConfig         `json:"provider"`
+	RunTimeout Duration               `json:"runTimeout"` // Limit on a whole agent run or workflow; 0 for none
+	Agents     map[string]AgentConfig `json:"agents"`     // Keyed by AgentSingle, AgentArchitect or AgentCoder
+
+	sources map[string]string // Setting key -> the layer that set it
+}
+
+// ProviderConfig selects and tunes the LLM provider.
+type ProviderConfig struct {
+	Name          string   `json:"name"`                    // e.g. "claude"
+	Model         string   `json:"model"`                   // Model ID passed to the provider
+	FallbackModel string   `json:"fallbackModel,omitempty"` // Used when the model is overloaded or failing; "" for none
+	MaxTokens     int      `json:"maxTokens"`               // Output limit of requests that do not set one
+	Temperature   *float64 `json:"temperature,omitempty"`   // Nil leaves the provider's default
+	Timeout       Duration `json:"timeout"`                 // Per-request HTTP timeout
+}
+
+// AgentConfig customizes one agent.
+type AgentConfig struct {
+	Model          string   `json:"model,omitempty"` // Overrides provider.model for this agent; "" to use it
+	SystemPrompt   string   `json:"systemPrompt"`
+	Tools          []string `json:"tools"` // Built-in tool names, see tool.BuiltinNames
+	MaxIterations  int      `json:"maxIterations"`
+	ThinkingBudget int      `json:"thinkingBudget"` // Extended thinking tokens per call; 0 disables it
+}
+
+// Duration is a time.Duration written in config files as a string such as
+// "90s" or "5m".
+type Duration time.Duration
+
+// MarshalJSON writes the duration as a string.
+func (d Duration) MarshalJSON() ([]byte, error) {
+	return json.Marshal(time.Duration(d).String())
+}
+
+// UnmarshalJSON parses a duration string.
+func (d *Duration) UnmarshalJSON(data []byte) error {
+	var s string
+	if err := json.Unmarshal(data, &s); err != nil {
+		return fmt.Errorf("duration must be a string such as \"90s\": %w", err)
+	}
+	parsed, err := time.ParseDuration(s)
+	if err != nil {
+		return err
+	}
+	*d = Duration(parsed)
+	return nil
+}
+
+// Default returns the built-in configuration, which matches the behavior of
+// the CLI without a config file.
+func Default() *Config {
+	return &Config{
+		Provider: ProviderConfig{
+			Name:      "claude",
+			Model:     provider.DefaultClaudeModel,
+			MaxTokens: provider.DefaultMaxTokens,
+			Timeout:   Duration(provider.DefaultTimeout),
+		},
+		Agents: map[string]AgentConfig{
+			AgentSingle: {
+				SystemPrompt:  agent.SingleAgentSystemPrompt,
+				Tools:         []string{"calculator", "read_file"},
+				MaxIterations: agent.DefaultMaxIterations,
+			},
+			AgentArchitect: {
+				SystemPrompt:  agent.ArchitectSystemPrompt,
+				Tools:         []string{},
+				MaxIterations: agent.DefaultMaxIterations,
+			},
+			AgentCoder: {
+				SystemPrompt:  agent.CoderSystemPrompt,
+				Tools:         []string{"read_file", "write_file"},
+				MaxIterations: agent.DefaultMaxIterations,
+			},
+		},
+		sources: make(map[string]string),
+	}
+}
+
+// Load returns the default config overridden by the user config file (see
+// UserConfigPath), the project config file at projectPath and the
+// environment variables read with getenv, in that order. Missing config
+// files are skipped; a nil getenv skips the environment. The result is not
+// validated, so that flags can still be applied with Set; call Validate
+// once they are.
+func Load(projectPath string, getenv func(string) string) (*Config, error) {
+	cfg := Default()
+
+	userPath, _ := UserConfigPath()
+	layers := []struct{ path, name string }{
+		{userPath, "user config"},
+		{projectPath, "project config"},
+	}
+	for _, layer := range layers {
+		if layer.path == "" {
+			continue
+		}
+		if _, err := os.Stat(layer.path); errors.Is(err, os.ErrNotExist) {
+			continue
+		}
+		if err := cfg.mergeFile(layer.path, fmt.Sprintf("%s %s", layer.name, layer.path)); err != nil {
+			return nil, err
+		}
+	}
+
+	if getenv != nil {
+		for _, env := range envVars {
+			if value := getenv(env.name); value != "" {
+				if err := cfg.Set(env.key, value, "env "+env.name); err != nil {
+					return nil, fmt.Errorf("%s: %w", env.name, err)
+				}
+			}
+		}
+	}
+
+	return cfg, nil
+}
+
+// UserConfigPath returns the path of the user config file,
+// e.g. ~/.config/agentic-poc/config.json on Linux.
+func UserConfigPath() (string, error) {
+	dir, err := os.UserConfigDir()
+	if err != nil {
+		return "", err
+	}
+	return filepath.Join(dir, userConfigSubpath), nil
+}
+
+// fileConfig is the format of a config file. Pointers tell settings that
+// are absent, and so inherited, from those set to their zero value.
+type fileConfig struct {
+	Provider struct {
+		Name          *string   `json:"name"`
+		Model         *string   `json:"model"`
+		FallbackModel *string   `json:"fallbackModel"`
+		MaxTokens     *int      `json:"maxTokens"`
+		Temperature   *float64  `json:"temperature"`
+		Timeout       *Duration `json:"timeout"`
+	} `json:"provider"`
+	RunTimeout *Duration                  `json:"runTimeout"`
+	Agents     map[string]fileAgentConfig `json:"agents"`
+}
+
+// fileAgentConfig is the format of one agent in a config file.
+type fileAgentConfig struct {
+	Model            *string  `json:"model"`
+	SystemPrompt     *string  `json:"systemPrompt"`
+	SystemPromptFile *string  `json:"systemPromptFile"` // Relative to the config file
+	Tools            []string `json:"tools"`            // Nil if absent, empty for no tools
+	MaxIterations    *int     `json:"maxIterations"`
+	ThinkingBudget   *int     `json:"thinkingBudget"`
+}
+
+// mergeFile applies the settings in the config file at path, recording
+// source as their origin. Unknown fields are rejected to catch typos.
+func (c *Config) mergeFile(path, source string) error {
+	data, err := os.ReadFile(path)
+	if err != nil {
+		return fmt.Errorf("failed to read config file: %w", err)
+	}
+
+	var file fileConfig
+	decoder := json.NewDecoder(bytes.NewReader(data))
+	decoder.DisallowUnknownFields()
+	if err := decoder.Decode(&file); err != nil {
+		return fmt.Errorf("failed to parse config file %s: %w", path, err)
+	}
+
+	p := file.Provider
+	if p.Name != nil {
+		c.Provider.Name = *p.Name
+		c.sources["provider.name"] = source
+	}
+	if p.Model != nil {
+		c.Provider.Model = *p.Model
+		c.sources["provider.model"] = source
+	}
+	if p.FallbackModel != nil {
+		c.Provider.FallbackModel = *p.FallbackModel
+		c.sources["provider.fallbackModel"] = source
+	}
+	if p.MaxTokens != nil {
+		c.Provider.MaxTokens = *p.MaxTokens
+		c.sources["provider.maxTokens"] = source
+	}
+	if p.Temperature != nil {
+		c.Provider.Temperature = p.Temperature
+		c.sources["provider.temperature"] = source
+	}
+	if p.Timeout != nil {
+		c.Provider.Timeout = *p.Timeout
+		c.sources["provider.timeout"] = source
+	}
+	if file.RunTimeout != nil {
+		c.RunTimeout = *file.RunTimeout
+		c.sources["runTimeout"] = source
+	}
+
+	// Sort so errors about several agents are reported deterministically
+	names := make([]string, 0, len(file.Agents))
+	for name := range file.Agents {
+		names = append(names, name)
+	}
+	sort.Strings(names)
+
+	for _, name := range names {
+		fa := file.Agents[name]
+		a, ok := c.Agents[name]
+		if !ok {
+			return fmt.Errorf("%s: unknown agent %q (expected one of %s)", path, name, strings.Join(agentNames, ", "))
+		}
+		prefix := "agents." + name + "."
+
+		if fa.Model != nil {
+			a.Model = *fa.Model
+			c.sources[prefix+"model"] = source
+		}
+		switch {
+		case fa.SystemPrompt != nil && fa.SystemPromptFile != nil:
+			return fmt.Errorf("%s: agent %q sets both systemPrompt and systemPromptFile", path, name)
+		case fa.SystemPrompt != nil:
+			a.SystemPrompt = *fa.SystemPrompt
+			c.sources[prefix+"systemPrompt"] = source
+		case fa.SystemPromptFile != nil:
+			promptPath := *fa.SystemPromptFile
+			if !filepath.IsAbs(promptPath) {
+				promptPath = filepath.Join(filepath.Dir(path), promptPath)
+			}
+			prompt, err := os.ReadFile(promptPath)
+			if err != nil {
+				return fmt.Errorf("%s: agent %q: failed to read systemPromptFile: %w", path, name, err)
+			}
+			a.SystemPrompt = strings.TrimSpace(string(prompt))
+			c.sources[prefix+"systemPrompt"] = fmt.Sprintf("%s (file %s)", source, promptPath)
+		}
+		if fa.Tools != nil {
+			a.Tools = fa.Tools
+			c.sources[prefix+"tools"] = source
+		}
+		if fa.MaxIterations != nil {
+			a.MaxIterations = *fa.MaxIterations
+			c.sources[prefix+"maxIterations"] = source
+		}
+		if fa.ThinkingBudget != nil {
+			a.ThinkingBudget = *fa.ThinkingBudget
+			c.sources[prefix+"thinkingBudget"] = source
+		}
+		c.Agents[name] = a
+	}
+
+	return nil
+}
+
+// Set changes the setting with the given key, such as "provider.model" or
+// "agents.coder.maxIterations", to value parsed from its string form, and
+// records source as its origin. Tools are comma-separated. The result is
+// not validated; call Validate after the last change.
+func (c *Config) Set(key, value, source string) error {
+	var err error
+	switch key {
+	case "provider.name":
+		c.Provider.Name = value
+	case "provider.model":
+		c.Provider.Model = value
+	case "provider.fallbackModel":
+		c.Provider.FallbackModel = value
+	case "provider.maxTokens":
+		c.Provider.MaxTokens, err = strconv.Atoi(value)
+	case "provider.temperature":
+		var t float64
+		if t, err = strconv.ParseFloat(value, 64); err == nil {
+			c.Provider.Temperature = &t
+		}
+	case "provider.timeout":
+		err = setDuration(&c.Provider.Timeout, value)
+	case "runTimeout":
+		err = setDuration(&c.RunTimeout, value)
+	default:
+		err = c.setAgent(key, value)
+	}
+	if err != nil {
+		return fmt.Errorf("invalid value %q for %s: %w", value, key, err)
+	}
+
+	c.sources[key] = source
+	return nil
+}
+
+// setAgent sets an agents.<name>.<setting> key.
+func (c *Config) setAgent(key, value string) error {
+	parts := strings.Split(key, ".")
+	if len(parts) != 3 || parts[0] != "agents" {
+		return fmt.Errorf("unknown setting")
+	}
+	a, ok := c.Agents[parts[1]]
+	if !ok {
+		return fmt.Errorf("unknown agent %q", parts[1])
+	}
+
+	switch parts[2] {
+	case "model":
+		a.Model = value
+	case "systemPrompt":
+		a.SystemPrompt = value
+	case "tools":
+		a.Tools = []string{}
+		for _, name := range strings.Split(value, ",") {
+			if name = strings.TrimSpace(name); name != "" {
+				a.Tools = append(a.Tools, name)
+			}
+		}
+	case "maxIterations":
+		n, err := strconv.Atoi(value)
+		if err != nil {
+			return err
+		}
+		a.MaxIterations = n
+	case "thinkingBudget":
+		n, err := strconv.Atoi(value)
+		if err != nil {
+			return err
+		}
+		a.ThinkingBudget = n
+	default:
+		return fmt.Errorf("unknown setting")
+	}
+
+	c.Agents[parts[1]] = a
+	return nil
+}
+
+// setDuration parses value into d.
+func setDuration(d *Duration, value string) error {
+	parsed, err := time.ParseDuration(value)
+	if err != nil {
+		return err
+	}
+	*d = Duration(parsed)
+	return nil
+}
+
+// Validate checks that every setting is in range. Errors name the setting
+// and the layer it came from.
+func (c *Config) Validate() error {
+	var errs []string
+	check := func(ok bool, key, problem string) {
+		if !ok {
+			errs = append(errs, fmt.Sprintf("%s %s (set by %s)", key, problem, c.Source(key)))
+		}
+	}
+
+	check(c.Provider.Name != "", "provider.name", "must not be empty")
+	check(c.Provider.Model != "", "provider.model", "must not be empty")
+	check(c.Provider.MaxTokens > 0, "provider.maxTokens", "must be positive")
+	if t := c.Provider.Temperature; t != nil {
+		check(*t >= 0 && *t <= 1, "provider.temperature", "must be between 0 and 1")
+	}
+	check(c.Provider.Timeout > 0, "provider.timeout", "must be positive")
+	check(c.RunTimeout >= 0, "runTimeout", "must not be negative")
+
+	builtins := tool.BuiltinNames()
+	for _, name := range agentNames {
+		a := c.Agents[name]
+		prefix := "agents." + name + "."
+		check(a.MaxIterations > 0, prefix+"maxIterations", "must be positive")
+		check(a.ThinkingBudget == 0 || a.ThinkingBudget >= provider.MinThinkingBudget, prefix+"thinkingBudget",
+			fmt.Sprintf("must be 0 (disabled) or at least %d", provider.MinThinkingBudget))
+		for _, t := range a.Tools {
+			i := sort.SearchStrings(builtins, t)
+			known := i < len(builtins) && builtins[i] == t
+			check(known, prefix+"tools", fmt.Sprintf("lists unknown tool %q (available: %s)", t, strings.Join(builtins, ", ")))
+		}
+	}
+
+	if len(errs) > 0 {
+		return fmt.Errorf("invalid config: %s", strings.Join(errs, "; "))
+	}
+	return nil
+}
+
+// AgentModel returns the model of the named agent: its own model if it
+// sets one, or else provider.model.
+func (c *Config) AgentModel(name string) string {
+	if model := c.Agents[name].Model; model != "" {
+		return model
+	}
+	return c.Provider.Model
+}
+
+// Source returns the layer that set the setting with the given key, such
+// as "default", "project config .agentic.json" or "env AGENTIC_MODEL".
+func (c *Config) Source(key string) string {
+	if source, ok := c.sources[key]; ok {
+		return source
+	}
+	return SourceDefault
+}
+
+// Setting is one effective setting, for display.
+type Setting struct {
+	Key    string
+	Value  string
+	Source string
+}
+
+// Settings returns every setting with its value and source, in a stable
+// order. Values are in the form accepted by Set.
+func (c *Config) Settings() []Setting {
+	temperature := ""
+	if c.Provider.Temperature != nil {
+		temperature = strconv.FormatFloat(*c.Provider.Temperature, 'g', -1, 64)
+	}
+
+	settings := []Setting{
+		{Key: "provider.name", Value: c.Provider.Name},
+		{Key: "provider.model", Value: c.Provider.Model},
+		{Key: "provider.fallbackModel", Value: c.Provider.FallbackModel},
+		{Key: "provider.maxTokens", Value: strconv.Itoa(c.Provider.MaxTokens)},
+		{Key: "provider.temperature", Value: temperature},
+		{Key: "provider.timeout", Value: time.Duration(c.Provider.Timeout).String()},
+		{Key: "runTimeout", Value: time.Duration(c.RunTimeout).String()},
+	}
+	for _, name := range agentNames {
+		a := c.Agents[name]
+		prefix := "agents." + name + "."
+		settings = append(settings,
+			Setting{Key: prefix + "model", Value: a.Model},
+			Setting{Key: prefix + "systemPrompt", Value: a.SystemPrompt},
+			Setting{Key: prefix + "tools", Value: strings.Join(a.Tools, ",")},
+			Setting{Key: prefix + "maxIterations", Value: strconv.Itoa(a.MaxIterations)},
+			Setting{Key: prefix + "thinkingBudget", Value: strconv.Itoa(a.ThinkingBudget)},
+		)
+	}
+
+	for i := range settings {
+		settings[i].Source = c.Source(settings[i].Key)
+	}
+	return settings
+}
@@ -0,0 +1,302 @@
+package config
+
+import (
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+	"time"
+
+	"agentic-poc/internal/agent"
+	"agentic-poc/internal/provider"
+)
+
+// isolateUserConfig points the user config directory at an empty temporary
+// directory and returns the user config path.
+func isolateUserConfig(t *testing.T) string {
+	t.Helper()
+	home := t.TempDir()
+	t.Setenv("HOME", home)
+	t.Setenv("XDG_CONFIG_HOME", filepath.Join(home, ".config"))
+	path, err := UserConfigPath()
+	if err != nil {
+		t.Fatal(err)
+	}
+	return path
+}
+
+// writeFile writes content to path, creating its directory.
+func writeFile(t *testing.T, path, content string) {
+	t.Helper()
+	if err := os.MkdirAll(filepath.Dir(path), 0755); err != nil {
+		t.Fatal(err)
+	}
+	if err := os.WriteFile(path, []byte(content), 0644); err != nil {
+		t.Fatal(err)
+	}
+}
+
+// env returns a getenv function backed by a map.
+func env(vars map[string]string) func(string) string {
+	return func(name string) string { return vars[name] }
+}
+
+func TestDefault(t *testing.T) {
+	cfg := Default()
+
+	if err := cfg.Validate(); err != nil {
+		t.Fatalf("default config is invalid: %v", err)
+	}
+	if cfg.Provider.Model != provider.DefaultClaudeModel || cfg.Provider.MaxTokens != provider.DefaultMaxTokens {
+		t.Errorf("provider = %+v, want the provider defaults", cfg.Provider)
+	}
+	if cfg.Agents[AgentArchitect].SystemPrompt != agent.ArchitectSystemPrompt {
+		t.Error("architect should default to ArchitectSystemPrompt")
+	}
+	for _, s := range cfg.Settings() {
+		if s.Source != SourceDefault {
+			t.Errorf("%s source = %q, want %q", s.Key, s.Source, SourceDefault)
+		}
+	}
+}
+
+func TestAgentNamesAreRoles(t *testing.T) {
+	// The router sends an agent's requests to its model by role, so the
+	// names must match the roles the agents are built with
+	if AgentArchitect != agent.RoleArchitect || AgentCoder != agent.RoleCoder {
+		t.Errorf("agent names %q, %q differ from roles %q, %q", AgentArchitect, AgentCoder, agent.RoleArchitect, agent.RoleCoder)
+	}
+}
+
+func TestLoad_Layers(t *testing.T) {
+	userPath := isolateUserConfig(t)
+	writeFile(t, userPath, `{
+		"provider": {"model": "user-model", "maxTokens": 1000, "temperature": 0.5},
+		"agents": {"coder": {"maxIterations": 30}, "architect": {"thinkingBudget": 8000, "model": "planner-model"}}
+	}`)
+
+	projectPath := filepath.Join(t.TempDir(), ".agentic.json")
+	writeFile(t, projectPath, `{
+		"provider": {"model": "project-model", "timeout": "90s"},
+		"runTimeout": "5m",
+		"agents": {"coder": {"tools": []}}
+	}`)
+
+	cfg, err := Load(projectPath, env(map[string]string{"AGENTIC_MAX_TOKENS": "2048"}))
+	if err != nil {
+		t.Fatalf("Load: %v", err)
+	}
+	if err := cfg.Set("provider.temperature", "0", "flag -temperature"); err != nil {
+		t.Fatal(err)
+	}
+	if err := cfg.Validate(); err != nil {
+		t.Fatal(err)
+	}
+
+	tests := []struct {
+		key    string
+		value  string
+		source string
+	}{
+		{"provider.name", "claude", SourceDefault},
+		{"provider.model", "project-model", "project config " + projectPath},
+		{"provider.fallbackModel", "", SourceDefault},
+		{"provider.maxTokens", "2048", "env AGENTIC_MAX_TOKENS"},
+		{"provider.temperature", "0", "flag -temperature"},
+		{"provider.timeout", "1m30s", "project config " + projectPath},
+		{"runTimeout", "5m0s", "project config " + projectPath},
+		{"agents.coder.maxIterations", "30", "user config " + userPath},
+		{"agents.architect.thinkingBudget", "8000", "user config " + userPath},
+		{"agents.single.thinkingBudget", "0", SourceDefault},
+		{"agents.architect.model", "planner-model", "user config " + userPath},
+		{"agents.coder.model", "", SourceDefault},
+		{"agents.coder.tools", "", "project config " + projectPath},
+		{"agents.single.tools", "calculator,read_file", SourceDefault},
+	}
+
+	settings := make(map[string]Setting)
+	for _, s := range cfg.Settings() {
+		settings[s.Key] = s
+	}
+	for _, tt := range tests {
+		got, ok := settings[tt.key]
+		if !ok {
+			t.Errorf("%s missing from Settings", tt.key)
+			continue
+		}
+		if got.Value != tt.value || got.Source != tt.source {
+			t.Errorf("%s = %q from %q, want %q from %q", tt.key, got.Value, got.Source, tt.value, tt.source)
+		}
+	}
+
+	if tools := cfg.Agents[AgentCoder].Tools; tools == nil || len(tools) != 0 {
+		t.Errorf("coder tools = %#v, want an empty list", tools)
+	}
+	if cfg.AgentModel(AgentArchitect) != "planner-model" || cfg.AgentModel(AgentCoder) != "project-model" {
+		t.Errorf("agent models = %q, %q", cfg.AgentModel(AgentArchitect), cfg.AgentModel(AgentCoder))
+	}
+	if cfg.RunTimeout != Duration(5*time.Minute) {
+		t.Errorf("run timeout = %v", time.Duration(cfg.RunTimeout))
+	}
+}
+
+func TestLoad_MissingFilesUseDefaults(t *testing.T) {
+	isolateUserConfig(t)
+
+	cfg, err := Load(filepath.Join(t.TempDir(), "missing.json"), nil)
+	if err != nil {
+		t.Fatalf("Load: %v", err)
+	}
+	if cfg.Provider.Model != provider.DefaultClaudeModel {
+		t.Errorf("model = %q, want the default", cfg.Provider.Model)
+	}
+}
+
+func TestLoad_SystemPromptFile(t *testing.T) {
+	isolateUserConfig(t)
+	dir := t.TempDir()
+	writeFile(t, filepath.Join(dir, "prompts", "architect.md"), "\nPlan in three steps or fewer.\n")
+	projectPath := filepath.Join(dir, ".agentic.json")
+	writeFile(t, projectPath, `{"agents": {"architect": {"systemPromptFile": "prompts/architect.md"}}}`)
+
+	cfg, err := Load(projectPath, nil)
+	if err != nil {
+		t.Fatalf("Load: %v", err)
+	}
+	if got := cfg.Agents[AgentArchitect].SystemPrompt; got != "Plan in three steps or fewer." {
+		t.Errorf("system prompt = %q", got)
+	}
+	if source := cfg.Source("agents.architect.systemPrompt"); !strings.Contains(source, "architect.md") {
+		t.Errorf("source = %q, want it to name the prompt file", source)
+	}
+}
+
+func TestLoad_Errors(t *testing.T) {
+	tests := []struct {
+		name    string
+		file    string
+		env     map[string]string
+		wantErr string
+	}{
+		{name: "unknown field", file: `{"provider": {"modle": "x"}}`, wantErr: `unknown field "modle"`},
+		{name: "unknown agent", file: `{"agents": {"reviewer": {}}}`, wantErr: `unknown agent "reviewer"`},
+		{name: "bad duration", file: `{"runTimeout": "soon"}`, wantErr: "invalid duration"},
+		{name: "numeric duration", file: `{"provider": {"timeout": 60}}`, wantErr: "must be a string"},
+		{
+			name:    "prompt and prompt file",
+			file:    `{"agents": {"coder": {"systemPrompt": "a", "systemPromptFile": "b.md"}}}`,
+			wantErr: "both systemPrompt and systemPromptFile",
+		},
+		{name: "missing prompt file", file: `{"agents": {"coder": {"systemPromptFile": "missing.md"}}}`, wantErr: "failed to read systemPromptFile"},
+		{name: "bad env value", file: `{}`, env: map[string]string{"AGENTIC_TEMPERATURE": "warm"}, wantErr: "AGENTIC_TEMPERATURE"},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			isolateUserConfig(t)
+			projectPath := filepath.Join(t.TempDir(), ".agentic.json")
+			writeFile(t, projectPath, tt.file)
+
+			_, err := Load(projectPath, env(tt.env))
+			if err == nil || !strings.Contains(err.Error(), tt.wantErr) {
+				t.Errorf("error = %v, want one containing %q", err, tt.wantErr)
+			}
+		})
+	}
+}
+
+func TestSet(t *testing.T) {
+	tests := []struct {
+		key     string
+		value   string
+		check   func(*Config) bool
+		wantErr bool
+	}{
+		{key: "provider.model", value: "m", check: func(c *Config) bool { return c.Provider.Model == "m" }},
+		{key: "provider.fallbackModel", value: "small", check: func(c *Config) bool { return c.Provider.FallbackModel == "small" }},
+		{key: "agents.coder.model", value: "fast", check: func(c *Config) bool { return c.Agents[AgentCoder].Model == "fast" }},
+		{key: "provider.maxTokens", value: "512", check: func(c *Config) bool { return c.Provider.MaxTokens == 512 }},
+		{key: "provider.maxTokens", value: "many", wantErr: true},
+		{key: "provider.timeout", value: "2m", check: func(c *Config) bool { return c.Provider.Timeout == Duration(2*time.Minute) }},
+		{key: "runTimeout", value: "later", wantErr: true},
+		{key: "agents.single.tools", value: "calculator, write_file", check: func(c *Config) bool {
+			tools := c.Agents[AgentSingle].Tools
+			return len(tools) == 2 && tools[1] == "write_file"
+		}},
+		{key: "agents.coder.maxIterations", value: "3", check: func(c *Config) bool { return c.Agents[AgentCoder].MaxIterations == 3 }},
+		{key: "agents.architect.thinkingBudget", value: "4096", check: func(c *Config) bool { return c.Agents[AgentArchitect].ThinkingBudget == 4096 }},
+		{key: "agents.architect.thinkingBudget", value: "deep", wantErr: true},
+		{key: "agents.reviewer.maxIterations", value: "3", wantErr: true},
+		{key: "agents.coder.color", value: "blue", wantErr: true},
+		{key: "verbose", value: "true", wantErr: true},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.key+"="+tt.value, func(t *testing.T) {
+			cfg := Default()
+			err := cfg.Set(tt.key, tt.value, "test")
+
+			if tt.wantErr {
+				if err == nil {
+					t.Error("expected an error")
+				}
+				if cfg.Source(tt.key) != SourceDefault {
+					t.Error("a failed Set should not record a source")
+				}
+				return
+			}
+			if err != nil {
+				t.Fatalf("unexpected error: %v", err)
+			}
+			if !tt.check(cfg) {
+				t.Error("setting not applied")
+			}
+			if cfg.Source(tt.key) != "test" {
+				t.Errorf("source = %q, want test", cfg.Source(tt.key))
+			}
+		})
+	}
+}
+
+func TestValidate(t *testing.T) {
+	tests := []struct {
+		key     string
+		value   string
+		wantErr string
+	}{
+		{key: "provider.model", value: "", wantErr: "provider.model must not be empty"},
+		{key: "provider.maxTokens", value: "0", wantErr: "provider.maxTokens must be positive"},
+		{key: "provider.temperature", value: "1.5", wantErr: "between 0 and 1"},
+		{key: "provider.timeout", value: "0s", wantErr: "provider.timeout must be positive"},
+		{key: "runTimeout", value: "-1s", wantErr: "must not be negative"},
+		{key: "agents.architect.maxIterations", value: "0", wantErr: "agents.architect.maxIterations must be positive"},
+		{key: "agents.coder.tools", value: "read_file,shell", wantErr: `unknown tool "shell"`},
+		{key: "agents.architect.thinkingBudget", value: "500", wantErr: "must be 0 (disabled) or at least 1024"},
+		{key: "agents.architect.thinkingBudget", value: "1024"},
+		{key: "provider.temperature", value: "1"},
+		{key: "runTimeout", value: "0s"},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.key+"="+tt.value, func(t *testing.T) {
+			cfg := Default()
+			if err := cfg.Set(tt.key, tt.value, "flag -test"); err != nil {
+				t.Fatal(err)
+			}
+
+			err := cfg.Validate()
+			if tt.wantErr == "" {
+				if err != nil {
+					t.Errorf("unexpected error: %v", err)
+				}
+				return
+			}
+			if err == nil || !strings.Contains(err.Error(), tt.wantErr) {
+				t.Fatalf("error = %v, want one containing %q", err, tt.wantErr)
+			}
+			if !strings.Contains(err.Error(), "set by flag -test") {
+				t.Errorf("error should name the source: %v", err)
+			}
+		})
+	}
+}
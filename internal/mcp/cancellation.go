@@ -0,0 +1,84 @@
+package mcp
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"log"
+)
+
+// errRequestCancelled is the cancellation cause of a request the client
+// cancelled with notifications/cancelled. No response is sent for it.
+var errRequestCancelled = errors.New("request cancelled by client")
+
+// requestKey returns a canonical form of a JSON-RPC request ID, so that IDs
+// compare equal regardless of formatting (e.g. 7 and 7.0, or extra spaces).
+func requestKey(id json.RawMessage) string {
+	var v interface{}
+	if err := json.Unmarshal(id, &v); err != nil {
+		return string(id)
+	}
+	key, err := json.Marshal(v)
+	if err != nil {
+		return string(id)
+	}
+	return string(key)
+}
+
+// trackRequest derives a context for a request that is cancelled when the
+// client sends notifications/cancelled for its ID. The returned function
+// must be called once the request is done.
+func (s *MCPServer) trackRequest(ctx context.Context, id json.RawMessage) (context.Context, func()) {
+	ctx, cancel := context.WithCancelCause(ctx)
+	key := requestKey(id)
+
+	s.inflightMu.Lock()
+	s.inflight[key] = cancel
+	s.inflightMu.Unlock()
+
+	return ctx, func() {
+		s.inflightMu.Lock()
+		delete(s.inflight, key)
+		s.inflightMu.Unlock()
+		cancel(nil)
+	}
+}
+
+// isCancellation reports whether a raw message is a notifications/cancelled
+// notification.
+func isCancellation(data []byte) bool {
+	var msg struct {
+		ID     json.RawMessage `json:"id"`
+		Method string          `json:"method"`
+	}
+	return json.Unmarshal(data, &msg) == nil && len(msg.ID) == 0 && msg.Method == "notifications/cancelled"
+}
+
+// handleCancelled cancels the in-flight request named by a
+// notifications/cancelled notification. Unknown or finished requests are
+// ignored, as the spec allows the notification to race the response.
+func (s *MCPServer) handleCancelled(req *JSONRPCRequest) {
+	params, _ := req.Params.(map[string]interface{})
+	id, ok := params["requestId"]
+	if !ok {
+		log.Printf("[MCP Server] Cancellation without a requestId")
+		return
+	}
+	raw, err := json.Marshal(id)
+	if err != nil {
+		return
+	}
+	key := requestKey(raw)
+
+	s.inflightMu.Lock()
+	cancel, ok := s.inflight[key]
+	s.inflightMu.Unlock()
+
+	if !ok {
+		log.Printf("[MCP Server] Cancellation for unknown request id=%s", key)
+		return
+	}
+	reason, _ := params["reason"].(string)
+	log.Printf("[MCP Server] Cancelling request id=%s: %s", key, reason)
+	cancel(errRequestCancelled)
+}
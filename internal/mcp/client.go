@@ -4,8 +4,10 @@ package mcp
 
 import (
 	"context"
+	"encoding/json"
 
 	"agentic-poc/internal/provider"
+	"agentic-poc/internal/tool"
 )
 
 // MCPToolInfo represents tool metadata from an MCP server.
@@ -13,6 +15,7 @@ type MCPToolInfo struct {
 	Name        string                 `json:"name"`
 	Description string                 `json:"description"`
 	InputSchema map[string]interface{} `json:"inputSchema"`
+	Annotations *tool.Annotations      `json:"annotations,omitempty"`
 }
 
 // MCPClient handles communication with an MCP server.
@@ -31,21 +34,39 @@ type MCPClient interface {
 }
 
 // JSONRPCRequest represents a JSON-RPC 2.0 request message.
+// The ID is kept as raw JSON because it may be a string, a number or null.
+// A request without an ID is a notification and never gets a response.
 type JSONRPCRequest struct {
-	JSONRPC string      `json:"jsonrpc"`
-	ID      int         `json:"id"`
-	Method  string      `json:"method"`
-	Params  interface{} `json:"params,omitempty"`
+	JSONRPC string          `json:"jsonrpc"`
+	ID      json.RawMessage `json:"id,omitempty"`
+	Method  string          `json:"method"`
+	Params  interface{}     `json:"params,omitempty"`
+}
+
+// IsNotification reports whether the request has no ID.
+func (r *JSONRPCRequest) IsNotification() bool {
+	return len(r.ID) == 0
 }
 
 // JSONRPCResponse represents a JSON-RPC 2.0 response message.
+// The ID echoes the request's ID verbatim, or is null when the request
+// could not be parsed.
 type JSONRPCResponse struct {
-	JSONRPC string        `json:"jsonrpc"`
-	ID      int           `json:"id"`
-	Result  interface{}   `json:"result,omitempty"`
-	Error   *JSONRPCError `json:"error,omitempty"`
+	JSONRPC string          `json:"jsonrpc"`
+	ID      json.RawMessage `json:"id"`
+	Result  interface{}     `json:"result,omitempty"`
+	Error   *JSONRPCError   `json:"error,omitempty"`
 }
 
+// JSON-RPC 2.0 error codes.
+const (
+	ErrCodeParse          = -32700
+	ErrCodeInvalidRequest = -32600
+	ErrCodeMethodNotFound = -32601
+	ErrCodeInvalidParams  = -32602
+	ErrCodeInternal       = -32603
+)
+
 // JSONRPCError represents an error in a JSON-RPC 2.0 response.
 type JSONRPCError struct {
 	Code    int         `json:"code"`
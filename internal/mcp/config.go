@@ -2,30 +2,157 @@ package mcp
 
 import (
 	"encoding/json"
+	"errors"
 	"fmt"
 	"os"
+	"path"
+	"path/filepath"
+	"sort"
+	"strings"
+	"time"
 )
 
+// userConfigSubpath is the location of the user-level MCP config relative
+// to the user config directory (e.g. ~/.config on Linux).
+const userConfigSubpath = "agentic-poc/mcp.json"
+
+// ErrConfigNotFound is returned when no MCP config file exists.
+var ErrConfigNotFound = errors.New("MCP config file not found")
+
 // MCPConfig defines MCP server connections.
 type MCPConfig struct {
 	Servers map[string]MCPServerConfig `json:"mcpServers"`
+	Gateway GatewayConfig              `json:"gateway"`
 }
 
 // MCPServerConfig defines the configuration for a single MCP server.
+//
+// Command, Args and Env values may reference environment variables as
+// ${VAR} or ${VAR:-default}. Variables defined in EnvFile are available
+// for expansion and are also passed to the server process.
 type MCPServerConfig struct {
 	Command     string            `json:"command"`
 	Args        []string          `json:"args"`
 	Env         map[string]string `json:"env"`
-	Disabled    bool              `json:"disabled"`
+	EnvFile     string            `json:"envFile"`  // dotenv file, relative to the config file
+	Cwd         string            `json:"cwd"`      // Working directory, relative to the config file
+	Disabled    *bool             `json:"disabled"` // Nil if not set, so a project entry keeps the user's setting
 	AutoApprove []string          `json:"autoApprove"`
+	Sampling    SamplingConfig    `json:"sampling"`
+}
+
+// SamplingConfig controls whether a server may request LLM completions
+// from the client via sampling/createMessage.
+type SamplingConfig struct {
+	Enabled     bool   `json:"enabled"`
+	MaxTokens   int    `json:"maxTokens"`   // Per-request cap; 0 uses DefaultSamplingMaxTokens
+	Timeout     string `json:"timeout"`     // Per-request LLM call limit such as "90s"; empty uses DefaultSamplingTimeout
+	AutoApprove bool   `json:"autoApprove"` // Do not ask the user before each request
+}
+
+// policy returns the SamplingPolicy the config describes. The timeout has
+// been checked by Validate.
+func (s SamplingConfig) policy() SamplingPolicy {
+	timeout, _ := time.ParseDuration(s.Timeout)
+	return SamplingPolicy{MaxTokens: s.MaxTokens, Timeout: timeout, AutoApprove: s.AutoApprove}
+}
+
+// GatewayConfig controls which upstream tools a gateway re-exports and how
+// calls to them are limited and recorded. Patterns use path.Match syntax and
+// are matched against the namespaced tool name, e.g. "github__*".
+type GatewayConfig struct {
+	Allow          []string       `json:"allow"`          // Only matching tools are exposed; empty allows all
+	Deny           []string       `json:"deny"`           // Matching tools are never exposed; wins over Allow
+	RateLimits     map[string]int `json:"rateLimits"`     // Calls per minute, per tool, keyed by pattern
+	AuditLog       string         `json:"auditLog"`       // JSON Lines file, relative to the config file
+	AuditArguments bool           `json:"auditArguments"` // Include call arguments in the audit log
+}
+
+// isEmpty reports whether no gateway settings are configured.
+func (g GatewayConfig) isEmpty() bool {
+	return len(g.Allow) == 0 && len(g.Deny) == 0 && len(g.RateLimits) == 0 &&
+		g.AuditLog == "" && !g.AuditArguments
 }
 
-// LoadMCPConfig loads MCP configuration from a JSON file.
+// validate checks the gateway patterns and rate limits.
+func (g GatewayConfig) validate() error {
+	patterns := append(append([]string{}, g.Allow...), g.Deny...)
+	for p := range g.RateLimits {
+		patterns = append(patterns, p)
+	}
+	for _, p := range patterns {
+		if _, err := path.Match(p, ""); err != nil {
+			return fmt.Errorf("gateway: invalid pattern %q: %w", p, err)
+		}
+	}
+	for p, limit := range g.RateLimits {
+		if limit <= 0 {
+			return fmt.Errorf("gateway: rate limit for %q must be positive", p)
+		}
+	}
+	return nil
+}
+
+// LoadMCPConfig loads MCP configuration from a JSON file and expands
+// environment variable references in enabled servers.
 func LoadMCPConfig(path string) (*MCPConfig, error) {
+	cfg, err := readMCPConfig(path)
+	if err != nil {
+		return nil, err
+	}
+	return finishConfig(cfg)
+}
+
+// LoadLayeredMCPConfig loads the user-level config (see UserMCPConfigPath)
+// and the project config at path, and merges them. Servers defined in both
+// take their fields from the project config, with env entries merged.
+// Either file may be missing, but not both.
+func LoadLayeredMCPConfig(path string) (*MCPConfig, error) {
+	userPath, _ := UserMCPConfigPath()
+
+	var layers []*MCPConfig
+	for _, p := range []string{userPath, path} {
+		if p == "" {
+			continue
+		}
+		if _, err := os.Stat(p); os.IsNotExist(err) {
+			continue
+		}
+		cfg, err := readMCPConfig(p)
+		if err != nil {
+			return nil, err
+		}
+		layers = append(layers, cfg)
+	}
+
+	if len(layers) == 0 {
+		return nil, fmt.Errorf("%w: %s", ErrConfigNotFound, path)
+	}
+
+	merged := &MCPConfig{}
+	for _, layer := range layers {
+		merged = mergeConfigs(merged, layer)
+	}
+	return finishConfig(merged)
+}
+
+// UserMCPConfigPath returns the path of the user-level MCP config,
+// e.g. ~/.config/agentic-poc/mcp.json on Linux.
+func UserMCPConfigPath() (string, error) {
+	dir, err := os.UserConfigDir()
+	if err != nil {
+		return "", err
+	}
+	return filepath.Join(dir, userConfigSubpath), nil
+}
+
+// readMCPConfig parses a single config file. Relative envFile and cwd paths
+// are resolved against the file's directory.
+func readMCPConfig(path string) (*MCPConfig, error) {
 	data, err := os.ReadFile(path)
 	if err != nil {
 		if os.IsNotExist(err) {
-			return nil, fmt.Errorf("MCP config file not found: %s", path)
+			return nil, fmt.Errorf("%w: %s", ErrConfigNotFound, path)
 		}
 		return nil, fmt.Errorf("failed to read MCP config file: %w", err)
 	}
@@ -35,16 +162,201 @@ func LoadMCPConfig(path string) (*MCPConfig, error) {
 		return nil, fmt.Errorf("failed to parse MCP config: %w", err)
 	}
 
-	// Validate configuration
+	baseDir := filepath.Dir(path)
+	cfg.Gateway.AuditLog = resolvePath(baseDir, cfg.Gateway.AuditLog)
+	for name, server := range cfg.Servers {
+		server.EnvFile = resolvePath(baseDir, server.EnvFile)
+		server.Cwd = resolvePath(baseDir, server.Cwd)
+		cfg.Servers[name] = server
+	}
+
+	return &cfg, nil
+}
+
+// finishConfig validates cfg and expands variables from the process environment.
+func finishConfig(cfg *MCPConfig) (*MCPConfig, error) {
 	if err := cfg.Validate(); err != nil {
 		return nil, fmt.Errorf("invalid MCP config: %w", err)
 	}
+	if err := cfg.expand(os.LookupEnv); err != nil {
+		return nil, fmt.Errorf("invalid MCP config: %w", err)
+	}
+	return cfg, nil
+}
 
-	return &cfg, nil
+// resolvePath makes p absolute relative to baseDir. A leading "~/" refers
+// to the user's home directory. Empty paths are returned unchanged.
+func resolvePath(baseDir, p string) string {
+	if p == "" {
+		return ""
+	}
+	if strings.HasPrefix(p, "~/") {
+		if home, err := os.UserHomeDir(); err == nil {
+			return filepath.Join(home, p[2:])
+		}
+	}
+	if filepath.IsAbs(p) {
+		return p
+	}
+	return filepath.Join(baseDir, p)
+}
+
+// mergeConfigs returns base overlaid with override. A server defined in both
+// takes each field from override when set there; env maps are merged with
+// override winning per key. Gateway settings are taken as a whole from
+// override if it has any.
+func mergeConfigs(base, override *MCPConfig) *MCPConfig {
+	merged := &MCPConfig{Servers: make(map[string]MCPServerConfig), Gateway: base.Gateway}
+	if !override.Gateway.isEmpty() {
+		merged.Gateway = override.Gateway
+	}
+	for name, server := range base.Servers {
+		merged.Servers[name] = server
+	}
+	for name, server := range override.Servers {
+		if existing, ok := merged.Servers[name]; ok {
+			server = mergeServer(existing, server)
+		}
+		merged.Servers[name] = server
+	}
+	return merged
+}
+
+// mergeServer overlays the fields set in override onto base.
+func mergeServer(base, override MCPServerConfig) MCPServerConfig {
+	merged := base
+	if override.Command != "" {
+		merged.Command = override.Command
+	}
+	if override.Args != nil {
+		merged.Args = override.Args
+	}
+	if override.EnvFile != "" {
+		merged.EnvFile = override.EnvFile
+	}
+	if override.Cwd != "" {
+		merged.Cwd = override.Cwd
+	}
+	if override.AutoApprove != nil {
+		merged.AutoApprove = override.AutoApprove
+	}
+	if override.Sampling != (SamplingConfig{}) {
+		merged.Sampling = override.Sampling
+	}
+	if override.Disabled != nil {
+		merged.Disabled = override.Disabled
+	}
+
+	if len(base.Env) > 0 || len(override.Env) > 0 {
+		merged.Env = make(map[string]string, len(base.Env)+len(override.Env))
+		for k, v := range base.Env {
+			merged.Env[k] = v
+		}
+		for k, v := range override.Env {
+			merged.Env[k] = v
+		}
+	}
+	return merged
+}
+
+// expand replaces variable references in the command, args and env of every
+// enabled server. Variables are looked up first with lookup, then in the
+// server's envFile, whose variables are also added to the server's env.
+// Disabled servers are left untouched so they may reference unset variables.
+func (c *MCPConfig) expand(lookup func(string) (string, bool)) error {
+	names := make([]string, 0, len(c.Servers))
+	for name := range c.Servers {
+		names = append(names, name)
+	}
+	sort.Strings(names)
+
+	var errs []error
+	for _, name := range names {
+		server := c.Servers[name]
+		if server.IsDisabled() {
+			continue
+		}
+
+		expanded, err := expandServer(server, lookup)
+		if err != nil {
+			errs = append(errs, fmt.Errorf("server %q: %w", name, err))
+			continue
+		}
+		c.Servers[name] = expanded
+	}
+
+	return errors.Join(errs...)
+}
+
+// expandServer returns a copy of server with variable references expanded.
+func expandServer(server MCPServerConfig, lookup func(string) (string, bool)) (MCPServerConfig, error) {
+	var fileVars map[string]string
+	if server.EnvFile != "" {
+		vars, err := parseEnvFile(server.EnvFile)
+		if err != nil {
+			return server, err
+		}
+		fileVars = vars
+	}
+
+	if server.Cwd != "" {
+		if info, err := os.Stat(server.Cwd); err != nil || !info.IsDir() {
+			return server, fmt.Errorf("cwd %q is not a directory", server.Cwd)
+		}
+	}
+
+	resolve := func(name string) (string, bool) {
+		if value, ok := lookup(name); ok && value != "" {
+			return value, true
+		}
+		value, ok := fileVars[name]
+		return value, ok
+	}
+
+	var errs []error
+	expandField := func(field, value string) string {
+		result, missing := expandVars(value, resolve)
+		for _, v := range missing {
+			errs = append(errs, fmt.Errorf("%s references unset environment variable %s (set it, add it to envFile, or use ${%s:-default})", field, v, v))
+		}
+		return result
+	}
+
+	server.Command = expandField("command", server.Command)
+
+	if server.Args != nil {
+		args := make([]string, len(server.Args))
+		for i, arg := range server.Args {
+			args[i] = expandField(fmt.Sprintf("args[%d]", i), arg)
+		}
+		server.Args = args
+	}
+
+	if len(server.Env) > 0 || len(fileVars) > 0 {
+		env := make(map[string]string, len(server.Env)+len(fileVars))
+		for k, v := range fileVars {
+			env[k] = v
+		}
+		keys := make([]string, 0, len(server.Env))
+		for k := range server.Env {
+			keys = append(keys, k)
+		}
+		sort.Strings(keys)
+		for _, k := range keys {
+			env[k] = expandField("env."+k, server.Env[k])
+		}
+		server.Env = env
+	}
+
+	return server, errors.Join(errs...)
 }
 
 // Validate checks that the configuration is valid.
 func (c *MCPConfig) Validate() error {
+	if err := c.Gateway.validate(); err != nil {
+		return err
+	}
+
 	if c.Servers == nil {
 		return nil
 	}
@@ -53,11 +365,25 @@ func (c *MCPConfig) Validate() error {
 		if server.Command == "" {
 			return fmt.Errorf("server %q: command is required", name)
 		}
+		if server.Sampling.MaxTokens < 0 {
+			return fmt.Errorf("server %q: sampling.maxTokens must not be negative", name)
+		}
+		if t := server.Sampling.Timeout; t != "" {
+			if d, err := time.ParseDuration(t); err != nil || d <= 0 {
+				return fmt.Errorf("server %q: sampling.timeout must be a positive duration such as \"90s\", got %q", name, t)
+			}
+		}
 	}
 
 	return nil
 }
 
+// IsDisabled reports whether the server is disabled. Servers are enabled
+// unless "disabled" is set to true.
+func (s MCPServerConfig) IsDisabled() bool {
+	return s.Disabled != nil && *s.Disabled
+}
+
 // EnabledServers returns only the servers that are not disabled.
 func (c *MCPConfig) EnabledServers() map[string]MCPServerConfig {
 	if c.Servers == nil {
@@ -66,7 +392,7 @@ func (c *MCPConfig) EnabledServers() map[string]MCPServerConfig {
 
 	enabled := make(map[string]MCPServerConfig)
 	for name, server := range c.Servers {
-		if !server.Disabled {
+		if !server.IsDisabled() {
 			enabled[name] = server
 		}
 	}
@@ -89,7 +415,7 @@ func (c *MCPConfig) EnabledServerCount() int {
 
 	count := 0
 	for _, server := range c.Servers {
-		if !server.Disabled {
+		if !server.IsDisabled() {
 			count++
 		}
 	}
@@ -4,6 +4,7 @@ import (
 	"os"
 	"path/filepath"
 	"testing"
+	"time"
 )
 
 func TestLoadMCPConfig(t *testing.T) {
@@ -157,6 +158,56 @@ func TestLoadMCPConfig(t *testing.T) {
 			wantErr:     true,
 			errContains: "command is required",
 		},
+		{
+			name: "server with sampling enabled",
+			content: `{
+				"mcpServers": {
+					"docs": {
+						"command": "docs-server",
+						"sampling": {"enabled": true, "maxTokens": 2048, "timeout": "90s", "autoApprove": true}
+					}
+				}
+			}`,
+			wantErr: false,
+			validate: func(t *testing.T, cfg *MCPConfig) {
+				sampling := cfg.Servers["docs"].Sampling
+				if !sampling.Enabled {
+					t.Error("expected sampling to be enabled")
+				}
+				if sampling.MaxTokens != 2048 {
+					t.Errorf("expected sampling maxTokens 2048, got %d", sampling.MaxTokens)
+				}
+				if want := (SamplingPolicy{MaxTokens: 2048, Timeout: 90 * time.Second, AutoApprove: true}); sampling.policy() != want {
+					t.Errorf("policy = %+v, want %+v", sampling.policy(), want)
+				}
+			},
+		},
+		{
+			name: "invalid sampling timeout",
+			content: `{
+				"mcpServers": {
+					"docs": {
+						"command": "docs-server",
+						"sampling": {"enabled": true, "timeout": "soon"}
+					}
+				}
+			}`,
+			wantErr:     true,
+			errContains: "sampling.timeout",
+		},
+		{
+			name: "negative sampling maxTokens",
+			content: `{
+				"mcpServers": {
+					"docs": {
+						"command": "docs-server",
+						"sampling": {"enabled": true, "maxTokens": -1}
+					}
+				}
+			}`,
+			wantErr:     true,
+			errContains: "sampling.maxTokens",
+		},
 		{
 			name:    "empty config",
 			content: `{}`,
@@ -278,3 +329,262 @@ func containsHelper(s, substr string) bool {
 	}
 	return false
 }
+
+// writeConfig writes content to name inside dir and returns the path.
+func writeConfig(t *testing.T, dir, name, content string) string {
+	t.Helper()
+	path := filepath.Join(dir, name)
+	if err := os.MkdirAll(filepath.Dir(path), 0755); err != nil {
+		t.Fatal(err)
+	}
+	if err := os.WriteFile(path, []byte(content), 0600); err != nil {
+		t.Fatal(err)
+	}
+	return path
+}
+
+func TestLoadMCPConfig_ExpandsVariables(t *testing.T) {
+	t.Setenv("MCP_TEST_TOKEN", "tok-123")
+	t.Setenv("MCP_TEST_BIN", "/usr/bin/server")
+
+	dir := t.TempDir()
+	path := writeConfig(t, dir, "mcp.json", `{
+		"mcpServers": {
+			"github": {
+				"command": "${MCP_TEST_BIN}",
+				"args": ["--token", "${MCP_TEST_TOKEN}", "--mode=${MCP_TEST_MODE:-read}"],
+				"env": {"GITHUB_TOKEN": "${MCP_TEST_TOKEN}"}
+			},
+			"off": {
+				"command": "${MCP_TEST_UNSET}",
+				"disabled": true
+			}
+		}
+	}`)
+
+	cfg, err := LoadMCPConfig(path)
+	if err != nil {
+		t.Fatalf("LoadMCPConfig() error = %v", err)
+	}
+
+	server := cfg.Servers["github"]
+	if server.Command != "/usr/bin/server" {
+		t.Errorf("command = %q", server.Command)
+	}
+	wantArgs := []string{"--token", "tok-123", "--mode=read"}
+	for i, arg := range wantArgs {
+		if server.Args[i] != arg {
+			t.Errorf("args[%d] = %q, want %q", i, server.Args[i], arg)
+		}
+	}
+	if server.Env["GITHUB_TOKEN"] != "tok-123" {
+		t.Errorf("env GITHUB_TOKEN = %q", server.Env["GITHUB_TOKEN"])
+	}
+	if cfg.Servers["off"].Command != "${MCP_TEST_UNSET}" {
+		t.Errorf("disabled server should not be expanded, got %q", cfg.Servers["off"].Command)
+	}
+}
+
+func TestLoadMCPConfig_MissingVariable(t *testing.T) {
+	dir := t.TempDir()
+	path := writeConfig(t, dir, "mcp.json", `{
+		"mcpServers": {
+			"github": {
+				"command": "npx",
+				"env": {"GITHUB_TOKEN": "${MCP_TEST_DEFINITELY_UNSET}"}
+			}
+		}
+	}`)
+
+	_, err := LoadMCPConfig(path)
+	if err == nil {
+		t.Fatal("expected error for unset variable")
+	}
+	for _, want := range []string{`server "github"`, "env.GITHUB_TOKEN", "MCP_TEST_DEFINITELY_UNSET"} {
+		if !contains(err.Error(), want) {
+			t.Errorf("error should mention %q: %v", want, err)
+		}
+	}
+}
+
+func TestLoadMCPConfig_EnvFileAndCwd(t *testing.T) {
+	dir := t.TempDir()
+	writeConfig(t, dir, "secrets/.env", "GITHUB_TOKEN=from-file\nEXTRA=1\n")
+	os.Mkdir(filepath.Join(dir, "work"), 0755)
+
+	path := writeConfig(t, dir, "mcp.json", `{
+		"mcpServers": {
+			"github": {
+				"command": "npx",
+				"args": ["${GITHUB_TOKEN}"],
+				"envFile": "secrets/.env",
+				"cwd": "work",
+				"env": {"MODE": "ci"}
+			}
+		}
+	}`)
+
+	cfg, err := LoadMCPConfig(path)
+	if err != nil {
+		t.Fatalf("LoadMCPConfig() error = %v", err)
+	}
+
+	server := cfg.Servers["github"]
+	if server.Args[0] != "from-file" {
+		t.Errorf("envFile variable not used for expansion: %q", server.Args[0])
+	}
+	if server.Env["EXTRA"] != "1" || server.Env["MODE"] != "ci" {
+		t.Errorf("env should combine envFile and env, got %v", server.Env)
+	}
+	if server.Cwd != filepath.Join(dir, "work") {
+		t.Errorf("cwd = %q, want %q", server.Cwd, filepath.Join(dir, "work"))
+	}
+}
+
+func TestLoadMCPConfig_InvalidCwd(t *testing.T) {
+	dir := t.TempDir()
+	path := writeConfig(t, dir, "mcp.json", `{
+		"mcpServers": {"srv": {"command": "echo", "cwd": "does-not-exist"}}
+	}`)
+
+	if _, err := LoadMCPConfig(path); err == nil || !contains(err.Error(), "cwd") {
+		t.Errorf("expected cwd error, got %v", err)
+	}
+}
+
+func TestLoadLayeredMCPConfig(t *testing.T) {
+	home := t.TempDir()
+	t.Setenv("HOME", home)
+	t.Setenv("XDG_CONFIG_HOME", filepath.Join(home, ".config"))
+
+	userPath, err := UserMCPConfigPath()
+	if err != nil {
+		t.Fatal(err)
+	}
+	writeConfig(t, filepath.Dir(userPath), filepath.Base(userPath), `{
+		"mcpServers": {
+			"github": {"command": "npx", "env": {"GITHUB_TOKEN": "user-secret"}},
+			"personal": {"command": "my-server"}
+		}
+	}`)
+
+	project := t.TempDir()
+	projectPath := writeConfig(t, project, "mcp.json", `{
+		"mcpServers": {
+			"github": {"command": "npx", "args": ["-y", "server-github"], "env": {"LOG": "debug"}},
+			"local": {"command": "./mcp-server"}
+		}
+	}`)
+
+	cfg, err := LoadLayeredMCPConfig(projectPath)
+	if err != nil {
+		t.Fatalf("LoadLayeredMCPConfig() error = %v", err)
+	}
+
+	if cfg.ServerCount() != 3 {
+		t.Errorf("expected 3 servers, got %d", cfg.ServerCount())
+	}
+	github := cfg.Servers["github"]
+	if len(github.Args) != 2 {
+		t.Errorf("project args should win, got %v", github.Args)
+	}
+	if github.Env["GITHUB_TOKEN"] != "user-secret" || github.Env["LOG"] != "debug" {
+		t.Errorf("env should be merged, got %v", github.Env)
+	}
+
+	// Only the user config exists
+	cfg, err = LoadLayeredMCPConfig(filepath.Join(project, "missing.json"))
+	if err != nil {
+		t.Fatalf("user-only config error = %v", err)
+	}
+	if cfg.ServerCount() != 2 {
+		t.Errorf("expected 2 user servers, got %d", cfg.ServerCount())
+	}
+}
+
+func TestLoadLayeredMCPConfig_Disabled(t *testing.T) {
+	tests := []struct {
+		name         string
+		project      string
+		wantDisabled bool
+	}{
+		{name: "project omits disabled", project: `{"command": "npx", "args": ["-y", "server-github"]}`, wantDisabled: true},
+		{name: "project enables", project: `{"command": "npx", "disabled": false}`, wantDisabled: false},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			home := t.TempDir()
+			t.Setenv("HOME", home)
+			t.Setenv("XDG_CONFIG_HOME", filepath.Join(home, ".config"))
+
+			userPath, err := UserMCPConfigPath()
+			if err != nil {
+				t.Fatal(err)
+			}
+			writeConfig(t, filepath.Dir(userPath), filepath.Base(userPath), `{
+				"mcpServers": {"github": {"command": "npx", "disabled": true}}
+			}`)
+			projectPath := writeConfig(t, t.TempDir(), "mcp.json", `{
+				"mcpServers": {"github": `+tt.project+`}
+			}`)
+
+			cfg, err := LoadLayeredMCPConfig(projectPath)
+			if err != nil {
+				t.Fatalf("LoadLayeredMCPConfig() error = %v", err)
+			}
+			if got := cfg.Servers["github"].IsDisabled(); got != tt.wantDisabled {
+				t.Errorf("disabled = %v, want %v", got, tt.wantDisabled)
+			}
+			if got := cfg.EnabledServerCount(); got != map[bool]int{true: 0, false: 1}[tt.wantDisabled] {
+				t.Errorf("enabled servers = %d", got)
+			}
+		})
+	}
+}
+
+func TestLoadLayeredMCPConfig_NoFiles(t *testing.T) {
+	t.Setenv("HOME", t.TempDir())
+	t.Setenv("XDG_CONFIG_HOME", t.TempDir())
+
+	_, err := LoadLayeredMCPConfig(filepath.Join(t.TempDir(), "mcp.json"))
+	if err == nil || !contains(err.Error(), "not found") {
+		t.Errorf("expected not found error, got %v", err)
+	}
+}
+
+func TestLoadMCPConfig_Gateway(t *testing.T) {
+	tests := []struct {
+		name        string
+		gateway     string
+		errContains string
+	}{
+		{name: "valid", gateway: `{"allow": ["github__*"], "deny": ["*delete*"], "rateLimits": {"github__*": 30}, "auditLog": "audit.jsonl"}`},
+		{name: "invalid pattern", gateway: `{"deny": ["[bad"]}`, errContains: "invalid pattern"},
+		{name: "zero rate limit", gateway: `{"rateLimits": {"*": 0}}`, errContains: "must be positive"},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			dir := t.TempDir()
+			path := writeConfig(t, dir, "mcp.json", `{"mcpServers": {}, "gateway": `+tt.gateway+`}`)
+
+			cfg, err := LoadMCPConfig(path)
+			if tt.errContains != "" {
+				if err == nil || !contains(err.Error(), tt.errContains) {
+					t.Fatalf("expected error containing %q, got %v", tt.errContains, err)
+				}
+				return
+			}
+			if err != nil {
+				t.Fatalf("LoadMCPConfig() error = %v", err)
+			}
+			if cfg.Gateway.RateLimits["github__*"] != 30 {
+				t.Errorf("rate limit not parsed: %+v", cfg.Gateway)
+			}
+			if cfg.Gateway.AuditLog != filepath.Join(dir, "audit.jsonl") {
+				t.Errorf("audit log should resolve against the config dir, got %q", cfg.Gateway.AuditLog)
+			}
+		})
+	}
+}
@@ -0,0 +1,195 @@
+package mcp
+
+import (
+	"encoding/json"
+	"fmt"
+	"strings"
+
+	"agentic-poc/internal/provider"
+)
+
+// extractContent converts an MCP tool result into its text form and typed
+// content blocks. Text items are joined with newlines for the text form;
+// if the result has no text but has structuredContent, the text form is
+// the JSON encoding of it.
+func extractContent(resultMap map[string]interface{}) (string, []provider.ContentBlock) {
+	blocks := make([]provider.ContentBlock, 0)
+	var texts []string
+
+	switch content := resultMap["content"].(type) {
+	case nil:
+	case []interface{}:
+		for _, item := range content {
+			itemMap, ok := item.(map[string]interface{})
+			if !ok {
+				continue
+			}
+			block, ok := contentBlockFromMCP(itemMap)
+			if !ok {
+				continue
+			}
+			if block.Type == provider.ContentText {
+				texts = append(texts, block.Text)
+			}
+			blocks = append(blocks, block)
+		}
+	default:
+		text := fmt.Sprintf("%v", content)
+		return text, []provider.ContentBlock{provider.TextBlock(text)}
+	}
+
+	if structured, ok := resultMap["structuredContent"]; ok && structured != nil {
+		blocks = append(blocks, provider.ContentBlock{
+			Type:       provider.ContentStructured,
+			Structured: structured,
+		})
+		if len(texts) == 0 {
+			if data, err := json.Marshal(structured); err == nil {
+				texts = append(texts, string(data))
+			}
+		}
+	}
+
+	return strings.Join(texts, "\n"), blocks
+}
+
+// contentBlockFromMCP converts a single MCP content item to a ContentBlock.
+// Unknown item types are skipped.
+func contentBlockFromMCP(item map[string]interface{}) (provider.ContentBlock, bool) {
+	switch getString(item, "type") {
+	case "text":
+		return provider.TextBlock(getString(item, "text")), true
+	case "image":
+		return provider.ImageBlock(getString(item, "mimeType"), getString(item, "data")), true
+	case "audio":
+		return provider.ContentBlock{
+			Type:     provider.ContentAudio,
+			MimeType: getString(item, "mimeType"),
+			Data:     getString(item, "data"),
+		}, true
+	case "resource_link":
+		return provider.ContentBlock{
+			Type:     provider.ContentResource,
+			URI:      getString(item, "uri"),
+			Name:     getString(item, "name"),
+			MimeType: getString(item, "mimeType"),
+		}, true
+	case "resource":
+		res, ok := item["resource"].(map[string]interface{})
+		if !ok {
+			return provider.ContentBlock{}, false
+		}
+		return provider.ContentBlock{
+			Type:     provider.ContentResource,
+			URI:      getString(res, "uri"),
+			Name:     getString(res, "name"),
+			MimeType: getString(res, "mimeType"),
+			Text:     getString(res, "text"),
+			Data:     getString(res, "blob"),
+		}, true
+	default:
+		// Tolerate servers that omit the type on text items
+		if text, ok := item["text"].(string); ok {
+			return provider.TextBlock(text), true
+		}
+		return provider.ContentBlock{}, false
+	}
+}
+
+// hasRichContent reports whether any block is something other than text.
+func hasRichContent(blocks []provider.ContentBlock) bool {
+	for _, b := range blocks {
+		if b.Type != provider.ContentText {
+			return true
+		}
+	}
+	return false
+}
+
+// toolResultToMCP converts a tool result into an MCP tools/call result.
+func toolResultToMCP(result *provider.ToolResult) map[string]interface{} {
+	if !result.Success {
+		return textToolResult(result.Error, true)
+	}
+	if !hasRichContent(result.Content) {
+		return textToolResult(result.Output, false)
+	}
+
+	items := make([]map[string]interface{}, 0, len(result.Content))
+	mcpResult := map[string]interface{}{"isError": false}
+	for _, b := range result.Content {
+		switch b.Type {
+		case provider.ContentText:
+			items = append(items, map[string]interface{}{"type": "text", "text": b.Text})
+		case provider.ContentImage, provider.ContentAudio:
+			items = append(items, map[string]interface{}{
+				"type":     string(b.Type),
+				"data":     b.Data,
+				"mimeType": b.MimeType,
+			})
+		case provider.ContentResource:
+			items = append(items, resourceToMCP(b))
+		case provider.ContentDocument:
+			// MCP has no document type, so send it as an embedded resource
+			if b.URI == "" {
+				b.URI = b.Name
+			}
+			items = append(items, resourceToMCP(b))
+		case provider.ContentStructured:
+			mcpResult["structuredContent"] = b.Structured
+		}
+	}
+
+	// Clients that only read text still get the text form
+	if result.Output != "" && !containsText(items) {
+		items = append([]map[string]interface{}{{"type": "text", "text": result.Output}}, items...)
+	}
+
+	mcpResult["content"] = items
+	return mcpResult
+}
+
+// resourceToMCP converts a resource block to an MCP resource or resource_link item.
+func resourceToMCP(b provider.ContentBlock) map[string]interface{} {
+	if b.Text == "" && b.Data == "" {
+		item := map[string]interface{}{"type": "resource_link", "uri": b.URI, "name": b.Name}
+		if b.MimeType != "" {
+			item["mimeType"] = b.MimeType
+		}
+		return item
+	}
+
+	res := map[string]interface{}{"uri": b.URI}
+	if b.MimeType != "" {
+		res["mimeType"] = b.MimeType
+	}
+	if b.Text != "" {
+		res["text"] = b.Text
+	} else {
+		res["blob"] = b.Data
+	}
+	return map[string]interface{}{"type": "resource", "resource": res}
+}
+
+// containsText reports whether any MCP content item is a text item.
+func containsText(items []map[string]interface{}) bool {
+	for _, item := range items {
+		if item["type"] == "text" {
+			return true
+		}
+	}
+	return false
+}
+
+// textToolResult builds an MCP tools/call result with a single text item.
+func textToolResult(text string, isError bool) map[string]interface{} {
+	return map[string]interface{}{
+		"content": []map[string]interface{}{
+			{
+				"type": "text",
+				"text": text,
+			},
+		},
+		"isError": isError,
+	}
+}
@@ -0,0 +1,173 @@
+package mcp
+
+import (
+	"context"
+	"encoding/json"
+	"testing"
+
+	"agentic-poc/internal/provider"
+)
+
+func parseJSONMap(t *testing.T, s string) map[string]interface{} {
+	t.Helper()
+	var m map[string]interface{}
+	if err := json.Unmarshal([]byte(s), &m); err != nil {
+		t.Fatalf("invalid test JSON: %v", err)
+	}
+	return m
+}
+
+func TestExtractContent(t *testing.T) {
+	tests := []struct {
+		name      string
+		result    string
+		wantText  string
+		wantTypes []provider.ContentType
+	}{
+		{
+			name:      "text only",
+			result:    `{"content":[{"type":"text","text":"one"},{"type":"text","text":"two"}]}`,
+			wantText:  "one\ntwo",
+			wantTypes: []provider.ContentType{provider.ContentText, provider.ContentText},
+		},
+		{
+			name:      "image with caption",
+			result:    `{"content":[{"type":"text","text":"screenshot"},{"type":"image","data":"iVBORw0=","mimeType":"image/png"}]}`,
+			wantText:  "screenshot",
+			wantTypes: []provider.ContentType{provider.ContentText, provider.ContentImage},
+		},
+		{
+			name:      "audio",
+			result:    `{"content":[{"type":"audio","data":"AAAA","mimeType":"audio/wav"}]}`,
+			wantText:  "",
+			wantTypes: []provider.ContentType{provider.ContentAudio},
+		},
+		{
+			name:      "resource link and embedded resource",
+			result:    `{"content":[{"type":"resource_link","uri":"file:///a.txt","name":"a.txt"},{"type":"resource","resource":{"uri":"file:///b.txt","mimeType":"text/plain","text":"B"}}]}`,
+			wantText:  "",
+			wantTypes: []provider.ContentType{provider.ContentResource, provider.ContentResource},
+		},
+		{
+			name:      "structured content without text",
+			result:    `{"content":[],"structuredContent":{"temperature":21}}`,
+			wantText:  `{"temperature":21}`,
+			wantTypes: []provider.ContentType{provider.ContentStructured},
+		},
+		{
+			name:      "unknown item type skipped",
+			result:    `{"content":[{"type":"hologram"},{"type":"text","text":"ok"}]}`,
+			wantText:  "ok",
+			wantTypes: []provider.ContentType{provider.ContentText},
+		},
+		{
+			name:      "no content",
+			result:    `{}`,
+			wantText:  "",
+			wantTypes: nil,
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			text, blocks := extractContent(parseJSONMap(t, tt.result))
+
+			if text != tt.wantText {
+				t.Errorf("text = %q, want %q", text, tt.wantText)
+			}
+			if len(blocks) != len(tt.wantTypes) {
+				t.Fatalf("got %d blocks, want %d", len(blocks), len(tt.wantTypes))
+			}
+			for i, b := range blocks {
+				if b.Type != tt.wantTypes[i] {
+					t.Errorf("block %d type = %q, want %q", i, b.Type, tt.wantTypes[i])
+				}
+			}
+		})
+	}
+}
+
+func TestExtractContent_ImageFields(t *testing.T) {
+	_, blocks := extractContent(parseJSONMap(t, `{"content":[{"type":"image","data":"iVBORw0=","mimeType":"image/png"}]}`))
+
+	if blocks[0].Data != "iVBORw0=" || blocks[0].MimeType != "image/png" {
+		t.Errorf("image fields not preserved: %+v", blocks[0])
+	}
+}
+
+func TestToolResultToMCP_RoundTrip(t *testing.T) {
+	original := &provider.ToolResult{
+		Success: true,
+		Output:  "chart attached",
+		Content: []provider.ContentBlock{
+			provider.TextBlock("chart attached"),
+			provider.ImageBlock("image/png", "iVBORw0="),
+			{Type: provider.ContentStructured, Structured: map[string]interface{}{"points": float64(3)}},
+		},
+	}
+
+	data, err := json.Marshal(toolResultToMCP(original))
+	if err != nil {
+		t.Fatalf("marshal failed: %v", err)
+	}
+	text, blocks := extractContent(parseJSONMap(t, string(data)))
+
+	if text != "chart attached" {
+		t.Errorf("text = %q", text)
+	}
+	if len(blocks) != 3 {
+		t.Fatalf("expected 3 blocks, got %d: %+v", len(blocks), blocks)
+	}
+	if blocks[1].Type != provider.ContentImage || blocks[1].Data != "iVBORw0=" {
+		t.Errorf("image not preserved: %+v", blocks[1])
+	}
+	if blocks[2].Type != provider.ContentStructured {
+		t.Errorf("structured content not preserved: %+v", blocks[2])
+	}
+}
+
+func TestToolResultToMCP_Document(t *testing.T) {
+	result := toolResultToMCP(&provider.ToolResult{
+		Success: true,
+		Output:  "spec.pdf (application/pdf, 9 bytes)",
+		Content: []provider.ContentBlock{provider.DocumentBlock("application/pdf", "spec.pdf", "JVBERi0=")},
+	})
+
+	items := result["content"].([]map[string]interface{})
+	if len(items) != 2 || items[0]["type"] != "text" {
+		t.Fatalf("expected the text form then the document, got %v", items)
+	}
+	res, _ := items[1]["resource"].(map[string]interface{})
+	if items[1]["type"] != "resource" || res["uri"] != "spec.pdf" || res["blob"] != "JVBERi0=" || res["mimeType"] != "application/pdf" {
+		t.Errorf("document item = %v, want an embedded resource", items[1])
+	}
+}
+
+func TestToolResultToMCP_TextOnly(t *testing.T) {
+	result := toolResultToMCP(&provider.ToolResult{Success: true, Output: "5"})
+
+	items := result["content"].([]map[string]interface{})
+	if len(items) != 1 || items[0]["text"] != "5" {
+		t.Errorf("unexpected content: %v", items)
+	}
+	if result["isError"] != false {
+		t.Error("expected isError false")
+	}
+}
+
+func TestMCPToolWrapper_PassesThroughRichContent(t *testing.T) {
+	client := NewMockMCPClient()
+	image := provider.ImageBlock("image/png", "iVBORw0=")
+	client.CallToolFunc = func(_ context.Context, name string, args map[string]interface{}) (*provider.ToolResult, error) {
+		return &provider.ToolResult{Success: true, Output: "", Content: []provider.ContentBlock{image}}, nil
+	}
+
+	wrapper := NewMCPToolWrapper(client, MCPToolInfo{Name: "screenshot"})
+	result, err := wrapper.Execute(context.Background(), nil)
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	if len(result.Content) != 1 || result.Content[0] != image {
+		t.Errorf("content not passed through: %+v", result.Content)
+	}
+}
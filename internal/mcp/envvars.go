@@ -0,0 +1,96 @@
+package mcp
+
+import (
+	"bufio"
+	"fmt"
+	"os"
+	"strings"
+)
+
+// expandVars replaces ${VAR} and ${VAR:-default} references in s using
+// lookup. "$${" produces a literal "${". Variables that are unset and have
+// no default are returned in missing, and expand to the empty string.
+func expandVars(s string, lookup func(string) (string, bool)) (result string, missing []string) {
+	var b strings.Builder
+	for i := 0; i < len(s); i++ {
+		if s[i] != '$' {
+			b.WriteByte(s[i])
+			continue
+		}
+
+		// Escaped "$${" is a literal "${"
+		if strings.HasPrefix(s[i:], "$${") {
+			b.WriteString("${")
+			i += 2
+			continue
+		}
+
+		if i+1 >= len(s) || s[i+1] != '{' {
+			b.WriteByte('$')
+			continue
+		}
+
+		end := strings.IndexByte(s[i+2:], '}')
+		if end < 0 {
+			// Unterminated reference, keep as-is
+			b.WriteString(s[i:])
+			break
+		}
+
+		expr := s[i+2 : i+2+end]
+		name, def, hasDefault := strings.Cut(expr, ":-")
+		if value, ok := lookup(name); ok && value != "" {
+			b.WriteString(value)
+		} else if hasDefault {
+			b.WriteString(def)
+		} else {
+			missing = append(missing, name)
+		}
+
+		i += 2 + end
+	}
+	return b.String(), missing
+}
+
+// parseEnvFile reads a dotenv-style file of KEY=VALUE lines. Blank lines,
+// comments starting with '#', and an optional "export " prefix are allowed.
+// Values may be wrapped in single or double quotes.
+func parseEnvFile(path string) (map[string]string, error) {
+	f, err := os.Open(path)
+	if err != nil {
+		if os.IsNotExist(err) {
+			return nil, fmt.Errorf("env file not found: %s", path)
+		}
+		return nil, fmt.Errorf("failed to read env file: %w", err)
+	}
+	defer f.Close()
+
+	vars := make(map[string]string)
+	scanner := bufio.NewScanner(f)
+	lineNum := 0
+	for scanner.Scan() {
+		lineNum++
+		line := strings.TrimSpace(scanner.Text())
+		if line == "" || strings.HasPrefix(line, "#") {
+			continue
+		}
+		line = strings.TrimPrefix(line, "export ")
+
+		key, value, ok := strings.Cut(line, "=")
+		key = strings.TrimSpace(key)
+		if !ok || key == "" {
+			return nil, fmt.Errorf("%s:%d: expected KEY=VALUE", path, lineNum)
+		}
+
+		value = strings.TrimSpace(value)
+		if len(value) >= 2 && (value[0] == '"' || value[0] == '\'') && value[len(value)-1] == value[0] {
+			value = value[1 : len(value)-1]
+		}
+		vars[key] = value
+	}
+	if err := scanner.Err(); err != nil {
+		return nil, fmt.Errorf("failed to read env file: %w", err)
+	}
+
+	return vars, nil
+}
@@ -0,0 +1,90 @@
+package mcp
+
+import (
+	"os"
+	"path/filepath"
+	"reflect"
+	"testing"
+)
+
+func TestExpandVars(t *testing.T) {
+	vars := map[string]string{"TOKEN": "secret", "EMPTY": ""}
+	lookup := func(name string) (string, bool) {
+		v, ok := vars[name]
+		return v, ok
+	}
+
+	tests := []struct {
+		name        string
+		input       string
+		want        string
+		wantMissing []string
+	}{
+		{name: "no references", input: "plain", want: "plain"},
+		{name: "simple reference", input: "${TOKEN}", want: "secret"},
+		{name: "embedded reference", input: "--token=${TOKEN}!", want: "--token=secret!"},
+		{name: "default unused", input: "${TOKEN:-x}", want: "secret"},
+		{name: "default used", input: "${UNSET:-fallback}", want: "fallback"},
+		{name: "empty default", input: "${UNSET:-}", want: ""},
+		{name: "empty value uses default", input: "${EMPTY:-d}", want: "d"},
+		{name: "missing variable", input: "a${UNSET}b", want: "ab", wantMissing: []string{"UNSET"}},
+		{name: "escaped reference", input: "$${TOKEN}", want: "${TOKEN}"},
+		{name: "bare dollar", input: "$5 and $HOME", want: "$5 and $HOME"},
+		{name: "unterminated", input: "x${TOKEN", want: "x${TOKEN"},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			got, missing := expandVars(tt.input, lookup)
+			if got != tt.want {
+				t.Errorf("expandVars(%q) = %q, want %q", tt.input, got, tt.want)
+			}
+			if !reflect.DeepEqual(missing, tt.wantMissing) {
+				t.Errorf("missing = %v, want %v", missing, tt.wantMissing)
+			}
+		})
+	}
+}
+
+func TestParseEnvFile(t *testing.T) {
+	path := filepath.Join(t.TempDir(), ".env")
+	content := `# secrets
+GITHUB_TOKEN=ghp_123
+export API_KEY="quoted value"
+SINGLE='single'
+
+EMPTY=
+`
+	if err := os.WriteFile(path, []byte(content), 0600); err != nil {
+		t.Fatal(err)
+	}
+
+	vars, err := parseEnvFile(path)
+	if err != nil {
+		t.Fatalf("parseEnvFile() error = %v", err)
+	}
+
+	want := map[string]string{
+		"GITHUB_TOKEN": "ghp_123",
+		"API_KEY":      "quoted value",
+		"SINGLE":       "single",
+		"EMPTY":        "",
+	}
+	if !reflect.DeepEqual(vars, want) {
+		t.Errorf("parseEnvFile() = %v, want %v", vars, want)
+	}
+}
+
+func TestParseEnvFile_Errors(t *testing.T) {
+	dir := t.TempDir()
+
+	if _, err := parseEnvFile(filepath.Join(dir, "missing.env")); err == nil || !contains(err.Error(), "not found") {
+		t.Errorf("expected not found error, got %v", err)
+	}
+
+	bad := filepath.Join(dir, "bad.env")
+	os.WriteFile(bad, []byte("NOT_AN_ASSIGNMENT\n"), 0600)
+	if _, err := parseEnvFile(bad); err == nil || !contains(err.Error(), "bad.env:1") {
+		t.Errorf("expected line-numbered error, got %v", err)
+	}
+}
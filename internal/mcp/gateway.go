@@ -0,0 +1,228 @@
+package mcp
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"io"
+	"log"
+	"path"
+	"sync"
+	"time"
+
+	"agentic-poc/internal/provider"
+	"agentic-poc/internal/tool"
+)
+
+// Gateway sits between upstream MCP servers and a single downstream MCP
+// endpoint. It filters the upstream tools with allow/deny patterns, applies
+// per-tool rate limits, and writes an audit record for every call.
+//
+// Serve it with NewMCPServer(..., WithToolSource(gateway)).
+type Gateway struct {
+	source tool.Provider
+	cfg    GatewayConfig
+	audit  *auditLogger
+
+	mu       sync.Mutex
+	limiters map[string]*rateLimiter
+
+	// now is the clock used for rate limiting and audit timestamps
+	now func() time.Time
+}
+
+// NewGateway creates a Gateway over source. If audit is non-nil, one JSON
+// line per tool call is written to it.
+func NewGateway(source tool.Provider, cfg GatewayConfig, audit io.Writer) *Gateway {
+	g := &Gateway{
+		source:   source,
+		cfg:      cfg,
+		limiters: make(map[string]*rateLimiter),
+		now:      time.Now,
+	}
+	if audit != nil {
+		g.audit = &auditLogger{w: audit}
+	}
+	return g
+}
+
+// GetTools returns the upstream tools that pass the allow/deny filters,
+// wrapped so that calls are rate limited and audited.
+func (g *Gateway) GetTools() []tool.Tool {
+	upstream := g.source.GetTools()
+	tools := make([]tool.Tool, 0, len(upstream))
+	for _, t := range upstream {
+		if !g.Allowed(t.Name()) {
+			continue
+		}
+		tools = append(tools, &gatewayTool{Tool: t, gateway: g})
+	}
+	return tools
+}
+
+// Allowed reports whether a tool with the given name is exposed. Deny
+// patterns win over allow patterns; an empty allow list allows everything.
+func (g *Gateway) Allowed(name string) bool {
+	if matchAny(g.cfg.Deny, name) {
+		return false
+	}
+	return len(g.cfg.Allow) == 0 || matchAny(g.cfg.Allow, name)
+}
+
+// matchAny reports whether name matches any of the patterns.
+func matchAny(patterns []string, name string) bool {
+	for _, p := range patterns {
+		if ok, _ := path.Match(p, name); ok {
+			return true
+		}
+	}
+	return false
+}
+
+// limiterFor returns the rate limiter for a tool, or nil if it is unlimited.
+// When several patterns match, the strictest limit applies.
+func (g *Gateway) limiterFor(name string) *rateLimiter {
+	g.mu.Lock()
+	defer g.mu.Unlock()
+
+	if l, ok := g.limiters[name]; ok {
+		return l
+	}
+
+	perMinute := 0
+	for pattern, limit := range g.cfg.RateLimits {
+		if ok, _ := path.Match(pattern, name); ok && (perMinute == 0 || limit < perMinute) {
+			perMinute = limit
+		}
+	}
+
+	var l *rateLimiter
+	if perMinute > 0 {
+		l = newRateLimiter(perMinute, g.now())
+	}
+	g.limiters[name] = l
+	return l
+}
+
+// gatewayTool wraps an upstream tool with the gateway's rate limit and audit log.
+type gatewayTool struct {
+	tool.Tool
+	gateway *Gateway
+}
+
+// Annotations passes through the upstream tool's annotations.
+func (t *gatewayTool) Annotations() tool.Annotations {
+	if a, ok := tool.AnnotationsOf(t.Tool); ok {
+		return a
+	}
+	return tool.DefaultAnnotations()
+}
+
+// Execute checks the rate limit, calls the upstream tool, and audits the call.
+func (t *gatewayTool) Execute(ctx context.Context, args map[string]interface{}) (*provider.ToolResult, error) {
+	g := t.gateway
+	name := t.Name()
+	start := g.now()
+
+	entry := auditEntry{Time: start.UTC().Format(time.RFC3339Nano), Tool: name}
+	if g.cfg.AuditArguments {
+		entry.Arguments = args
+	}
+
+	if l := g.limiterFor(name); l != nil && !l.allow(start) {
+		log.Printf("[MCP Gateway] Rate limit exceeded for tool %q", name)
+		entry.Outcome = "rate_limited"
+		g.audit.write(entry)
+		return &provider.ToolResult{
+			Success: false,
+			Error:   fmt.Sprintf("rate limit exceeded for tool %q (%d calls per minute)", name, l.perMinute),
+		}, nil
+	}
+
+	result, err := t.Tool.Execute(ctx, args)
+	entry.DurationMs = g.now().Sub(start).Milliseconds()
+
+	switch {
+	case err != nil:
+		entry.Outcome = "error"
+		entry.Error = err.Error()
+	case !result.Success:
+		entry.Outcome = "error"
+		entry.Error = result.Error
+	default:
+		entry.Outcome = "success"
+	}
+	g.audit.write(entry)
+
+	return result, err
+}
+
+// rateLimiter is a token bucket holding up to perMinute tokens and
+// refilling at perMinute tokens per minute.
+type rateLimiter struct {
+	mu        sync.Mutex
+	perMinute int
+	tokens    float64
+	last      time.Time
+}
+
+// newRateLimiter creates a full bucket.
+func newRateLimiter(perMinute int, now time.Time) *rateLimiter {
+	return &rateLimiter{perMinute: perMinute, tokens: float64(perMinute), last: now}
+}
+
+// allow takes a token if one is available at time now.
+func (l *rateLimiter) allow(now time.Time) bool {
+	l.mu.Lock()
+	defer l.mu.Unlock()
+
+	elapsed := now.Sub(l.last)
+	if elapsed > 0 {
+		l.tokens += elapsed.Minutes() * float64(l.perMinute)
+		if l.tokens > float64(l.perMinute) {
+			l.tokens = float64(l.perMinute)
+		}
+		l.last = now
+	}
+
+	if l.tokens < 1 {
+		return false
+	}
+	l.tokens--
+	return true
+}
+
+// auditEntry is one line of the gateway audit log.
+type auditEntry struct {
+	Time       string                 `json:"time"`
+	Tool       string                 `json:"tool"`
+	Outcome    string                 `json:"outcome"` // success, error or rate_limited
+	DurationMs int64                  `json:"durationMs"`
+	Error      string                 `json:"error,omitempty"`
+	Arguments  map[string]interface{} `json:"arguments,omitempty"`
+}
+
+// auditLogger writes audit entries as JSON Lines. A nil logger discards them.
+type auditLogger struct {
+	mu sync.Mutex
+	w  io.Writer
+}
+
+// write appends entry to the log.
+func (a *auditLogger) write(entry auditEntry) {
+	if a == nil {
+		return
+	}
+
+	data, err := json.Marshal(entry)
+	if err != nil {
+		log.Printf("[MCP Gateway] Failed to encode audit entry: %v", err)
+		return
+	}
+
+	a.mu.Lock()
+	defer a.mu.Unlock()
+	if _, err := a.w.Write(append(data, '\n')); err != nil {
+		log.Printf("[MCP Gateway] Failed to write audit entry: %v", err)
+	}
+}
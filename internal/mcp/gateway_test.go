@@ -0,0 +1,194 @@
+package mcp
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"strings"
+	"testing"
+	"time"
+
+	"agentic-poc/internal/provider"
+	"agentic-poc/internal/tool"
+)
+
+// stubTool is a minimal tool that returns a fixed result.
+type stubTool struct {
+	name   string
+	result *provider.ToolResult
+	calls  int
+}
+
+func (s *stubTool) Name() string        { return s.name }
+func (s *stubTool) Description() string { return "stub " + s.name }
+func (s *stubTool) Parameters() map[string]interface{} {
+	return map[string]interface{}{"type": "object"}
+}
+
+func (s *stubTool) Execute(ctx context.Context, args map[string]interface{}) (*provider.ToolResult, error) {
+	s.calls++
+	if s.result != nil {
+		return s.result, nil
+	}
+	return &provider.ToolResult{Success: true, Output: "ok"}, nil
+}
+
+func TestToolProviders_ImplementInterface(t *testing.T) {
+	// Both are served with WithToolSource and given to agents as their
+	// ToolProvider
+	var _ tool.Provider = (*MCPManager)(nil)
+	var _ tool.Provider = (*Gateway)(nil)
+}
+
+// staticSource is a tool.Provider with a fixed set of tools.
+type staticSource []tool.Tool
+
+func (s staticSource) GetTools() []tool.Tool { return s }
+
+func TestGateway_Allowed(t *testing.T) {
+	tests := []struct {
+		name  string
+		allow []string
+		deny  []string
+		tool  string
+		want  bool
+	}{
+		{name: "no filters", tool: "github__create_issue", want: true},
+		{name: "allowed by pattern", allow: []string{"github__*"}, tool: "github__list_issues", want: true},
+		{name: "not in allow list", allow: []string{"github__*"}, tool: "filesystem__read_file", want: false},
+		{name: "denied", deny: []string{"*__delete_*"}, tool: "github__delete_repo", want: false},
+		{name: "deny wins over allow", allow: []string{"github__*"}, deny: []string{"github__delete_repo"}, tool: "github__delete_repo", want: false},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			g := NewGateway(staticSource{}, GatewayConfig{Allow: tt.allow, Deny: tt.deny}, nil)
+			if got := g.Allowed(tt.tool); got != tt.want {
+				t.Errorf("Allowed(%q) = %v, want %v", tt.tool, got, tt.want)
+			}
+		})
+	}
+}
+
+func TestGateway_GetToolsFilters(t *testing.T) {
+	source := staticSource{
+		&stubTool{name: "github__list_issues"},
+		&stubTool{name: "github__delete_repo"},
+		&stubTool{name: "filesystem__read_file"},
+	}
+	g := NewGateway(source, GatewayConfig{Allow: []string{"github__*"}, Deny: []string{"*delete*"}}, nil)
+
+	tools := g.GetTools()
+	if len(tools) != 1 || tools[0].Name() != "github__list_issues" {
+		names := make([]string, len(tools))
+		for i, t := range tools {
+			names[i] = t.Name()
+		}
+		t.Errorf("expected only github__list_issues, got %v", names)
+	}
+}
+
+func TestGateway_RateLimit(t *testing.T) {
+	upstream := &stubTool{name: "github__search"}
+	g := NewGateway(staticSource{upstream}, GatewayConfig{RateLimits: map[string]int{"github__*": 2}}, nil)
+
+	now := time.Date(2025, 1, 1, 0, 0, 0, 0, time.UTC)
+	g.now = func() time.Time { return now }
+
+	call := func() *provider.ToolResult {
+		t.Helper()
+		result, err := g.GetTools()[0].Execute(context.Background(), nil)
+		if err != nil {
+			t.Fatalf("unexpected error: %v", err)
+		}
+		return result
+	}
+
+	if !call().Success || !call().Success {
+		t.Fatal("first two calls should be allowed")
+	}
+	if result := call(); result.Success || !strings.Contains(result.Error, "rate limit") {
+		t.Fatalf("third call should be rate limited, got %+v", result)
+	}
+	if upstream.calls != 2 {
+		t.Errorf("rate limited call reached upstream: %d calls", upstream.calls)
+	}
+
+	// Half a minute refills one of the two tokens
+	now = now.Add(30 * time.Second)
+	if !call().Success {
+		t.Error("call after refill should be allowed")
+	}
+	if call().Success {
+		t.Error("bucket should be empty again")
+	}
+}
+
+func TestGateway_AuditLog(t *testing.T) {
+	source := staticSource{
+		&stubTool{name: "ok_tool"},
+		&stubTool{name: "failing_tool", result: &provider.ToolResult{Success: false, Error: "boom"}},
+	}
+
+	tests := []struct {
+		name          string
+		withArguments bool
+	}{
+		{name: "without arguments", withArguments: false},
+		{name: "with arguments", withArguments: true},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			var buf bytes.Buffer
+			g := NewGateway(source, GatewayConfig{AuditArguments: tt.withArguments}, &buf)
+
+			for _, tl := range g.GetTools() {
+				tl.Execute(context.Background(), map[string]interface{}{"secret": "x"})
+			}
+
+			lines := strings.Split(strings.TrimSpace(buf.String()), "\n")
+			if len(lines) != 2 {
+				t.Fatalf("expected 2 audit lines, got %d: %q", len(lines), buf.String())
+			}
+
+			outcomes := make(map[string]auditEntry)
+			for _, line := range lines {
+				var e auditEntry
+				if err := json.Unmarshal([]byte(line), &e); err != nil {
+					t.Fatalf("invalid audit line %q: %v", line, err)
+				}
+				if (e.Arguments != nil) != tt.withArguments {
+					t.Errorf("arguments logged = %v, want %v", e.Arguments != nil, tt.withArguments)
+				}
+				outcomes[e.Tool] = e
+			}
+
+			if outcomes["ok_tool"].Outcome != "success" {
+				t.Errorf("expected success for ok_tool, got %+v", outcomes["ok_tool"])
+			}
+			if e := outcomes["failing_tool"]; e.Outcome != "error" || e.Error != "boom" {
+				t.Errorf("expected error for failing_tool, got %+v", e)
+			}
+		})
+	}
+}
+
+func TestMCPServer_WithToolSource(t *testing.T) {
+	source := staticSource{&stubTool{name: "github__search"}, &stubTool{name: "github__delete_repo"}}
+	g := NewGateway(source, GatewayConfig{Deny: []string{"*delete*"}}, nil)
+	conn := servePipe(t, NewMCPServer("gateway", "1.0.0", nil, WithToolSource(g)))
+
+	conn.send(`{"jsonrpc":"2.0","id":1,"method":"tools/list"}`)
+	resp := conn.read()
+	tools := resp.Result.(map[string]interface{})["tools"].([]interface{})
+	if len(tools) != 1 || tools[0].(map[string]interface{})["name"] != "github__search" {
+		t.Fatalf("expected only github__search, got %v", tools)
+	}
+
+	conn.send(`{"jsonrpc":"2.0","id":2,"method":"tools/call","params":{"name":"github__delete_repo"}}`)
+	result := conn.read().Result.(map[string]interface{})
+	if isError, _ := result["isError"].(bool); !isError {
+		t.Error("denied tool should not be callable")
+	}
+}
@@ -0,0 +1,86 @@
+package mcp
+
+import (
+	"context"
+	"errors"
+	"fmt"
+)
+
+// ErrLoggingNotSupported is returned by SetLogLevel when the server did not
+// advertise the logging capability.
+var ErrLoggingNotSupported = errors.New("MCP server does not support logging")
+
+// MCP log levels, in increasing order of severity (RFC 5424).
+const (
+	LogLevelDebug     = "debug"
+	LogLevelInfo      = "info"
+	LogLevelNotice    = "notice"
+	LogLevelWarning   = "warning"
+	LogLevelError     = "error"
+	LogLevelCritical  = "critical"
+	LogLevelAlert     = "alert"
+	LogLevelEmergency = "emergency"
+)
+
+// logLevels lists the MCP log levels from least to most severe.
+var logLevels = []string{
+	LogLevelDebug, LogLevelInfo, LogLevelNotice, LogLevelWarning,
+	LogLevelError, LogLevelCritical, LogLevelAlert, LogLevelEmergency,
+}
+
+// logLevelRank returns the severity of level, or -1 if it is not a valid level.
+func logLevelRank(level string) int {
+	for i, l := range logLevels {
+		if l == level {
+			return i
+		}
+	}
+	return -1
+}
+
+// ValidateLogLevel returns an error if level is not an MCP log level.
+func ValidateLogLevel(level string) error {
+	if logLevelRank(level) < 0 {
+		return fmt.Errorf("invalid log level %q (expected one of %v)", level, logLevels)
+	}
+	return nil
+}
+
+// LogMessage is a notifications/message log entry sent by an MCP server.
+type LogMessage struct {
+	Server string      // Set by MCPManager
+	Level  string      // One of the LogLevel constants
+	Logger string      // Optional name of the component that logged
+	Data   interface{} // Any JSON value, usually a string
+}
+
+// ProgressEvent is a notifications/progress update for a tool call.
+type ProgressEvent struct {
+	Server   string  // Set by MCPManager
+	Tool     string  // Name of the tool on the server
+	Progress float64 // Increases with every update
+	Total    float64 // Total amount of work, or 0 if unknown
+	Message  string
+}
+
+// describe renders the event as a single line of text.
+func (e ProgressEvent) describe() string {
+	var progress string
+	if e.Total > 0 {
+		progress = fmt.Sprintf("%g/%g", e.Progress, e.Total)
+	} else {
+		progress = fmt.Sprintf("%g", e.Progress)
+	}
+	if e.Message == "" {
+		return progress
+	}
+	return fmt.Sprintf("%s (%s)", e.Message, progress)
+}
+
+// eventSource is implemented by clients that can report progress and log
+// notifications from their server and change the server's log level.
+type eventSource interface {
+	OnProgress(fn func(ProgressEvent))
+	OnLogMessage(fn func(LogMessage))
+	SetLogLevel(ctx context.Context, level string) error
+}
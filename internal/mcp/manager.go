@@ -2,26 +2,130 @@ package mcp
 
 import (
 	"context"
+	"errors"
 	"fmt"
 	"log"
+	"sort"
+	"strings"
 	"sync"
+	"time"
 
 	"agentic-poc/internal/tool"
 )
 
+// toolRefreshTimeout bounds how long a re-list triggered by a
+// tools/list_changed notification may take.
+const toolRefreshTimeout = 30 * time.Second
+
+// toolsChangedNotifier is implemented by clients that can report
+// notifications/tools/list_changed from their server.
+type toolsChangedNotifier interface {
+	OnToolsChanged(fn func())
+}
+
 // MCPManager handles multiple MCP server connections and provides
 // a unified interface to access all MCP tools.
+//
+// Tools are keyed internally as "server/tool". Each tool is also given a
+// unique LLM-facing name by the manager's ToolNamer (by default
+// "server__tool"), which is what the wrapper's Name returns.
 type MCPManager struct {
-	clients map[string]MCPClient
-	tools   map[string]*MCPToolWrapper
-	mu      sync.RWMutex
+	clients   map[string]MCPClient
+	configs   map[string]MCPServerConfig // Enabled servers from LoadFromConfig, connected or not
+	tools     map[string]*MCPToolWrapper
+	names     map[string]string // LLM-facing name -> server/tool key
+	conflicts []ToolNameConflict
+	namer     ToolNamer
+	sampling  *SamplingHandler
+	mu        sync.RWMutex
+
+	// Event callbacks and log level have their own lock because callbacks
+	// run on client read loops while m.mu may be held waiting on them.
+	eventsMu     sync.Mutex
+	onProgress   func(ProgressEvent)
+	onLogMessage func(LogMessage)
+	logLevel     string
 }
 
-// NewMCPManager creates a new MCPManager.
+// NewMCPManager creates a new MCPManager that uses NamespacedToolName.
 func NewMCPManager() *MCPManager {
 	return &MCPManager{
 		clients: make(map[string]MCPClient),
+		configs: make(map[string]MCPServerConfig),
 		tools:   make(map[string]*MCPToolWrapper),
+		names:   make(map[string]string),
+		namer:   NamespacedToolName,
+	}
+}
+
+// SetToolNamer sets the strategy for LLM-facing tool names.
+// It must be called before any servers are added.
+func (m *MCPManager) SetToolNamer(namer ToolNamer) {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	m.namer = namer
+}
+
+// SetSamplingHandler sets the handler used to answer sampling requests.
+// Only servers with sampling enabled in their config are allowed to use it.
+// It must be called before LoadFromConfig.
+func (m *MCPManager) SetSamplingHandler(h *SamplingHandler) {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	m.sampling = h
+}
+
+// OnProgress registers a callback for progress notifications about tool
+// calls on any server. It must be called before servers are added. The
+// callback runs on the client's read loop, so it must return quickly.
+func (m *MCPManager) OnProgress(fn func(ProgressEvent)) {
+	m.eventsMu.Lock()
+	defer m.eventsMu.Unlock()
+	m.onProgress = fn
+}
+
+// OnLogMessage registers a callback for log messages from any server. Like
+// OnProgress, it must be called before servers are added.
+func (m *MCPManager) OnLogMessage(fn func(LogMessage)) {
+	m.eventsMu.Lock()
+	defer m.eventsMu.Unlock()
+	m.onLogMessage = fn
+}
+
+// SetLogLevel sets the minimum level of log messages servers should send.
+// It applies to connected servers now and to servers added later. Servers
+// without the logging capability are skipped; other failures are logged.
+func (m *MCPManager) SetLogLevel(ctx context.Context, level string) error {
+	if err := ValidateLogLevel(level); err != nil {
+		return err
+	}
+
+	m.eventsMu.Lock()
+	m.logLevel = level
+	m.eventsMu.Unlock()
+
+	m.mu.RLock()
+	clients := make(map[string]MCPClient, len(m.clients))
+	for name, client := range m.clients {
+		clients[name] = client
+	}
+	m.mu.RUnlock()
+
+	for name, client := range clients {
+		m.applyLogLevel(ctx, name, client, level)
+	}
+	return nil
+}
+
+// applyLogLevel asks a server to send log messages at level and above.
+func (m *MCPManager) applyLogLevel(ctx context.Context, name string, client MCPClient, level string) {
+	source, ok := client.(eventSource)
+	if !ok {
+		return
+	}
+	err := source.SetLogLevel(ctx, level)
+	if err != nil && !errors.Is(err, ErrLoggingNotSupported) {
+		log.Printf("Failed to set log level for MCP server %q: %v", name, err)
 	}
 }
 
@@ -36,13 +140,23 @@ func (m *MCPManager) LoadFromConfig(ctx context.Context, cfg *MCPConfig) error {
 		return nil
 	}
 
+	// Load in a stable order so name collisions resolve the same way every run
+	names := make([]string, 0, len(cfg.Servers))
+	for name := range cfg.Servers {
+		names = append(names, name)
+	}
+	sort.Strings(names)
+
 	var loadedCount int
-	for name, serverCfg := range cfg.Servers {
-		if serverCfg.Disabled {
+	for _, name := range names {
+		serverCfg := cfg.Servers[name]
+		if serverCfg.IsDisabled() {
 			log.Printf("MCP server %q is disabled, skipping", name)
 			continue
 		}
 
+		// Remembered even if loading fails, so the server can be reconnected
+		m.configs[name] = serverCfg
 		if err := m.loadServer(ctx, name, serverCfg); err != nil {
 			// Log error but continue with other servers (Property 23)
 			log.Printf("Failed to load MCP server %q: %v", name, err)
@@ -58,11 +172,62 @@ func (m *MCPManager) LoadFromConfig(ctx context.Context, cfg *MCPConfig) error {
 // loadServer connects to a single MCP server and registers its tools.
 func (m *MCPManager) loadServer(ctx context.Context, name string, cfg MCPServerConfig) error {
 	client := NewStdioMCPClient(cfg.Command, cfg.Args, cfg.Env)
+	client.SetWorkingDir(cfg.Cwd)
+
+	if cfg.Sampling.Enabled && m.sampling != nil {
+		m.sampling.Allow(name, cfg.Sampling.policy())
+		client.SetSamplingHandler(m.sampling.ForServer(name))
+		log.Printf("MCP server %q may request sampling", name)
+	}
+
+	return m.connectClient(ctx, name, client)
+}
+
+// AddClient adds a pre-configured MCP client to the manager.
+// This is useful for testing or when using custom client implementations.
+func (m *MCPManager) AddClient(ctx context.Context, name string, client MCPClient) error {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+
+	if err := m.connectClient(ctx, name, client); err != nil {
+		return fmt.Errorf("client %q: %w", name, err)
+	}
+	return nil
+}
+
+// connectClient connects a client, lists its tools and registers them.
+// Callers must hold m.mu.
+func (m *MCPManager) connectClient(ctx context.Context, name string, client MCPClient) error {
+	if notifier, ok := client.(toolsChangedNotifier); ok {
+		notifier.OnToolsChanged(func() { m.handleToolsChanged(name) })
+	}
+
+	m.eventsMu.Lock()
+	onProgress, onLogMessage, level := m.onProgress, m.onLogMessage, m.logLevel
+	m.eventsMu.Unlock()
+
+	source, isSource := client.(eventSource)
+	if isSource && onProgress != nil {
+		source.OnProgress(func(e ProgressEvent) {
+			e.Server = name
+			onProgress(e)
+		})
+	}
+	if isSource && onLogMessage != nil {
+		source.OnLogMessage(func(msg LogMessage) {
+			msg.Server = name
+			onLogMessage(msg)
+		})
+	}
 
 	if err := client.Connect(ctx); err != nil {
 		return fmt.Errorf("failed to connect: %w", err)
 	}
 
+	if level != "" {
+		m.applyLogLevel(ctx, name, client, level)
+	}
+
 	tools, err := client.ListTools(ctx)
 	if err != nil {
 		client.Close()
@@ -70,45 +235,113 @@ func (m *MCPManager) loadServer(ctx context.Context, name string, cfg MCPServerC
 	}
 
 	m.clients[name] = client
+	m.registerTools(name, client, tools)
+
+	return nil
+}
+
+// registerTools replaces the registered tools for a server.
+// Callers must hold m.mu.
+func (m *MCPManager) registerTools(name string, client MCPClient, tools []MCPToolInfo) {
+	prefix := name + "/"
+	for key, wrapper := range m.tools {
+		if strings.HasPrefix(key, prefix) {
+			delete(m.names, wrapper.Name())
+			delete(m.tools, key)
+		}
+	}
+
+	kept := m.conflicts[:0]
+	for _, c := range m.conflicts {
+		if !strings.HasPrefix(c.Renamed, prefix) {
+			kept = append(kept, c)
+		}
+	}
+	m.conflicts = kept
 
 	for _, toolInfo := range tools {
-		wrapper := NewMCPToolWrapperWithServer(client, toolInfo, name)
 		// Use server name prefix to avoid tool name collisions
-		toolKey := fmt.Sprintf("%s/%s", name, toolInfo.Name)
+		toolKey := prefix + toolInfo.Name
+		exposed := m.uniqueName(toolKey, m.namer(name, toolInfo.Name))
+
+		wrapper := NewMCPToolWrapperWithServer(client, toolInfo, name)
+		wrapper.exposedName = exposed
 		m.tools[toolKey] = wrapper
-		log.Printf("Registered MCP tool: %s", toolKey)
+		m.names[exposed] = toolKey
+		log.Printf("Registered MCP tool: %s (as %s)", toolKey, exposed)
 	}
+}
 
-	return nil
+// uniqueName returns name if it is free, otherwise the first free variant
+// with a numeric suffix. Renames are recorded as conflicts.
+// Callers must hold m.mu.
+func (m *MCPManager) uniqueName(toolKey, name string) string {
+	owner, taken := m.names[name]
+	if !taken {
+		return name
+	}
+
+	candidate := name
+	for n := 2; taken; n++ {
+		candidate = withSuffix(name, n)
+		_, taken = m.names[candidate]
+	}
+
+	conflict := ToolNameConflict{Name: name, Owner: owner, Renamed: toolKey, NewName: candidate}
+	m.conflicts = append(m.conflicts, conflict)
+	log.Printf("MCP tool name conflict: %s", conflict)
+	return candidate
 }
 
-// AddClient adds a pre-configured MCP client to the manager.
-// This is useful for testing or when using custom client implementations.
-func (m *MCPManager) AddClient(ctx context.Context, name string, client MCPClient) error {
-	m.mu.Lock()
-	defer m.mu.Unlock()
+// NameConflicts returns the tools that were renamed because their
+// LLM-facing name was already taken.
+func (m *MCPManager) NameConflicts() []ToolNameConflict {
+	m.mu.RLock()
+	defer m.mu.RUnlock()
 
-	if err := client.Connect(ctx); err != nil {
-		return fmt.Errorf("failed to connect client %q: %w", name, err)
+	conflicts := make([]ToolNameConflict, len(m.conflicts))
+	copy(conflicts, m.conflicts)
+	return conflicts
+}
+
+// RefreshTools re-lists the tools of a connected server and replaces its
+// entries in the registry.
+func (m *MCPManager) RefreshTools(ctx context.Context, name string) error {
+	client, ok := m.GetClient(name)
+	if !ok {
+		return fmt.Errorf("MCP server %q is not connected", name)
 	}
 
+	// List without holding the lock so other callers aren't blocked on I/O
 	tools, err := client.ListTools(ctx)
 	if err != nil {
-		client.Close()
 		return fmt.Errorf("failed to list tools for %q: %w", name, err)
 	}
 
-	m.clients[name] = client
+	m.mu.Lock()
+	defer m.mu.Unlock()
 
-	for _, toolInfo := range tools {
-		wrapper := NewMCPToolWrapper(client, toolInfo)
-		toolKey := fmt.Sprintf("%s/%s", name, toolInfo.Name)
-		m.tools[toolKey] = wrapper
+	// The server may have been removed while we were listing
+	if current, ok := m.clients[name]; !ok || current != client {
+		return fmt.Errorf("MCP server %q disconnected during refresh", name)
 	}
 
+	m.registerTools(name, client, tools)
+	log.Printf("Refreshed MCP server %q: %d tools", name, len(tools))
 	return nil
 }
 
+// handleToolsChanged re-lists a server's tools after it reports that its
+// tool list changed.
+func (m *MCPManager) handleToolsChanged(name string) {
+	ctx, cancel := context.WithTimeout(context.Background(), toolRefreshTimeout)
+	defer cancel()
+
+	if err := m.RefreshTools(ctx, name); err != nil {
+		log.Printf("Failed to refresh tools for MCP server %q: %v", name, err)
+	}
+}
+
 // GetTools returns all MCP tools as Tool interface implementations.
 func (m *MCPManager) GetTools() []tool.Tool {
 	m.mu.RLock()
@@ -121,14 +354,19 @@ func (m *MCPManager) GetTools() []tool.Tool {
 	return tools
 }
 
-// GetTool returns a specific MCP tool by its full name (server/tool).
+// GetTool returns a specific MCP tool by its full name (server/tool) or by
+// its LLM-facing name.
 func (m *MCPManager) GetTool(name string) (tool.Tool, bool) {
 	m.mu.RLock()
 	defer m.mu.RUnlock()
 
 	wrapper, ok := m.tools[name]
 	if !ok {
-		return nil, false
+		key, found := m.names[name]
+		if !found {
+			return nil, false
+		}
+		wrapper = m.tools[key]
 	}
 	return wrapper, true
 }
@@ -154,6 +392,76 @@ func (m *MCPManager) ServerNames() []string {
 	return names
 }
 
+// ServerStatus describes an MCP server known to the manager.
+type ServerStatus struct {
+	Name      string
+	Connected bool
+	ToolCount int
+}
+
+// Servers returns the status of every connected server and of every
+// configured server that failed to connect, sorted by name.
+func (m *MCPManager) Servers() []ServerStatus {
+	m.mu.RLock()
+	defer m.mu.RUnlock()
+
+	byName := make(map[string]*ServerStatus)
+	for name := range m.configs {
+		byName[name] = &ServerStatus{Name: name}
+	}
+	for name := range m.clients {
+		byName[name] = &ServerStatus{Name: name, Connected: true}
+	}
+	for key := range m.tools {
+		server, _, _ := strings.Cut(key, "/")
+		if status, ok := byName[server]; ok {
+			status.ToolCount++
+		}
+	}
+
+	statuses := make([]ServerStatus, 0, len(byName))
+	for _, status := range byName {
+		statuses = append(statuses, *status)
+	}
+	sort.Slice(statuses, func(i, j int) bool { return statuses[i].Name < statuses[j].Name })
+	return statuses
+}
+
+// Reconnect closes the connection to a server, if any, and connects again.
+// Servers from the config get a new client, so a crashed server process
+// is restarted; clients added with AddClient are reconnected in place.
+func (m *MCPManager) Reconnect(ctx context.Context, name string) error {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+
+	client, connected := m.clients[name]
+	cfg, configured := m.configs[name]
+	if !connected && !configured {
+		return fmt.Errorf("unknown MCP server %q", name)
+	}
+
+	if connected {
+		if err := client.Close(); err != nil {
+			log.Printf("Error closing MCP server %q: %v", name, err)
+		}
+		delete(m.clients, name)
+		m.registerTools(name, nil, nil)
+	}
+
+	var err error
+	if configured {
+		err = m.loadServer(ctx, name, cfg)
+	} else {
+		err = m.connectClient(ctx, name, client)
+	}
+	if err != nil {
+		return fmt.Errorf("failed to reconnect MCP server %q: %w", name, err)
+	}
+
+	log.Printf("Reconnected MCP server %q", name)
+	return nil
+}
+
 // ToolCount returns the total number of registered MCP tools.
 func (m *MCPManager) ToolCount() int {
 	m.mu.RLock()
@@ -176,7 +484,10 @@ func (m *MCPManager) Shutdown() error {
 
 	// Clear maps
 	m.clients = make(map[string]MCPClient)
+	m.configs = make(map[string]MCPServerConfig)
 	m.tools = make(map[string]*MCPToolWrapper)
+	m.names = make(map[string]string)
+	m.conflicts = nil
 
 	return lastErr
 }
@@ -125,8 +125,14 @@ func TestMCPManager_GetTool(t *testing.T) {
 	if !ok {
 		t.Error("expected to find tool 'filesystem/read_file'")
 	}
-	if tool.Name() != "read_file" {
-		t.Errorf("expected tool name 'read_file', got %q", tool.Name())
+	// The LLM-facing name is namespaced by server
+	if tool.Name() != "filesystem__read_file" {
+		t.Errorf("expected tool name 'filesystem__read_file', got %q", tool.Name())
+	}
+
+	// Tool should also be accessible by its LLM-facing name
+	if _, ok := manager.GetTool("filesystem__read_file"); !ok {
+		t.Error("expected to find tool 'filesystem__read_file'")
 	}
 
 	// Non-existent tool
@@ -397,3 +403,307 @@ func TestMCPManager_ConnectionFailuresIsolated(t *testing.T) {
 		t.Error("server3/tool3 should be available")
 	}
 }
+
+func TestMCPManager_RefreshTools(t *testing.T) {
+	manager := NewMCPManager()
+	client := NewMockMCPClient()
+	current := []MCPToolInfo{
+		{Name: "old_tool", Description: "Old"},
+		{Name: "kept_tool", Description: "Kept"},
+	}
+	client.ListToolsFunc = func(ctx context.Context) ([]MCPToolInfo, error) {
+		return current, nil
+	}
+
+	if err := manager.AddClient(context.Background(), "server", client); err != nil {
+		t.Fatalf("AddClient() error = %v", err)
+	}
+
+	current = []MCPToolInfo{
+		{Name: "kept_tool", Description: "Kept"},
+		{Name: "new_tool", Description: "New"},
+		{Name: "another_tool", Description: "Another"},
+	}
+
+	if err := manager.RefreshTools(context.Background(), "server"); err != nil {
+		t.Fatalf("RefreshTools() error = %v", err)
+	}
+
+	if manager.ToolCount() != 3 {
+		t.Errorf("expected 3 tools after refresh, got %d", manager.ToolCount())
+	}
+	if _, ok := manager.GetTool("server/old_tool"); ok {
+		t.Error("old_tool should have been removed")
+	}
+	if _, ok := manager.GetTool("server/new_tool"); !ok {
+		t.Error("new_tool should have been added")
+	}
+}
+
+func TestMCPManager_RefreshTools_LeavesOtherServers(t *testing.T) {
+	manager := NewMCPManager()
+
+	client1 := NewMockMCPClient()
+	client1.ListToolsFunc = func(ctx context.Context) ([]MCPToolInfo, error) {
+		return []MCPToolInfo{{Name: "tool_a"}}, nil
+	}
+	client2 := NewMockMCPClient()
+	client2.ListToolsFunc = func(ctx context.Context) ([]MCPToolInfo, error) {
+		return []MCPToolInfo{{Name: "tool_b"}}, nil
+	}
+
+	manager.AddClient(context.Background(), "server1", client1)
+	manager.AddClient(context.Background(), "server2", client2)
+
+	client1.ListToolsFunc = func(ctx context.Context) ([]MCPToolInfo, error) {
+		return []MCPToolInfo{}, nil
+	}
+	if err := manager.RefreshTools(context.Background(), "server1"); err != nil {
+		t.Fatalf("RefreshTools() error = %v", err)
+	}
+
+	if _, ok := manager.GetTool("server2/tool_b"); !ok {
+		t.Error("server2 tools should be unaffected")
+	}
+	if manager.ToolCount() != 1 {
+		t.Errorf("expected 1 tool, got %d", manager.ToolCount())
+	}
+}
+
+func TestMCPManager_RefreshTools_UnknownServer(t *testing.T) {
+	manager := NewMCPManager()
+	if err := manager.RefreshTools(context.Background(), "missing"); err == nil {
+		t.Error("expected error for unknown server")
+	}
+}
+
+func TestMCPManager_ToolsChangedNotification(t *testing.T) {
+	manager := NewMCPManager()
+	client := NewMockMCPClient()
+	names := []string{"first"}
+	client.ListToolsFunc = func(ctx context.Context) ([]MCPToolInfo, error) {
+		tools := make([]MCPToolInfo, len(names))
+		for i, n := range names {
+			tools[i] = MCPToolInfo{Name: n}
+		}
+		return tools, nil
+	}
+
+	if err := manager.AddClient(context.Background(), "server", client); err != nil {
+		t.Fatalf("AddClient() error = %v", err)
+	}
+
+	names = []string{"first", "second"}
+	client.TriggerToolsChanged()
+
+	if _, ok := manager.GetTool("server/second"); !ok {
+		t.Error("expected tool list to be refreshed after notification")
+	}
+}
+
+func TestMCPManager_SameToolOnTwoServers(t *testing.T) {
+	manager := NewMCPManager()
+
+	var calledServer string
+	newClient := func(server string) *MockMCPClient {
+		c := NewMockMCPClient()
+		c.ListToolsFunc = func(ctx context.Context) ([]MCPToolInfo, error) {
+			return []MCPToolInfo{{Name: "read_file"}}, nil
+		}
+		c.CallToolFunc = func(ctx context.Context, name string, args map[string]interface{}) (*provider.ToolResult, error) {
+			calledServer = server
+			if name != "read_file" {
+				t.Errorf("server should receive its own tool name, got %q", name)
+			}
+			return &provider.ToolResult{Success: true}, nil
+		}
+		return c
+	}
+
+	manager.AddClient(context.Background(), "alpha", newClient("alpha"))
+	manager.AddClient(context.Background(), "beta", newClient("beta"))
+
+	names := make(map[string]bool)
+	for _, tl := range manager.GetTools() {
+		names[tl.Name()] = true
+	}
+	if !names["alpha__read_file"] || !names["beta__read_file"] {
+		t.Fatalf("expected distinct namespaced names, got %v", names)
+	}
+
+	tl, _ := manager.GetTool("beta__read_file")
+	tl.Execute(context.Background(), nil)
+	if calledServer != "beta" {
+		t.Errorf("call routed to %q, want beta", calledServer)
+	}
+	if len(manager.NameConflicts()) != 0 {
+		t.Errorf("expected no conflicts, got %v", manager.NameConflicts())
+	}
+}
+
+func TestMCPManager_NameConflictsReported(t *testing.T) {
+	manager := NewMCPManager()
+	manager.SetToolNamer(RawToolName)
+
+	for _, server := range []string{"alpha", "beta"} {
+		c := NewMockMCPClient()
+		c.ListToolsFunc = func(ctx context.Context) ([]MCPToolInfo, error) {
+			return []MCPToolInfo{{Name: "search"}}, nil
+		}
+		if err := manager.AddClient(context.Background(), server, c); err != nil {
+			t.Fatalf("AddClient(%q) error = %v", server, err)
+		}
+	}
+
+	if manager.ToolCount() != 2 {
+		t.Fatalf("expected both tools to be kept, got %d", manager.ToolCount())
+	}
+
+	conflicts := manager.NameConflicts()
+	if len(conflicts) != 1 {
+		t.Fatalf("expected 1 conflict, got %d", len(conflicts))
+	}
+	c := conflicts[0]
+	if c.Name != "search" || c.Owner != "alpha/search" || c.Renamed != "beta/search" || c.NewName != "search_2" {
+		t.Errorf("unexpected conflict: %+v", c)
+	}
+
+	if tl, ok := manager.GetTool("search_2"); !ok || tl.(*MCPToolWrapper).ServerName() != "beta" {
+		t.Error("renamed tool should resolve to beta")
+	}
+}
+
+func TestMCPManager_EventCallbacks(t *testing.T) {
+	manager := NewMCPManager()
+
+	var progress []ProgressEvent
+	var logs []LogMessage
+	manager.OnProgress(func(e ProgressEvent) { progress = append(progress, e) })
+	manager.OnLogMessage(func(msg LogMessage) { logs = append(logs, msg) })
+
+	client := NewMockMCPClient()
+	if err := manager.AddClient(context.Background(), "server", client); err != nil {
+		t.Fatalf("AddClient() error = %v", err)
+	}
+
+	client.TriggerProgress(ProgressEvent{Tool: "build", Progress: 1, Total: 2})
+	client.TriggerLogMessage(LogMessage{Level: LogLevelInfo, Data: "hello"})
+
+	if len(progress) != 1 || progress[0].Server != "server" || progress[0].Tool != "build" {
+		t.Errorf("unexpected progress events: %+v", progress)
+	}
+	if len(logs) != 1 || logs[0].Server != "server" || logs[0].Data != "hello" {
+		t.Errorf("unexpected log messages: %+v", logs)
+	}
+}
+
+func TestMCPManager_SetLogLevel(t *testing.T) {
+	manager := NewMCPManager()
+
+	if err := manager.SetLogLevel(context.Background(), "verbose"); err == nil {
+		t.Error("expected error for invalid level")
+	}
+
+	early := NewMockMCPClient()
+	if err := manager.AddClient(context.Background(), "early", early); err != nil {
+		t.Fatalf("AddClient() error = %v", err)
+	}
+	if err := manager.SetLogLevel(context.Background(), LogLevelWarning); err != nil {
+		t.Fatalf("SetLogLevel() error = %v", err)
+	}
+
+	late := NewMockMCPClient()
+	if err := manager.AddClient(context.Background(), "late", late); err != nil {
+		t.Fatalf("AddClient() error = %v", err)
+	}
+
+	for name, client := range map[string]*MockMCPClient{"early": early, "late": late} {
+		if len(client.logLevels) != 1 || client.logLevels[0] != LogLevelWarning {
+			t.Errorf("%s: expected log level warning to be applied once, got %v", name, client.logLevels)
+		}
+	}
+}
+
+func TestMCPManager_Servers(t *testing.T) {
+	manager := NewMCPManager()
+
+	two := NewMockMCPClient()
+	two.ListToolsFunc = func(ctx context.Context) ([]MCPToolInfo, error) {
+		return []MCPToolInfo{{Name: "a"}, {Name: "b"}}, nil
+	}
+	if err := manager.AddClient(context.Background(), "beta", two); err != nil {
+		t.Fatalf("AddClient() error = %v", err)
+	}
+	if err := manager.AddClient(context.Background(), "alpha", NewMockMCPClient()); err != nil {
+		t.Fatalf("AddClient() error = %v", err)
+	}
+
+	// A configured server that cannot start is reported as disconnected
+	cfg := &MCPConfig{Servers: map[string]MCPServerConfig{
+		"broken": {Command: "/nonexistent/mcp-server"},
+	}}
+	if err := manager.LoadFromConfig(context.Background(), cfg); err != nil {
+		t.Fatalf("LoadFromConfig() error = %v", err)
+	}
+
+	want := []ServerStatus{
+		{Name: "alpha", Connected: true, ToolCount: 1},
+		{Name: "beta", Connected: true, ToolCount: 2},
+		{Name: "broken", Connected: false, ToolCount: 0},
+	}
+	got := manager.Servers()
+	if len(got) != len(want) {
+		t.Fatalf("Servers() = %+v, want %+v", got, want)
+	}
+	for i := range want {
+		if got[i] != want[i] {
+			t.Errorf("Servers()[%d] = %+v, want %+v", i, got[i], want[i])
+		}
+	}
+
+	if err := manager.Reconnect(context.Background(), "broken"); err == nil {
+		t.Error("expected reconnecting a server that cannot start to fail")
+	}
+}
+
+func TestMCPManager_Reconnect(t *testing.T) {
+	manager := NewMCPManager()
+
+	var connects, closes int
+	client := NewMockMCPClient()
+	client.ConnectFunc = func(ctx context.Context) error {
+		connects++
+		return nil
+	}
+	client.CloseFunc = func() error {
+		closes++
+		return nil
+	}
+	tools := []MCPToolInfo{{Name: "old"}}
+	client.ListToolsFunc = func(ctx context.Context) ([]MCPToolInfo, error) {
+		return tools, nil
+	}
+
+	if err := manager.AddClient(context.Background(), "server", client); err != nil {
+		t.Fatalf("AddClient() error = %v", err)
+	}
+
+	tools = []MCPToolInfo{{Name: "new"}}
+	if err := manager.Reconnect(context.Background(), "server"); err != nil {
+		t.Fatalf("Reconnect() error = %v", err)
+	}
+
+	if connects != 2 || closes != 1 {
+		t.Errorf("connects = %d, closes = %d; want 2 and 1", connects, closes)
+	}
+	if _, ok := manager.GetTool("server/old"); ok {
+		t.Error("tools from before the reconnect should be gone")
+	}
+	if _, ok := manager.GetTool("server/new"); !ok {
+		t.Error("tools should be re-listed after the reconnect")
+	}
+
+	if err := manager.Reconnect(context.Background(), "missing"); err == nil {
+		t.Error("expected error for unknown server")
+	}
+}
@@ -14,7 +14,11 @@ type MockMCPClient struct {
 	CallToolFunc  func(ctx context.Context, name string, args map[string]interface{}) (*provider.ToolResult, error)
 	CloseFunc     func() error
 
-	connected bool
+	connected    bool
+	toolsChanged func()
+	onProgress   func(ProgressEvent)
+	onLogMessage func(LogMessage)
+	logLevels    []string // Levels passed to SetLogLevel, in order
 }
 
 // NewMockMCPClient creates a new MockMCPClient with default implementations.
@@ -74,6 +78,48 @@ func (m *MockMCPClient) Close() error {
 	return nil
 }
 
+// OnToolsChanged implements toolsChangedNotifier.
+func (m *MockMCPClient) OnToolsChanged(fn func()) {
+	m.toolsChanged = fn
+}
+
+// TriggerToolsChanged simulates a notifications/tools/list_changed from the server.
+func (m *MockMCPClient) TriggerToolsChanged() {
+	if m.toolsChanged != nil {
+		m.toolsChanged()
+	}
+}
+
+// OnProgress implements eventSource.
+func (m *MockMCPClient) OnProgress(fn func(ProgressEvent)) {
+	m.onProgress = fn
+}
+
+// OnLogMessage implements eventSource.
+func (m *MockMCPClient) OnLogMessage(fn func(LogMessage)) {
+	m.onLogMessage = fn
+}
+
+// SetLogLevel implements eventSource.
+func (m *MockMCPClient) SetLogLevel(ctx context.Context, level string) error {
+	m.logLevels = append(m.logLevels, level)
+	return nil
+}
+
+// TriggerProgress simulates a notifications/progress from the server.
+func (m *MockMCPClient) TriggerProgress(e ProgressEvent) {
+	if m.onProgress != nil {
+		m.onProgress(e)
+	}
+}
+
+// TriggerLogMessage simulates a notifications/message from the server.
+func (m *MockMCPClient) TriggerLogMessage(msg LogMessage) {
+	if m.onLogMessage != nil {
+		m.onLogMessage(msg)
+	}
+}
+
 // IsConnected returns whether the mock client is connected.
 func (m *MockMCPClient) IsConnected() bool {
 	return m.connected
@@ -0,0 +1,88 @@
+package mcp
+
+import (
+	"crypto/sha256"
+	"encoding/hex"
+	"fmt"
+	"strings"
+)
+
+// MaxToolNameLength is the longest tool name accepted by LLM providers.
+// Claude and OpenAI both require names matching ^[a-zA-Z0-9_-]{1,64}$.
+const MaxToolNameLength = 64
+
+// ToolNamer builds the name under which a server's tool is offered to the LLM.
+// The result must be a valid provider tool name; the manager guarantees
+// uniqueness by renaming on collision.
+type ToolNamer func(serverName, toolName string) string
+
+// NamespacedToolName is the default ToolNamer. It joins the server and tool
+// names with a double underscore, e.g. "filesystem__read_file".
+func NamespacedToolName(serverName, toolName string) string {
+	return SanitizeToolName(serverName + "__" + toolName)
+}
+
+// RawToolName is a ToolNamer that uses the server's own tool name. Tools
+// from different servers are more likely to collide; collisions are still
+// resolved by the manager.
+func RawToolName(serverName, toolName string) string {
+	return SanitizeToolName(toolName)
+}
+
+// SanitizeToolName replaces characters not allowed in provider tool names
+// with underscores and shortens names longer than MaxToolNameLength.
+// Shortened names end with a hash of the full name so they stay distinct.
+func SanitizeToolName(name string) string {
+	var b strings.Builder
+	for _, r := range name {
+		if isToolNameChar(r) {
+			b.WriteRune(r)
+		} else {
+			b.WriteByte('_')
+		}
+	}
+
+	sanitized := b.String()
+	if sanitized == "" {
+		return "tool"
+	}
+
+	if len(sanitized) > MaxToolNameLength {
+		sum := sha256.Sum256([]byte(name))
+		suffix := "_" + hex.EncodeToString(sum[:])[:8]
+		sanitized = sanitized[:MaxToolNameLength-len(suffix)] + suffix
+	}
+
+	return sanitized
+}
+
+// isToolNameChar reports whether r is allowed in a provider tool name.
+func isToolNameChar(r rune) bool {
+	return r >= 'a' && r <= 'z' ||
+		r >= 'A' && r <= 'Z' ||
+		r >= '0' && r <= '9' ||
+		r == '_' || r == '-'
+}
+
+// withSuffix appends "_n" to name, trimming it to stay within MaxToolNameLength.
+func withSuffix(name string, n int) string {
+	suffix := fmt.Sprintf("_%d", n)
+	if len(name)+len(suffix) > MaxToolNameLength {
+		name = name[:MaxToolNameLength-len(suffix)]
+	}
+	return name + suffix
+}
+
+// ToolNameConflict records an MCP tool whose LLM-facing name was already
+// taken and had to be renamed.
+type ToolNameConflict struct {
+	Name    string // The contested LLM-facing name
+	Owner   string // server/tool key that kept the name
+	Renamed string // server/tool key that was renamed
+	NewName string // The name given to the renamed tool
+}
+
+// String describes the conflict for logs and the CLI.
+func (c ToolNameConflict) String() string {
+	return fmt.Sprintf("tool name %q from %s is already used by %s; exposed as %q", c.Name, c.Renamed, c.Owner, c.NewName)
+}
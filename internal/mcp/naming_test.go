@@ -0,0 +1,71 @@
+package mcp
+
+import (
+	"regexp"
+	"strings"
+	"testing"
+)
+
+var validToolName = regexp.MustCompile(`^[a-zA-Z0-9_-]{1,64}$`)
+
+func TestNamespacedToolName(t *testing.T) {
+	tests := []struct {
+		server string
+		tool   string
+		want   string
+	}{
+		{server: "filesystem", tool: "read_file", want: "filesystem__read_file"},
+		{server: "my.server", tool: "get data", want: "my_server__get_data"},
+		{server: "gh", tool: "repos/list", want: "gh__repos_list"},
+		{server: "web-search", tool: "query", want: "web-search__query"},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.want, func(t *testing.T) {
+			if got := NamespacedToolName(tt.server, tt.tool); got != tt.want {
+				t.Errorf("NamespacedToolName(%q, %q) = %q, want %q", tt.server, tt.tool, got, tt.want)
+			}
+		})
+	}
+}
+
+func TestSanitizeToolName(t *testing.T) {
+	tests := []struct {
+		name  string
+		input string
+	}{
+		{name: "empty", input: ""},
+		{name: "unicode", input: "résumé✓"},
+		{name: "long", input: strings.Repeat("a", 100)},
+		{name: "long with symbols", input: strings.Repeat("a.b", 40)},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			got := SanitizeToolName(tt.input)
+			if !validToolName.MatchString(got) {
+				t.Errorf("SanitizeToolName(%q) = %q, not a valid tool name", tt.input, got)
+			}
+		})
+	}
+}
+
+func TestSanitizeToolName_LongNamesStayDistinct(t *testing.T) {
+	prefix := strings.Repeat("x", 70)
+	a := SanitizeToolName(prefix + "_one")
+	b := SanitizeToolName(prefix + "_two")
+
+	if len(a) != MaxToolNameLength {
+		t.Errorf("expected length %d, got %d", MaxToolNameLength, len(a))
+	}
+	if a == b {
+		t.Errorf("truncated names should differ, both %q", a)
+	}
+}
+
+func TestWithSuffix_RespectsMaxLength(t *testing.T) {
+	got := withSuffix(strings.Repeat("n", MaxToolNameLength), 12)
+	if len(got) != MaxToolNameLength || !strings.HasSuffix(got, "_12") {
+		t.Errorf("withSuffix() = %q", got)
+	}
+}
@@ -0,0 +1,8 @@
+//go:build !unix
+
+package mcp
+
+import "os/exec"
+
+// setProcessGroup is a no-op on platforms without Unix process groups.
+func setProcessGroup(cmd *exec.Cmd) {}
@@ -0,0 +1,15 @@
+//go:build unix
+
+package mcp
+
+import (
+	"os/exec"
+	"syscall"
+)
+
+// setProcessGroup starts the server in its own process group, so a Ctrl-C
+// in the terminal reaches only the CLI. The CLI then decides whether to
+// cancel the current run or shut down, closing its servers itself.
+func setProcessGroup(cmd *exec.Cmd) {
+	cmd.SysProcAttr = &syscall.SysProcAttr{Setpgid: true}
+}
@@ -0,0 +1,223 @@
+package mcp
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"log"
+	"sync"
+	"time"
+
+	"agentic-poc/internal/provider"
+)
+
+// Defaults for a server's sampling policy.
+const (
+	// DefaultSamplingMaxTokens is the per-request token cap used when a
+	// server's sampling policy does not set one.
+	DefaultSamplingMaxTokens = 1024
+	// DefaultSamplingTimeout limits the LLM call of a sampling request
+	// when a server's sampling policy does not set a timeout.
+	DefaultSamplingTimeout = 2 * time.Minute
+)
+
+// ErrSamplingDenied is returned when a server is not permitted to sample.
+var ErrSamplingDenied = errors.New("sampling request denied")
+
+// SamplingContent is a single content item in an MCP sampling message.
+type SamplingContent struct {
+	Type     string `json:"type"`
+	Text     string `json:"text,omitempty"`
+	Data     string `json:"data,omitempty"`
+	MimeType string `json:"mimeType,omitempty"`
+}
+
+// SamplingMessage is a message in a sampling/createMessage request.
+type SamplingMessage struct {
+	Role    string          `json:"role"`
+	Content SamplingContent `json:"content"`
+}
+
+// SamplingRequest holds the params of a sampling/createMessage request.
+type SamplingRequest struct {
+	Messages      []SamplingMessage `json:"messages"`
+	SystemPrompt  string            `json:"systemPrompt,omitempty"`
+	MaxTokens     int               `json:"maxTokens"`
+	Temperature   *float64          `json:"temperature,omitempty"`
+	StopSequences []string          `json:"stopSequences,omitempty"`
+}
+
+// SamplingResult is the result returned to the server for sampling/createMessage.
+type SamplingResult struct {
+	Role       string          `json:"role"`
+	Content    SamplingContent `json:"content"`
+	Model      string          `json:"model"`
+	StopReason string          `json:"stopReason,omitempty"`
+}
+
+// SamplingFunc answers a sampling request on behalf of a single server.
+type SamplingFunc func(ctx context.Context, req *SamplingRequest) (*SamplingResult, error)
+
+// SamplingApprover is consulted before a sampling request is sent to the
+// provider. Returning an error rejects the request.
+type SamplingApprover func(ctx context.Context, serverName string, req *SamplingRequest) error
+
+// SamplingPolicy controls sampling for a single server.
+type SamplingPolicy struct {
+	// MaxTokens caps the tokens a single request may ask for. Larger requests
+	// are clamped. Zero means DefaultSamplingMaxTokens.
+	MaxTokens int
+	// Timeout limits the LLM call of a single request. Zero means
+	// DefaultSamplingTimeout.
+	Timeout time.Duration
+	// AutoApprove skips the approver for this server's requests.
+	AutoApprove bool
+}
+
+// SamplingHandler routes sampling requests from MCP servers to an LLM provider.
+// Servers must be explicitly allowed; requests from any other server are denied.
+type SamplingHandler struct {
+	provider provider.LLMProvider
+	approve  SamplingApprover
+	policies map[string]SamplingPolicy
+	mu       sync.RWMutex
+}
+
+// NewSamplingHandler creates a SamplingHandler backed by the given provider.
+// No servers are allowed to sample until Allow is called.
+func NewSamplingHandler(llmProvider provider.LLMProvider) *SamplingHandler {
+	return &SamplingHandler{
+		provider: llmProvider,
+		policies: make(map[string]SamplingPolicy),
+	}
+}
+
+// SetApprover sets an optional hook that can reject individual requests
+// from otherwise allowed servers, such as by asking the user. Servers
+// whose policy has AutoApprove skip it.
+func (h *SamplingHandler) SetApprover(fn SamplingApprover) {
+	h.mu.Lock()
+	defer h.mu.Unlock()
+	h.approve = fn
+}
+
+// Allow permits the named server to sample under the given policy.
+func (h *SamplingHandler) Allow(serverName string, policy SamplingPolicy) {
+	h.mu.Lock()
+	defer h.mu.Unlock()
+	h.policies[serverName] = policy
+}
+
+// ForServer returns a SamplingFunc bound to the named server, suitable for
+// StdioMCPClient.SetSamplingHandler.
+func (h *SamplingHandler) ForServer(serverName string) SamplingFunc {
+	return func(ctx context.Context, req *SamplingRequest) (*SamplingResult, error) {
+		return h.CreateMessage(ctx, serverName, req)
+	}
+}
+
+// CreateMessage checks the server's policy, converts the MCP messages to
+// provider messages, and runs a single completion.
+func (h *SamplingHandler) CreateMessage(ctx context.Context, serverName string, req *SamplingRequest) (*SamplingResult, error) {
+	h.mu.RLock()
+	policy, allowed := h.policies[serverName]
+	approve := h.approve
+	h.mu.RUnlock()
+
+	if !allowed {
+		log.Printf("[MCP Client] Denied sampling request from server %q", serverName)
+		return nil, fmt.Errorf("%w: server %q is not allowed to sample", ErrSamplingDenied, serverName)
+	}
+
+	if approve != nil && !policy.AutoApprove {
+		if err := approve(ctx, serverName, req); err != nil {
+			log.Printf("[MCP Client] Sampling request from server %q rejected: %v", serverName, err)
+			return nil, fmt.Errorf("%w: %v", ErrSamplingDenied, err)
+		}
+	}
+
+	messages, err := toProviderMessages(req.Messages)
+	if err != nil {
+		return nil, err
+	}
+
+	maxTokens := policy.MaxTokens
+	if maxTokens <= 0 {
+		maxTokens = DefaultSamplingMaxTokens
+	}
+	if req.MaxTokens > 0 && req.MaxTokens < maxTokens {
+		maxTokens = req.MaxTokens
+	}
+
+	timeout := policy.Timeout
+	if timeout <= 0 {
+		timeout = DefaultSamplingTimeout
+	}
+	ctx, cancel := context.WithTimeout(ctx, timeout)
+	defer cancel()
+
+	log.Printf("[MCP Client] Sampling for server %q: %d messages, max %d tokens", serverName, len(messages), maxTokens)
+
+	resp, err := h.provider.Generate(ctx, provider.GenerateRequest{
+		Messages:      messages,
+		SystemPrompt:  req.SystemPrompt,
+		MaxTokens:     maxTokens,
+		Temperature:   req.Temperature,
+		StopSequences: req.StopSequences,
+	})
+	if err != nil {
+		return nil, fmt.Errorf("sampling failed: %w", err)
+	}
+
+	return &SamplingResult{
+		Role:       "assistant",
+		Content:    SamplingContent{Type: "text", Text: resp.Text},
+		Model:      h.provider.Name(),
+		StopReason: samplingStopReason(resp.StopReason),
+	}, nil
+}
+
+// samplingStopReason converts a provider stop reason to its MCP name.
+// Providers that report none are taken to have ended their turn.
+func samplingStopReason(reason provider.StopReason) string {
+	switch reason {
+	case provider.StopMaxTokens:
+		return "maxTokens"
+	case provider.StopSequenceMatched:
+		return "stopSequence"
+	default:
+		return "endTurn"
+	}
+}
+
+// toProviderMessages converts MCP sampling messages to provider messages.
+// Only text content is supported.
+func toProviderMessages(msgs []SamplingMessage) ([]provider.Message, error) {
+	if len(msgs) == 0 {
+		return nil, errors.New("sampling request has no messages")
+	}
+
+	messages := make([]provider.Message, 0, len(msgs))
+	for i, m := range msgs {
+		if m.Role != "user" && m.Role != "assistant" {
+			return nil, fmt.Errorf("message %d: invalid role %q", i, m.Role)
+		}
+		if m.Content.Type != "text" {
+			return nil, fmt.Errorf("message %d: unsupported content type %q", i, m.Content.Type)
+		}
+		messages = append(messages, provider.Message{
+			Role:    m.Role,
+			Content: m.Content.Text,
+		})
+	}
+	return messages, nil
+}
+
+// samplingError converts a sampling failure to a JSON-RPC error.
+func samplingError(err error) *JSONRPCError {
+	if errors.Is(err, ErrSamplingDenied) {
+		// -1 is the code MCP uses for a user-rejected sampling request
+		return &JSONRPCError{Code: -1, Message: err.Error()}
+	}
+	return &JSONRPCError{Code: ErrCodeInternal, Message: err.Error()}
+}
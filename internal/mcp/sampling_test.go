@@ -0,0 +1,248 @@
+package mcp
+
+import (
+	"context"
+	"errors"
+	"strings"
+	"testing"
+	"time"
+
+	"agentic-poc/internal/provider"
+)
+
+// stubProvider is a test double for LLMProvider that records requests.
+type stubProvider struct {
+	text       string
+	stopReason provider.StopReason
+	err        error
+	requests   []provider.GenerateRequest
+}
+
+func (p *stubProvider) Generate(ctx context.Context, req provider.GenerateRequest) (*provider.LLMResponse, error) {
+	p.requests = append(p.requests, req)
+	if p.err != nil {
+		return nil, p.err
+	}
+	return &provider.LLMResponse{Text: p.text, StopReason: p.stopReason}, nil
+}
+
+func (p *stubProvider) Name() string {
+	return "stub"
+}
+
+func textRequest(maxTokens int, texts ...string) *SamplingRequest {
+	req := &SamplingRequest{MaxTokens: maxTokens}
+	for _, text := range texts {
+		req.Messages = append(req.Messages, SamplingMessage{
+			Role:    "user",
+			Content: SamplingContent{Type: "text", Text: text},
+		})
+	}
+	return req
+}
+
+func TestSamplingHandler_DeniesUnknownServer(t *testing.T) {
+	p := &stubProvider{text: "hi"}
+	h := NewSamplingHandler(p)
+
+	_, err := h.CreateMessage(context.Background(), "untrusted", textRequest(100, "hello"))
+	if !errors.Is(err, ErrSamplingDenied) {
+		t.Fatalf("expected ErrSamplingDenied, got %v", err)
+	}
+	if len(p.requests) != 0 {
+		t.Error("provider should not be called for a denied server")
+	}
+	if code := samplingError(err).Code; code != -1 {
+		t.Errorf("expected JSON-RPC code -1, got %d", code)
+	}
+}
+
+func TestSamplingHandler_CreateMessage(t *testing.T) {
+	p := &stubProvider{text: "a summary"}
+	h := NewSamplingHandler(p)
+	h.Allow("docs", SamplingPolicy{MaxTokens: 500})
+
+	req := textRequest(200, "summarize this")
+	req.SystemPrompt = "Be brief."
+
+	result, err := h.CreateMessage(context.Background(), "docs", req)
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+
+	if result.Role != "assistant" || result.Content.Type != "text" || result.Content.Text != "a summary" {
+		t.Errorf("unexpected result: %+v", result)
+	}
+	if result.Model != "stub" {
+		t.Errorf("expected model 'stub', got %q", result.Model)
+	}
+
+	got := p.requests[0]
+	if got.SystemPrompt != "Be brief." {
+		t.Errorf("system prompt not forwarded: %q", got.SystemPrompt)
+	}
+	if len(got.Messages) != 1 || got.Messages[0].Content != "summarize this" {
+		t.Errorf("messages not converted: %+v", got.Messages)
+	}
+	if got.MaxTokens != 200 {
+		t.Errorf("expected max tokens 200, got %d", got.MaxTokens)
+	}
+}
+
+func TestSamplingHandler_TokenCap(t *testing.T) {
+	tests := []struct {
+		name      string
+		policyCap int
+		requested int
+		want      int
+	}{
+		{name: "request above cap is clamped", policyCap: 100, requested: 5000, want: 100},
+		{name: "request below cap is kept", policyCap: 100, requested: 50, want: 50},
+		{name: "missing request uses cap", policyCap: 100, requested: 0, want: 100},
+		{name: "missing cap uses default", policyCap: 0, requested: 99999, want: DefaultSamplingMaxTokens},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			p := &stubProvider{text: "ok"}
+			h := NewSamplingHandler(p)
+			h.Allow("srv", SamplingPolicy{MaxTokens: tt.policyCap})
+
+			if _, err := h.CreateMessage(context.Background(), "srv", textRequest(tt.requested, "hi")); err != nil {
+				t.Fatalf("unexpected error: %v", err)
+			}
+			if got := p.requests[0].MaxTokens; got != tt.want {
+				t.Errorf("expected max tokens %d, got %d", tt.want, got)
+			}
+		})
+	}
+}
+
+func TestSamplingHandler_SamplingParameters(t *testing.T) {
+	tests := []struct {
+		stopReason provider.StopReason
+		want       string
+	}{
+		{stopReason: "", want: "endTurn"},
+		{stopReason: provider.StopEndTurn, want: "endTurn"},
+		{stopReason: provider.StopMaxTokens, want: "maxTokens"},
+		{stopReason: provider.StopSequenceMatched, want: "stopSequence"},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.want+"/"+string(tt.stopReason), func(t *testing.T) {
+			p := &stubProvider{text: "ok", stopReason: tt.stopReason}
+			h := NewSamplingHandler(p)
+			h.Allow("srv", SamplingPolicy{})
+
+			temperature := 0.3
+			req := textRequest(50, "hi")
+			req.Temperature = &temperature
+			req.StopSequences = []string{"END"}
+
+			result, err := h.CreateMessage(context.Background(), "srv", req)
+			if err != nil {
+				t.Fatalf("unexpected error: %v", err)
+			}
+			if result.StopReason != tt.want {
+				t.Errorf("stop reason = %q, want %q", result.StopReason, tt.want)
+			}
+			got := p.requests[0]
+			if got.Temperature == nil || *got.Temperature != 0.3 || len(got.StopSequences) != 1 || got.StopSequences[0] != "END" {
+				t.Errorf("sampling parameters not forwarded: %+v", got)
+			}
+		})
+	}
+}
+
+func TestSamplingHandler_Approver(t *testing.T) {
+	p := &stubProvider{text: "ok"}
+	h := NewSamplingHandler(p)
+	h.Allow("srv", SamplingPolicy{})
+
+	var seenServer string
+	h.SetApprover(func(ctx context.Context, serverName string, req *SamplingRequest) error {
+		seenServer = serverName
+		return errors.New("user declined")
+	})
+
+	_, err := h.CreateMessage(context.Background(), "srv", textRequest(10, "hi"))
+	if !errors.Is(err, ErrSamplingDenied) {
+		t.Fatalf("expected ErrSamplingDenied, got %v", err)
+	}
+	if !strings.Contains(err.Error(), "user declined") {
+		t.Errorf("error should include approver reason: %v", err)
+	}
+	if seenServer != "srv" {
+		t.Errorf("approver got server %q", seenServer)
+	}
+	if len(p.requests) != 0 {
+		t.Error("provider should not be called when approver rejects")
+	}
+}
+
+func TestSamplingHandler_AutoApprove(t *testing.T) {
+	p := &stubProvider{text: "ok"}
+	h := NewSamplingHandler(p)
+	h.Allow("trusted", SamplingPolicy{AutoApprove: true})
+	h.SetApprover(func(ctx context.Context, serverName string, req *SamplingRequest) error {
+		t.Errorf("approver called for an auto-approved server")
+		return errors.New("user declined")
+	})
+
+	if _, err := h.CreateMessage(context.Background(), "trusted", textRequest(10, "hi")); err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+}
+
+// blockingProvider waits for its context to end.
+type blockingProvider struct{}
+
+func (blockingProvider) Generate(ctx context.Context, req provider.GenerateRequest) (*provider.LLMResponse, error) {
+	<-ctx.Done()
+	return nil, ctx.Err()
+}
+
+func (blockingProvider) Name() string { return "blocking" }
+
+func TestSamplingHandler_Timeout(t *testing.T) {
+	h := NewSamplingHandler(blockingProvider{})
+	h.Allow("slow", SamplingPolicy{Timeout: 10 * time.Millisecond})
+
+	_, err := h.CreateMessage(context.Background(), "slow", textRequest(10, "hi"))
+	if !errors.Is(err, context.DeadlineExceeded) {
+		t.Errorf("expected the sampling timeout, got %v", err)
+	}
+}
+
+func TestSamplingHandler_InvalidMessages(t *testing.T) {
+	tests := []struct {
+		name string
+		req  *SamplingRequest
+	}{
+		{name: "no messages", req: &SamplingRequest{}},
+		{
+			name: "image content",
+			req: &SamplingRequest{Messages: []SamplingMessage{
+				{Role: "user", Content: SamplingContent{Type: "image", Data: "AAAA", MimeType: "image/png"}},
+			}},
+		},
+		{
+			name: "invalid role",
+			req: &SamplingRequest{Messages: []SamplingMessage{
+				{Role: "system", Content: SamplingContent{Type: "text", Text: "hi"}},
+			}},
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			h := NewSamplingHandler(&stubProvider{})
+			h.Allow("srv", SamplingPolicy{})
+
+			if _, err := h.CreateMessage(context.Background(), "srv", tt.req); err == nil {
+				t.Error("expected error")
+			}
+		})
+	}
+}
@@ -4,6 +4,7 @@ package mcp
 
 import (
 	"bufio"
+	"bytes"
 	"context"
 	"encoding/json"
 	"fmt"
@@ -14,7 +15,15 @@ import (
 	"agentic-poc/internal/tool"
 )
 
+// DefaultMaxConcurrency is the default number of requests an MCPServer
+// handles at the same time.
+const DefaultMaxConcurrency = 8
+
 // MCPServer handles incoming JSON-RPC requests and exposes tools via MCP protocol.
+//
+// Requests are handled concurrently by a bounded pool of workers, so a slow
+// tool call does not hold up tools/list or ping. Responses may therefore be
+// written in a different order than the requests arrived.
 type MCPServer struct {
 	tools   map[string]tool.Tool
 	input   io.Reader
@@ -22,26 +31,77 @@ type MCPServer struct {
 	mu      sync.Mutex
 	running bool
 
+	maxConcurrency int
+	source         tool.Provider
+	readOnly       bool
+
+	// logLevel is the minimum level sent as notifications/message. It is
+	// empty, sending nothing, until the client calls logging/setLevel.
+	logMu    sync.Mutex
+	logLevel string
+
+	// inflight holds the cancel function of each request being handled,
+	// keyed by requestKey, for notifications/cancelled.
+	inflightMu sync.Mutex
+	inflight   map[string]context.CancelCauseFunc
+
 	// Server info
 	name    string
 	version string
 }
 
+// ServerOption configures an MCPServer.
+type ServerOption func(*MCPServer)
+
+// WithMaxConcurrency sets how many requests are handled at once.
+// Values below 1 are ignored.
+func WithMaxConcurrency(n int) ServerOption {
+	return func(s *MCPServer) {
+		if n > 0 {
+			s.maxConcurrency = n
+		}
+	}
+}
+
+// WithToolSource makes the server list and call the tools supplied by src,
+// re-read on every request, in addition to the tools given to NewMCPServer.
+// Tools given to NewMCPServer win on name conflicts.
+func WithToolSource(src tool.Provider) ServerOption {
+	return func(s *MCPServer) {
+		s.source = src
+	}
+}
+
+// WithReadOnly hides tools that are not annotated as read-only and refuses
+// calls to them.
+func WithReadOnly() ServerOption {
+	return func(s *MCPServer) {
+		s.readOnly = true
+	}
+}
+
 // NewMCPServer creates a new MCP server with the given tools.
-func NewMCPServer(name, version string, tools []tool.Tool) *MCPServer {
+func NewMCPServer(name, version string, tools []tool.Tool, opts ...ServerOption) *MCPServer {
 	toolMap := make(map[string]tool.Tool)
 	for _, t := range tools {
 		toolMap[t.Name()] = t
 	}
-	return &MCPServer{
-		tools:   toolMap,
-		name:    name,
-		version: version,
+	s := &MCPServer{
+		tools:          toolMap,
+		name:           name,
+		version:        version,
+		maxConcurrency: DefaultMaxConcurrency,
+		inflight:       make(map[string]context.CancelCauseFunc),
+	}
+	for _, opt := range opts {
+		opt(s)
 	}
+	return s
 }
 
 // Serve starts the MCP server, reading from input and writing to output.
-// It blocks until the context is cancelled or an error occurs.
+// It blocks until the context is cancelled or an error occurs, and waits
+// for in-flight requests to finish before returning.
 func (s *MCPServer) Serve(ctx context.Context, input io.Reader, output io.Writer) error {
 	s.mu.Lock()
 	if s.running {
@@ -59,6 +119,10 @@ func (s *MCPServer) Serve(ctx context.Context, input io.Reader, output io.Writer
 		s.mu.Unlock()
 	}()
 
+	workers := make(chan struct{}, s.maxConcurrency)
+	var wg sync.WaitGroup
+	defer wg.Wait()
+
 	scanner := bufio.NewScanner(input)
 	for scanner.Scan() {
 		select {
@@ -67,18 +131,51 @@ func (s *MCPServer) Serve(ctx context.Context, input io.Reader, output io.Writer
 		default:
 		}
 
-		line := scanner.Bytes()
+		line := bytes.TrimSpace(scanner.Bytes())
 		if len(line) == 0 {
 			continue
 		}
 
-		var req JSONRPCRequest
-		if err := json.Unmarshal(line, &req); err != nil {
-			s.sendError(0, -32700, "Parse error", nil)
+		if !json.Valid(line) {
+			s.writeMessage(errorResponse(nil, ErrCodeParse, "Parse error"))
 			continue
 		}
 
-		s.handleRequest(ctx, &req)
+		// The scanner reuses its buffer, so keep a copy for the worker
+		msg := make([]byte, len(line))
+		copy(msg, line)
+
+		if msg[0] == '[' {
+			wg.Add(1)
+			go func() {
+				defer wg.Done()
+				s.handleBatch(ctx, msg, workers)
+			}()
+			continue
+		}
+
+		// Cancellations are handled inline so they are not stuck behind
+		// the requests they cancel when every worker is busy
+		if isCancellation(msg) {
+			s.handleMessage(ctx, msg)
+			continue
+		}
+
+		// Acquiring a worker here applies backpressure: once the pool is
+		// full, no further input is read until a request finishes
+		select {
+		case workers <- struct{}{}:
+		case <-ctx.Done():
+			return ctx.Err()
+		}
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			defer func() { <-workers }()
+			if resp := s.handleMessage(ctx, msg); resp != nil {
+				s.writeMessage(resp)
+			}
+		}()
 	}
 
 	if err := scanner.Err(); err != nil {
@@ -88,81 +185,220 @@ func (s *MCPServer) Serve(ctx context.Context, input io.Reader, output io.Writer
 	return nil
 }
 
-// handleRequest processes a single JSON-RPC request.
-func (s *MCPServer) handleRequest(ctx context.Context, req *JSONRPCRequest) {
-	log.Printf("[MCP Server] Received request: method=%s id=%d", req.Method, req.ID)
+// handleBatch processes a JSON-RPC batch. Its elements are handled
+// concurrently and their responses are written as a single array; a batch
+// made up only of notifications gets no response, and neither does one
+// cut short by the context being cancelled.
+func (s *MCPServer) handleBatch(ctx context.Context, data []byte, workers chan struct{}) {
+	var batch []json.RawMessage
+	if err := json.Unmarshal(data, &batch); err != nil || len(batch) == 0 {
+		s.writeMessage(errorResponse(nil, ErrCodeInvalidRequest, "Invalid Request"))
+		return
+	}
+
+	responses := make([]*JSONRPCResponse, len(batch))
+	var wg sync.WaitGroup
+	for i, raw := range batch {
+		wg.Add(1)
+		go func(i int, raw json.RawMessage) {
+			defer wg.Done()
+			select {
+			case workers <- struct{}{}:
+			case <-ctx.Done():
+				return
+			}
+			defer func() { <-workers }()
+			responses[i] = s.handleMessage(ctx, raw)
+		}(i, raw)
+	}
+	wg.Wait()
+
+	// The connection is closing, and some elements were never handled
+	if ctx.Err() != nil {
+		return
+	}
+
+	results := make([]*JSONRPCResponse, 0, len(responses))
+	for _, resp := range responses {
+		if resp != nil {
+			results = append(results, resp)
+		}
+	}
+	if len(results) > 0 {
+		s.writeMessage(results)
+	}
+}
+
+// handleMessage processes a single JSON-RPC message and returns the
+// response to send, or nil if the message was a notification.
+func (s *MCPServer) handleMessage(ctx context.Context, data []byte) *JSONRPCResponse {
+	var req JSONRPCRequest
+	if err := json.Unmarshal(data, &req); err != nil {
+		return errorResponse(nil, ErrCodeInvalidRequest, "Invalid Request")
+	}
+	if req.Method == "" {
+		return errorResponse(req.ID, ErrCodeInvalidRequest, "Invalid Request")
+	}
+
+	if req.IsNotification() {
+		s.handleRequest(ctx, &req)
+		return nil
+	}
+
+	reqCtx, done := s.trackRequest(ctx, req.ID)
+	defer done()
+
+	result, rpcErr := s.handleRequest(reqCtx, &req)
+	if context.Cause(reqCtx) == errRequestCancelled {
+		log.Printf("[MCP Server] Request id=%s cancelled, not responding", req.ID)
+		return nil
+	}
+	if rpcErr != nil {
+		return &JSONRPCResponse{JSONRPC: "2.0", ID: req.ID, Error: rpcErr}
+	}
+	return &JSONRPCResponse{JSONRPC: "2.0", ID: req.ID, Result: result}
+}
+
+// handleRequest dispatches a request to its handler and returns either a
+// result or an error.
+func (s *MCPServer) handleRequest(ctx context.Context, req *JSONRPCRequest) (interface{}, *JSONRPCError) {
+	if req.IsNotification() {
+		log.Printf("[MCP Server] Received notification: method=%s", req.Method)
+	} else {
+		log.Printf("[MCP Server] Received request: method=%s id=%s", req.Method, req.ID)
+	}
 
 	switch req.Method {
 	case "initialize":
-		s.handleInitialize(req)
+		return s.handleInitialize(req), nil
 	case "notifications/initialized":
 		log.Printf("[MCP Server] Client initialized")
-		// Notification, no response needed
+		return nil, nil
+	case "notifications/cancelled":
+		s.handleCancelled(req)
+		return nil, nil
+	case "ping":
+		return map[string]interface{}{}, nil
 	case "tools/list":
-		s.handleToolsList(req)
+		return s.handleToolsList(req), nil
 	case "tools/call":
-		s.handleToolsCall(ctx, req)
+		return s.handleToolsCall(ctx, req)
+	case "logging/setLevel":
+		return s.handleSetLevel(req)
 	default:
 		log.Printf("[MCP Server] Unknown method: %s", req.Method)
-		s.sendError(req.ID, -32601, fmt.Sprintf("Method not found: %s", req.Method), nil)
+		return nil, &JSONRPCError{Code: ErrCodeMethodNotFound, Message: fmt.Sprintf("Method not found: %s", req.Method)}
 	}
 }
 
 // handleInitialize handles the MCP initialize request.
-func (s *MCPServer) handleInitialize(req *JSONRPCRequest) {
+func (s *MCPServer) handleInitialize(req *JSONRPCRequest) interface{} {
 	log.Printf("[MCP Server] Initializing server: %s v%s", s.name, s.version)
-	result := map[string]interface{}{
+	return map[string]interface{}{
 		"protocolVersion": "2024-11-05",
 		"capabilities": map[string]interface{}{
-			"tools": map[string]interface{}{},
+			"tools":   map[string]interface{}{},
+			"logging": map[string]interface{}{},
 		},
 		"serverInfo": map[string]interface{}{
 			"name":    s.name,
 			"version": s.version,
 		},
 	}
-	s.sendResult(req.ID, result)
+}
+
+// handleSetLevel handles the logging/setLevel request.
+func (s *MCPServer) handleSetLevel(req *JSONRPCRequest) (interface{}, *JSONRPCError) {
+	params, _ := req.Params.(map[string]interface{})
+	level, _ := params["level"].(string)
+	if err := ValidateLogLevel(level); err != nil {
+		return nil, &JSONRPCError{Code: ErrCodeInvalidParams, Message: err.Error()}
+	}
+
+	s.logMu.Lock()
+	s.logLevel = level
+	s.logMu.Unlock()
+
+	log.Printf("[MCP Server] Log level set to %s", level)
+	return map[string]interface{}{}, nil
+}
+
+// Log sends a notifications/message to the client if level is at or above
+// the level the client asked for with logging/setLevel. Nothing is sent
+// before the client has set a level. data may be any JSON value.
+func (s *MCPServer) Log(level, logger string, data interface{}) {
+	s.logMu.Lock()
+	threshold := s.logLevel
+	s.logMu.Unlock()
+
+	rank := logLevelRank(level)
+	if threshold == "" || rank < 0 || rank < logLevelRank(threshold) {
+		return
+	}
+
+	params := map[string]interface{}{
+		"level": level,
+		"data":  data,
+	}
+	if logger != "" {
+		params["logger"] = logger
+	}
+	s.writeMessage(JSONRPCRequest{
+		JSONRPC: "2.0",
+		Method:  "notifications/message",
+		Params:  params,
+	})
 }
 
 // handleToolsList handles the tools/list request.
-func (s *MCPServer) handleToolsList(req *JSONRPCRequest) {
-	tools := make([]map[string]interface{}, 0, len(s.tools))
-	for _, t := range s.tools {
-		tools = append(tools, map[string]interface{}{
+func (s *MCPServer) handleToolsList(req *JSONRPCRequest) interface{} {
+	current := s.currentTools()
+	tools := make([]map[string]interface{}, 0, len(current))
+	for _, t := range current {
+		if s.readOnly && !tool.IsReadOnly(t) {
+			continue
+		}
+		info := map[string]interface{}{
 			"name":        t.Name(),
 			"description": t.Description(),
 			"inputSchema": t.Parameters(),
-		})
+		}
+		if annotations, ok := tool.AnnotationsOf(t); ok {
+			info["annotations"] = annotations
+		}
+		tools = append(tools, info)
 	}
 
 	log.Printf("[MCP Server] Listing %d tools", len(tools))
-	result := map[string]interface{}{
+	return map[string]interface{}{
 		"tools": tools,
 	}
-	s.sendResult(req.ID, result)
 }
 
-// handleToolsCall handles the tools/call request.
-func (s *MCPServer) handleToolsCall(ctx context.Context, req *JSONRPCRequest) {
+// handleToolsCall handles the tools/call request. Tool failures are
+// reported in the result with isError set, not as JSON-RPC errors.
+func (s *MCPServer) handleToolsCall(ctx context.Context, req *JSONRPCRequest) (interface{}, *JSONRPCError) {
 	params, ok := req.Params.(map[string]interface{})
 	if !ok {
 		log.Printf("[MCP Server] Invalid params for tools/call")
-		s.sendError(req.ID, -32602, "Invalid params", nil)
-		return
+		return nil, &JSONRPCError{Code: ErrCodeInvalidParams, Message: "Invalid params"}
 	}
 
 	name, ok := params["name"].(string)
 	if !ok {
 		log.Printf("[MCP Server] Missing tool name in tools/call")
-		s.sendError(req.ID, -32602, "Missing tool name", nil)
-		return
+		return nil, &JSONRPCError{Code: ErrCodeInvalidParams, Message: "Missing tool name"}
 	}
 
-	t, exists := s.tools[name]
+	t, exists := s.currentTools()[name]
 	if !exists {
 		log.Printf("[MCP Server] Unknown tool requested: %s", name)
-		s.sendToolResult(req.ID, fmt.Sprintf("Unknown tool: %s", name), true)
-		return
+		return textToolResult(fmt.Sprintf("Unknown tool: %s", name), true), nil
+	}
+
+	if s.readOnly && !tool.IsReadOnly(t) {
+		log.Printf("[MCP Server] Refusing %q in read-only mode", name)
+		return textToolResult(fmt.Sprintf("Tool %s is not available: server is in read-only mode", name), true), nil
 	}
 
 	args, _ := params["arguments"].(map[string]interface{})
@@ -170,71 +406,111 @@ func (s *MCPServer) handleToolsCall(ctx context.Context, req *JSONRPCRequest) {
 		args = make(map[string]interface{})
 	}
 
+	// Invalid arguments are a tool error, not a protocol error, so that the
+	// calling model sees them and can retry
+	if err := tool.ValidateArguments(t.Parameters(), args); err != nil {
+		log.Printf("[MCP Server] Invalid arguments for tool %q: %v", name, err)
+		return textToolResult(fmt.Sprintf("Invalid arguments for tool %s: %v", name, err), true), nil
+	}
+
+	// Stream progress from long-running tools when the client asked for it
+	if token := progressToken(params); token != nil {
+		ctx = tool.WithProgress(ctx, s.progressReporter(token))
+	}
+
 	log.Printf("[MCP Server] Executing tool %q with args: %v", name, args)
+	s.Log(LogLevelDebug, name, fmt.Sprintf("Executing tool %s", name))
 
 	result, err := t.Execute(ctx, args)
 	if err != nil {
 		log.Printf("[MCP Server] Tool %q execution error: %v", name, err)
-		s.sendToolResult(req.ID, fmt.Sprintf("Tool execution error: %v", err), true)
-		return
+		s.Log(LogLevelError, name, fmt.Sprintf("Tool %s execution error: %v", name, err))
+		return textToolResult(fmt.Sprintf("Tool execution error: %v", err), true), nil
 	}
 
 	if !result.Success {
 		log.Printf("[MCP Server] Tool %q returned error: %s", name, result.Error)
-		s.sendToolResult(req.ID, result.Error, true)
-		return
+		s.Log(LogLevelWarning, name, fmt.Sprintf("Tool %s failed: %s", name, result.Error))
+		return textToolResult(result.Error, true), nil
 	}
 
 	log.Printf("[MCP Server] Tool %q succeeded: %s", name, result.Output)
-	s.sendToolResult(req.ID, result.Output, false)
+	s.Log(LogLevelInfo, name, fmt.Sprintf("Tool %s succeeded", name))
+	return toolResultToMCP(result), nil
 }
 
-// sendResult sends a successful JSON-RPC response.
-func (s *MCPServer) sendResult(id int, result interface{}) {
-	resp := JSONRPCResponse{
-		JSONRPC: "2.0",
-		ID:      id,
-		Result:  result,
+// currentTools returns the server's tools merged with those from its tool
+// source, if any.
+func (s *MCPServer) currentTools() map[string]tool.Tool {
+	if s.source == nil {
+		return s.tools
 	}
-	s.writeResponse(resp)
+
+	dynamic := s.source.GetTools()
+	tools := make(map[string]tool.Tool, len(s.tools)+len(dynamic))
+	for _, t := range dynamic {
+		tools[t.Name()] = t
+	}
+	for name, t := range s.tools {
+		tools[name] = t
+	}
+	return tools
 }
 
-// sendError sends a JSON-RPC error response.
-func (s *MCPServer) sendError(id int, code int, message string, data interface{}) {
-	resp := JSONRPCResponse{
-		JSONRPC: "2.0",
-		ID:      id,
-		Error: &JSONRPCError{
-			Code:    code,
-			Message: message,
-			Data:    data,
-		},
+// progressToken returns the _meta.progressToken of a request, or nil if the
+// client did not ask for progress notifications.
+func progressToken(params map[string]interface{}) interface{} {
+	meta, _ := params["_meta"].(map[string]interface{})
+	switch token := meta["progressToken"].(type) {
+	case string, float64:
+		return token
+	default:
+		return nil
 	}
-	s.writeResponse(resp)
 }
 
-// sendToolResult sends a tool call result in MCP format.
-func (s *MCPServer) sendToolResult(id int, content string, isError bool) {
-	result := map[string]interface{}{
-		"content": []map[string]interface{}{
-			{
-				"type": "text",
-				"text": content,
+// progressReporter returns a ProgressFunc that sends notifications/progress
+// for token. The progress value counts the updates sent, so it increases
+// with every notification as the protocol requires.
+func (s *MCPServer) progressReporter(token interface{}) tool.ProgressFunc {
+	var mu sync.Mutex
+	count := 0
+	return func(message string) {
+		// Hold the lock while writing so updates go out in order
+		mu.Lock()
+		defer mu.Unlock()
+		count++
+
+		s.writeMessage(JSONRPCRequest{
+			JSONRPC: "2.0",
+			Method:  "notifications/progress",
+			Params: map[string]interface{}{
+				"progressToken": token,
+				"progress":      count,
+				"message":       message,
 			},
-		},
-		"isError": isError,
+		})
 	}
-	s.sendResult(id, result)
 }
 
-// writeResponse writes a JSON-RPC response to the output.
-func (s *MCPServer) writeResponse(resp JSONRPCResponse) {
-	s.mu.Lock()
-	defer s.mu.Unlock()
+// errorResponse builds a JSON-RPC error response. A nil id is sent as null.
+func errorResponse(id json.RawMessage, code int, message string) *JSONRPCResponse {
+	return &JSONRPCResponse{
+		JSONRPC: "2.0",
+		ID:      id,
+		Error:   &JSONRPCError{Code: code, Message: message},
+	}
+}
 
-	data, err := json.Marshal(resp)
+// writeMessage writes a JSON-RPC message, or a batch of them, to the output.
+func (s *MCPServer) writeMessage(msg interface{}) {
+	data, err := json.Marshal(msg)
 	if err != nil {
+		log.Printf("[MCP Server] Failed to marshal message: %v", err)
 		return
 	}
+
+	s.mu.Lock()
+	defer s.mu.Unlock()
 	s.output.Write(append(data, '\n'))
 }
@@ -1,13 +1,17 @@
 package mcp
 
 import (
+	"bufio"
 	"bytes"
 	"context"
 	"encoding/json"
+	"fmt"
+	"io"
 	"strings"
 	"testing"
 	"time"
 
+	"agentic-poc/internal/provider"
 	"agentic-poc/internal/tool"
 )
 
@@ -241,3 +245,478 @@ func TestMCPServer_UnknownMethod(t *testing.T) {
 		t.Errorf("Expected error code -32601, got %d", resp.Error.Code)
 	}
 }
+
+// blockingTool is a tool whose Execute waits until release is closed.
+type blockingTool struct {
+	started chan struct{}
+	release chan struct{}
+}
+
+func (b *blockingTool) Name() string        { return "slow" }
+func (b *blockingTool) Description() string { return "Blocks until released" }
+func (b *blockingTool) Parameters() map[string]interface{} {
+	return map[string]interface{}{"type": "object"}
+}
+
+func (b *blockingTool) Execute(ctx context.Context, args map[string]interface{}) (*provider.ToolResult, error) {
+	close(b.started)
+	select {
+	case <-b.release:
+		return &provider.ToolResult{Success: true, Output: "done"}, nil
+	case <-ctx.Done():
+		return nil, ctx.Err()
+	}
+}
+
+// pipeConn is the client end of an MCPServer served over in-memory pipes.
+type pipeConn struct {
+	t      *testing.T
+	writer io.WriteCloser
+	reader *bufio.Reader
+}
+
+// servePipe runs server.Serve on in-memory pipes until the test ends.
+func servePipe(t *testing.T, server *MCPServer) *pipeConn {
+	t.Helper()
+
+	serverIn, clientOut := io.Pipe()
+	clientIn, serverOut := io.Pipe()
+
+	ctx, cancel := context.WithCancel(context.Background())
+	done := make(chan struct{})
+	go func() {
+		defer close(done)
+		server.Serve(ctx, serverIn, serverOut)
+		serverOut.Close()
+	}()
+
+	t.Cleanup(func() {
+		cancel()
+		clientOut.Close()
+		<-done
+	})
+
+	return &pipeConn{t: t, writer: clientOut, reader: bufio.NewReader(clientIn)}
+}
+
+// send writes a raw line to the server.
+func (c *pipeConn) send(line string) {
+	c.t.Helper()
+	if _, err := c.writer.Write([]byte(line + "\n")); err != nil {
+		c.t.Fatalf("write failed: %v", err)
+	}
+}
+
+// readLine reads the next raw line from the server, failing after a timeout.
+func (c *pipeConn) readLine() []byte {
+	c.t.Helper()
+	ch := make(chan []byte, 1)
+	go func() {
+		line, _ := c.reader.ReadBytes('\n')
+		ch <- line
+	}()
+	select {
+	case line := <-ch:
+		return line
+	case <-time.After(2 * time.Second):
+		c.t.Fatal("timed out waiting for server response")
+		return nil
+	}
+}
+
+// read reads and parses the next single response from the server.
+func (c *pipeConn) read() JSONRPCResponse {
+	c.t.Helper()
+	var resp JSONRPCResponse
+	line := c.readLine()
+	if err := json.Unmarshal(line, &resp); err != nil {
+		c.t.Fatalf("failed to parse response %q: %v", line, err)
+	}
+	return resp
+}
+
+func TestMCPServer_IDTypes(t *testing.T) {
+	tests := []struct {
+		name string
+		id   string
+	}{
+		{name: "number", id: `42`},
+		{name: "string", id: `"abc-1"`},
+		{name: "null", id: `null`},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			conn := servePipe(t, NewMCPServer("test", "1.0.0", nil))
+			conn.send(`{"jsonrpc":"2.0","id":` + tt.id + `,"method":"ping"}`)
+
+			resp := conn.read()
+			if string(resp.ID) != tt.id {
+				t.Errorf("expected ID %s to be echoed, got %s", tt.id, resp.ID)
+			}
+			if resp.Error != nil {
+				t.Errorf("unexpected error: %v", resp.Error)
+			}
+		})
+	}
+}
+
+func TestMCPServer_NotificationsGetNoResponse(t *testing.T) {
+	conn := servePipe(t, NewMCPServer("test", "1.0.0", nil))
+
+	// Neither a known nor an unknown notification may be answered
+	conn.send(`{"jsonrpc":"2.0","method":"notifications/initialized"}`)
+	conn.send(`{"jsonrpc":"2.0","method":"unknown/notification"}`)
+	conn.send(`{"jsonrpc":"2.0","id":9,"method":"ping"}`)
+
+	resp := conn.read()
+	if string(resp.ID) != "9" {
+		t.Errorf("expected only the ping response, got ID %s", resp.ID)
+	}
+}
+
+func TestMCPServer_ParseAndInvalidRequestErrors(t *testing.T) {
+	tests := []struct {
+		name     string
+		input    string
+		wantCode int
+		wantID   string
+	}{
+		{name: "malformed JSON", input: `{"jsonrpc":`, wantCode: ErrCodeParse, wantID: "null"},
+		{name: "not an object", input: `123`, wantCode: ErrCodeInvalidRequest, wantID: "null"},
+		{name: "missing method", input: `{"jsonrpc":"2.0","id":3}`, wantCode: ErrCodeInvalidRequest, wantID: "3"},
+		{name: "empty batch", input: `[]`, wantCode: ErrCodeInvalidRequest, wantID: "null"},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			conn := servePipe(t, NewMCPServer("test", "1.0.0", nil))
+			conn.send(tt.input)
+
+			resp := conn.read()
+			if resp.Error == nil || resp.Error.Code != tt.wantCode {
+				t.Fatalf("expected error code %d, got %+v", tt.wantCode, resp.Error)
+			}
+			if string(resp.ID) != tt.wantID {
+				t.Errorf("expected ID %s, got %s", tt.wantID, resp.ID)
+			}
+		})
+	}
+}
+
+func TestMCPServer_Batch(t *testing.T) {
+	conn := servePipe(t, NewMCPServer("test", "1.0.0", []tool.Tool{tool.NewCalculatorTool()}))
+
+	conn.send(`[` +
+		`{"jsonrpc":"2.0","id":1,"method":"ping"},` +
+		`{"jsonrpc":"2.0","method":"notifications/initialized"},` +
+		`{"jsonrpc":"2.0","id":"two","method":"tools/list"},` +
+		`{"jsonrpc":"2.0","id":3,"method":"nope"}` +
+		`]`)
+
+	var responses []JSONRPCResponse
+	if err := json.Unmarshal(conn.readLine(), &responses); err != nil {
+		t.Fatalf("expected batch response: %v", err)
+	}
+	if len(responses) != 3 {
+		t.Fatalf("expected 3 responses (notification omitted), got %d", len(responses))
+	}
+
+	byID := make(map[string]JSONRPCResponse)
+	for _, r := range responses {
+		byID[string(r.ID)] = r
+	}
+	if r, ok := byID["1"]; !ok || r.Error != nil {
+		t.Errorf("ping response missing or failed: %+v", r)
+	}
+	if r, ok := byID[`"two"`]; !ok || r.Result == nil {
+		t.Errorf("tools/list response missing: %+v", r)
+	}
+	if r, ok := byID["3"]; !ok || r.Error == nil || r.Error.Code != ErrCodeMethodNotFound {
+		t.Errorf("expected method not found for ID 3: %+v", r)
+	}
+}
+
+func TestMCPServer_NotificationOnlyBatch(t *testing.T) {
+	conn := servePipe(t, NewMCPServer("test", "1.0.0", nil))
+
+	conn.send(`[{"jsonrpc":"2.0","method":"notifications/initialized"}]`)
+	conn.send(`{"jsonrpc":"2.0","id":1,"method":"ping"}`)
+
+	resp := conn.read()
+	if string(resp.ID) != "1" {
+		t.Errorf("notification-only batch should not be answered, got ID %s", resp.ID)
+	}
+}
+
+func TestMCPServer_SlowToolDoesNotBlockPing(t *testing.T) {
+	slow := &blockingTool{started: make(chan struct{}), release: make(chan struct{})}
+	conn := servePipe(t, NewMCPServer("test", "1.0.0", []tool.Tool{slow}))
+
+	conn.send(`{"jsonrpc":"2.0","id":1,"method":"tools/call","params":{"name":"slow"}}`)
+	<-slow.started
+
+	conn.send(`{"jsonrpc":"2.0","id":2,"method":"ping"}`)
+	if resp := conn.read(); string(resp.ID) != "2" {
+		t.Fatalf("expected ping response first, got ID %s", resp.ID)
+	}
+
+	close(slow.release)
+	if resp := conn.read(); string(resp.ID) != "1" {
+		t.Errorf("expected tool response, got ID %s", resp.ID)
+	}
+}
+
+func TestMCPServer_BatchCancelledWhileWorkersBusy(t *testing.T) {
+	server := NewMCPServer("test", "1.0.0", nil)
+	output := &bytes.Buffer{}
+	server.output = output
+
+	// Every worker is taken, and the connection is cancelled
+	workers := make(chan struct{}, 1)
+	workers <- struct{}{}
+	ctx, cancel := context.WithCancel(context.Background())
+	cancel()
+
+	done := make(chan struct{})
+	go func() {
+		defer close(done)
+		server.handleBatch(ctx, []byte(`[{"jsonrpc":"2.0","id":1,"method":"ping"},{"jsonrpc":"2.0","id":2,"method":"ping"}]`), workers)
+	}()
+	select {
+	case <-done:
+	case <-time.After(2 * time.Second):
+		t.Fatal("handleBatch kept waiting for a worker after cancellation")
+	}
+	if output.Len() != 0 {
+		t.Errorf("expected no response, got %s", output.String())
+	}
+}
+
+func TestMCPServer_MaxConcurrency(t *testing.T) {
+	slow := &blockingTool{started: make(chan struct{}), release: make(chan struct{})}
+	conn := servePipe(t, NewMCPServer("test", "1.0.0", []tool.Tool{slow}, WithMaxConcurrency(1)))
+
+	conn.send(`{"jsonrpc":"2.0","id":1,"method":"tools/call","params":{"name":"slow"}}`)
+	<-slow.started
+	conn.send(`{"jsonrpc":"2.0","id":2,"method":"ping"}`)
+
+	// With a single worker the ping has to wait for the tool call
+	close(slow.release)
+	if resp := conn.read(); string(resp.ID) != "1" {
+		t.Errorf("expected tool response first with one worker, got ID %s", resp.ID)
+	}
+	if resp := conn.read(); string(resp.ID) != "2" {
+		t.Errorf("expected ping response second, got ID %s", resp.ID)
+	}
+}
+
+func TestMCPServer_CancelledRequest(t *testing.T) {
+	slow := &blockingTool{started: make(chan struct{}), release: make(chan struct{})}
+	conn := servePipe(t, NewMCPServer("test", "1.0.0", []tool.Tool{slow}, WithMaxConcurrency(1)))
+
+	conn.send(`{"jsonrpc":"2.0","id":"call-1","method":"tools/call","params":{"name":"slow"}}`)
+	<-slow.started
+
+	// The cancellation gets through even though the only worker is busy,
+	// and the cancelled request gets no response
+	conn.send(`{"jsonrpc":"2.0","method":"notifications/cancelled","params":{"requestId":"call-1","reason":"user interrupt"}}`)
+	conn.send(`{"jsonrpc":"2.0","id":2,"method":"ping"}`)
+	if resp := conn.read(); string(resp.ID) != "2" {
+		t.Errorf("expected only the ping response, got ID %s", resp.ID)
+	}
+
+	// Cancelling an unknown or finished request is ignored
+	conn.send(`{"jsonrpc":"2.0","method":"notifications/cancelled","params":{"requestId":"call-1"}}`)
+	conn.send(`{"jsonrpc":"2.0","id":3,"method":"ping"}`)
+	if resp := conn.read(); string(resp.ID) != "3" {
+		t.Errorf("expected ping response, got ID %s", resp.ID)
+	}
+}
+
+func TestRequestKey(t *testing.T) {
+	tests := []struct {
+		a, b string
+		same bool
+	}{
+		{`7`, `7.0`, true},
+		{`"abc"`, ` "abc" `, true},
+		{`7`, `"7"`, false},
+		{`1`, `2`, false},
+	}
+	for _, tt := range tests {
+		if got := requestKey(json.RawMessage(tt.a)) == requestKey(json.RawMessage(tt.b)); got != tt.same {
+			t.Errorf("requestKey(%s) == requestKey(%s) is %v, want %v", tt.a, tt.b, got, tt.same)
+		}
+	}
+}
+
+// progressTool reports two progress updates before succeeding.
+type progressTool struct{}
+
+func (progressTool) Name() string        { return "progress" }
+func (progressTool) Description() string { return "Reports progress" }
+func (progressTool) Parameters() map[string]interface{} {
+	return map[string]interface{}{"type": "object"}
+}
+
+func (progressTool) Execute(ctx context.Context, args map[string]interface{}) (*provider.ToolResult, error) {
+	tool.ReportProgress(ctx, "step one")
+	tool.ReportProgress(ctx, "step two")
+	return &provider.ToolResult{Success: true, Output: "ok"}, nil
+}
+
+func TestMCPServer_ProgressNotifications(t *testing.T) {
+	conn := servePipe(t, NewMCPServer("test", "1.0.0", []tool.Tool{progressTool{}}))
+
+	conn.send(`{"jsonrpc":"2.0","id":1,"method":"tools/call","params":{"name":"progress","_meta":{"progressToken":"tok"}}}`)
+
+	for i, want := range []string{"step one", "step two"} {
+		var note struct {
+			ID     json.RawMessage `json:"id"`
+			Method string          `json:"method"`
+			Params struct {
+				ProgressToken string  `json:"progressToken"`
+				Progress      float64 `json:"progress"`
+				Message       string  `json:"message"`
+			} `json:"params"`
+		}
+		if err := json.Unmarshal(conn.readLine(), &note); err != nil {
+			t.Fatalf("failed to parse notification: %v", err)
+		}
+		if note.Method != "notifications/progress" || len(note.ID) != 0 {
+			t.Fatalf("expected progress notification, got %+v", note)
+		}
+		if note.Params.ProgressToken != "tok" || note.Params.Message != want || note.Params.Progress != float64(i+1) {
+			t.Errorf("unexpected progress params: %+v", note.Params)
+		}
+	}
+
+	if resp := conn.read(); string(resp.ID) != "1" || resp.Error != nil {
+		t.Errorf("expected tool response after progress, got %+v", resp)
+	}
+}
+
+func TestMCPServer_NoProgressWithoutToken(t *testing.T) {
+	conn := servePipe(t, NewMCPServer("test", "1.0.0", []tool.Tool{progressTool{}}))
+
+	conn.send(`{"jsonrpc":"2.0","id":1,"method":"tools/call","params":{"name":"progress"}}`)
+
+	if resp := conn.read(); string(resp.ID) != "1" {
+		t.Errorf("expected the response with no notifications first, got %+v", resp)
+	}
+}
+
+func TestMCPServer_ToolAnnotations(t *testing.T) {
+	conn := servePipe(t, NewMCPServer("test", "1.0.0", []tool.Tool{tool.NewCalculatorTool(), &stubTool{name: "plain"}}))
+
+	conn.send(`{"jsonrpc":"2.0","id":1,"method":"tools/list"}`)
+	tools := conn.read().Result.(map[string]interface{})["tools"].([]interface{})
+
+	found := make(map[string]map[string]interface{})
+	for _, raw := range tools {
+		info := raw.(map[string]interface{})
+		annotations, _ := info["annotations"].(map[string]interface{})
+		found[info["name"].(string)] = annotations
+	}
+
+	if calc := found["calculator"]; calc["readOnlyHint"] != true || calc["destructiveHint"] != false {
+		t.Errorf("unexpected calculator annotations: %v", calc)
+	}
+	if plain := found["plain"]; plain != nil {
+		t.Errorf("unannotated tool should have no annotations, got %v", plain)
+	}
+}
+
+func TestMCPServer_ReadOnly(t *testing.T) {
+	writer := tool.NewFileWriterTool(t.TempDir())
+	conn := servePipe(t, NewMCPServer("test", "1.0.0", []tool.Tool{tool.NewCalculatorTool(), writer}, WithReadOnly()))
+
+	conn.send(`{"jsonrpc":"2.0","id":1,"method":"tools/list"}`)
+	tools := conn.read().Result.(map[string]interface{})["tools"].([]interface{})
+	if len(tools) != 1 || tools[0].(map[string]interface{})["name"] != "calculator" {
+		t.Errorf("expected only calculator in read-only mode, got %v", tools)
+	}
+
+	conn.send(`{"jsonrpc":"2.0","id":2,"method":"tools/call","params":{"name":"write_file","arguments":{"path":"a.txt","content":"x"}}}`)
+	result := conn.read().Result.(map[string]interface{})
+	if isError, _ := result["isError"].(bool); !isError {
+		t.Fatal("write_file should be refused in read-only mode")
+	}
+	text := result["content"].([]interface{})[0].(map[string]interface{})["text"].(string)
+	if !strings.Contains(text, "read-only") {
+		t.Errorf("expected read-only explanation, got %q", text)
+	}
+}
+
+func TestMCPServer_SetLevelAndLogMessages(t *testing.T) {
+	server := NewMCPServer("test", "1.0.0", []tool.Tool{tool.NewCalculatorTool()})
+	conn := servePipe(t, server)
+
+	conn.send(`{"jsonrpc":"2.0","id":1,"method":"logging/setLevel","params":{"level":"loud"}}`)
+	if resp := conn.read(); resp.Error == nil || resp.Error.Code != ErrCodeInvalidParams {
+		t.Fatalf("expected invalid params for unknown level, got %+v", resp)
+	}
+
+	conn.send(`{"jsonrpc":"2.0","id":2,"method":"logging/setLevel","params":{"level":"warning"}}`)
+	if resp := conn.read(); resp.Error != nil {
+		t.Fatalf("setLevel failed: %+v", resp.Error)
+	}
+
+	// Below the threshold: nothing is sent, so the next line is the ping response
+	server.Log(LogLevelInfo, "test", "ignored")
+	conn.send(`{"jsonrpc":"2.0","id":3,"method":"ping"}`)
+	if resp := conn.read(); string(resp.ID) != "3" {
+		t.Fatalf("expected ping response, got %+v", resp)
+	}
+
+	// The pipe is unbuffered, so log from another goroutine while we read
+	go server.Log(LogLevelError, "test", map[string]interface{}{"code": 7})
+	var note struct {
+		Method string `json:"method"`
+		Params struct {
+			Level  string                 `json:"level"`
+			Logger string                 `json:"logger"`
+			Data   map[string]interface{} `json:"data"`
+		} `json:"params"`
+	}
+	if err := json.Unmarshal(conn.readLine(), &note); err != nil {
+		t.Fatalf("failed to parse notification: %v", err)
+	}
+	if note.Method != "notifications/message" || note.Params.Level != "error" ||
+		note.Params.Logger != "test" || note.Params.Data["code"] != float64(7) {
+		t.Errorf("unexpected log notification: %+v", note)
+	}
+}
+
+func TestMCPServer_NoLogMessagesByDefault(t *testing.T) {
+	server := NewMCPServer("test", "1.0.0", nil)
+	conn := servePipe(t, server)
+
+	server.Log(LogLevelEmergency, "", "not requested")
+	conn.send(`{"jsonrpc":"2.0","id":1,"method":"ping"}`)
+	if resp := conn.read(); string(resp.ID) != "1" {
+		t.Errorf("expected no log message before setLevel, got %+v", resp)
+	}
+}
+
+func TestMCPServer_ToolsCall_InvalidArguments(t *testing.T) {
+	conn := servePipe(t, NewMCPServer("test", "1.0.0", []tool.Tool{tool.NewCalculatorTool()}))
+
+	conn.send(`{"jsonrpc":"2.0","id":1,"method":"tools/call","params":{"name":"calculator","arguments":{"operation":"pow","a":"2"}}}`)
+
+	resp := conn.read()
+	if resp.Error != nil {
+		t.Fatalf("expected a tool error result, got JSON-RPC error %+v", resp.Error)
+	}
+	result, _ := resp.Result.(map[string]interface{})
+	if result["isError"] != true {
+		t.Fatalf("expected isError result, got %v", resp.Result)
+	}
+	text := fmt.Sprint(result["content"])
+	for _, want := range []string{"b: is required", "a: expected number, got string", `operation: must be one of`} {
+		if !strings.Contains(text, want) {
+			t.Errorf("expected %q in %s", want, text)
+		}
+	}
+}
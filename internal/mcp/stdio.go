@@ -8,18 +8,25 @@ import (
 	"io"
 	"log"
 	"os/exec"
+	"strconv"
 	"sync"
 	"sync/atomic"
 
 	"agentic-poc/internal/provider"
+	"agentic-poc/internal/tool"
 )
 
 // StdioMCPClient spawns an MCP server as a subprocess and communicates
 // via JSON-RPC 2.0 over stdin/stdout.
+//
+// A background read loop demultiplexes the server's stdout: responses are
+// routed to the waiting request by ID, while server-initiated requests
+// (such as sampling/createMessage) are dispatched to registered handlers.
 type StdioMCPClient struct {
 	command string
 	args    []string
 	env     map[string]string
+	dir     string
 
 	cmd    *exec.Cmd
 	stdin  io.WriteCloser
@@ -29,6 +36,33 @@ type StdioMCPClient struct {
 	requestID atomic.Int64
 	mu        sync.Mutex
 	connected bool
+
+	writeMu   sync.Mutex
+	pendingMu sync.Mutex
+	pending   map[int]chan *JSONRPCResponse
+	done      chan struct{}
+
+	// lifetime bounds work done for server-initiated requests; it ends
+	// when the client is closed or the connection drops
+	lifetime context.Context
+	stop     context.CancelFunc
+
+	sampling       SamplingFunc
+	onToolsChanged func()
+	onProgress     func(ProgressEvent)
+	onLogMessage   func(LogMessage)
+
+	supportsLogging bool // Server advertised the logging capability
+
+	progressSeq atomic.Int64
+	progressMu  sync.Mutex
+	progress    map[string]progressTarget // progressToken -> in-flight tool call
+}
+
+// progressTarget identifies the tool call a progress token was issued for.
+type progressTarget struct {
+	tool     string
+	reporter tool.ProgressFunc // From the caller's context, may be nil
 }
 
 // NewStdioMCPClient creates a new StdioMCPClient with the given command and arguments.
@@ -40,6 +74,73 @@ func NewStdioMCPClient(command string, args []string, env map[string]string) *St
 	}
 }
 
+// SetWorkingDir sets the directory the server process is started in.
+// An empty dir uses the current working directory.
+func (c *StdioMCPClient) SetWorkingDir(dir string) {
+	c.dir = dir
+}
+
+// OnProgress registers a callback for notifications/progress about tool
+// calls made by this client. It runs on the read loop, so it must not block
+// or make requests to the server.
+func (c *StdioMCPClient) OnProgress(fn func(ProgressEvent)) {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	c.onProgress = fn
+}
+
+// OnLogMessage registers a callback for notifications/message log entries
+// from the server. Like OnProgress, it runs on the read loop.
+func (c *StdioMCPClient) OnLogMessage(fn func(LogMessage)) {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	c.onLogMessage = fn
+}
+
+// SetLogLevel asks the server to send log messages at level and above.
+func (c *StdioMCPClient) SetLogLevel(ctx context.Context, level string) error {
+	if err := ValidateLogLevel(level); err != nil {
+		return err
+	}
+	if !c.isConnected() {
+		return fmt.Errorf("not connected to MCP server")
+	}
+
+	c.mu.Lock()
+	supported := c.supportsLogging
+	c.mu.Unlock()
+	if !supported {
+		return ErrLoggingNotSupported
+	}
+
+	resp, err := c.sendRequest(ctx, "logging/setLevel", map[string]interface{}{"level": level})
+	if err != nil {
+		return fmt.Errorf("logging/setLevel failed: %w", err)
+	}
+	if resp.Error != nil {
+		return fmt.Errorf("logging/setLevel error: %s", resp.Error.Message)
+	}
+	return nil
+}
+
+// SetSamplingHandler registers the function used to answer sampling/createMessage
+// requests from the server. It must be called before Connect so that the
+// sampling capability is advertised during initialization.
+func (c *StdioMCPClient) SetSamplingHandler(fn SamplingFunc) {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	c.sampling = fn
+}
+
+// OnToolsChanged registers a callback invoked when the server sends
+// notifications/tools/list_changed. The callback runs on its own goroutine,
+// so it may call ListTools.
+func (c *StdioMCPClient) OnToolsChanged(fn func()) {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	c.onToolsChanged = fn
+}
+
 // Connect starts the MCP server subprocess and initializes the connection.
 func (c *StdioMCPClient) Connect(ctx context.Context) error {
 	c.mu.Lock()
@@ -50,6 +151,8 @@ func (c *StdioMCPClient) Connect(ctx context.Context) error {
 	}
 
 	c.cmd = exec.CommandContext(ctx, c.command, c.args...)
+	c.cmd.Dir = c.dir
+	setProcessGroup(c.cmd)
 
 	// Set environment variables
 	if len(c.env) > 0 {
@@ -63,13 +166,11 @@ func (c *StdioMCPClient) Connect(ctx context.Context) error {
 	if err != nil {
 		return fmt.Errorf("failed to create stdin pipe: %w", err)
 	}
-	c.stdin = stdin
 
 	stdout, err := c.cmd.StdoutPipe()
 	if err != nil {
 		return fmt.Errorf("failed to create stdout pipe: %w", err)
 	}
-	c.stdout = bufio.NewReader(stdout)
 
 	stderr, err := c.cmd.StderrPipe()
 	if err != nil {
@@ -84,21 +185,28 @@ func (c *StdioMCPClient) Connect(ctx context.Context) error {
 	// Start goroutine to read and log server stderr
 	go c.logServerStderr()
 
+	// Start goroutine to demultiplex server messages
+	c.start(stdout, stdin)
+
 	// Send initialize request
-	if err := c.initialize(ctx); err != nil {
+	logging, err := c.initialize(ctx)
+	if err != nil {
 		c.cmd.Process.Kill()
 		return fmt.Errorf("failed to initialize MCP connection: %w", err)
 	}
 
+	c.supportsLogging = logging
 	c.connected = true
 	return nil
 }
 
-// initialize sends the MCP initialize request and waits for response.
-func (c *StdioMCPClient) initialize(ctx context.Context) error {
+// initialize sends the MCP initialize request and waits for response. It
+// reports whether the server supports logging, for Connect to record, since
+// Connect holds c.mu while it waits.
+func (c *StdioMCPClient) initialize(ctx context.Context) (logging bool, err error) {
 	initParams := map[string]interface{}{
 		"protocolVersion": "2024-11-05",
-		"capabilities":    map[string]interface{}{},
+		"capabilities":    c.capabilities(),
 		"clientInfo": map[string]interface{}{
 			"name":    "agentic-poc",
 			"version": "1.0.0",
@@ -107,13 +215,17 @@ func (c *StdioMCPClient) initialize(ctx context.Context) error {
 
 	resp, err := c.sendRequest(ctx, "initialize", initParams)
 	if err != nil {
-		return fmt.Errorf("initialize request failed: %w", err)
+		return false, fmt.Errorf("initialize request failed: %w", err)
 	}
 
 	if resp.Error != nil {
-		return fmt.Errorf("initialize error: %s", resp.Error.Message)
+		return false, fmt.Errorf("initialize error: %s", resp.Error.Message)
 	}
 
+	result, _ := resp.Result.(map[string]interface{})
+	capabilities, _ := result["capabilities"].(map[string]interface{})
+	_, logging = capabilities["logging"]
+
 	// Send initialized notification
 	notification := JSONRPCRequest{
 		JSONRPC: "2.0",
@@ -121,44 +233,82 @@ func (c *StdioMCPClient) initialize(ctx context.Context) error {
 	}
 
 	if err := c.writeMessage(notification); err != nil {
-		return fmt.Errorf("failed to send initialized notification: %w", err)
+		return false, fmt.Errorf("failed to send initialized notification: %w", err)
 	}
 
-	return nil
+	return logging, nil
 }
 
-// ListTools retrieves the list of available tools from the MCP server.
-func (c *StdioMCPClient) ListTools(ctx context.Context) ([]MCPToolInfo, error) {
-	c.mu.Lock()
-	defer c.mu.Unlock()
+// capabilities returns the client capabilities advertised during initialization.
+func (c *StdioMCPClient) capabilities() map[string]interface{} {
+	caps := map[string]interface{}{}
+	if c.sampling != nil {
+		caps["sampling"] = map[string]interface{}{}
+	}
+	return caps
+}
 
-	if !c.connected {
+// maxToolListPages bounds tools/list pagination to guard against servers
+// that never stop returning a cursor.
+const maxToolListPages = 100
+
+// ListTools retrieves the list of available tools from the MCP server,
+// following nextCursor until all pages have been read.
+func (c *StdioMCPClient) ListTools(ctx context.Context) ([]MCPToolInfo, error) {
+	if !c.isConnected() {
 		return nil, fmt.Errorf("not connected to MCP server")
 	}
 
-	resp, err := c.sendRequest(ctx, "tools/list", nil)
-	if err != nil {
-		return nil, fmt.Errorf("tools/list request failed: %w", err)
-	}
+	tools := make([]MCPToolInfo, 0)
+	cursor := ""
+	for page := 0; page < maxToolListPages; page++ {
+		var params interface{}
+		if cursor != "" {
+			params = map[string]interface{}{"cursor": cursor}
+		}
 
-	if resp.Error != nil {
-		return nil, fmt.Errorf("tools/list error: %s", resp.Error.Message)
+		resp, err := c.sendRequest(ctx, "tools/list", params)
+		if err != nil {
+			return nil, fmt.Errorf("tools/list request failed: %w", err)
+		}
+
+		if resp.Error != nil {
+			return nil, fmt.Errorf("tools/list error: %s", resp.Error.Message)
+		}
+
+		pageTools, next, err := parseToolsPage(resp.Result)
+		if err != nil {
+			return nil, err
+		}
+		tools = append(tools, pageTools...)
+
+		if next == "" {
+			return tools, nil
+		}
+		cursor = next
 	}
 
-	// Parse the result
-	resultMap, ok := resp.Result.(map[string]interface{})
+	return nil, fmt.Errorf("tools/list exceeded %d pages", maxToolListPages)
+}
+
+// parseToolsPage parses a single tools/list result into tool infos and the
+// cursor for the next page, if any.
+func parseToolsPage(result interface{}) ([]MCPToolInfo, string, error) {
+	resultMap, ok := result.(map[string]interface{})
 	if !ok {
-		return nil, fmt.Errorf("unexpected result type: %T", resp.Result)
+		return nil, "", fmt.Errorf("unexpected result type: %T", result)
 	}
 
+	next := getString(resultMap, "nextCursor")
+
 	toolsRaw, ok := resultMap["tools"]
 	if !ok {
-		return []MCPToolInfo{}, nil
+		return []MCPToolInfo{}, next, nil
 	}
 
 	toolsList, ok := toolsRaw.([]interface{})
 	if !ok {
-		return nil, fmt.Errorf("unexpected tools type: %T", toolsRaw)
+		return nil, "", fmt.Errorf("unexpected tools type: %T", toolsRaw)
 	}
 
 	tools := make([]MCPToolInfo, 0, len(toolsList))
@@ -177,18 +327,33 @@ func (c *StdioMCPClient) ListTools(ctx context.Context) ([]MCPToolInfo, error) {
 			info.InputSchema = schema
 		}
 
+		if raw, ok := toolMap["annotations"].(map[string]interface{}); ok {
+			info.Annotations = parseAnnotations(raw)
+		}
+
 		tools = append(tools, info)
 	}
 
-	return tools, nil
+	return tools, next, nil
+}
+
+// parseAnnotations reads tool annotations from a tools/list entry. Hints the
+// server leaves out take the protocol defaults.
+func parseAnnotations(raw map[string]interface{}) *tool.Annotations {
+	annotations := tool.DefaultAnnotations()
+	data, err := json.Marshal(raw)
+	if err != nil {
+		return nil
+	}
+	if err := json.Unmarshal(data, &annotations); err != nil {
+		return nil
+	}
+	return &annotations
 }
 
 // CallTool invokes a tool on the MCP server with the given arguments.
 func (c *StdioMCPClient) CallTool(ctx context.Context, name string, args map[string]interface{}) (*provider.ToolResult, error) {
-	c.mu.Lock()
-	defer c.mu.Unlock()
-
-	if !c.connected {
+	if !c.isConnected() {
 		return nil, fmt.Errorf("not connected to MCP server")
 	}
 
@@ -197,6 +362,12 @@ func (c *StdioMCPClient) CallTool(ctx context.Context, name string, args map[str
 		"arguments": args,
 	}
 
+	// Ask for progress notifications when someone is listening for them
+	if token := c.startProgress(ctx, name); token != "" {
+		params["_meta"] = map[string]interface{}{"progressToken": token}
+		defer c.endProgress(token)
+	}
+
 	resp, err := c.sendRequest(ctx, "tools/call", params)
 	if err != nil {
 		return &provider.ToolResult{
@@ -225,19 +396,24 @@ func (c *StdioMCPClient) CallTool(ctx context.Context, name string, args map[str
 	isError, _ := resultMap["isError"].(bool)
 
 	// Extract content
-	content := extractContent(resultMap)
+	text, blocks := extractContent(resultMap)
 
 	if isError {
 		return &provider.ToolResult{
 			Success: false,
-			Error:   content,
+			Error:   text,
 		}, nil
 	}
 
-	return &provider.ToolResult{
+	result := &provider.ToolResult{
 		Success: true,
-		Output:  content,
-	}, nil
+		Output:  text,
+	}
+	// Only attach blocks when they carry more than the text already in Output
+	if hasRichContent(blocks) {
+		result.Content = blocks
+	}
+	return result, nil
 }
 
 // Close terminates the MCP server subprocess.
@@ -245,6 +421,10 @@ func (c *StdioMCPClient) Close() error {
 	c.mu.Lock()
 	defer c.mu.Unlock()
 
+	if c.stop != nil {
+		c.stop()
+	}
+
 	if !c.connected {
 		return nil
 	}
@@ -263,6 +443,13 @@ func (c *StdioMCPClient) Close() error {
 	return nil
 }
 
+// isConnected reports whether the client has completed initialization.
+func (c *StdioMCPClient) isConnected() bool {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	return c.connected
+}
+
 // logServerStderr reads and logs the server's stderr output.
 func (c *StdioMCPClient) logServerStderr() {
 	for {
@@ -276,13 +463,35 @@ func (c *StdioMCPClient) logServerStderr() {
 	}
 }
 
-// sendRequest sends a JSON-RPC request and waits for the response.
+// start attaches the client to the server's stdout and stdin and launches
+// the read loop.
+func (c *StdioMCPClient) start(r io.Reader, w io.WriteCloser) {
+	c.stdin = w
+	c.stdout = bufio.NewReader(r)
+	c.pending = make(map[int]chan *JSONRPCResponse)
+	c.done = make(chan struct{})
+	c.lifetime, c.stop = context.WithCancel(context.Background())
+	go c.readLoop()
+}
+
+// sendRequest sends a JSON-RPC request and waits for the matching response.
 func (c *StdioMCPClient) sendRequest(ctx context.Context, method string, params interface{}) (*JSONRPCResponse, error) {
 	id := int(c.requestID.Add(1))
 
+	ch := make(chan *JSONRPCResponse, 1)
+	c.pendingMu.Lock()
+	c.pending[id] = ch
+	c.pendingMu.Unlock()
+
+	defer func() {
+		c.pendingMu.Lock()
+		delete(c.pending, id)
+		c.pendingMu.Unlock()
+	}()
+
 	req := JSONRPCRequest{
 		JSONRPC: "2.0",
-		ID:      id,
+		ID:      json.RawMessage(strconv.Itoa(id)),
 		Method:  method,
 		Params:  params,
 	}
@@ -291,12 +500,37 @@ func (c *StdioMCPClient) sendRequest(ctx context.Context, method string, params
 		return nil, fmt.Errorf("failed to write request: %w", err)
 	}
 
-	resp, err := c.readResponse(ctx)
-	if err != nil {
-		return nil, fmt.Errorf("failed to read response: %w", err)
+	select {
+	case resp := <-ch:
+		return resp, nil
+	case <-c.done:
+		return nil, fmt.Errorf("failed to read response: connection closed")
+	case <-ctx.Done():
+		// The initialize request cannot be cancelled; a failed handshake
+		// kills the server instead.
+		if method != "initialize" {
+			c.notifyCancelled(req.ID, ctx.Err())
+		}
+		return nil, ctx.Err()
 	}
+}
 
-	return resp, nil
+// notifyCancelled tells the server that the client is no longer waiting
+// for a request so it can stop working on it. It is best effort: the
+// server may already have responded, and a response that arrives later is
+// dropped by the read loop.
+func (c *StdioMCPClient) notifyCancelled(id json.RawMessage, reason error) {
+	notification := JSONRPCRequest{
+		JSONRPC: "2.0",
+		Method:  "notifications/cancelled",
+		Params: map[string]interface{}{
+			"requestId": id,
+			"reason":    reason.Error(),
+		},
+	}
+	if err := c.writeMessage(notification); err != nil {
+		log.Printf("[MCP Client] Failed to cancel request %s: %v", id, err)
+	}
 }
 
 // writeMessage writes a JSON-RPC message to stdin.
@@ -306,6 +540,9 @@ func (c *StdioMCPClient) writeMessage(msg interface{}) error {
 		return fmt.Errorf("failed to marshal message: %w", err)
 	}
 
+	c.writeMu.Lock()
+	defer c.writeMu.Unlock()
+
 	// Write message with newline delimiter
 	if _, err := c.stdin.Write(append(data, '\n')); err != nil {
 		return fmt.Errorf("failed to write message: %w", err)
@@ -314,56 +551,228 @@ func (c *StdioMCPClient) writeMessage(msg interface{}) error {
 	return nil
 }
 
-// readResponse reads a JSON-RPC response from stdout.
-func (c *StdioMCPClient) readResponse(ctx context.Context) (*JSONRPCResponse, error) {
-	// Read line from stdout
-	line, err := c.stdout.ReadBytes('\n')
-	if err != nil {
-		return nil, fmt.Errorf("failed to read response: %w", err)
-	}
+// incomingMessage is the envelope for any message read from the server.
+// Whether it is a response, a request, or a notification is determined by
+// which of ID and Method are present.
+type incomingMessage struct {
+	JSONRPC string          `json:"jsonrpc"`
+	ID      json.RawMessage `json:"id,omitempty"`
+	Method  string          `json:"method,omitempty"`
+	Params  json.RawMessage `json:"params,omitempty"`
+	Result  interface{}     `json:"result,omitempty"`
+	Error   *JSONRPCError   `json:"error,omitempty"`
+}
+
+// readLoop reads messages from stdout until EOF and dispatches each one.
+func (c *StdioMCPClient) readLoop() {
+	defer close(c.done)
+	defer c.stop()
 
-	var resp JSONRPCResponse
-	if err := json.Unmarshal(line, &resp); err != nil {
-		return nil, fmt.Errorf("failed to unmarshal response: %w", err)
+	for {
+		line, err := c.stdout.ReadBytes('\n')
+		if len(line) > 0 {
+			c.dispatch(line)
+		}
+		if err != nil {
+			return
+		}
 	}
+}
 
-	return &resp, nil
+// dispatch routes a single message from the server.
+func (c *StdioMCPClient) dispatch(line []byte) {
+	var msg incomingMessage
+	if err := json.Unmarshal(line, &msg); err != nil {
+		log.Printf("[MCP Client] Failed to parse server message: %v", err)
+		return
+	}
+
+	hasID := len(msg.ID) > 0 && string(msg.ID) != "null"
+
+	switch {
+	case msg.Method != "" && hasID:
+		// Handle asynchronously so a slow handler doesn't stall responses
+		go c.handleServerRequest(msg)
+	case msg.Method != "":
+		c.handleNotification(msg)
+	case hasID:
+		var id int
+		if err := json.Unmarshal(msg.ID, &id); err != nil {
+			log.Printf("[MCP Client] Response with unexpected ID %s", string(msg.ID))
+			return
+		}
+		c.pendingMu.Lock()
+		ch, ok := c.pending[id]
+		c.pendingMu.Unlock()
+		if !ok {
+			log.Printf("[MCP Client] Response for unknown request ID %d", id)
+			return
+		}
+		ch <- &JSONRPCResponse{
+			JSONRPC: msg.JSONRPC,
+			ID:      msg.ID,
+			Result:  msg.Result,
+			Error:   msg.Error,
+		}
+	}
 }
 
-// getString safely extracts a string from a map.
-func getString(m map[string]interface{}, key string) string {
-	if v, ok := m[key].(string); ok {
-		return v
+// handleNotification processes a notification from the server.
+func (c *StdioMCPClient) handleNotification(msg incomingMessage) {
+	switch msg.Method {
+	case "notifications/tools/list_changed":
+		c.mu.Lock()
+		fn := c.onToolsChanged
+		c.mu.Unlock()
+		if fn != nil {
+			// Run off the read loop: the callback will issue requests whose
+			// responses the read loop has to deliver
+			go fn()
+		}
+	case "notifications/progress":
+		c.handleProgress(msg.Params)
+	case "notifications/message":
+		c.handleLogMessage(msg.Params)
+	default:
+		log.Printf("[MCP Client] Ignoring notification: %s", msg.Method)
 	}
-	return ""
 }
 
-// extractContent extracts text content from an MCP tool result.
-func extractContent(resultMap map[string]interface{}) string {
-	contentRaw, ok := resultMap["content"]
-	if !ok {
+// startProgress issues a progress token for a call to the named tool if the
+// context carries a progress reporter or an OnProgress callback is set.
+// It returns "" when nobody would receive the updates.
+func (c *StdioMCPClient) startProgress(ctx context.Context, name string) string {
+	reporter := tool.ProgressReporter(ctx)
+
+	c.mu.Lock()
+	listening := c.onProgress != nil
+	c.mu.Unlock()
+	if reporter == nil && !listening {
 		return ""
 	}
 
-	contentList, ok := contentRaw.([]interface{})
+	token := fmt.Sprintf("progress-%d", c.progressSeq.Add(1))
+	c.progressMu.Lock()
+	if c.progress == nil {
+		c.progress = make(map[string]progressTarget)
+	}
+	c.progress[token] = progressTarget{tool: name, reporter: reporter}
+	c.progressMu.Unlock()
+	return token
+}
+
+// endProgress forgets a progress token once its call has finished.
+func (c *StdioMCPClient) endProgress(token string) {
+	c.progressMu.Lock()
+	defer c.progressMu.Unlock()
+	delete(c.progress, token)
+}
+
+// handleProgress delivers a notifications/progress update to the caller's
+// context reporter and to the OnProgress callback.
+func (c *StdioMCPClient) handleProgress(raw json.RawMessage) {
+	var params struct {
+		ProgressToken interface{} `json:"progressToken"`
+		Progress      float64     `json:"progress"`
+		Total         float64     `json:"total"`
+		Message       string      `json:"message"`
+	}
+	if err := json.Unmarshal(raw, &params); err != nil {
+		log.Printf("[MCP Client] Invalid progress notification: %v", err)
+		return
+	}
+
+	token, _ := params.ProgressToken.(string)
+	c.progressMu.Lock()
+	target, ok := c.progress[token]
+	c.progressMu.Unlock()
 	if !ok {
-		return fmt.Sprintf("%v", contentRaw)
+		// Late updates for a call that already returned are expected
+		return
 	}
 
-	var result string
-	for _, item := range contentList {
-		itemMap, ok := item.(map[string]interface{})
-		if !ok {
-			continue
-		}
+	event := ProgressEvent{
+		Tool:     target.tool,
+		Progress: params.Progress,
+		Total:    params.Total,
+		Message:  params.Message,
+	}
 
-		if text, ok := itemMap["text"].(string); ok {
-			if result != "" {
-				result += "\n"
-			}
-			result += text
+	if target.reporter != nil {
+		target.reporter(event.describe())
+	}
+
+	c.mu.Lock()
+	fn := c.onProgress
+	c.mu.Unlock()
+	if fn != nil {
+		fn(event)
+	}
+}
+
+// handleLogMessage delivers a notifications/message entry to the
+// OnLogMessage callback, or to the process log if none is set.
+func (c *StdioMCPClient) handleLogMessage(raw json.RawMessage) {
+	var params struct {
+		Level  string      `json:"level"`
+		Logger string      `json:"logger"`
+		Data   interface{} `json:"data"`
+	}
+	if err := json.Unmarshal(raw, &params); err != nil {
+		log.Printf("[MCP Client] Invalid log notification: %v", err)
+		return
+	}
+
+	c.mu.Lock()
+	fn := c.onLogMessage
+	c.mu.Unlock()
+	if fn == nil {
+		log.Printf("[MCP Client] Server log [%s] %v", params.Level, params.Data)
+		return
+	}
+	fn(LogMessage{Level: params.Level, Logger: params.Logger, Data: params.Data})
+}
+
+// handleServerRequest answers a request initiated by the server.
+func (c *StdioMCPClient) handleServerRequest(msg incomingMessage) {
+	resp := JSONRPCResponse{JSONRPC: "2.0", ID: msg.ID}
+
+	switch msg.Method {
+	case "sampling/createMessage":
+		c.mu.Lock()
+		sampling := c.sampling
+		c.mu.Unlock()
+		if sampling == nil {
+			resp.Error = &JSONRPCError{Code: ErrCodeMethodNotFound, Message: "sampling not supported"}
+			break
+		}
+		var req SamplingRequest
+		if err := json.Unmarshal(msg.Params, &req); err != nil {
+			resp.Error = &JSONRPCError{Code: ErrCodeInvalidParams, Message: fmt.Sprintf("invalid sampling params: %v", err)}
+			break
+		}
+		// Tied to the connection, so closing the client abandons the request
+		result, err := sampling(c.lifetime, &req)
+		if err != nil {
+			resp.Error = samplingError(err)
+			break
 		}
+		resp.Result = result
+	case "ping":
+		resp.Result = map[string]interface{}{}
+	default:
+		resp.Error = &JSONRPCError{Code: ErrCodeMethodNotFound, Message: fmt.Sprintf("Method not found: %s", msg.Method)}
 	}
 
-	return result
+	if err := c.writeMessage(resp); err != nil {
+		log.Printf("[MCP Client] Failed to respond to %s: %v", msg.Method, err)
+	}
+}
+
+// getString safely extracts a string from a map.
+func getString(m map[string]interface{}, key string) string {
+	if v, ok := m[key].(string); ok {
+		return v
+	}
+	return ""
 }
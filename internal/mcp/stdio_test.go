@@ -0,0 +1,484 @@
+package mcp
+
+import (
+	"bufio"
+	"context"
+	"encoding/json"
+	"errors"
+	"io"
+	"os"
+	"strings"
+	"testing"
+	"time"
+
+	"agentic-poc/internal/tool"
+)
+
+// pipeServer is the server side of an in-memory connection to a StdioMCPClient.
+type pipeServer struct {
+	t      *testing.T
+	reader *bufio.Reader
+	writer io.WriteCloser
+}
+
+// newPipeClient returns a client attached to in-memory pipes, along with the
+// server end of the connection.
+func newPipeClient(t *testing.T) (*StdioMCPClient, *pipeServer) {
+	t.Helper()
+
+	clientIn, serverOut := io.Pipe()
+	serverIn, clientOut := io.Pipe()
+
+	client := NewStdioMCPClient("unused", nil, nil)
+	client.start(clientIn, clientOut)
+
+	t.Cleanup(func() {
+		serverOut.Close()
+		clientOut.Close()
+	})
+
+	return client, &pipeServer{t: t, reader: bufio.NewReader(serverIn), writer: serverOut}
+}
+
+// read reads the next message sent by the client.
+func (s *pipeServer) read() incomingMessage {
+	s.t.Helper()
+	line, err := s.reader.ReadBytes('\n')
+	if err != nil {
+		s.t.Fatalf("server read failed: %v", err)
+	}
+	var msg incomingMessage
+	if err := json.Unmarshal(line, &msg); err != nil {
+		s.t.Fatalf("server failed to parse %q: %v", line, err)
+	}
+	return msg
+}
+
+// write sends a raw JSON line to the client.
+func (s *pipeServer) write(line string) {
+	s.t.Helper()
+	if _, err := s.writer.Write([]byte(line + "\n")); err != nil {
+		s.t.Fatalf("server write failed: %v", err)
+	}
+}
+
+func TestStdioMCPClient_InitializeAdvertisesSampling(t *testing.T) {
+	client, server := newPipeClient(t)
+	client.SetSamplingHandler(func(ctx context.Context, req *SamplingRequest) (*SamplingResult, error) {
+		return nil, nil
+	})
+
+	errCh := make(chan error, 1)
+	go func() {
+		_, err := client.initialize(context.Background())
+		errCh <- err
+	}()
+
+	msg := server.read()
+	if msg.Method != "initialize" {
+		t.Fatalf("expected initialize, got %q", msg.Method)
+	}
+	var params struct {
+		Capabilities map[string]interface{} `json:"capabilities"`
+	}
+	if err := json.Unmarshal(msg.Params, &params); err != nil {
+		t.Fatalf("failed to parse params: %v", err)
+	}
+	if _, ok := params.Capabilities["sampling"]; !ok {
+		t.Errorf("expected sampling capability, got %v", params.Capabilities)
+	}
+
+	server.write(`{"jsonrpc":"2.0","id":` + string(msg.ID) + `,"result":{}}`)
+
+	if note := server.read(); note.Method != "notifications/initialized" {
+		t.Errorf("expected initialized notification, got %q", note.Method)
+	}
+	if err := <-errCh; err != nil {
+		t.Fatalf("initialize failed: %v", err)
+	}
+}
+
+// TestHelperMCPServer is not a real test: run by the test binary in a
+// subprocess with MCP_TEST_SERVER=1, it serves the calculator over stdio.
+func TestHelperMCPServer(t *testing.T) {
+	if os.Getenv("MCP_TEST_SERVER") != "1" {
+		t.Skip("only runs as a subprocess")
+	}
+	server := NewMCPServer("test-server", "1.0.0", []tool.Tool{tool.NewCalculatorTool()})
+	server.Serve(context.Background(), os.Stdin, os.Stdout)
+	os.Exit(0)
+}
+
+func TestStdioMCPClient_ConnectSubprocess(t *testing.T) {
+	client := NewStdioMCPClient(os.Args[0], []string{"-test.run=^TestHelperMCPServer$"}, map[string]string{"MCP_TEST_SERVER": "1"})
+
+	// Cancelling ctx kills the server, even if Connect never returns
+	ctx, cancel := context.WithTimeout(context.Background(), 10*time.Second)
+	defer cancel()
+
+	// A deadlock would not honor the context, so time out from outside
+	errCh := make(chan error, 1)
+	go func() { errCh <- client.Connect(ctx) }()
+	select {
+	case err := <-errCh:
+		if err != nil {
+			t.Fatalf("Connect() error = %v", err)
+		}
+	case <-time.After(10 * time.Second):
+		t.Fatal("Connect() did not return")
+	}
+	defer client.Close()
+
+	tools, err := client.ListTools(ctx)
+	if err != nil || len(tools) != 1 || tools[0].Name != "calculator" {
+		t.Fatalf("ListTools() = %v, %v; want the calculator", tools, err)
+	}
+	// The server advertises logging, which Connect records
+	if err := client.SetLogLevel(ctx, LogLevelInfo); err != nil {
+		t.Errorf("SetLogLevel() error = %v", err)
+	}
+}
+
+func TestStdioMCPClient_ResponsesRoutedByID(t *testing.T) {
+	client, server := newPipeClient(t)
+
+	type result struct {
+		resp *JSONRPCResponse
+		err  error
+	}
+	first := make(chan result, 1)
+	second := make(chan result, 1)
+
+	go func() {
+		resp, err := client.sendRequest(context.Background(), "first", nil)
+		first <- result{resp, err}
+	}()
+	msg1 := server.read()
+
+	go func() {
+		resp, err := client.sendRequest(context.Background(), "second", nil)
+		second <- result{resp, err}
+	}()
+	msg2 := server.read()
+
+	// Answer out of order
+	server.write(`{"jsonrpc":"2.0","id":` + string(msg2.ID) + `,"result":"two"}`)
+	server.write(`{"jsonrpc":"2.0","id":` + string(msg1.ID) + `,"result":"one"}`)
+
+	r1, r2 := <-first, <-second
+	if r1.err != nil || r2.err != nil {
+		t.Fatalf("unexpected errors: %v, %v", r1.err, r2.err)
+	}
+	if r1.resp.Result != "one" || r2.resp.Result != "two" {
+		t.Errorf("responses misrouted: first=%v second=%v", r1.resp.Result, r2.resp.Result)
+	}
+}
+
+func TestStdioMCPClient_AnswersSamplingRequest(t *testing.T) {
+	client, server := newPipeClient(t)
+
+	p := &stubProvider{text: "sampled"}
+	h := NewSamplingHandler(p)
+	h.Allow("srv", SamplingPolicy{MaxTokens: 64})
+	client.SetSamplingHandler(h.ForServer("srv"))
+
+	server.write(`{"jsonrpc":"2.0","id":"req-1","method":"sampling/createMessage","params":{"messages":[{"role":"user","content":{"type":"text","text":"hello"}}],"maxTokens":1000}}`)
+
+	resp := server.read()
+	if string(resp.ID) != `"req-1"` {
+		t.Errorf("expected string ID to be echoed, got %s", resp.ID)
+	}
+	if resp.Error != nil {
+		t.Fatalf("unexpected error: %v", resp.Error)
+	}
+
+	result, ok := resp.Result.(map[string]interface{})
+	if !ok {
+		t.Fatalf("expected map result, got %T", resp.Result)
+	}
+	content, _ := result["content"].(map[string]interface{})
+	if content["text"] != "sampled" {
+		t.Errorf("expected sampled text, got %v", result["content"])
+	}
+	if p.requests[0].MaxTokens != 64 {
+		t.Errorf("expected token cap 64, got %d", p.requests[0].MaxTokens)
+	}
+}
+
+func TestStdioMCPClient_CloseCancelsSampling(t *testing.T) {
+	client, server := newPipeClient(t)
+
+	started := make(chan struct{})
+	cancelled := make(chan error, 1)
+	client.SetSamplingHandler(func(ctx context.Context, req *SamplingRequest) (*SamplingResult, error) {
+		close(started)
+		<-ctx.Done()
+		cancelled <- ctx.Err()
+		return nil, ctx.Err()
+	})
+
+	server.write(`{"jsonrpc":"2.0","id":1,"method":"sampling/createMessage","params":{"messages":[{"role":"user","content":{"type":"text","text":"hello"}}]}}`)
+	<-started
+	client.Close()
+
+	select {
+	case err := <-cancelled:
+		if !errors.Is(err, context.Canceled) {
+			t.Errorf("sampling context error = %v, want context.Canceled", err)
+		}
+	case <-time.After(time.Second):
+		t.Fatal("closing the client should cancel the sampling request")
+	}
+}
+
+func TestStdioMCPClient_RejectsSamplingWithoutHandler(t *testing.T) {
+	_, server := newPipeClient(t)
+
+	server.write(`{"jsonrpc":"2.0","id":7,"method":"sampling/createMessage","params":{"messages":[]}}`)
+
+	resp := server.read()
+	if resp.Error == nil {
+		t.Fatal("expected error when sampling is not supported")
+	}
+	if resp.Error.Code != -32601 {
+		t.Errorf("expected code -32601, got %d", resp.Error.Code)
+	}
+}
+
+func TestStdioMCPClient_RequestFailsWhenConnectionCloses(t *testing.T) {
+	client, server := newPipeClient(t)
+
+	errCh := make(chan error, 1)
+	go func() {
+		_, err := client.sendRequest(context.Background(), "tools/list", nil)
+		errCh <- err
+	}()
+	server.read()
+	server.writer.Close()
+
+	select {
+	case err := <-errCh:
+		if err == nil {
+			t.Error("expected error after connection closed")
+		}
+	case <-time.After(time.Second):
+		t.Fatal("request did not fail after connection closed")
+	}
+}
+
+func TestStdioMCPClient_CancelledRequestNotifiesServer(t *testing.T) {
+	client, server := newPipeClient(t)
+
+	ctx, cancel := context.WithCancel(context.Background())
+	errCh := make(chan error, 1)
+	go func() {
+		_, err := client.sendRequest(ctx, "tools/call", map[string]interface{}{"name": "slow"})
+		errCh <- err
+	}()
+	req := server.read()
+	cancel()
+
+	msg := server.read()
+	if msg.Method != "notifications/cancelled" || len(msg.ID) != 0 {
+		t.Fatalf("expected a cancellation notification, got %+v", msg)
+	}
+	var params struct {
+		RequestID json.RawMessage `json:"requestId"`
+		Reason    string          `json:"reason"`
+	}
+	if err := json.Unmarshal(msg.Params, &params); err != nil {
+		t.Fatal(err)
+	}
+	if string(params.RequestID) != string(req.ID) || params.Reason == "" {
+		t.Errorf("params = %s, want requestId %s and a reason", msg.Params, req.ID)
+	}
+	if err := <-errCh; !errors.Is(err, context.Canceled) {
+		t.Errorf("error = %v, want context.Canceled", err)
+	}
+}
+
+func TestStdioMCPClient_ListToolsPagination(t *testing.T) {
+	client, server := newPipeClient(t)
+	client.connected = true
+
+	type result struct {
+		tools []MCPToolInfo
+		err   error
+	}
+	done := make(chan result, 1)
+	go func() {
+		tools, err := client.ListTools(context.Background())
+		done <- result{tools, err}
+	}()
+
+	first := server.read()
+	if len(first.Params) != 0 && string(first.Params) != "null" {
+		t.Errorf("first page should have no cursor, got %s", first.Params)
+	}
+	server.write(`{"jsonrpc":"2.0","id":` + string(first.ID) + `,"result":{"tools":[{"name":"a"},{"name":"b"}],"nextCursor":"page2"}}`)
+
+	second := server.read()
+	var params struct {
+		Cursor string `json:"cursor"`
+	}
+	json.Unmarshal(second.Params, &params)
+	if params.Cursor != "page2" {
+		t.Errorf("expected cursor page2, got %q", params.Cursor)
+	}
+	server.write(`{"jsonrpc":"2.0","id":` + string(second.ID) + `,"result":{"tools":[{"name":"c"}]}}`)
+
+	r := <-done
+	if r.err != nil {
+		t.Fatalf("ListTools() error = %v", r.err)
+	}
+	if len(r.tools) != 3 || r.tools[2].Name != "c" {
+		t.Errorf("expected tools a, b, c; got %+v", r.tools)
+	}
+}
+
+func TestStdioMCPClient_ToolsListChangedNotification(t *testing.T) {
+	client, server := newPipeClient(t)
+
+	called := make(chan struct{}, 1)
+	client.OnToolsChanged(func() { called <- struct{}{} })
+
+	server.write(`{"jsonrpc":"2.0","method":"notifications/tools/list_changed"}`)
+
+	select {
+	case <-called:
+	case <-time.After(time.Second):
+		t.Fatal("tools changed callback was not invoked")
+	}
+}
+
+func TestParseToolsPage_Annotations(t *testing.T) {
+	result := map[string]interface{}{
+		"tools": []interface{}{
+			map[string]interface{}{"name": "plain"},
+			map[string]interface{}{"name": "reader", "annotations": map[string]interface{}{"readOnlyHint": true}},
+		},
+	}
+
+	tools, _, err := parseToolsPage(result)
+	if err != nil {
+		t.Fatalf("parseToolsPage() error = %v", err)
+	}
+
+	if tools[0].Annotations != nil {
+		t.Errorf("tool without annotations should have none, got %+v", tools[0].Annotations)
+	}
+
+	a := tools[1].Annotations
+	if a == nil || !a.ReadOnlyHint {
+		t.Fatalf("expected readOnlyHint, got %+v", a)
+	}
+	// Hints the server left out take the protocol defaults
+	if !a.DestructiveHint || !a.OpenWorldHint {
+		t.Errorf("expected default destructive and open-world hints, got %+v", a)
+	}
+}
+
+func TestStdioMCPClient_ProgressRoutedToCallbacks(t *testing.T) {
+	client, server := newPipeClient(t)
+	client.connected = true
+
+	events := make(chan ProgressEvent, 2)
+	client.OnProgress(func(e ProgressEvent) { events <- e })
+
+	var reported []string
+	ctx := tool.WithProgress(context.Background(), func(msg string) { reported = append(reported, msg) })
+
+	done := make(chan error, 1)
+	go func() {
+		_, err := client.CallTool(ctx, "build", nil)
+		done <- err
+	}()
+
+	msg := server.read()
+	var params struct {
+		Meta struct {
+			ProgressToken string `json:"progressToken"`
+		} `json:"_meta"`
+	}
+	if err := json.Unmarshal(msg.Params, &params); err != nil || params.Meta.ProgressToken == "" {
+		t.Fatalf("expected a progress token in %s (err=%v)", msg.Params, err)
+	}
+	token := params.Meta.ProgressToken
+
+	server.write(`{"jsonrpc":"2.0","method":"notifications/progress","params":{"progressToken":"` + token + `","progress":1,"total":4,"message":"compiling"}}`)
+	server.write(`{"jsonrpc":"2.0","method":"notifications/progress","params":{"progressToken":"unknown","progress":1}}`)
+	server.write(`{"jsonrpc":"2.0","id":` + string(msg.ID) + `,"result":{"content":[{"type":"text","text":"ok"}]}}`)
+
+	if err := <-done; err != nil {
+		t.Fatalf("CallTool() error = %v", err)
+	}
+
+	select {
+	case e := <-events:
+		if e.Tool != "build" || e.Progress != 1 || e.Total != 4 || e.Message != "compiling" {
+			t.Errorf("unexpected progress event: %+v", e)
+		}
+	default:
+		t.Fatal("progress callback was not invoked")
+	}
+	if len(events) != 0 {
+		t.Errorf("expected updates for unknown tokens to be dropped, got %+v", <-events)
+	}
+	if len(reported) != 1 || reported[0] != "compiling (1/4)" {
+		t.Errorf("expected context reporter to get the update, got %v", reported)
+	}
+}
+
+func TestStdioMCPClient_NoProgressTokenWithoutListener(t *testing.T) {
+	client, server := newPipeClient(t)
+	client.connected = true
+
+	go client.CallTool(context.Background(), "build", nil)
+
+	msg := server.read()
+	if strings.Contains(string(msg.Params), "progressToken") {
+		t.Errorf("expected no progress token, got %s", msg.Params)
+	}
+	server.write(`{"jsonrpc":"2.0","id":` + string(msg.ID) + `,"result":{"content":[]}}`)
+}
+
+func TestStdioMCPClient_LogMessageCallback(t *testing.T) {
+	client, server := newPipeClient(t)
+
+	logs := make(chan LogMessage, 1)
+	client.OnLogMessage(func(msg LogMessage) { logs <- msg })
+
+	server.write(`{"jsonrpc":"2.0","method":"notifications/message","params":{"level":"warning","logger":"db","data":"slow query"}}`)
+
+	select {
+	case msg := <-logs:
+		if msg.Level != "warning" || msg.Logger != "db" || msg.Data != "slow query" {
+			t.Errorf("unexpected log message: %+v", msg)
+		}
+	case <-time.After(time.Second):
+		t.Fatal("log callback was not invoked")
+	}
+}
+
+func TestStdioMCPClient_SetLogLevel(t *testing.T) {
+	client, server := newPipeClient(t)
+	client.connected = true
+
+	if err := client.SetLogLevel(context.Background(), LogLevelInfo); !errors.Is(err, ErrLoggingNotSupported) {
+		t.Fatalf("expected ErrLoggingNotSupported before the capability is known, got %v", err)
+	}
+
+	client.supportsLogging = true
+	errCh := make(chan error, 1)
+	go func() { errCh <- client.SetLogLevel(context.Background(), LogLevelInfo) }()
+
+	msg := server.read()
+	if msg.Method != "logging/setLevel" || !strings.Contains(string(msg.Params), `"info"`) {
+		t.Errorf("unexpected request: %s %s", msg.Method, msg.Params)
+	}
+	server.write(`{"jsonrpc":"2.0","id":` + string(msg.ID) + `,"result":{}}`)
+
+	if err := <-errCh; err != nil {
+		t.Errorf("SetLogLevel() error = %v", err)
+	}
+}
@@ -5,14 +5,16 @@ import (
 	"log"
 
 	"agentic-poc/internal/provider"
+	"agentic-poc/internal/tool"
 )
 
 // MCPToolWrapper adapts an MCP tool to the Tool interface.
 // This allows MCP tools to be used seamlessly by agents alongside built-in tools.
 type MCPToolWrapper struct {
-	client     MCPClient
-	info       MCPToolInfo
-	serverName string
+	client      MCPClient
+	info        MCPToolInfo
+	serverName  string
+	exposedName string // LLM-facing name assigned by MCPManager
 }
 
 // NewMCPToolWrapper creates a new MCPToolWrapper for the given tool info.
@@ -32,16 +34,35 @@ func NewMCPToolWrapperWithServer(client MCPClient, info MCPToolInfo, serverName
 	}
 }
 
-// Name returns the tool's name from the MCP server.
+// Name returns the name the tool is offered to the LLM under. For tools
+// registered through MCPManager this is the namespaced name; otherwise it is
+// the tool's name on the MCP server.
 func (w *MCPToolWrapper) Name() string {
+	if w.exposedName != "" {
+		return w.exposedName
+	}
 	return w.info.Name
 }
 
+// ServerName returns the name of the MCP server providing the tool.
+func (w *MCPToolWrapper) ServerName() string {
+	return w.serverName
+}
+
 // Description returns the tool's description from the MCP server.
 func (w *MCPToolWrapper) Description() string {
 	return w.info.Description
 }
 
+// Annotations returns the hints the MCP server gave for the tool, or the
+// protocol defaults if it gave none.
+func (w *MCPToolWrapper) Annotations() tool.Annotations {
+	if w.info.Annotations != nil {
+		return *w.info.Annotations
+	}
+	return tool.DefaultAnnotations()
+}
+
 // Parameters returns the tool's input schema from the MCP server.
 func (w *MCPToolWrapper) Parameters() map[string]interface{} {
 	if w.info.InputSchema == nil {
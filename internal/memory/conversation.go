@@ -32,9 +32,29 @@ func (m *ConversationMemory) AddMessage(role, content string) {
 	})
 }
 
+// AddMessageWithContent appends a message that carries rich content
+// blocks, such as attached images, alongside its text form.
+func (m *ConversationMemory) AddMessageWithContent(role, content string, blocks []provider.ContentBlock) {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+
+	m.messages = append(m.messages, provider.Message{
+		Role:    role,
+		Content: content,
+		Blocks:  blocks,
+	})
+}
+
 // AddAssistantMessageWithToolCalls appends an assistant message that includes tool calls.
 // This is used when the LLM responds with tool_use blocks.
 func (m *ConversationMemory) AddAssistantMessageWithToolCalls(content string, toolCalls []provider.ToolCall) {
+	m.AddAssistantMessageWithThinking(content, toolCalls, nil)
+}
+
+// AddAssistantMessageWithThinking appends an assistant message with the
+// extended thinking that preceded its text and tool calls. The thinking is
+// kept unchanged so it can be sent back with the tool results.
+func (m *ConversationMemory) AddAssistantMessageWithThinking(content string, toolCalls []provider.ToolCall, thinking []provider.Thinking) {
 	m.mu.Lock()
 	defer m.mu.Unlock()
 
@@ -42,20 +62,44 @@ func (m *ConversationMemory) AddAssistantMessageWithToolCalls(content string, to
 		Role:      "assistant",
 		Content:   content,
 		ToolCalls: toolCalls,
+		Thinking:  thinking,
 	})
 }
 
 // AddToolResult appends a tool result message to the conversation history.
 // The message includes the tool call ID and tool name for proper context.
 func (m *ConversationMemory) AddToolResult(toolCallID, toolName, result string) {
+	m.AddToolResultWithContent(toolCallID, toolName, result, nil)
+}
+
+// AddToolResultWithContent appends a tool result that carries rich content
+// blocks (images, resources, structured data) alongside its text form.
+func (m *ConversationMemory) AddToolResultWithContent(toolCallID, toolName, result string, content []provider.ContentBlock) {
 	m.mu.Lock()
 	defer m.mu.Unlock()
 
 	m.messages = append(m.messages, provider.Message{
 		Role:       "tool",
 		Content:    result,
+		Blocks:     content,
+		ToolCallID: toolCallID,
+		ToolName:   toolName,
+	})
+}
+
+// AddToolError appends the result of a failed tool call. The message text
+// is the error message, and ToolError lets providers flag the result as an
+// error in their native format.
+func (m *ConversationMemory) AddToolError(toolCallID, toolName string, toolErr *provider.ToolError) {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+
+	m.messages = append(m.messages, provider.Message{
+		Role:       "tool",
+		Content:    toolErr.Message,
 		ToolCallID: toolCallID,
 		ToolName:   toolName,
+		ToolError:  toolErr,
 	})
 }
 
@@ -71,6 +115,16 @@ func (m *ConversationMemory) GetMessages() []provider.Message {
 	return result
 }
 
+// SetMessages replaces the conversation history with a copy of messages,
+// for example to restore a saved session.
+func (m *ConversationMemory) SetMessages(messages []provider.Message) {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+
+	m.messages = make([]provider.Message, len(messages))
+	copy(m.messages, messages)
+}
+
 // Clear removes all messages from the conversation history.
 func (m *ConversationMemory) Clear() {
 	m.mu.Lock()
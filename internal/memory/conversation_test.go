@@ -3,6 +3,8 @@ package memory
 import (
 	"sync"
 	"testing"
+
+	"agentic-poc/internal/provider"
 )
 
 func TestNewConversationMemory(t *testing.T) {
@@ -123,6 +125,61 @@ func TestAddToolResult(t *testing.T) {
 	}
 }
 
+func TestAddToolResultWithContent(t *testing.T) {
+	mem := NewConversationMemory()
+	blocks := []provider.ContentBlock{
+		provider.TextBlock("caption"),
+		provider.ImageBlock("image/png", "iVBORw0="),
+	}
+
+	mem.AddToolResultWithContent("call_1", "screenshot", "caption", blocks)
+
+	msg := mem.GetMessages()[0]
+	if msg.Role != "tool" || msg.ToolCallID != "call_1" || msg.ToolName != "screenshot" {
+		t.Errorf("unexpected message: %+v", msg)
+	}
+	if msg.Content != "caption" {
+		t.Errorf("Content = %q, want %q", msg.Content, "caption")
+	}
+	if len(msg.Blocks) != 2 || msg.Blocks[1].Type != provider.ContentImage {
+		t.Errorf("Blocks not stored: %+v", msg.Blocks)
+	}
+}
+
+func TestAddMessageWithContent(t *testing.T) {
+	mem := NewConversationMemory()
+	blocks := []provider.ContentBlock{
+		provider.ImageBlock("image/png", "iVBORw0="),
+		provider.TextBlock("What is this?"),
+	}
+
+	mem.AddMessageWithContent("user", "What is this?", blocks)
+
+	msg := mem.GetMessages()[0]
+	if msg.Role != "user" || msg.Content != "What is this?" {
+		t.Errorf("unexpected message: %+v", msg)
+	}
+	if len(msg.Blocks) != 2 || msg.Blocks[0].Type != provider.ContentImage {
+		t.Errorf("Blocks not stored: %+v", msg.Blocks)
+	}
+}
+
+func TestAddAssistantMessageWithThinking(t *testing.T) {
+	mem := NewConversationMemory()
+	toolCalls := []provider.ToolCall{{ID: "call_1", Name: "read_file"}}
+	thinking := []provider.Thinking{{Text: "Read it first.", Signature: "sig"}}
+
+	mem.AddAssistantMessageWithThinking("Reading.", toolCalls, thinking)
+
+	msg := mem.GetMessages()[0]
+	if msg.Role != "assistant" || msg.Content != "Reading." || len(msg.ToolCalls) != 1 {
+		t.Errorf("unexpected message: %+v", msg)
+	}
+	if len(msg.Thinking) != 1 || msg.Thinking[0] != thinking[0] {
+		t.Errorf("Thinking not stored: %+v", msg.Thinking)
+	}
+}
+
 func TestGetMessages_ReturnsOrderedMessages(t *testing.T) {
 	mem := NewConversationMemory()
 
@@ -312,3 +369,40 @@ func TestConcurrentReadWrite(t *testing.T) {
 		t.Errorf("expected %d messages, got %d", expectedCount, mem.Len())
 	}
 }
+
+func TestAddToolError(t *testing.T) {
+	mem := NewConversationMemory()
+	toolErr := &provider.ToolError{Kind: provider.ToolErrorNotFound, Message: "file not found: a.txt"}
+
+	mem.AddToolError("call_1", "read_file", toolErr)
+
+	msg := mem.GetMessages()[0]
+	if msg.Role != "tool" || msg.ToolCallID != "call_1" || msg.ToolName != "read_file" {
+		t.Errorf("unexpected message: %+v", msg)
+	}
+	if msg.Content != "file not found: a.txt" || msg.ToolError != toolErr {
+		t.Errorf("error not stored: %+v", msg)
+	}
+}
+
+func TestSetMessages(t *testing.T) {
+	mem := NewConversationMemory()
+	mem.AddMessage("user", "To be replaced")
+
+	saved := []provider.Message{
+		{Role: "user", Content: "Hello"},
+		{Role: "assistant", Content: "Hi there"},
+	}
+	mem.SetMessages(saved)
+
+	// Changing the caller's slice must not affect the memory
+	saved[0].Content = "Changed"
+
+	messages := mem.GetMessages()
+	if len(messages) != 2 {
+		t.Fatalf("expected 2 messages, got %d", len(messages))
+	}
+	if messages[0].Content != "Hello" || messages[1].Content != "Hi there" {
+		t.Errorf("unexpected messages: %+v", messages)
+	}
+}
@@ -3,12 +3,14 @@ package orchestrator
 
 import (
 	"context"
+	"errors"
 	"fmt"
 	"sync"
 
 	"agentic-poc/internal/agent"
 	"agentic-poc/internal/memory"
 	"agentic-poc/internal/provider"
+	"agentic-poc/internal/tool"
 )
 
 // WorkflowPhase represents the current phase of the orchestrator workflow.
@@ -25,6 +27,8 @@ const (
 	PhaseComplete WorkflowPhase = "complete"
 	// PhaseFailed indicates the workflow failed due to an error.
 	PhaseFailed WorkflowPhase = "failed"
+	// PhaseCancelled indicates the workflow's context was cancelled.
+	PhaseCancelled WorkflowPhase = "cancelled"
 )
 
 // WorkflowState represents the current state of the orchestrator workflow.
@@ -37,11 +41,12 @@ type WorkflowState struct {
 
 // OrchestratorResult represents the result of an orchestrator run.
 type OrchestratorResult struct {
-	Success      bool
-	Plan         *agent.Plan
-	ActionsTaken []string
-	Summary      string
-	Error        string
+	Success      bool           `json:"success"`
+	Plan         *agent.Plan    `json:"plan,omitempty"`
+	ActionsTaken []string       `json:"actions_taken"`
+	Summary      string         `json:"summary"`
+	Usage        provider.Usage `json:"usage"` // Summed over the agents that completed
+	Error        string         `json:"error,omitempty"`
 }
 
 // Orchestrator coordinates the multi-agent workflow between Architect and Coder agents.
@@ -49,22 +54,65 @@ type OrchestratorResult struct {
 //
 // Validates: Requirements 7.1, 7.2, 7.3, 7.4, 7.5, 7.6
 type Orchestrator struct {
-	provider provider.LLMProvider
-	basePath string
-	state    WorkflowState
-	mu       sync.RWMutex
+	architect     provider.LLMProvider // Provider for the Architect agent
+	coder         provider.LLMProvider // Provider for the Coder agent
+	basePath      string
+	architectOpts []agent.Option
+	coderOpts     []agent.Option
+	state         WorkflowState
+	mu            sync.RWMutex
+}
+
+// Option configures an Orchestrator.
+type Option func(*Orchestrator)
+
+// WithArchitectOptions customizes the Architect agent of each run.
+func WithArchitectOptions(opts ...agent.Option) Option {
+	return func(o *Orchestrator) {
+		o.architectOpts = append(o.architectOpts, opts...)
+	}
+}
+
+// WithCoderOptions customizes the Coder agent of each run.
+func WithCoderOptions(opts ...agent.Option) Option {
+	return func(o *Orchestrator) {
+		o.coderOpts = append(o.coderOpts, opts...)
+	}
+}
+
+// WithArchitectProvider runs the Architect agent on p instead of the
+// orchestrator's provider, for example a stronger model for planning.
+func WithArchitectProvider(p provider.LLMProvider) Option {
+	return func(o *Orchestrator) {
+		o.architect = p
+	}
+}
+
+// WithCoderProvider runs the Coder agent on p instead of the
+// orchestrator's provider.
+func WithCoderProvider(p provider.LLMProvider) Option {
+	return func(o *Orchestrator) {
+		o.coder = p
+	}
 }
 
 // NewOrchestrator creates a new Orchestrator with the given LLM provider and base path.
 // The basePath is used for file operations by the Coder agent.
-func NewOrchestrator(llmProvider provider.LLMProvider, basePath string) *Orchestrator {
-	return &Orchestrator{
-		provider: llmProvider,
-		basePath: basePath,
+// The provider is used by both agents unless WithArchitectProvider or
+// WithCoderProvider gives one of them its own.
+func NewOrchestrator(llmProvider provider.LLMProvider, basePath string, opts ...Option) *Orchestrator {
+	o := &Orchestrator{
+		architect: llmProvider,
+		coder:     llmProvider,
+		basePath:  basePath,
 		state: WorkflowState{
 			Phase: PhaseIdle,
 		},
 	}
+	for _, opt := range opts {
+		opt(o)
+	}
+	return o
 }
 
 // State returns a copy of the current workflow state.
@@ -90,21 +138,28 @@ func (o *Orchestrator) setPlan(plan *agent.Plan) {
 	o.state.Plan = plan
 }
 
-// setError sets the error state and transitions to failed phase.
-func (o *Orchestrator) setError(err string) {
+// setError sets the error state and transitions to failed phase, or to
+// the cancelled phase if ctx has ended.
+func (o *Orchestrator) setError(ctx context.Context, err string) {
 	o.mu.Lock()
 	defer o.mu.Unlock()
 	o.state.Phase = PhaseFailed
+	if ctx.Err() != nil {
+		o.state.Phase = PhaseCancelled
+	}
 	o.state.Error = err
 }
 
 // Run executes the multi-agent workflow with the given goal.
 // It coordinates the Architect -> Coder flow:
 // 1. Set phase to Planning, invoke Architect agent
-// 2. Capture plan from FinishPlanTool
+// 2. Take the plan from the Architect's structured answer
 // 3. Set phase to Executing, invoke Coder agent with plan
 // 4. Return result with actions taken
 //
+// If ctx is cancelled, the result still reports the progress made: the
+// plan if the architect produced one, the actions taken and the usage.
+//
 // Validates: Properties 15, 16, 17
 func (o *Orchestrator) Run(ctx context.Context, goal string) (*OrchestratorResult, error) {
 	// Reset state for new run
@@ -114,38 +169,43 @@ func (o *Orchestrator) Run(ctx context.Context, goal string) (*OrchestratorResul
 
 	// Phase 1: Planning with Architect agent
 	o.setPhase(PhasePlanning, "architect")
+	tool.ReportProgress(ctx, "Planning with architect agent")
 
-	architectAgent, finishPlanTool := agent.NewArchitectAgent(o.provider)
+	architectAgent := agent.NewArchitectAgent(o.architect, o.architectOpts...)
 	architectMemory := memory.NewConversationMemory()
 
-	architectResult, err := architectAgent.Run(ctx, goal, architectMemory)
+	architectResult, err := architectAgent.Run(tool.WithProgressPrefix(ctx, "architect: "), goal, architectMemory)
 	if err != nil {
 		errMsg := fmt.Sprintf("architect agent failed: %v", err)
-		o.setError(errMsg)
-		return &OrchestratorResult{
+		o.setError(ctx, errMsg)
+		result := &OrchestratorResult{
 			Success: false,
 			Error:   errMsg,
-		}, fmt.Errorf("architect agent failed: %w", err)
+		}
+		// An interrupted architect still reports what it got done
+		if architectResult != nil {
+			result.ActionsTaken = agent.DescribeToolCalls(architectResult.ToolCallsMade)
+			result.Usage = architectResult.Usage
+		}
+		return result, fmt.Errorf("architect agent failed: %w", err)
 	}
 
-	// Check if a plan was captured
-	if !finishPlanTool.HasCapturedPlan() {
+	plan, err := agent.PlanFromResult(architectResult)
+	if errors.Is(err, agent.ErrNoStructuredOutput) {
 		errMsg := "architect agent did not produce a plan"
-		o.setError(errMsg)
+		o.setError(ctx, errMsg)
 		return &OrchestratorResult{
 			Success: false,
+			Usage:   architectResult.Usage,
 			Error:   errMsg,
-		}, fmt.Errorf(errMsg)
+		}, errors.New(errMsg)
 	}
-
-	// Parse the captured plan
-	planJSON := finishPlanTool.GetCapturedPlan()
-	plan, err := agent.ParsePlan(planJSON)
 	if err != nil {
 		errMsg := fmt.Sprintf("failed to parse architect plan: %v", err)
-		o.setError(errMsg)
+		o.setError(ctx, errMsg)
 		return &OrchestratorResult{
 			Success: false,
+			Usage:   architectResult.Usage,
 			Error:   errMsg,
 		}, fmt.Errorf("failed to parse architect plan: %w", err)
 	}
@@ -154,55 +214,57 @@ func (o *Orchestrator) Run(ctx context.Context, goal string) (*OrchestratorResul
 
 	// Phase 2: Executing with Coder agent
 	o.setPhase(PhaseExecuting, "coder")
+	tool.ReportProgress(ctx, fmt.Sprintf("Executing %d-step plan with coder agent", len(plan.Steps)))
 
-	coderAgent := agent.NewCoderAgent(o.provider, o.basePath)
+	coderAgent := agent.NewCoderAgent(o.coder, o.basePath, o.coderOpts...)
 	coderMemory := memory.NewConversationMemory()
 
 	// Prepare the plan as input for the Coder agent
 	planInput, err := plan.ToJSON()
 	if err != nil {
 		errMsg := fmt.Sprintf("failed to serialize plan for coder: %v", err)
-		o.setError(errMsg)
+		o.setError(ctx, errMsg)
 		return &OrchestratorResult{
 			Success: false,
 			Plan:    plan,
+			Usage:   architectResult.Usage,
 			Error:   errMsg,
 		}, fmt.Errorf("failed to serialize plan for coder: %w", err)
 	}
 
 	coderPrompt := fmt.Sprintf("Execute the following plan:\n\n%s", planInput)
-	coderResult, err := coderAgent.Run(ctx, coderPrompt, coderMemory)
+	coderResult, err := coderAgent.Run(tool.WithProgressPrefix(ctx, "coder: "), coderPrompt, coderMemory)
 	if err != nil {
 		errMsg := fmt.Sprintf("coder agent failed: %v", err)
-		o.setError(errMsg)
-		return &OrchestratorResult{
-			Success: false,
-			Plan:    plan,
-			Error:   errMsg,
-		}, fmt.Errorf("coder agent failed: %w", err)
+		o.setError(ctx, errMsg)
+		result := &OrchestratorResult{
+			Success:      false,
+			Plan:         plan,
+			ActionsTaken: agent.DescribeToolCalls(architectResult.ToolCallsMade),
+			Usage:        architectResult.Usage,
+			Error:        errMsg,
+		}
+		// An interrupted coder still reports what it got done
+		if coderResult != nil {
+			result.ActionsTaken = append(result.ActionsTaken, agent.DescribeToolCalls(coderResult.ToolCallsMade)...)
+			result.Usage = result.Usage.Add(coderResult.Usage)
+		}
+		return result, fmt.Errorf("coder agent failed: %w", err)
 	}
 
 	// Phase 3: Complete
 	o.setPhase(PhaseComplete, "")
+	tool.ReportProgress(ctx, "Workflow complete")
 
-	// Collect actions taken from coder's tool calls
-	actionsTaken := make([]string, 0, len(coderResult.ToolCallsMade))
-	for _, tc := range coderResult.ToolCallsMade {
-		actionsTaken = append(actionsTaken, fmt.Sprintf("%s: %v", tc.Name, tc.Arguments))
-	}
-
-	// Also include architect's tool calls
-	architectActions := make([]string, 0, len(architectResult.ToolCallsMade))
-	for _, tc := range architectResult.ToolCallsMade {
-		architectActions = append(architectActions, fmt.Sprintf("%s: %v", tc.Name, tc.Arguments))
-	}
-
-	allActions := append(architectActions, actionsTaken...)
+	// Architect's tool calls come first, then the coder's
+	allActions := append(agent.DescribeToolCalls(architectResult.ToolCallsMade),
+		agent.DescribeToolCalls(coderResult.ToolCallsMade)...)
 
 	return &OrchestratorResult{
 		Success:      true,
 		Plan:         plan,
 		ActionsTaken: allActions,
 		Summary:      coderResult.Response,
+		Usage:        architectResult.Usage.Add(coderResult.Usage),
 	}, nil
 }
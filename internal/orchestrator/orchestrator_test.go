@@ -6,6 +6,7 @@ import (
 	"errors"
 	"testing"
 
+	"agentic-poc/internal/agent"
 	"agentic-poc/internal/provider"
 )
 
@@ -37,7 +38,7 @@ func (m *MockLLMProvider) Name() string {
 // Validates: Requirements 7.1-7.6, Properties 15, 16, 17
 func TestSuccessfulArchitectCoderWorkflow(t *testing.T) {
 	// Mock responses:
-	// 1. Architect calls finish_plan with a valid plan
+	// 1. Architect calls finish_plan with a valid plan, which ends its run
 	// 2. Coder processes the plan and returns success
 	mockProvider := &MockLLMProvider{
 		responses: []provider.LLMResponse{
@@ -62,14 +63,12 @@ func TestSuccessfulArchitectCoderWorkflow(t *testing.T) {
 						},
 					},
 				},
-			},
-			// Architect final response after tool result
-			{
-				Text: "Plan created successfully",
+				Usage: provider.Usage{InputTokens: 10, OutputTokens: 2},
 			},
 			// Coder response - no tool calls, just completion
 			{
-				Text: "Plan executed successfully",
+				Text:  "Plan executed successfully",
+				Usage: provider.Usage{InputTokens: 5, OutputTokens: 3},
 			},
 		},
 	}
@@ -103,6 +102,11 @@ func TestSuccessfulArchitectCoderWorkflow(t *testing.T) {
 		t.Errorf("Expected 1 plan step, got %d", len(result.Plan.Steps))
 	}
 
+	// Usage covers both agents
+	if want := (provider.Usage{InputTokens: 15, OutputTokens: 5}); result.Usage != want {
+		t.Errorf("Expected usage %+v, got %+v", want, result.Usage)
+	}
+
 	// Verify final state is complete
 	state = orch.State()
 	if state.Phase != PhaseComplete {
@@ -110,6 +114,104 @@ func TestSuccessfulArchitectCoderWorkflow(t *testing.T) {
 	}
 }
 
+// promptRecordingProvider records the system prompt of each request.
+type promptRecordingProvider struct {
+	*MockLLMProvider
+	prompts []string
+}
+
+func (p *promptRecordingProvider) Generate(ctx context.Context, req provider.GenerateRequest) (*provider.LLMResponse, error) {
+	p.prompts = append(p.prompts, req.SystemPrompt)
+	return p.MockLLMProvider.Generate(ctx, req)
+}
+
+// TestAgentOptions verifies that per-agent options reach the architect and
+// the coder.
+func TestAgentOptions(t *testing.T) {
+	mock := &promptRecordingProvider{MockLLMProvider: &MockLLMProvider{
+		responses: []provider.LLMResponse{
+			{ToolCalls: []provider.ToolCall{{
+				ID:   "call_1",
+				Name: "finish_plan",
+				Arguments: map[string]interface{}{
+					"goal":  "Test goal",
+					"steps": []interface{}{map[string]interface{}{"description": "Step 1", "action": "respond"}},
+				},
+			}}},
+			{Text: "Done"},
+		},
+	}}
+
+	orch := NewOrchestrator(mock, t.TempDir(),
+		WithArchitectOptions(agent.WithSystemPrompt("architect prompt")),
+		WithCoderOptions(agent.WithSystemPrompt("coder prompt")),
+	)
+	if _, err := orch.Run(context.Background(), "Test goal"); err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+
+	want := []string{"architect prompt", "coder prompt"}
+	if len(mock.prompts) != len(want) {
+		t.Fatalf("prompts = %q, want %q", mock.prompts, want)
+	}
+	for i := range want {
+		if mock.prompts[i] != want[i] {
+			t.Errorf("call %d system prompt = %q, want %q", i+1, mock.prompts[i], want[i])
+		}
+	}
+}
+
+// roleRecordingProvider records the agent role of each request.
+type roleRecordingProvider struct {
+	*MockLLMProvider
+	roles []string
+}
+
+func (p *roleRecordingProvider) Generate(ctx context.Context, req provider.GenerateRequest) (*provider.LLMResponse, error) {
+	p.roles = append(p.roles, provider.RoleFromContext(ctx))
+	return p.MockLLMProvider.Generate(ctx, req)
+}
+
+// TestPerRoleProviders verifies that the architect and the coder can run
+// on their own providers, and that requests carry the agent's role.
+func TestPerRoleProviders(t *testing.T) {
+	architect := &roleRecordingProvider{MockLLMProvider: &MockLLMProvider{
+		responses: []provider.LLMResponse{
+			{ToolCalls: []provider.ToolCall{{
+				ID:   "call_1",
+				Name: "finish_plan",
+				Arguments: map[string]interface{}{
+					"goal":  "Test goal",
+					"steps": []interface{}{map[string]interface{}{"description": "Step 1", "action": "respond"}},
+				},
+			}}},
+		},
+	}}
+	coder := &roleRecordingProvider{MockLLMProvider: &MockLLMProvider{
+		responses: []provider.LLMResponse{{Text: "Done"}},
+	}}
+	shared := &roleRecordingProvider{MockLLMProvider: &MockLLMProvider{}}
+
+	orch := NewOrchestrator(shared, t.TempDir(), WithArchitectProvider(architect), WithCoderProvider(coder))
+	result, err := orch.Run(context.Background(), "Test goal")
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	if result.Summary != "Done" {
+		t.Errorf("summary = %q, want the coder provider's answer", result.Summary)
+	}
+
+	if len(shared.roles) != 0 {
+		t.Errorf("shared provider got %d requests, want none", len(shared.roles))
+	}
+	if len(architect.roles) != 1 || architect.roles[0] != agent.RoleArchitect {
+		t.Errorf("architect provider roles = %q", architect.roles)
+	}
+	if len(coder.roles) != 1 || coder.roles[0] != agent.RoleCoder {
+		t.Errorf("coder provider roles = %q", coder.roles)
+	}
+}
+
 // TestWorkflowStateTransitions verifies that the orchestrator correctly transitions
 // through phases: idle -> planning -> executing -> complete
 // Validates: Property 16
@@ -135,8 +237,6 @@ func TestWorkflowStateTransitions(t *testing.T) {
 					},
 				},
 			},
-			// Architect final response
-			{Text: "Done planning"},
 			// Coder final response
 			{Text: "Done executing"},
 		},
@@ -273,7 +373,7 @@ func (m *FailAfterNCallsProvider) Name() string {
 // the orchestrator returns the plan that was created along with the error.
 // Validates: Property 17
 func TestCoderFailureReturnsPartialResult(t *testing.T) {
-	// Provider that succeeds for architect (2 calls) but fails for coder
+	// Provider that succeeds for architect (1 call) but fails for coder
 	mockProvider := &FailAfterNCallsProvider{
 		responses: []provider.LLMResponse{
 			// Architect calls finish_plan
@@ -295,10 +395,8 @@ func TestCoderFailureReturnsPartialResult(t *testing.T) {
 					},
 				},
 			},
-			// Architect final response
-			{Text: "Plan created"},
 		},
-		failAfter: 2,
+		failAfter: 1,
 		failError: errors.New("coder LLM error"),
 	}
 
@@ -399,3 +497,73 @@ func containsHelper(s, substr string) bool {
 	}
 	return false
 }
+
+// cancelAfterNCallsProvider cancels the workflow's context on call N+1,
+// like a user pressing Ctrl-C, and then fails with the context's error.
+type cancelAfterNCallsProvider struct {
+	responses []provider.LLMResponse
+	callCount int
+	cancel    context.CancelFunc
+}
+
+func (p *cancelAfterNCallsProvider) Generate(ctx context.Context, req provider.GenerateRequest) (*provider.LLMResponse, error) {
+	if p.callCount >= len(p.responses) {
+		p.cancel()
+		return nil, ctx.Err()
+	}
+	resp := p.responses[p.callCount]
+	p.callCount++
+	return &resp, nil
+}
+
+func (p *cancelAfterNCallsProvider) Name() string {
+	return "cancel-after-n"
+}
+
+// TestCancelledWorkflowReportsPartialResult verifies that cancelling the
+// coder keeps the plan, the actions taken so far and the usage.
+func TestCancelledWorkflowReportsPartialResult(t *testing.T) {
+	ctx, cancel := context.WithCancel(context.Background())
+	defer cancel()
+
+	mockProvider := &cancelAfterNCallsProvider{
+		cancel: cancel,
+		responses: []provider.LLMResponse{
+			{
+				ToolCalls: []provider.ToolCall{{
+					ID:   "call_1",
+					Name: "finish_plan",
+					Arguments: map[string]interface{}{
+						"goal": "Test",
+						"steps": []interface{}{
+							map[string]interface{}{"description": "Do something", "action": "test"},
+						},
+					},
+				}},
+				Usage: provider.Usage{InputTokens: 7, OutputTokens: 2},
+			},
+		},
+	}
+
+	orch := NewOrchestrator(mockProvider, "/tmp/test")
+	result, err := orch.Run(ctx, "Test")
+
+	if !errors.Is(err, context.Canceled) {
+		t.Fatalf("Expected context.Canceled, got: %v", err)
+	}
+	if result.Success {
+		t.Error("Expected success=false for a cancelled workflow")
+	}
+	if result.Plan == nil || result.Plan.Goal != "Test" {
+		t.Errorf("Expected the captured plan, got %+v", result.Plan)
+	}
+	if len(result.ActionsTaken) != 1 {
+		t.Errorf("Expected the architect's finish_plan action, got %v", result.ActionsTaken)
+	}
+	if result.Usage.InputTokens != 7 {
+		t.Errorf("Expected the architect's usage, got %+v", result.Usage)
+	}
+	if state := orch.State(); state.Phase != PhaseCancelled {
+		t.Errorf("Expected phase cancelled, got %s", state.Phase)
+	}
+}
@@ -0,0 +1,119 @@
+package orchestrator
+
+import (
+	"context"
+	"fmt"
+	"strings"
+	"sync"
+
+	"agentic-poc/internal/provider"
+	"agentic-poc/internal/tool"
+)
+
+// WorkflowTool exposes an Orchestrator as the run_workflow tool, so MCP hosts
+// can run the Architect -> Coder workflow. An Orchestrator tracks a single
+// workflow at a time, so concurrent calls are run one after another.
+type WorkflowTool struct {
+	orchestrator *Orchestrator
+	mu           sync.Mutex
+}
+
+// NewWorkflowTool creates a run_workflow tool backed by o.
+func NewWorkflowTool(o *Orchestrator) *WorkflowTool {
+	return &WorkflowTool{orchestrator: o}
+}
+
+// Name returns the tool's identifier.
+func (t *WorkflowTool) Name() string {
+	return "run_workflow"
+}
+
+// Description returns what the tool does.
+func (t *WorkflowTool) Description() string {
+	return "Plans and carries out a goal using the Architect and Coder agents. Returns the plan, the actions taken and a summary as structured content."
+}
+
+// Annotations describes the tool's behavior for MCP clients.
+func (t *WorkflowTool) Annotations() tool.Annotations {
+	return tool.Annotations{DestructiveHint: true, OpenWorldHint: true}
+}
+
+// Parameters returns the JSON Schema for the tool's input.
+func (t *WorkflowTool) Parameters() map[string]interface{} {
+	return map[string]interface{}{
+		"type": "object",
+		"properties": map[string]interface{}{
+			"goal": map[string]interface{}{
+				"type":        "string",
+				"description": "The goal to accomplish",
+			},
+		},
+		"required": []string{"goal"},
+	}
+}
+
+// Execute runs the workflow for the given goal.
+func (t *WorkflowTool) Execute(ctx context.Context, args map[string]interface{}) (*provider.ToolResult, error) {
+	goal, ok := args["goal"].(string)
+	if !ok || goal == "" {
+		return &provider.ToolResult{
+			Success: false,
+			Error:   "missing or invalid 'goal' argument",
+		}, nil
+	}
+
+	t.mu.Lock()
+	defer t.mu.Unlock()
+
+	result, err := t.orchestrator.Run(ctx, goal)
+	if err != nil {
+		msg := err.Error()
+		if result != nil && result.Error != "" {
+			msg = result.Error
+		}
+		return &provider.ToolResult{
+			Success: false,
+			Error:   fmt.Sprintf("workflow failed: %s", msg),
+		}, nil
+	}
+
+	structured := map[string]interface{}{
+		"goal":    goal,
+		"plan":    result.Plan,
+		"actions": result.ActionsTaken,
+		"summary": result.Summary,
+	}
+
+	text := formatWorkflowResult(result)
+	return &provider.ToolResult{
+		Success: true,
+		Output:  text,
+		Content: []provider.ContentBlock{
+			provider.TextBlock(text),
+			{Type: provider.ContentStructured, Structured: structured},
+		},
+	}, nil
+}
+
+// formatWorkflowResult renders a successful result as plain text for
+// clients that do not read structured content.
+func formatWorkflowResult(result *OrchestratorResult) string {
+	var b strings.Builder
+	b.WriteString(result.Summary)
+
+	if result.Plan != nil {
+		b.WriteString("\n\nPlan:")
+		for i, step := range result.Plan.Steps {
+			fmt.Fprintf(&b, "\n%d. %s (%s)", i+1, step.Description, step.Action)
+		}
+	}
+
+	if len(result.ActionsTaken) > 0 {
+		b.WriteString("\n\nActions taken:")
+		for _, action := range result.ActionsTaken {
+			fmt.Fprintf(&b, "\n- %s", action)
+		}
+	}
+
+	return b.String()
+}
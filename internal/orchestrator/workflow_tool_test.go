@@ -0,0 +1,79 @@
+package orchestrator
+
+import (
+	"context"
+	"strings"
+	"testing"
+
+	"agentic-poc/internal/provider"
+	"agentic-poc/internal/tool"
+)
+
+func TestWorkflowTool_Execute(t *testing.T) {
+	mockProvider := &MockLLMProvider{
+		responses: []provider.LLMResponse{
+			{
+				ToolCalls: []provider.ToolCall{{
+					ID:   "call_1",
+					Name: "finish_plan",
+					Arguments: map[string]interface{}{
+						"goal": "Test goal",
+						"steps": []interface{}{
+							map[string]interface{}{"description": "Step 1", "action": "write_file"},
+						},
+					},
+				}},
+			},
+			{Text: "Plan executed successfully"},
+		},
+	}
+
+	wt := NewWorkflowTool(NewOrchestrator(mockProvider, t.TempDir()))
+
+	var progress []string
+	ctx := tool.WithProgress(context.Background(), func(m string) { progress = append(progress, m) })
+
+	result, err := wt.Execute(ctx, map[string]interface{}{"goal": "Test goal"})
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	if !result.Success {
+		t.Fatalf("expected success, got %q", result.Error)
+	}
+	if !strings.Contains(result.Output, "Plan executed successfully") || !strings.Contains(result.Output, "1. Step 1") {
+		t.Errorf("unexpected text output: %s", result.Output)
+	}
+
+	var structured map[string]interface{}
+	for _, b := range result.Content {
+		if b.Type == provider.ContentStructured {
+			structured = b.Structured.(map[string]interface{})
+		}
+	}
+	if structured == nil {
+		t.Fatal("expected structured content")
+	}
+	if structured["summary"] != "Plan executed successfully" || structured["plan"] == nil {
+		t.Errorf("unexpected structured content: %v", structured)
+	}
+
+	joined := strings.Join(progress, "\n")
+	for _, want := range []string{"Planning with architect agent", "architect: Running tool finish_plan", "Workflow complete"} {
+		if !strings.Contains(joined, want) {
+			t.Errorf("progress missing %q:\n%s", want, joined)
+		}
+	}
+}
+
+func TestWorkflowTool_Failure(t *testing.T) {
+	mockProvider := &MockLLMProvider{responses: []provider.LLMResponse{{Text: "no plan"}}}
+	wt := NewWorkflowTool(NewOrchestrator(mockProvider, t.TempDir()))
+
+	result, err := wt.Execute(context.Background(), map[string]interface{}{"goal": "x"})
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	if result.Success || !strings.Contains(result.Error, "did not produce a plan") {
+		t.Errorf("expected workflow failure, got %+v", result)
+	}
+}
@@ -10,6 +10,7 @@ import (
 	"io"
 	"net/http"
 	"os"
+	"sync"
 	"time"
 )
 
@@ -22,14 +23,25 @@ const (
 	DefaultTimeout = 60 * time.Second
 	// AnthropicAPIVersion is the required API version header.
 	AnthropicAPIVersion = "2023-06-01"
+	// DefaultMaxTokens is used when a request does not set MaxTokens.
+	DefaultMaxTokens = 4096
+	// MinThinkingBudget is the smallest extended thinking budget Claude
+	// accepts.
+	MinThinkingBudget = 1024
+	// maxCacheBreakpoints is the number of cache_control markers Claude
+	// accepts in one request.
+	maxCacheBreakpoints = 4
 )
 
 // ClaudeProvider implements LLMProvider for Anthropic's Claude API.
 type ClaudeProvider struct {
-	apiKey  string
-	model   string
-	client  *http.Client
-	baseURL string
+	apiKey      string
+	model       string
+	maxTokens   int      // Used when a request does not set MaxTokens
+	temperature *float64 // Nil leaves the API default
+	client      *http.Client
+	baseURL     string
+	modelMu     sync.RWMutex // Guards model, which SetModel may change mid-session
 }
 
 // ClaudeOption is a functional option for configuring ClaudeProvider.
@@ -42,6 +54,36 @@ func WithModel(model string) ClaudeOption {
 	}
 }
 
+// WithMaxTokens sets the output token limit of requests that do not set
+// MaxTokens. Values below 1 are ignored.
+func WithMaxTokens(n int) ClaudeOption {
+	return func(c *ClaudeProvider) {
+		if n > 0 {
+			c.maxTokens = n
+		}
+	}
+}
+
+// WithTemperature sets the sampling temperature of every request.
+func WithTemperature(t float64) ClaudeOption {
+	return func(c *ClaudeProvider) {
+		c.temperature = &t
+	}
+}
+
+// WithTimeout sets the timeout of each HTTP request to the API. It applies
+// to a copy of the HTTP client, so a client passed to WithHTTPClient
+// earlier is not modified. Values below 1 are ignored.
+func WithTimeout(timeout time.Duration) ClaudeOption {
+	return func(c *ClaudeProvider) {
+		if timeout > 0 {
+			client := *c.client
+			client.Timeout = timeout
+			c.client = &client
+		}
+	}
+}
+
 // WithHTTPClient sets a custom HTTP client.
 func WithHTTPClient(client *http.Client) ClaudeOption {
 	return func(c *ClaudeProvider) {
@@ -65,10 +107,11 @@ func NewClaudeProvider(opts ...ClaudeOption) (*ClaudeProvider, error) {
 	}
 
 	provider := &ClaudeProvider{
-		apiKey:  apiKey,
-		model:   DefaultClaudeModel,
-		client:  &http.Client{Timeout: DefaultTimeout},
-		baseURL: DefaultClaudeBaseURL,
+		apiKey:    apiKey,
+		model:     DefaultClaudeModel,
+		maxTokens: DefaultMaxTokens,
+		client:    &http.Client{Timeout: DefaultTimeout},
+		baseURL:   DefaultClaudeBaseURL,
 	}
 
 	for _, opt := range opts {
@@ -86,10 +129,11 @@ func NewClaudeProviderWithKey(apiKey string, opts ...ClaudeOption) (*ClaudeProvi
 	}
 
 	provider := &ClaudeProvider{
-		apiKey:  apiKey,
-		model:   DefaultClaudeModel,
-		client:  &http.Client{Timeout: DefaultTimeout},
-		baseURL: DefaultClaudeBaseURL,
+		apiKey:    apiKey,
+		model:     DefaultClaudeModel,
+		maxTokens: DefaultMaxTokens,
+		client:    &http.Client{Timeout: DefaultTimeout},
+		baseURL:   DefaultClaudeBaseURL,
 	}
 
 	for _, opt := range opts {
@@ -104,6 +148,20 @@ func (c *ClaudeProvider) Name() string {
 	return "claude"
 }
 
+// Model returns the Claude model used for new requests.
+func (c *ClaudeProvider) Model() string {
+	c.modelMu.RLock()
+	defer c.modelMu.RUnlock()
+	return c.model
+}
+
+// SetModel changes the Claude model used for new requests.
+func (c *ClaudeProvider) SetModel(model string) {
+	c.modelMu.Lock()
+	defer c.modelMu.Unlock()
+	c.model = model
+}
+
 // Generate sends a request to Claude and returns the response.
 func (c *ClaudeProvider) Generate(ctx context.Context, req GenerateRequest) (*LLMResponse, error) {
 	claudeReq, err := c.buildRequest(req)
@@ -145,11 +203,28 @@ func (c *ClaudeProvider) Generate(ctx context.Context, req GenerateRequest) (*LL
 
 // claudeRequest represents the request body for Claude API.
 type claudeRequest struct {
-	Model     string       `json:"model"`
-	MaxTokens int          `json:"max_tokens"`
-	System    string       `json:"system,omitempty"`
-	Messages  []claudeMsg  `json:"messages"`
-	Tools     []claudeTool `json:"tools,omitempty"`
+	Model         string            `json:"model"`
+	MaxTokens     int               `json:"max_tokens"`
+	Temperature   *float64          `json:"temperature,omitempty"`
+	TopP          *float64          `json:"top_p,omitempty"`
+	StopSequences []string          `json:"stop_sequences,omitempty"`
+	System        interface{}       `json:"system,omitempty"` // string, or []contentPart to cache it
+	Messages      []claudeMsg       `json:"messages"`
+	Tools         []claudeTool      `json:"tools,omitempty"`
+	ToolChoice    *claudeToolChoice `json:"tool_choice,omitempty"`
+	Thinking      *claudeThinking   `json:"thinking,omitempty"`
+}
+
+// claudeThinking represents the thinking field of a Claude request.
+type claudeThinking struct {
+	Type         string `json:"type"`
+	BudgetTokens int    `json:"budget_tokens"`
+}
+
+// claudeToolChoice represents the tool_choice field of a Claude request.
+type claudeToolChoice struct {
+	Type string `json:"type"`
+	Name string `json:"name,omitempty"`
 }
 
 // claudeMsg represents a message in Claude's format.
@@ -163,10 +238,33 @@ type contentPart struct {
 	Type      string                 `json:"type"`
 	Text      string                 `json:"text,omitempty"`
 	ToolUseID string                 `json:"tool_use_id,omitempty"`
-	Content   string                 `json:"content,omitempty"`
-	ID        string                 `json:"id,omitempty"`    // For tool_use blocks
-	Name      string                 `json:"name,omitempty"`  // For tool_use blocks
-	Input     map[string]interface{} `json:"input,omitempty"` // For tool_use blocks
+	Content   interface{}            `json:"content,omitempty"`   // For tool_result: string or []contentPart
+	Source    *claudeSource          `json:"source,omitempty"`    // For image and document blocks
+	Title     string                 `json:"title,omitempty"`     // For document blocks
+	ID        string                 `json:"id,omitempty"`        // For tool_use blocks
+	Name      string                 `json:"name,omitempty"`      // For tool_use blocks
+	Input     map[string]interface{} `json:"input,omitempty"`     // For tool_use blocks
+	IsError   bool                   `json:"is_error,omitempty"`  // For tool_result blocks
+	Thinking  string                 `json:"thinking,omitempty"`  // For thinking blocks
+	Signature string                 `json:"signature,omitempty"` // For thinking blocks
+	Data      string                 `json:"data,omitempty"`      // For redacted_thinking blocks
+
+	CacheControl *claudeCacheControl `json:"cache_control,omitempty"`
+}
+
+// claudeCacheControl marks the end of a cached prompt prefix.
+type claudeCacheControl struct {
+	Type string `json:"type"`
+}
+
+// ephemeralCache is the cache_control value of every breakpoint.
+var ephemeralCache = &claudeCacheControl{Type: "ephemeral"}
+
+// claudeSource represents the source of an image or document block.
+type claudeSource struct {
+	Type      string `json:"type"`
+	MediaType string `json:"media_type"`
+	Data      string `json:"data"`
 }
 
 // claudeTool represents a tool definition in Claude's format.
@@ -174,6 +272,8 @@ type claudeTool struct {
 	Name        string                 `json:"name"`
 	Description string                 `json:"description"`
 	InputSchema map[string]interface{} `json:"input_schema"`
+
+	CacheControl *claudeCacheControl `json:"cache_control,omitempty"`
 }
 
 // claudeResponse represents the response from Claude API.
@@ -190,17 +290,22 @@ type claudeResponse struct {
 
 // claudeContentBlock represents a content block in Claude's response.
 type claudeContentBlock struct {
-	Type  string                 `json:"type"`
-	Text  string                 `json:"text,omitempty"`
-	ID    string                 `json:"id,omitempty"`
-	Name  string                 `json:"name,omitempty"`
-	Input map[string]interface{} `json:"input,omitempty"`
+	Type      string                 `json:"type"`
+	Text      string                 `json:"text,omitempty"`
+	ID        string                 `json:"id,omitempty"`
+	Name      string                 `json:"name,omitempty"`
+	Input     map[string]interface{} `json:"input,omitempty"`
+	Thinking  string                 `json:"thinking,omitempty"`
+	Signature string                 `json:"signature,omitempty"`
+	Data      string                 `json:"data,omitempty"`
 }
 
 // claudeUsage represents token usage in Claude's response.
 type claudeUsage struct {
-	InputTokens  int `json:"input_tokens"`
-	OutputTokens int `json:"output_tokens"`
+	InputTokens              int `json:"input_tokens"`
+	OutputTokens             int `json:"output_tokens"`
+	CacheCreationInputTokens int `json:"cache_creation_input_tokens"`
+	CacheReadInputTokens     int `json:"cache_read_input_tokens"`
 }
 
 // claudeErrorResponse represents an error response from Claude API.
@@ -214,11 +319,27 @@ type claudeErrorResponse struct {
 
 // buildRequest converts a GenerateRequest to Claude's API format.
 func (c *ClaudeProvider) buildRequest(req GenerateRequest) (*claudeRequest, error) {
+	maxTokens := req.MaxTokens
+	if maxTokens <= 0 {
+		maxTokens = c.maxTokens
+	}
+
+	// A temperature set on the request takes precedence over the provider's
+	temperature := req.Temperature
+	if temperature == nil {
+		temperature = c.temperature
+	}
+
 	claudeReq := &claudeRequest{
-		Model:     c.model,
-		MaxTokens: 4096,
-		System:    req.SystemPrompt,
-		Messages:  make([]claudeMsg, 0, len(req.Messages)),
+		Model:         c.Model(),
+		MaxTokens:     maxTokens,
+		Temperature:   temperature,
+		TopP:          req.TopP,
+		StopSequences: req.StopSequences,
+		Messages:      make([]claudeMsg, 0, len(req.Messages)),
+	}
+	if req.SystemPrompt != "" {
+		claudeReq.System = req.SystemPrompt
 	}
 
 	// Convert messages to Claude format
@@ -239,9 +360,94 @@ func (c *ClaudeProvider) buildRequest(req GenerateRequest) (*claudeRequest, erro
 		})
 	}
 
+	if req.ToolChoice != nil {
+		choice, err := convertToolChoice(*req.ToolChoice, req.Tools)
+		if err != nil {
+			return nil, err
+		}
+		claudeReq.ToolChoice = choice
+	}
+
+	if req.ThinkingBudget > 0 {
+		if err := enableThinking(claudeReq, req); err != nil {
+			return nil, err
+		}
+	}
+
+	if req.Cache != nil {
+		addCacheBreakpoints(claudeReq, *req.Cache)
+	}
+
 	return claudeReq, nil
 }
 
+// enableThinking turns on extended thinking for a request. The budget is
+// added to max_tokens, which must exceed it, so reasoning does not eat
+// into the answer. Claude rejects a temperature or top_p with thinking, so
+// they are dropped. It also rejects forcing a tool, so a request that
+// forces one is sent without thinking.
+func enableThinking(claudeReq *claudeRequest, req GenerateRequest) error {
+	if req.ThinkingBudget < MinThinkingBudget {
+		return fmt.Errorf("thinking budget %d is below the minimum of %d", req.ThinkingBudget, MinThinkingBudget)
+	}
+	if c := req.ToolChoice; c != nil && (c.Mode == ToolChoiceAny || c.Mode == ToolChoiceTool) {
+		return nil
+	}
+
+	claudeReq.Thinking = &claudeThinking{Type: "enabled", BudgetTokens: req.ThinkingBudget}
+	claudeReq.MaxTokens += req.ThinkingBudget
+	claudeReq.Temperature = nil
+	claudeReq.TopP = nil
+	return nil
+}
+
+// addCacheBreakpoints marks the parts of a request selected by policy with
+// cache_control. Claude caches the prefix in the order tools, system,
+// messages, so each breakpoint also covers everything before it. Message
+// breakpoints are dropped beyond Claude's limit of four per request.
+func addCacheBreakpoints(req *claudeRequest, policy CachePolicy) {
+	used := 0
+	if policy.Tools && len(req.Tools) > 0 {
+		req.Tools[len(req.Tools)-1].CacheControl = ephemeralCache
+		used++
+	}
+	if system, ok := req.System.(string); ok && policy.System {
+		req.System = []contentPart{{Type: "text", Text: system, CacheControl: ephemeralCache}}
+		used++
+	}
+
+	n := policy.Messages
+	if n > maxCacheBreakpoints-used {
+		n = maxCacheBreakpoints - used
+	}
+	for i := len(req.Messages) - 1; i >= 0 && n > 0; i-- {
+		content := req.Messages[i].Content
+		if len(content) == 0 {
+			continue
+		}
+		content[len(content)-1].CacheControl = ephemeralCache
+		n--
+	}
+}
+
+// convertToolChoice converts a ToolChoice to Claude's format, checking that
+// a forced tool is one of the request's tools.
+func convertToolChoice(choice ToolChoice, tools []ToolDefinition) (*claudeToolChoice, error) {
+	switch choice.Mode {
+	case ToolChoiceAuto, ToolChoiceAny, ToolChoiceNone:
+		return &claudeToolChoice{Type: string(choice.Mode)}, nil
+	case ToolChoiceTool:
+		for _, t := range tools {
+			if t.Name == choice.Name {
+				return &claudeToolChoice{Type: "tool", Name: choice.Name}, nil
+			}
+		}
+		return nil, fmt.Errorf("tool choice names unknown tool %q", choice.Name)
+	default:
+		return nil, fmt.Errorf("invalid tool choice mode %q", choice.Mode)
+	}
+}
+
 // convertMessage converts a Message to Claude's message format.
 func (c *ClaudeProvider) convertMessage(msg Message) (claudeMsg, error) {
 	cm := claudeMsg{
@@ -252,14 +458,27 @@ func (c *ClaudeProvider) convertMessage(msg Message) (claudeMsg, error) {
 	// Handle tool result messages
 	if msg.ToolCallID != "" {
 		cm.Role = "user"
-		cm.Content = append(cm.Content, contentPart{
+		part := contentPart{
 			Type:      "tool_result",
 			ToolUseID: msg.ToolCallID,
 			Content:   msg.Content,
-		})
+		}
+		if len(msg.Blocks) > 0 {
+			part.Content = convertBlocks(msg.Blocks)
+		}
+		if msg.ToolError != nil {
+			part.IsError = true
+			part.Content = msg.ToolError.Describe()
+		}
+		cm.Content = append(cm.Content, part)
 		return cm, nil
 	}
 
+	// Thinking comes first in an assistant message, exactly as received
+	if msg.Role == "assistant" {
+		cm.Content = append(cm.Content, thinkingParts(msg.Thinking)...)
+	}
+
 	// Handle assistant messages with tool calls
 	if msg.Role == "assistant" && len(msg.ToolCalls) > 0 {
 		// Add text content if present
@@ -281,6 +500,15 @@ func (c *ClaudeProvider) convertMessage(msg Message) (claudeMsg, error) {
 		return cm, nil
 	}
 
+	// Handle messages with rich content, such as attached images, falling
+	// back to the text form if none of the blocks could be converted
+	if len(msg.Blocks) > 0 {
+		if parts := convertBlocks(msg.Blocks); len(parts) > 0 {
+			cm.Content = append(cm.Content, parts...)
+			return cm, nil
+		}
+	}
+
 	// Handle regular text messages
 	cm.Content = append(cm.Content, contentPart{
 		Type: "text",
@@ -290,6 +518,90 @@ func (c *ClaudeProvider) convertMessage(msg Message) (claudeMsg, error) {
 	return cm, nil
 }
 
+// thinkingParts converts thinking blocks back to Claude content parts.
+func thinkingParts(thinking []Thinking) []contentPart {
+	parts := make([]contentPart, 0, len(thinking))
+	for _, t := range thinking {
+		if t.Redacted != "" {
+			parts = append(parts, contentPart{Type: "redacted_thinking", Data: t.Redacted})
+			continue
+		}
+		parts = append(parts, contentPart{Type: "thinking", Thinking: t.Text, Signature: t.Signature})
+	}
+	return parts
+}
+
+// convertBlocks converts content blocks to Claude content parts.
+// Claude accepts text, images and PDF documents; other block types are
+// rendered as text.
+func convertBlocks(blocks []ContentBlock) []contentPart {
+	parts := make([]contentPart, 0, len(blocks))
+	for _, b := range blocks {
+		switch {
+		case (b.Type == ContentImage || b.Type == ContentResource && b.Data != "") && isImageMime(b.MimeType):
+			parts = append(parts, contentPart{
+				Type: "image",
+				Source: &claudeSource{
+					Type:      "base64",
+					MediaType: b.MimeType,
+					Data:      b.Data,
+				},
+			})
+		case (b.Type == ContentDocument || b.Type == ContentResource && b.Data != "") && b.MimeType == "application/pdf":
+			parts = append(parts, contentPart{
+				Type: "document",
+				Source: &claudeSource{
+					Type:      "base64",
+					MediaType: b.MimeType,
+					Data:      b.Data,
+				},
+				Title: b.Name,
+			})
+		default:
+			if text := blockText(b); text != "" {
+				parts = append(parts, contentPart{Type: "text", Text: text})
+			}
+		}
+	}
+	return parts
+}
+
+// blockText renders a content block Claude can't accept natively as text.
+func blockText(b ContentBlock) string {
+	switch b.Type {
+	case ContentText:
+		return b.Text
+	case ContentImage:
+		return fmt.Sprintf("[Image (%s) omitted: unsupported format]", b.MimeType)
+	case ContentStructured:
+		data, err := json.Marshal(b.Structured)
+		if err != nil {
+			return fmt.Sprintf("%v", b.Structured)
+		}
+		return string(data)
+	case ContentResource:
+		if b.Text != "" {
+			return fmt.Sprintf("Resource %s:\n%s", b.URI, b.Text)
+		}
+		return fmt.Sprintf("[Resource: %s %s (%s)]", b.Name, b.URI, b.MimeType)
+	case ContentAudio:
+		return fmt.Sprintf("[Audio content (%s) omitted: not supported by this model]", b.MimeType)
+	case ContentDocument:
+		return fmt.Sprintf("[Document %s (%s) omitted: unsupported format]", b.Name, b.MimeType)
+	default:
+		return b.Text
+	}
+}
+
+// isImageMime reports whether a MIME type is an image type Claude accepts.
+func isImageMime(mimeType string) bool {
+	switch mimeType {
+	case "image/png", "image/jpeg", "image/gif", "image/webp":
+		return true
+	}
+	return false
+}
+
 // parseResponse parses Claude's response into an LLMResponse.
 func (c *ClaudeProvider) parseResponse(body []byte) (*LLMResponse, error) {
 	var resp claudeResponse
@@ -299,6 +611,16 @@ func (c *ClaudeProvider) parseResponse(body []byte) (*LLMResponse, error) {
 
 	llmResp := &LLMResponse{
 		ToolCalls: make([]ToolCall, 0),
+		Usage: Usage{
+			InputTokens:      resp.Usage.InputTokens,
+			OutputTokens:     resp.Usage.OutputTokens,
+			CacheWriteTokens: resp.Usage.CacheCreationInputTokens,
+			CacheReadTokens:  resp.Usage.CacheReadInputTokens,
+		},
+		StopReason: StopReason(resp.StopReason),
+	}
+	if resp.StopSequence != nil {
+		llmResp.StopSequence = *resp.StopSequence
 	}
 
 	for _, block := range resp.Content {
@@ -311,31 +633,24 @@ func (c *ClaudeProvider) parseResponse(body []byte) (*LLMResponse, error) {
 				Name:      block.Name,
 				Arguments: block.Input,
 			})
+		case "thinking":
+			llmResp.Thinking = append(llmResp.Thinking, Thinking{Text: block.Thinking, Signature: block.Signature})
+		case "redacted_thinking":
+			llmResp.Thinking = append(llmResp.Thinking, Thinking{Redacted: block.Data})
 		}
 	}
 
 	return llmResp, nil
 }
 
-// handleErrorResponse creates an appropriate error for non-200 responses.
+// handleErrorResponse creates an *APIError for non-200 responses.
 func (c *ClaudeProvider) handleErrorResponse(statusCode int, body []byte) error {
+	apiErr := &APIError{Provider: "claude", StatusCode: statusCode, Message: string(body)}
+
 	var errResp claudeErrorResponse
-	if err := json.Unmarshal(body, &errResp); err != nil {
-		return fmt.Errorf("claude: API error (status %d): %s", statusCode, string(body))
-	}
-
-	switch statusCode {
-	case http.StatusUnauthorized:
-		return fmt.Errorf("claude: authentication failed: %s", errResp.Error.Message)
-	case http.StatusForbidden:
-		return fmt.Errorf("claude: access forbidden: %s", errResp.Error.Message)
-	case http.StatusTooManyRequests:
-		return fmt.Errorf("claude: rate limit exceeded: %s", errResp.Error.Message)
-	case http.StatusBadRequest:
-		return fmt.Errorf("claude: bad request: %s", errResp.Error.Message)
-	case http.StatusInternalServerError, http.StatusBadGateway, http.StatusServiceUnavailable:
-		return fmt.Errorf("claude: server error (status %d): %s", statusCode, errResp.Error.Message)
-	default:
-		return fmt.Errorf("claude: API error (status %d): %s", statusCode, errResp.Error.Message)
+	if err := json.Unmarshal(body, &errResp); err == nil {
+		apiErr.Type = errResp.Error.Type
+		apiErr.Message = errResp.Error.Message
 	}
+	return apiErr
 }
@@ -3,9 +3,11 @@ package provider
 import (
 	"context"
 	"encoding/json"
+	"fmt"
 	"net/http"
 	"net/http/httptest"
 	"os"
+	"strings"
 	"testing"
 	"time"
 )
@@ -143,6 +145,51 @@ func TestClaudeProviderOptions(t *testing.T) {
 	}
 }
 
+func TestClaudeProviderTemperatureAndTimeout(t *testing.T) {
+	var received map[string]interface{}
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		json.NewDecoder(r.Body).Decode(&received)
+		w.Header().Set("Content-Type", "application/json")
+		json.NewEncoder(w).Encode(claudeResponse{Content: []claudeContentBlock{{Type: "text", Text: "ok"}}})
+	}))
+	defer server.Close()
+
+	customClient := &http.Client{Timeout: 30 * time.Second}
+	provider, err := NewClaudeProviderWithKey("test-key",
+		WithBaseURL(server.URL),
+		WithHTTPClient(customClient),
+		WithTimeout(5*time.Second),
+		WithTemperature(0),
+	)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	if provider.client.Timeout != 5*time.Second {
+		t.Errorf("timeout = %v, want 5s", provider.client.Timeout)
+	}
+	if customClient.Timeout != 30*time.Second {
+		t.Error("WithTimeout should not modify the client passed to WithHTTPClient")
+	}
+
+	if _, err := provider.Generate(context.Background(), GenerateRequest{Messages: []Message{{Role: "user", Content: "Hi"}}}); err != nil {
+		t.Fatal(err)
+	}
+	if temp, ok := received["temperature"]; !ok || temp != 0.0 {
+		t.Errorf("temperature = %v (present %v), want 0", temp, ok)
+	}
+
+	// Without WithTemperature the API default applies
+	provider, _ = NewClaudeProviderWithKey("test-key", WithBaseURL(server.URL))
+	received = nil
+	if _, err := provider.Generate(context.Background(), GenerateRequest{Messages: []Message{{Role: "user", Content: "Hi"}}}); err != nil {
+		t.Fatal(err)
+	}
+	if _, ok := received["temperature"]; ok {
+		t.Error("temperature should be omitted by default")
+	}
+}
+
 func TestClaudeProviderGenerate_TextResponse(t *testing.T) {
 	// Create mock server
 	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
@@ -197,6 +244,10 @@ func TestClaudeProviderGenerate_TextResponse(t *testing.T) {
 	if resp.HasToolCalls() {
 		t.Error("expected no tool calls")
 	}
+
+	if want := (Usage{InputTokens: 10, OutputTokens: 8}); resp.Usage != want {
+		t.Errorf("usage = %+v, want %+v", resp.Usage, want)
+	}
 }
 
 func TestClaudeProviderGenerate_ToolCallResponse(t *testing.T) {
@@ -316,6 +367,380 @@ func TestClaudeProviderGenerate_WithSystemPrompt(t *testing.T) {
 	}
 }
 
+func TestClaudeProviderGenerate_MaxTokens(t *testing.T) {
+	tests := []struct {
+		name      string
+		opts      []ClaudeOption
+		maxTokens int
+		want      int
+	}{
+		{name: "default when unset", maxTokens: 0, want: DefaultMaxTokens},
+		{name: "explicit value", maxTokens: 256, want: 256},
+		{name: "provider default", opts: []ClaudeOption{WithMaxTokens(1024)}, want: 1024},
+		{name: "request wins over provider default", opts: []ClaudeOption{WithMaxTokens(1024)}, maxTokens: 256, want: 256},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			var receivedReq claudeRequest
+
+			server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+				json.NewDecoder(r.Body).Decode(&receivedReq)
+				w.Header().Set("Content-Type", "application/json")
+				json.NewEncoder(w).Encode(claudeResponse{
+					Content: []claudeContentBlock{{Type: "text", Text: "ok"}},
+				})
+			}))
+			defer server.Close()
+
+			provider, err := NewClaudeProviderWithKey("test-api-key", append(tt.opts, WithBaseURL(server.URL))...)
+			if err != nil {
+				t.Fatalf("failed to create provider: %v", err)
+			}
+
+			req := GenerateRequest{
+				Messages:  []Message{{Role: "user", Content: "Hello"}},
+				MaxTokens: tt.maxTokens,
+			}
+			if _, err := provider.Generate(context.Background(), req); err != nil {
+				t.Fatalf("unexpected error: %v", err)
+			}
+
+			if receivedReq.MaxTokens != tt.want {
+				t.Errorf("expected max_tokens %d, got %d", tt.want, receivedReq.MaxTokens)
+			}
+		})
+	}
+}
+
+func TestClaudeProviderGenerate_SamplingParameters(t *testing.T) {
+	var received map[string]interface{}
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		json.NewDecoder(r.Body).Decode(&received)
+		w.Header().Set("Content-Type", "application/json")
+		json.NewEncoder(w).Encode(claudeResponse{Content: []claudeContentBlock{{Type: "text", Text: "ok"}}})
+	}))
+	defer server.Close()
+
+	provider, err := NewClaudeProviderWithKey("test-api-key", WithBaseURL(server.URL), WithTemperature(0.9))
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	temperature, topP := 0.2, 0.8
+	req := GenerateRequest{
+		Messages:      []Message{{Role: "user", Content: "Hello"}},
+		Tools:         []ToolDefinition{{Name: "finish_plan", Parameters: map[string]interface{}{"type": "object"}}},
+		Temperature:   &temperature,
+		TopP:          &topP,
+		StopSequences: []string{"</plan>"},
+		ToolChoice:    ForceTool("finish_plan"),
+	}
+	if _, err := provider.Generate(context.Background(), req); err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+
+	if received["temperature"] != 0.2 {
+		t.Errorf("temperature = %v, want the request's 0.2", received["temperature"])
+	}
+	if received["top_p"] != 0.8 {
+		t.Errorf("top_p = %v, want 0.8", received["top_p"])
+	}
+	if stops, _ := received["stop_sequences"].([]interface{}); len(stops) != 1 || stops[0] != "</plan>" {
+		t.Errorf("stop_sequences = %v", received["stop_sequences"])
+	}
+	choice, _ := received["tool_choice"].(map[string]interface{})
+	if choice["type"] != "tool" || choice["name"] != "finish_plan" {
+		t.Errorf("tool_choice = %v, want finish_plan forced", received["tool_choice"])
+	}
+
+	// Unset fields are omitted so the API defaults apply
+	received = nil
+	if _, err := provider.Generate(context.Background(), GenerateRequest{Messages: []Message{{Role: "user", Content: "Hi"}}}); err != nil {
+		t.Fatal(err)
+	}
+	for _, field := range []string{"top_p", "stop_sequences", "tool_choice"} {
+		if _, ok := received[field]; ok {
+			t.Errorf("%s should be omitted when unset", field)
+		}
+	}
+}
+
+func TestClaudeBuildRequest_ToolChoice(t *testing.T) {
+	tools := []ToolDefinition{{Name: "read_file"}}
+	tests := []struct {
+		name    string
+		choice  ToolChoice
+		want    claudeToolChoice
+		wantErr string
+	}{
+		{name: "auto", choice: ToolChoice{Mode: ToolChoiceAuto}, want: claudeToolChoice{Type: "auto"}},
+		{name: "any", choice: ToolChoice{Mode: ToolChoiceAny}, want: claudeToolChoice{Type: "any"}},
+		{name: "none", choice: ToolChoice{Mode: ToolChoiceNone}, want: claudeToolChoice{Type: "none"}},
+		{name: "forced tool", choice: *ForceTool("read_file"), want: claudeToolChoice{Type: "tool", Name: "read_file"}},
+		{name: "unknown tool", choice: *ForceTool("write_file"), wantErr: `unknown tool "write_file"`},
+		{name: "invalid mode", choice: ToolChoice{Mode: "sometimes"}, wantErr: "invalid tool choice mode"},
+	}
+
+	provider, _ := NewClaudeProviderWithKey("test-api-key")
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			choice := tt.choice
+			req, err := provider.buildRequest(GenerateRequest{
+				Messages:   []Message{{Role: "user", Content: "Hi"}},
+				Tools:      tools,
+				ToolChoice: &choice,
+			})
+			if tt.wantErr != "" {
+				if err == nil || !strings.Contains(err.Error(), tt.wantErr) {
+					t.Errorf("error = %v, want one containing %q", err, tt.wantErr)
+				}
+				return
+			}
+			if err != nil {
+				t.Fatalf("unexpected error: %v", err)
+			}
+			if *req.ToolChoice != tt.want {
+				t.Errorf("tool_choice = %+v, want %+v", *req.ToolChoice, tt.want)
+			}
+		})
+	}
+}
+
+func TestClaudeParseResponse_StopReason(t *testing.T) {
+	tests := []struct {
+		body         string
+		wantReason   StopReason
+		wantSequence string
+		truncated    bool
+	}{
+		{body: `{"content":[{"type":"text","text":"done"}],"stop_reason":"end_turn"}`, wantReason: StopEndTurn},
+		{body: `{"content":[{"type":"text","text":"cut"}],"stop_reason":"max_tokens"}`, wantReason: StopMaxTokens, truncated: true},
+		{body: `{"content":[],"stop_reason":"stop_sequence","stop_sequence":"</plan>"}`, wantReason: StopSequenceMatched, wantSequence: "</plan>"},
+		{body: `{"content":[{"type":"tool_use","id":"t1","name":"x","input":{}}],"stop_reason":"tool_use"}`, wantReason: StopToolUse},
+	}
+
+	provider, _ := NewClaudeProviderWithKey("test-api-key")
+	for _, tt := range tests {
+		t.Run(string(tt.wantReason), func(t *testing.T) {
+			resp, err := provider.parseResponse([]byte(tt.body))
+			if err != nil {
+				t.Fatal(err)
+			}
+			if resp.StopReason != tt.wantReason || resp.StopSequence != tt.wantSequence {
+				t.Errorf("stop = %q/%q, want %q/%q", resp.StopReason, resp.StopSequence, tt.wantReason, tt.wantSequence)
+			}
+			if resp.Truncated() != tt.truncated {
+				t.Errorf("Truncated() = %v, want %v", resp.Truncated(), tt.truncated)
+			}
+		})
+	}
+}
+
+func TestClaudeBuildRequest_Thinking(t *testing.T) {
+	temperature := 0.5
+	tools := []ToolDefinition{{Name: "finish_plan"}}
+	tests := []struct {
+		name          string
+		budget        int
+		choice        *ToolChoice
+		wantThinking  bool
+		wantMaxTokens int
+		wantErr       string
+	}{
+		{name: "disabled", budget: 0, wantMaxTokens: 1000},
+		{name: "enabled", budget: 2048, wantThinking: true, wantMaxTokens: 3048},
+		{name: "auto tool choice", budget: 2048, choice: &ToolChoice{Mode: ToolChoiceAuto}, wantThinking: true, wantMaxTokens: 3048},
+		{name: "forced tool", budget: 2048, choice: ForceTool("finish_plan"), wantMaxTokens: 1000},
+		{name: "below minimum", budget: 500, wantErr: "below the minimum of 1024"},
+	}
+
+	provider, _ := NewClaudeProviderWithKey("test-api-key", WithMaxTokens(1000))
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			req, err := provider.buildRequest(GenerateRequest{
+				Messages:       []Message{{Role: "user", Content: "Hi"}},
+				Tools:          tools,
+				Temperature:    &temperature,
+				ToolChoice:     tt.choice,
+				ThinkingBudget: tt.budget,
+			})
+			if tt.wantErr != "" {
+				if err == nil || !strings.Contains(err.Error(), tt.wantErr) {
+					t.Errorf("error = %v, want one containing %q", err, tt.wantErr)
+				}
+				return
+			}
+			if err != nil {
+				t.Fatalf("unexpected error: %v", err)
+			}
+
+			if (req.Thinking != nil) != tt.wantThinking {
+				t.Fatalf("thinking = %+v, want enabled %v", req.Thinking, tt.wantThinking)
+			}
+			if req.MaxTokens != tt.wantMaxTokens {
+				t.Errorf("max_tokens = %d, want %d", req.MaxTokens, tt.wantMaxTokens)
+			}
+			if tt.wantThinking {
+				if *req.Thinking != (claudeThinking{Type: "enabled", BudgetTokens: tt.budget}) {
+					t.Errorf("thinking = %+v", *req.Thinking)
+				}
+				if req.Temperature != nil {
+					t.Error("temperature should be dropped with thinking enabled")
+				}
+			} else if req.Temperature == nil {
+				t.Error("temperature should be kept without thinking")
+			}
+		})
+	}
+}
+
+func TestClaudeThinkingRoundTrip(t *testing.T) {
+	provider, _ := NewClaudeProviderWithKey("test-api-key")
+	resp, err := provider.parseResponse([]byte(`{"content":[
+		{"type":"thinking","thinking":"Check the file.","signature":"sig"},
+		{"type":"redacted_thinking","data":"opaque"},
+		{"type":"tool_use","id":"t1","name":"read_file","input":{}}
+	],"stop_reason":"tool_use"}`))
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	want := []Thinking{{Text: "Check the file.", Signature: "sig"}, {Redacted: "opaque"}}
+	if len(resp.Thinking) != 2 || resp.Thinking[0] != want[0] || resp.Thinking[1] != want[1] {
+		t.Fatalf("thinking = %+v, want %+v", resp.Thinking, want)
+	}
+
+	// Sent back, the thinking precedes the tool call unchanged
+	cm, err := provider.convertMessage(Message{Role: "assistant", ToolCalls: resp.ToolCalls, Thinking: resp.Thinking})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(cm.Content) != 3 {
+		t.Fatalf("content = %+v, want thinking, redacted thinking and tool_use", cm.Content)
+	}
+	if p := cm.Content[0]; p.Type != "thinking" || p.Thinking != "Check the file." || p.Signature != "sig" {
+		t.Errorf("first part = %+v", p)
+	}
+	if p := cm.Content[1]; p.Type != "redacted_thinking" || p.Data != "opaque" {
+		t.Errorf("second part = %+v", p)
+	}
+	if cm.Content[2].Type != "tool_use" {
+		t.Errorf("third part = %+v, want tool_use", cm.Content[2])
+	}
+}
+
+func TestClaudeBuildRequest_CacheBreakpoints(t *testing.T) {
+	messages := []Message{
+		{Role: "user", Content: "one"},
+		{Role: "assistant", Content: "two"},
+		{Role: "user", Content: "three"},
+		{Role: "assistant", Content: "four"},
+		{Role: "user", Content: "five"},
+	}
+	tools := []ToolDefinition{{Name: "a"}, {Name: "b"}}
+
+	tests := []struct {
+		name         string
+		system       string
+		policy       *CachePolicy
+		wantSystem   bool
+		wantTools    bool
+		wantMessages []int // Indexes of messages with a breakpoint
+	}{
+		{name: "no policy", system: "sys"},
+		{name: "system and tools", system: "sys", policy: &CachePolicy{System: true, Tools: true}, wantSystem: true, wantTools: true},
+		{name: "last message", system: "sys", policy: &CachePolicy{Messages: 1}, wantMessages: []int{4}},
+		{name: "limit of four", system: "sys", policy: &CachePolicy{System: true, Tools: true, Messages: 3}, wantSystem: true, wantTools: true, wantMessages: []int{3, 4}},
+		{name: "empty system is not cached", policy: &CachePolicy{System: true, Messages: 4}, wantMessages: []int{1, 2, 3, 4}},
+		{name: "more than the messages", policy: &CachePolicy{Messages: 4}, wantMessages: []int{1, 2, 3, 4}},
+	}
+
+	provider, _ := NewClaudeProviderWithKey("test-api-key")
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			req, err := provider.buildRequest(GenerateRequest{
+				Messages:     messages,
+				Tools:        tools,
+				SystemPrompt: tt.system,
+				Cache:        tt.policy,
+			})
+			if err != nil {
+				t.Fatal(err)
+			}
+
+			system, cached := req.System.([]contentPart)
+			if cached != tt.wantSystem {
+				t.Errorf("system = %#v, want cached %v", req.System, tt.wantSystem)
+			}
+			if cached && (system[0].Text != tt.system || system[0].CacheControl == nil) {
+				t.Errorf("cached system block = %+v", system[0])
+			}
+			if tt.system == "" && req.System != nil {
+				t.Errorf("empty system prompt should be omitted, got %#v", req.System)
+			}
+
+			if req.Tools[0].CacheControl != nil || (req.Tools[1].CacheControl != nil) != tt.wantTools {
+				t.Errorf("tool breakpoints = %v, %v; want only the last, %v", req.Tools[0].CacheControl, req.Tools[1].CacheControl, tt.wantTools)
+			}
+
+			var got []int
+			for i, msg := range req.Messages {
+				if msg.Content[len(msg.Content)-1].CacheControl != nil {
+					got = append(got, i)
+				}
+			}
+			if fmt.Sprint(got) != fmt.Sprint(tt.wantMessages) {
+				t.Errorf("message breakpoints = %v, want %v", got, tt.wantMessages)
+			}
+		})
+	}
+}
+
+func TestClaudeProviderGenerate_CacheUsage(t *testing.T) {
+	var received map[string]interface{}
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		json.NewDecoder(r.Body).Decode(&received)
+		w.Header().Set("Content-Type", "application/json")
+		w.Write([]byte(`{"content":[{"type":"text","text":"ok"}],"stop_reason":"end_turn",
+			"usage":{"input_tokens":12,"output_tokens":3,"cache_creation_input_tokens":200,"cache_read_input_tokens":1800}}`))
+	}))
+	defer server.Close()
+
+	provider, _ := NewClaudeProviderWithKey("test-api-key", WithBaseURL(server.URL))
+	resp, err := provider.Generate(context.Background(), GenerateRequest{
+		Messages:     []Message{{Role: "user", Content: "Hi"}},
+		SystemPrompt: "Be brief.",
+		Cache:        &CachePolicy{System: true},
+	})
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	want := Usage{InputTokens: 12, OutputTokens: 3, CacheWriteTokens: 200, CacheReadTokens: 1800}
+	if resp.Usage != want {
+		t.Errorf("usage = %+v, want %+v", resp.Usage, want)
+	}
+
+	system, _ := received["system"].([]interface{})
+	if len(system) != 1 {
+		t.Fatalf("system = %#v, want one cached block", received["system"])
+	}
+	block, _ := system[0].(map[string]interface{})
+	if control, _ := block["cache_control"].(map[string]interface{}); control["type"] != "ephemeral" {
+		t.Errorf("system block = %v, want an ephemeral cache_control", block)
+	}
+}
+
+func TestUsageAdd(t *testing.T) {
+	a := Usage{InputTokens: 1, OutputTokens: 2, CacheWriteTokens: 3, CacheReadTokens: 4}
+	b := Usage{InputTokens: 10, OutputTokens: 20, CacheWriteTokens: 30, CacheReadTokens: 40}
+
+	if got, want := a.Add(b), (Usage{InputTokens: 11, OutputTokens: 22, CacheWriteTokens: 33, CacheReadTokens: 44}); got != want {
+		t.Errorf("Add = %+v, want %+v", got, want)
+	}
+}
+
 func TestClaudeProviderGenerate_ToolResultMessage(t *testing.T) {
 	var receivedReq claudeRequest
 
@@ -378,6 +803,70 @@ func TestClaudeProviderGenerate_ToolResultMessage(t *testing.T) {
 	}
 }
 
+func TestClaudeProviderGenerate_ToolResultWithImage(t *testing.T) {
+	var receivedBody map[string]interface{}
+
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		json.NewDecoder(r.Body).Decode(&receivedBody)
+		w.Header().Set("Content-Type", "application/json")
+		json.NewEncoder(w).Encode(claudeResponse{
+			Content: []claudeContentBlock{{Type: "text", Text: "I see a chart."}},
+		})
+	}))
+	defer server.Close()
+
+	provider, err := NewClaudeProviderWithKey("test-api-key", WithBaseURL(server.URL))
+	if err != nil {
+		t.Fatalf("failed to create provider: %v", err)
+	}
+
+	req := GenerateRequest{
+		Messages: []Message{
+			{Role: "user", Content: "Take a screenshot"},
+			{Role: "tool", Content: "screenshot taken", ToolCallID: "toolu_1", Blocks: []ContentBlock{
+				TextBlock("screenshot taken"),
+				ImageBlock("image/png", "iVBORw0="),
+				{Type: ContentStructured, Structured: map[string]interface{}{"width": 800}},
+				ImageBlock("image/svg+xml", "PHN2Zz4="),
+			}},
+		},
+	}
+
+	if _, err := provider.Generate(context.Background(), req); err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+
+	messages := receivedBody["messages"].([]interface{})
+	toolResult := messages[1].(map[string]interface{})["content"].([]interface{})[0].(map[string]interface{})
+	if toolResult["type"] != "tool_result" {
+		t.Fatalf("expected tool_result, got %v", toolResult["type"])
+	}
+
+	parts, ok := toolResult["content"].([]interface{})
+	if !ok {
+		t.Fatalf("expected tool_result content array, got %T", toolResult["content"])
+	}
+	if len(parts) != 4 {
+		t.Fatalf("expected 4 content parts, got %d", len(parts))
+	}
+
+	image := parts[1].(map[string]interface{})
+	source, _ := image["source"].(map[string]interface{})
+	if image["type"] != "image" || source["type"] != "base64" || source["media_type"] != "image/png" || source["data"] != "iVBORw0=" {
+		t.Errorf("unexpected image part: %v", image)
+	}
+
+	structured := parts[2].(map[string]interface{})
+	if structured["type"] != "text" || structured["text"] != `{"width":800}` {
+		t.Errorf("structured content should be sent as JSON text, got %v", structured)
+	}
+
+	unsupported := parts[3].(map[string]interface{})
+	if unsupported["type"] != "text" {
+		t.Errorf("unsupported image format should be sent as text, got %v", unsupported)
+	}
+}
+
 func TestClaudeProviderGenerate_ErrorResponses(t *testing.T) {
 	tests := []struct {
 		name       string
@@ -546,3 +1035,157 @@ func containsSubstringHelper(s, substr string) bool {
 	}
 	return false
 }
+
+func TestClaudeConvertMessage_ToolError(t *testing.T) {
+	c := &ClaudeProvider{}
+
+	failed, err := c.convertMessage(Message{
+		Role:       "tool",
+		Content:    "path: is required",
+		ToolCallID: "toolu_1",
+		ToolError:  &ToolError{Kind: ToolErrorValidation, Message: "path: is required", Retryable: true},
+	})
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	part := failed.Content[0]
+	if !part.IsError {
+		t.Error("expected is_error on a failed tool result")
+	}
+	if part.Content != "validation error (retryable): path: is required" {
+		t.Errorf("unexpected error content: %v", part.Content)
+	}
+
+	succeeded, _ := c.convertMessage(Message{Role: "tool", Content: "ok", ToolCallID: "toolu_2"})
+	data, _ := json.Marshal(succeeded.Content[0])
+	if strings.Contains(string(data), "is_error") {
+		t.Errorf("is_error should be omitted for successful results: %s", data)
+	}
+}
+
+func TestClaudeConvertMessage_UserContentBlocks(t *testing.T) {
+	c := &ClaudeProvider{}
+
+	msg, err := c.convertMessage(Message{
+		Role:    "user",
+		Content: "Compare these",
+		Blocks: []ContentBlock{
+			ImageBlock("image/png", "aW1n"),
+			DocumentBlock("application/pdf", "spec.pdf", "cGRm"),
+			DocumentBlock("application/msword", "old.doc", "ZG9j"),
+			TextBlock("Compare these"),
+		},
+	})
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+
+	if len(msg.Content) != 4 {
+		t.Fatalf("expected 4 parts, got %d: %+v", len(msg.Content), msg.Content)
+	}
+	if p := msg.Content[0]; p.Type != "image" || p.Source.MediaType != "image/png" || p.Source.Data != "aW1n" {
+		t.Errorf("image part = %+v", p)
+	}
+	if p := msg.Content[1]; p.Type != "document" || p.Source.MediaType != "application/pdf" || p.Title != "spec.pdf" {
+		t.Errorf("document part = %+v", p)
+	}
+	if p := msg.Content[2]; p.Type != "text" || !strings.Contains(p.Text, "old.doc") {
+		t.Errorf("unsupported document should become text, got %+v", p)
+	}
+	if p := msg.Content[3]; p.Type != "text" || p.Text != "Compare these" {
+		t.Errorf("text part = %+v", p)
+	}
+
+	// Blocks that convert to nothing fall back to the text form
+	msg, _ = c.convertMessage(Message{Role: "user", Content: "fallback", Blocks: []ContentBlock{TextBlock("")}})
+	if len(msg.Content) != 1 || msg.Content[0].Text != "fallback" {
+		t.Errorf("expected the text form as fallback, got %+v", msg.Content)
+	}
+}
+
+func TestMediaBlock(t *testing.T) {
+	tests := []struct {
+		name     string
+		data     []byte
+		wantOK   bool
+		wantType ContentType
+		wantMime string
+	}{
+		{name: "photo.png", data: []byte("\x89PNG\r\n\x1a\n\x00\x00"), wantOK: true, wantType: ContentImage, wantMime: "image/png"},
+		{name: "photo.jpg", data: []byte("\xff\xd8\xff\xe0\x00\x10JFIF"), wantOK: true, wantType: ContentImage, wantMime: "image/jpeg"},
+		{name: "docs/spec.pdf", data: []byte("%PDF-1.7\n"), wantOK: true, wantType: ContentDocument, wantMime: "application/pdf"},
+		{name: "misnamed.png", data: []byte("plain text"), wantOK: false},
+		{name: "icon.bmp", data: []byte("BM\x00\x00"), wantOK: false},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			block, ok := MediaBlock(tt.name, tt.data)
+			if ok != tt.wantOK {
+				t.Fatalf("ok = %v, want %v", ok, tt.wantOK)
+			}
+			if !ok {
+				return
+			}
+			if block.Type != tt.wantType || block.MimeType != tt.wantMime {
+				t.Errorf("block = %s %s, want %s %s", block.Type, block.MimeType, tt.wantType, tt.wantMime)
+			}
+			if block.Data == "" {
+				t.Error("expected base64 data")
+			}
+			if block.Type == ContentDocument && block.Name != "spec.pdf" {
+				t.Errorf("document name = %q, want the base name", block.Name)
+			}
+		})
+	}
+}
+
+func TestToolResultErr(t *testing.T) {
+	if err := (&ToolResult{Success: true}).Err(); err != nil {
+		t.Errorf("expected nil for a successful result, got %+v", err)
+	}
+
+	got := (&ToolResult{Success: false, Error: "boom"}).Err()
+	want := &ToolError{Kind: ToolErrorInternal, Message: "boom"}
+	if *got != *want {
+		t.Errorf("Err() = %+v, want %+v", got, want)
+	}
+}
+
+func TestClaudeProviderSetModel(t *testing.T) {
+	var gotModel string
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		var req claudeRequest
+		json.NewDecoder(r.Body).Decode(&req)
+		gotModel = req.Model
+
+		w.Header().Set("Content-Type", "application/json")
+		json.NewEncoder(w).Encode(claudeResponse{
+			Content: []claudeContentBlock{{Type: "text", Text: "ok"}},
+		})
+	}))
+	defer server.Close()
+
+	p, err := NewClaudeProviderWithKey("test-api-key", WithBaseURL(server.URL))
+	if err != nil {
+		t.Fatalf("failed to create provider: %v", err)
+	}
+
+	var _ ModelSwitcher = p
+	if p.Model() != DefaultClaudeModel {
+		t.Errorf("Model() = %q, want %q", p.Model(), DefaultClaudeModel)
+	}
+
+	p.SetModel("claude-other")
+	if p.Model() != "claude-other" {
+		t.Errorf("Model() = %q after SetModel", p.Model())
+	}
+
+	req := GenerateRequest{Messages: []Message{{Role: "user", Content: "Hi"}}}
+	if _, err := p.Generate(context.Background(), req); err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	if gotModel != "claude-other" {
+		t.Errorf("request model = %q, want %q", gotModel, "claude-other")
+	}
+}
@@ -0,0 +1,67 @@
+package provider
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"net"
+	"net/http"
+)
+
+// statusOverloaded is the status Anthropic's API uses when it is
+// temporarily overloaded.
+const statusOverloaded = 529
+
+// APIError is an error response from an LLM provider's API.
+type APIError struct {
+	Provider   string // Provider name, e.g. "claude"
+	StatusCode int    // HTTP status code
+	Type       string // Provider's error type, e.g. "overloaded_error", if any
+	Message    string // Provider's error message, or the raw response body
+}
+
+// Error returns the error message, prefixed with the provider name.
+func (e *APIError) Error() string {
+	switch e.StatusCode {
+	case http.StatusUnauthorized:
+		return fmt.Sprintf("%s: authentication failed: %s", e.Provider, e.Message)
+	case http.StatusForbidden:
+		return fmt.Sprintf("%s: access forbidden: %s", e.Provider, e.Message)
+	case http.StatusTooManyRequests:
+		return fmt.Sprintf("%s: rate limit exceeded: %s", e.Provider, e.Message)
+	case http.StatusBadRequest:
+		return fmt.Sprintf("%s: bad request: %s", e.Provider, e.Message)
+	case statusOverloaded:
+		return fmt.Sprintf("%s: overloaded: %s", e.Provider, e.Message)
+	case http.StatusInternalServerError, http.StatusBadGateway, http.StatusServiceUnavailable:
+		return fmt.Sprintf("%s: server error (status %d): %s", e.Provider, e.StatusCode, e.Message)
+	default:
+		return fmt.Sprintf("%s: API error (status %d): %s", e.Provider, e.StatusCode, e.Message)
+	}
+}
+
+// Transient reports whether the error is likely to go away on its own,
+// such as an overloaded or failing server or a rate limit, rather than a
+// problem with the request or the credentials.
+func (e *APIError) Transient() bool {
+	return e.StatusCode == http.StatusRequestTimeout ||
+		e.StatusCode == http.StatusTooManyRequests ||
+		e.StatusCode >= http.StatusInternalServerError ||
+		e.Type == "overloaded_error"
+}
+
+// IsTransient reports whether err from Generate is a transient failure of
+// the provider: a transient APIError, or a network error such as a refused
+// connection or a provider timeout. Cancellation by the caller is not
+// transient.
+func IsTransient(err error) bool {
+	var apiErr *APIError
+	if errors.As(err, &apiErr) {
+		return apiErr.Transient()
+	}
+	if errors.Is(err, context.Canceled) {
+		return false
+	}
+	var netErr net.Error
+	return errors.As(err, &netErr)
+}
@@ -0,0 +1,56 @@
+package provider
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"net"
+	"testing"
+)
+
+func TestIsTransient(t *testing.T) {
+	tests := []struct {
+		name string
+		err  error
+		want bool
+	}{
+		{name: "overloaded", err: &APIError{Provider: "claude", StatusCode: 529, Type: "overloaded_error"}, want: true},
+		{name: "server error", err: &APIError{Provider: "claude", StatusCode: 500}, want: true},
+		{name: "bad gateway", err: &APIError{Provider: "claude", StatusCode: 502}, want: true},
+		{name: "rate limit", err: &APIError{Provider: "claude", StatusCode: 429}, want: true},
+		{name: "bad request", err: &APIError{Provider: "claude", StatusCode: 400}, want: false},
+		{name: "authentication", err: &APIError{Provider: "claude", StatusCode: 401}, want: false},
+		{name: "wrapped", err: fmt.Errorf("LLM generation failed: %w", &APIError{StatusCode: 503}), want: true},
+		{name: "network", err: fmt.Errorf("claude: failed to send request: %w", &net.OpError{Op: "dial", Err: errors.New("connection refused")}), want: true},
+		{name: "cancelled", err: fmt.Errorf("claude: failed to send request: %w", context.Canceled), want: false},
+		{name: "other", err: errors.New("claude: failed to parse response"), want: false},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if got := IsTransient(tt.err); got != tt.want {
+				t.Errorf("IsTransient(%v) = %v, want %v", tt.err, got, tt.want)
+			}
+		})
+	}
+}
+
+func TestAPIError_Error(t *testing.T) {
+	tests := []struct {
+		status int
+		want   string
+	}{
+		{401, "claude: authentication failed: nope"},
+		{429, "claude: rate limit exceeded: nope"},
+		{529, "claude: overloaded: nope"},
+		{503, "claude: server error (status 503): nope"},
+		{418, "claude: API error (status 418): nope"},
+	}
+
+	for _, tt := range tests {
+		err := &APIError{Provider: "claude", StatusCode: tt.status, Message: "nope"}
+		if err.Error() != tt.want {
+			t.Errorf("status %d: error = %q, want %q", tt.status, err.Error(), tt.want)
+		}
+	}
+}
@@ -0,0 +1,28 @@
+package provider
+
+import (
+	"encoding/base64"
+	"net/http"
+	"path/filepath"
+)
+
+// MaxMediaSize is the largest image or document, in bytes, that should
+// be sent to a model. Claude rejects images over 5 MB.
+const MaxMediaSize = 5 << 20
+
+// MediaBlock returns the content block for file data that models take as
+// media rather than text: an image block for PNG, JPEG, GIF and WebP
+// images and a document block for PDFs. The type is detected from the
+// data, not the file name. It reports false for any other data, which the
+// caller should treat as text or reject.
+func MediaBlock(name string, data []byte) (ContentBlock, bool) {
+	mimeType := http.DetectContentType(data)
+
+	switch {
+	case isImageMime(mimeType):
+		return ImageBlock(mimeType, base64.StdEncoding.EncodeToString(data)), true
+	case mimeType == "application/pdf":
+		return DocumentBlock(mimeType, filepath.Base(name), base64.StdEncoding.EncodeToString(data)), true
+	}
+	return ContentBlock{}, false
+}
@@ -0,0 +1,553 @@
+package provider
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"log"
+	"net/http"
+	"os"
+	"strings"
+	"sync"
+	"sync/atomic"
+	"time"
+)
+
+const (
+	// DefaultOllamaModel is the default model of an OllamaProvider.
+	DefaultOllamaModel = "llama3.1"
+	// DefaultOllamaBaseURL is the address of a local Ollama server.
+	DefaultOllamaBaseURL = "http://localhost:11434"
+)
+
+// OllamaProvider implements LLMProvider for Ollama and servers with the
+// same native /api/chat API, such as a local model on a developer laptop.
+//
+// Models with native tool calling receive the tools in the request. For a
+// model without it, the tools are described in the system prompt instead,
+// and a JSON tool call is parsed out of the model's text. The provider
+// switches a model to this prompt-based mode the first time the server
+// rejects its tools, or for every model with WithPromptToolCalling.
+type OllamaProvider struct {
+	model       string
+	maxTokens   int      // Used when a request does not set MaxTokens
+	temperature *float64 // Nil leaves the model default
+	client      *http.Client
+	baseURL     string
+	promptTools bool // Use prompt-based tool calls for every model
+	modelMu     sync.RWMutex
+
+	promptModels sync.Map     // Models found to lack native tool calling
+	callSeq      atomic.Int64 // Numbers the tool call IDs, which Ollama does not assign
+}
+
+// OllamaOption is a functional option for configuring OllamaProvider.
+type OllamaOption func(*OllamaProvider)
+
+// WithOllamaModel sets the model to use, e.g. "qwen2.5-coder:7b".
+func WithOllamaModel(model string) OllamaOption {
+	return func(o *OllamaProvider) {
+		o.model = model
+	}
+}
+
+// WithOllamaBaseURL sets the server address, e.g. "http://gpu-box:11434".
+func WithOllamaBaseURL(url string) OllamaOption {
+	return func(o *OllamaProvider) {
+		o.baseURL = strings.TrimRight(url, "/")
+	}
+}
+
+// WithOllamaMaxTokens sets the output token limit of requests that do not
+// set MaxTokens. Values below 1 are ignored.
+func WithOllamaMaxTokens(n int) OllamaOption {
+	return func(o *OllamaProvider) {
+		if n > 0 {
+			o.maxTokens = n
+		}
+	}
+}
+
+// WithOllamaTemperature sets the sampling temperature of every request.
+func WithOllamaTemperature(t float64) OllamaOption {
+	return func(o *OllamaProvider) {
+		o.temperature = &t
+	}
+}
+
+// WithOllamaTimeout sets the timeout of each HTTP request to the server.
+// Local models can be slow to load, so it may need to be generous. Values
+// below 1 are ignored.
+func WithOllamaTimeout(timeout time.Duration) OllamaOption {
+	return func(o *OllamaProvider) {
+		if timeout > 0 {
+			client := *o.client
+			client.Timeout = timeout
+			o.client = &client
+		}
+	}
+}
+
+// WithOllamaHTTPClient sets a custom HTTP client.
+func WithOllamaHTTPClient(client *http.Client) OllamaOption {
+	return func(o *OllamaProvider) {
+		o.client = client
+	}
+}
+
+// WithPromptToolCalling describes tools in the system prompt and parses
+// tool calls out of the model's text for every model, instead of using
+// native tool calling.
+func WithPromptToolCalling() OllamaOption {
+	return func(o *OllamaProvider) {
+		o.promptTools = true
+	}
+}
+
+// NewOllamaProvider creates a new OllamaProvider. The server address is
+// taken from the OLLAMA_HOST environment variable if it is set, and
+// otherwise defaults to DefaultOllamaBaseURL.
+func NewOllamaProvider(opts ...OllamaOption) *OllamaProvider {
+	baseURL := DefaultOllamaBaseURL
+	if host := os.Getenv("OLLAMA_HOST"); host != "" {
+		if !strings.Contains(host, "://") {
+			host = "http://" + host
+		}
+		baseURL = strings.TrimRight(host, "/")
+	}
+
+	provider := &OllamaProvider{
+		model:     DefaultOllamaModel,
+		maxTokens: DefaultMaxTokens,
+		client:    &http.Client{Timeout: DefaultTimeout},
+		baseURL:   baseURL,
+	}
+
+	for _, opt := range opts {
+		opt(provider)
+	}
+
+	return provider
+}
+
+// Name returns the provider name.
+func (o *OllamaProvider) Name() string {
+	return "ollama"
+}
+
+// Model returns the model used for new requests.
+func (o *OllamaProvider) Model() string {
+	o.modelMu.RLock()
+	defer o.modelMu.RUnlock()
+	return o.model
+}
+
+// SetModel changes the model used for new requests.
+func (o *OllamaProvider) SetModel(model string) {
+	o.modelMu.Lock()
+	defer o.modelMu.Unlock()
+	o.model = model
+}
+
+// Generate sends a request to the Ollama server and returns the response.
+func (o *OllamaProvider) Generate(ctx context.Context, req GenerateRequest) (*LLMResponse, error) {
+	model := o.Model()
+	_, promptMode := o.promptModels.Load(model)
+	promptMode = promptMode || o.promptTools
+
+	resp, err := o.generate(ctx, model, req, promptMode)
+	if err != nil && !promptMode && len(req.Tools) > 0 && lacksToolSupport(err) {
+		log.Printf("[Ollama] Model %s does not support native tool calling; describing tools in the prompt instead", model)
+		o.promptModels.Store(model, true)
+		return o.generate(ctx, model, req, true)
+	}
+	return resp, err
+}
+
+// lacksToolSupport reports whether err is the server rejecting tools for a
+// model without native tool calling.
+func lacksToolSupport(err error) bool {
+	var apiErr *APIError
+	return errors.As(err, &apiErr) && apiErr.StatusCode == http.StatusBadRequest && strings.Contains(apiErr.Message, "does not support tools")
+}
+
+// generate sends one request, with native or prompt-based tool calls.
+func (o *OllamaProvider) generate(ctx context.Context, model string, req GenerateRequest, promptMode bool) (*LLMResponse, error) {
+	ollamaReq, err := o.buildRequest(model, req, promptMode)
+	if err != nil {
+		return nil, fmt.Errorf("ollama: failed to build request: %w", err)
+	}
+
+	body, err := json.Marshal(ollamaReq)
+	if err != nil {
+		return nil, fmt.Errorf("ollama: failed to marshal request: %w", err)
+	}
+
+	httpReq, err := http.NewRequestWithContext(ctx, http.MethodPost, o.baseURL+"/api/chat", bytes.NewReader(body))
+	if err != nil {
+		return nil, fmt.Errorf("ollama: failed to create HTTP request: %w", err)
+	}
+	httpReq.Header.Set("Content-Type", "application/json")
+
+	resp, err := o.client.Do(httpReq)
+	if err != nil {
+		return nil, fmt.Errorf("ollama: failed to send request: %w", err)
+	}
+	defer resp.Body.Close()
+
+	respBody, err := io.ReadAll(resp.Body)
+	if err != nil {
+		return nil, fmt.Errorf("ollama: failed to read response body: %w", err)
+	}
+
+	if resp.StatusCode != http.StatusOK {
+		apiErr := &APIError{Provider: "ollama", StatusCode: resp.StatusCode, Message: string(respBody)}
+		var errResp struct {
+			Error string `json:"error"`
+		}
+		if json.Unmarshal(respBody, &errResp) == nil && errResp.Error != "" {
+			apiErr.Message = errResp.Error
+		}
+		return nil, apiErr
+	}
+
+	var tools []ToolDefinition
+	if promptMode {
+		tools = ollamaRequestTools(req)
+	}
+	return o.parseResponse(respBody, tools)
+}
+
+// ollamaRequest represents the request body of /api/chat.
+type ollamaRequest struct {
+	Model    string         `json:"model"`
+	Messages []ollamaMsg    `json:"messages"`
+	Tools    []ollamaTool   `json:"tools,omitempty"`
+	Stream   bool           `json:"stream"`
+	Think    bool           `json:"think,omitempty"`
+	Options  *ollamaOptions `json:"options,omitempty"`
+}
+
+// ollamaOptions holds the sampling options of a request.
+type ollamaOptions struct {
+	Temperature *float64 `json:"temperature,omitempty"`
+	TopP        *float64 `json:"top_p,omitempty"`
+	NumPredict  int      `json:"num_predict,omitempty"`
+	Stop        []string `json:"stop,omitempty"`
+}
+
+// ollamaMsg represents a message in Ollama's format.
+type ollamaMsg struct {
+	Role      string           `json:"role"`
+	Content   string           `json:"content"`
+	Images    []string         `json:"images,omitempty"`     // Base64 image data
+	ToolCalls []ollamaToolCall `json:"tool_calls,omitempty"` // For assistant messages
+	ToolName  string           `json:"tool_name,omitempty"`  // For tool results
+	Thinking  string           `json:"thinking,omitempty"`   // In responses with thinking enabled
+}
+
+// ollamaTool represents a tool definition in Ollama's format.
+type ollamaTool struct {
+	Type     string             `json:"type"`
+	Function ollamaToolFunction `json:"function"`
+}
+
+// ollamaToolFunction describes the function of a tool.
+type ollamaToolFunction struct {
+	Name        string                 `json:"name"`
+	Description string                 `json:"description"`
+	Parameters  map[string]interface{} `json:"parameters"`
+}
+
+// ollamaToolCall represents a tool call in Ollama's format.
+type ollamaToolCall struct {
+	Function struct {
+		Name      string                 `json:"name"`
+		Arguments map[string]interface{} `json:"arguments"`
+	} `json:"function"`
+}
+
+// ollamaResponse represents the response body of /api/chat.
+type ollamaResponse struct {
+	Model           string    `json:"model"`
+	Message         ollamaMsg `json:"message"`
+	Done            bool      `json:"done"`
+	DoneReason      string    `json:"done_reason"`
+	PromptEvalCount int       `json:"prompt_eval_count"`
+	EvalCount       int       `json:"eval_count"`
+}
+
+// buildRequest converts a GenerateRequest to Ollama's format. In prompt
+// mode the tools are described in the system prompt, and tool calls and
+// results in the history are written as text.
+func (o *OllamaProvider) buildRequest(model string, req GenerateRequest, promptMode bool) (*ollamaRequest, error) {
+	tools := ollamaRequestTools(req)
+	system := req.SystemPrompt
+	if c := req.ToolChoice; c != nil {
+		switch c.Mode {
+		case ToolChoiceAuto, ToolChoiceNone:
+		case ToolChoiceAny:
+			system = joinPrompt(system, "You must call one of the tools in your next reply.")
+		case ToolChoiceTool:
+			if len(tools) == 0 {
+				return nil, fmt.Errorf("tool choice names unknown tool %q", c.Name)
+			}
+			system = joinPrompt(system, fmt.Sprintf("You must call the %s tool in your next reply.", c.Name))
+		default:
+			return nil, fmt.Errorf("invalid tool choice mode %q", c.Mode)
+		}
+	}
+	if promptMode && len(tools) > 0 {
+		system = joinPrompt(system, promptToolInstructions(tools))
+	}
+
+	ollamaReq := &ollamaRequest{
+		Model:  model,
+		Stream: false,
+		Think:  req.ThinkingBudget > 0,
+		Options: &ollamaOptions{
+			Temperature: o.temperature,
+			TopP:        req.TopP,
+			NumPredict:  o.maxTokens,
+			Stop:        req.StopSequences,
+		},
+	}
+	if req.MaxTokens > 0 {
+		ollamaReq.Options.NumPredict = req.MaxTokens
+	}
+	if req.Temperature != nil {
+		ollamaReq.Options.Temperature = req.Temperature
+	}
+
+	if system != "" {
+		ollamaReq.Messages = append(ollamaReq.Messages, ollamaMsg{Role: "system", Content: system})
+	}
+	for _, msg := range req.Messages {
+		ollamaReq.Messages = append(ollamaReq.Messages, convertOllamaMessage(msg, promptMode))
+	}
+
+	if !promptMode {
+		for _, t := range tools {
+			ollamaReq.Tools = append(ollamaReq.Tools, ollamaTool{
+				Type:     "function",
+				Function: ollamaToolFunction{Name: t.Name, Description: t.Description, Parameters: t.Parameters},
+			})
+		}
+	}
+
+	return ollamaReq, nil
+}
+
+// ollamaRequestTools returns the tools the model may call under the
+// request's tool choice. Ollama has no tool choice, so a forced tool is
+// offered alone and ToolChoiceNone offers none.
+func ollamaRequestTools(req GenerateRequest) []ToolDefinition {
+	c := req.ToolChoice
+	switch {
+	case c == nil:
+		return req.Tools
+	case c.Mode == ToolChoiceNone:
+		return nil
+	case c.Mode == ToolChoiceTool:
+		for _, t := range req.Tools {
+			if t.Name == c.Name {
+				return []ToolDefinition{t}
+			}
+		}
+		return nil
+	default:
+		return req.Tools
+	}
+}
+
+// joinPrompt appends a paragraph to a system prompt.
+func joinPrompt(prompt, paragraph string) string {
+	if prompt == "" {
+		return paragraph
+	}
+	return prompt + "\n\n" + paragraph
+}
+
+// promptToolInstructions describes tools for a model without native tool
+// calling, and how to call them.
+func promptToolInstructions(tools []ToolDefinition) string {
+	var b strings.Builder
+	b.WriteString("You can call the following tools:\n")
+	for _, t := range tools {
+		params, _ := json.Marshal(t.Parameters)
+		fmt.Fprintf(&b, "\n- %s: %s\n  Parameters (JSON Schema): %s\n", t.Name, t.Description, params)
+	}
+	b.WriteString("\nTo call a tool, reply with only a JSON object such as\n")
+	b.WriteString(`{"tool": "tool_name", "arguments": {"param": "value"}}`)
+	b.WriteString("\nand nothing else. Call one tool per reply; its result comes in the next message. ")
+	b.WriteString("To answer without calling a tool, reply in plain text.")
+	return b.String()
+}
+
+// convertOllamaMessage converts a Message to Ollama's format.
+func convertOllamaMessage(msg Message, promptMode bool) ollamaMsg {
+	om := ollamaMsg{Role: msg.Role, Content: msg.Content}
+	if len(msg.Blocks) > 0 {
+		om.Content, om.Images = ollamaContent(msg.Blocks)
+	}
+
+	// Ollama has no error flag on tool results, so the error is described
+	// in the content
+	if msg.ToolCallID != "" {
+		if msg.ToolError != nil {
+			om.Content = msg.ToolError.Describe()
+		}
+		if promptMode {
+			om.Role = "user"
+			om.Content = fmt.Sprintf("Result of the %s tool:\n%s", msg.ToolName, om.Content)
+			return om
+		}
+		om.Role = "tool"
+		om.ToolName = msg.ToolName
+		return om
+	}
+
+	for _, tc := range msg.ToolCalls {
+		if promptMode {
+			call, _ := json.Marshal(promptToolCall{Tool: tc.Name, Arguments: tc.Arguments})
+			om.Content = strings.TrimSpace(om.Content + "\n" + string(call))
+			continue
+		}
+		var call ollamaToolCall
+		call.Function.Name = tc.Name
+		call.Function.Arguments = tc.Arguments
+		om.ToolCalls = append(om.ToolCalls, call)
+	}
+	return om
+}
+
+// ollamaContent splits content blocks into text and base64 images, the
+// only media Ollama accepts. Other blocks are rendered as text.
+func ollamaContent(blocks []ContentBlock) (string, []string) {
+	var texts, images []string
+	for _, b := range blocks {
+		if b.Type == ContentImage && isImageMime(b.MimeType) {
+			images = append(images, b.Data)
+			continue
+		}
+		texts = append(texts, blockText(b))
+	}
+	return strings.Join(texts, "\n"), images
+}
+
+// promptToolCall is the JSON object a model writes to call a tool in
+// prompt mode.
+type promptToolCall struct {
+	Tool      string                 `json:"tool"`
+	Arguments map[string]interface{} `json:"arguments"`
+}
+
+// parseResponse converts an Ollama response to an LLMResponse. With
+// promptTools set, a JSON tool call to one of them is parsed out of the
+// text.
+func (o *OllamaProvider) parseResponse(body []byte, promptTools []ToolDefinition) (*LLMResponse, error) {
+	var resp ollamaResponse
+	if err := json.Unmarshal(body, &resp); err != nil {
+		return nil, fmt.Errorf("ollama: failed to parse response: %w", err)
+	}
+
+	llmResp := &LLMResponse{
+		Text: resp.Message.Content,
+		Usage: Usage{
+			InputTokens:  resp.PromptEvalCount,
+			OutputTokens: resp.EvalCount,
+		},
+		StopReason: StopEndTurn,
+	}
+	if resp.Message.Thinking != "" {
+		llmResp.Thinking = []Thinking{{Text: resp.Message.Thinking}}
+	}
+
+	for _, tc := range resp.Message.ToolCalls {
+		llmResp.ToolCalls = append(llmResp.ToolCalls, ToolCall{
+			ID:        o.nextCallID(),
+			Name:      tc.Function.Name,
+			Arguments: tc.Function.Arguments,
+		})
+	}
+	if len(promptTools) > 0 && len(llmResp.ToolCalls) == 0 {
+		if call, rest, ok := parsePromptToolCall(llmResp.Text, promptTools); ok {
+			call.ID = o.nextCallID()
+			llmResp.ToolCalls = []ToolCall{call}
+			llmResp.Text = rest
+		}
+	}
+
+	switch {
+	case resp.DoneReason == "length":
+		llmResp.StopReason = StopMaxTokens
+	case len(llmResp.ToolCalls) > 0:
+		llmResp.StopReason = StopToolUse
+	}
+
+	return llmResp, nil
+}
+
+// nextCallID returns a new tool call ID.
+func (o *OllamaProvider) nextCallID() string {
+	return fmt.Sprintf("call_%d", o.callSeq.Add(1))
+}
+
+// parsePromptToolCall finds the first JSON object in text that calls one
+// of tools, as {"tool": ..., "arguments": {...}}. Models often say "name"
+// for "tool" or wrap the object in a Markdown code fence, so both are
+// accepted. It returns the call and the rest of the text.
+func parsePromptToolCall(text string, tools []ToolDefinition) (ToolCall, string, bool) {
+	known := make(map[string]bool, len(tools))
+	for _, t := range tools {
+		known[t.Name] = true
+	}
+
+	for start := strings.IndexByte(text, '{'); start >= 0; {
+		var raw struct {
+			Tool       string                 `json:"tool"`
+			Name       string                 `json:"name"`
+			Arguments  map[string]interface{} `json:"arguments"`
+			Parameters map[string]interface{} `json:"parameters"`
+		}
+		decoder := json.NewDecoder(strings.NewReader(text[start:]))
+		if err := decoder.Decode(&raw); err == nil {
+			name, args := raw.Tool, raw.Arguments
+			if name == "" {
+				name = raw.Name
+			}
+			if args == nil {
+				args = raw.Parameters
+			}
+			if known[name] {
+				if args == nil {
+					args = map[string]interface{}{}
+				}
+				end := start + int(decoder.InputOffset())
+				return ToolCall{Name: name, Arguments: args}, stripCodeFence(text[:start], text[end:]), true
+			}
+		}
+
+		next := strings.IndexByte(text[start+1:], '{')
+		if next < 0 {
+			break
+		}
+		start += next + 1
+	}
+	return ToolCall{}, text, false
+}
+
+// stripCodeFence joins the text around a parsed tool call, dropping the
+// Markdown code fence that enclosed it, if any.
+func stripCodeFence(before, after string) string {
+	before = strings.TrimRight(before, " \t\n")
+	if i := strings.LastIndex(before, "```"); i >= 0 && !strings.Contains(before[i:], "\n") {
+		before = before[:i]
+	}
+	after = strings.TrimLeft(after, " \t\n")
+	after = strings.TrimPrefix(after, "```")
+	return strings.TrimSpace(strings.TrimSpace(before) + "\n" + strings.TrimSpace(after))
+}
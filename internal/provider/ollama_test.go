@@ -0,0 +1,370 @@
+package provider
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+)
+
+// ollamaServer is an httptest stand-in for an Ollama server. It records
+// each /api/chat request and answers with the next response, or with a
+// 400 error for tools if the model lacks tool support.
+type ollamaServer struct {
+	*httptest.Server
+	requests  []ollamaRequest
+	responses []ollamaResponse
+	noTools   bool
+}
+
+func newOllamaServer(t *testing.T, responses ...ollamaResponse) *ollamaServer {
+	s := &ollamaServer{responses: responses}
+	s.Server = httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if r.URL.Path != "/api/chat" {
+			t.Errorf("path = %q, want /api/chat", r.URL.Path)
+		}
+		var req ollamaRequest
+		if err := json.NewDecoder(r.Body).Decode(&req); err != nil {
+			t.Errorf("invalid request body: %v", err)
+		}
+		s.requests = append(s.requests, req)
+
+		w.Header().Set("Content-Type", "application/json")
+		if s.noTools && len(req.Tools) > 0 {
+			w.WriteHeader(http.StatusBadRequest)
+			json.NewEncoder(w).Encode(map[string]string{"error": "registry.ollama.ai/library/" + req.Model + ":latest does not support tools"})
+			return
+		}
+		resp := ollamaResponse{Message: ollamaMsg{Role: "assistant", Content: "default"}, Done: true, DoneReason: "stop"}
+		if len(s.responses) > 0 {
+			resp, s.responses = s.responses[0], s.responses[1:]
+		}
+		json.NewEncoder(w).Encode(resp)
+	}))
+	t.Cleanup(s.Close)
+	return s
+}
+
+var ollamaReadFile = ToolDefinition{
+	Name:        "read_file",
+	Description: "Read a file",
+	Parameters:  map[string]interface{}{"type": "object", "properties": map[string]interface{}{"path": map[string]interface{}{"type": "string"}}},
+}
+
+func TestNewOllamaProvider(t *testing.T) {
+	t.Setenv("OLLAMA_HOST", "")
+	p := NewOllamaProvider()
+	if p.Name() != "ollama" || p.Model() != DefaultOllamaModel || p.baseURL != DefaultOllamaBaseURL {
+		t.Errorf("provider = %s %s %s", p.Name(), p.Model(), p.baseURL)
+	}
+
+	t.Setenv("OLLAMA_HOST", "gpu-box:11434")
+	if p := NewOllamaProvider(); p.baseURL != "http://gpu-box:11434" {
+		t.Errorf("base URL = %q, want OLLAMA_HOST with a scheme", p.baseURL)
+	}
+	if p := NewOllamaProvider(WithOllamaBaseURL("http://other:1/")); p.baseURL != "http://other:1" {
+		t.Errorf("base URL = %q, want the option to win", p.baseURL)
+	}
+
+	var switcher ModelSwitcher = p
+	switcher.SetModel("qwen2.5-coder")
+	if p.Model() != "qwen2.5-coder" {
+		t.Errorf("model = %q after SetModel", p.Model())
+	}
+}
+
+func TestOllamaProviderGenerate_TextResponse(t *testing.T) {
+	server := newOllamaServer(t, ollamaResponse{
+		Message:         ollamaMsg{Role: "assistant", Content: "Hello!"},
+		Done:            true,
+		DoneReason:      "stop",
+		PromptEvalCount: 12,
+		EvalCount:       3,
+	})
+	p := NewOllamaProvider(WithOllamaBaseURL(server.URL), WithOllamaModel("llama3.2"), WithOllamaMaxTokens(256), WithOllamaTemperature(0.3))
+
+	temperature := 0.1
+	resp, err := p.Generate(context.Background(), GenerateRequest{
+		SystemPrompt:  "Be brief.",
+		Messages:      []Message{{Role: "user", Content: "Hi"}},
+		Temperature:   &temperature,
+		StopSequences: []string{"END"},
+	})
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+
+	if resp.Text != "Hello!" || resp.StopReason != StopEndTurn {
+		t.Errorf("response = %+v", resp)
+	}
+	if resp.Usage != (Usage{InputTokens: 12, OutputTokens: 3}) {
+		t.Errorf("usage = %+v", resp.Usage)
+	}
+
+	req := server.requests[0]
+	if req.Model != "llama3.2" || req.Stream {
+		t.Errorf("model = %q, stream = %v", req.Model, req.Stream)
+	}
+	if len(req.Messages) != 2 || req.Messages[0].Role != "system" || req.Messages[0].Content != "Be brief." {
+		t.Errorf("messages = %+v, want the system prompt first", req.Messages)
+	}
+	opts := req.Options
+	if opts.NumPredict != 256 || *opts.Temperature != 0.1 || len(opts.Stop) != 1 {
+		t.Errorf("options = %+v, want the provider limit and the request's temperature", opts)
+	}
+}
+
+func TestOllamaProviderGenerate_NativeToolCalls(t *testing.T) {
+	var call ollamaToolCall
+	call.Function.Name = "read_file"
+	call.Function.Arguments = map[string]interface{}{"path": "go.mod"}
+	server := newOllamaServer(t, ollamaResponse{
+		Message: ollamaMsg{Role: "assistant", ToolCalls: []ollamaToolCall{call}},
+		Done:    true,
+	})
+	p := NewOllamaProvider(WithOllamaBaseURL(server.URL))
+
+	resp, err := p.Generate(context.Background(), GenerateRequest{
+		Messages: []Message{
+			{Role: "user", Content: "Read two files"},
+			{Role: "assistant", ToolCalls: []ToolCall{{ID: "call_a", Name: "read_file", Arguments: map[string]interface{}{"path": "a"}}}},
+			{Role: "tool", Content: "contents of a", ToolCallID: "call_a", ToolName: "read_file"},
+			{Role: "assistant", ToolCalls: []ToolCall{{ID: "call_b", Name: "read_file", Arguments: map[string]interface{}{"path": "b"}}}},
+			{Role: "tool", ToolCallID: "call_b", ToolName: "read_file", ToolError: &ToolError{Kind: ToolErrorNotFound, Message: "b: no such file"}},
+		},
+		Tools: []ToolDefinition{ollamaReadFile},
+	})
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+
+	if len(resp.ToolCalls) != 1 || resp.ToolCalls[0].Name != "read_file" || resp.ToolCalls[0].Arguments["path"] != "go.mod" {
+		t.Fatalf("tool calls = %+v", resp.ToolCalls)
+	}
+	if resp.ToolCalls[0].ID == "" || resp.StopReason != StopToolUse {
+		t.Errorf("tool call ID = %q, stop reason = %q", resp.ToolCalls[0].ID, resp.StopReason)
+	}
+
+	req := server.requests[0]
+	if len(req.Tools) != 1 || req.Tools[0].Type != "function" || req.Tools[0].Function.Name != "read_file" {
+		t.Errorf("tools = %+v", req.Tools)
+	}
+	if tc := req.Messages[1].ToolCalls; len(tc) != 1 || tc[0].Function.Arguments["path"] != "a" {
+		t.Errorf("assistant tool calls = %+v", tc)
+	}
+	if m := req.Messages[2]; m.Role != "tool" || m.ToolName != "read_file" || m.Content != "contents of a" {
+		t.Errorf("tool result = %+v", m)
+	}
+	if m := req.Messages[4]; m.Content != "not_found error: b: no such file" {
+		t.Errorf("failed tool result content = %q, want the described error", m.Content)
+	}
+}
+
+func TestOllamaProviderGenerate_Images(t *testing.T) {
+	server := newOllamaServer(t)
+	p := NewOllamaProvider(WithOllamaBaseURL(server.URL))
+
+	_, err := p.Generate(context.Background(), GenerateRequest{Messages: []Message{{
+		Role:    "user",
+		Content: "What is this?",
+		Blocks:  []ContentBlock{ImageBlock("image/png", "iVBORw0="), TextBlock("What is this?")},
+	}}})
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	msg := server.requests[0].Messages[0]
+	if len(msg.Images) != 1 || msg.Images[0] != "iVBORw0=" || msg.Content != "What is this?" {
+		t.Errorf("message = %+v, want the image apart from the text", msg)
+	}
+}
+
+func TestOllamaProviderGenerate_PromptToolCallingFallback(t *testing.T) {
+	server := newOllamaServer(t,
+		ollamaResponse{Message: ollamaMsg{Role: "assistant", Content: "Let me check.\n```json\n{\"tool\": \"read_file\", \"arguments\": {\"path\": \"go.mod\"}}\n```"}},
+		ollamaResponse{Message: ollamaMsg{Role: "assistant", Content: "The module is agentic-poc."}},
+	)
+	server.noTools = true
+	p := NewOllamaProvider(WithOllamaBaseURL(server.URL), WithOllamaModel("gemma"))
+
+	req := GenerateRequest{
+		SystemPrompt: "Be brief.",
+		Messages:     []Message{{Role: "user", Content: "What is the module name?"}},
+		Tools:        []ToolDefinition{ollamaReadFile},
+	}
+	resp, err := p.Generate(context.Background(), req)
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+
+	// The rejected native request is retried with the tools in the prompt
+	if len(server.requests) != 2 || len(server.requests[1].Tools) != 0 {
+		t.Fatalf("requests = %+v, want a retry without native tools", server.requests)
+	}
+	system := server.requests[1].Messages[0].Content
+	if !strings.HasPrefix(system, "Be brief.") || !strings.Contains(system, "- read_file: Read a file") {
+		t.Errorf("system prompt = %q, want the tools described", system)
+	}
+	if len(resp.ToolCalls) != 1 || resp.ToolCalls[0].Arguments["path"] != "go.mod" || resp.StopReason != StopToolUse {
+		t.Fatalf("response = %+v, want the parsed tool call", resp)
+	}
+	if resp.Text != "Let me check." {
+		t.Errorf("text = %q, want the text around the call", resp.Text)
+	}
+
+	// The model stays in prompt mode, and the history is written as text
+	req.Messages = append(req.Messages,
+		Message{Role: "assistant", Content: resp.Text, ToolCalls: resp.ToolCalls},
+		Message{Role: "tool", Content: "module agentic-poc", ToolCallID: resp.ToolCalls[0].ID, ToolName: "read_file"},
+	)
+	if _, err := p.Generate(context.Background(), req); err != nil {
+		t.Fatal(err)
+	}
+	if len(server.requests) != 3 {
+		t.Fatalf("got %d requests, want no second native attempt", len(server.requests))
+	}
+	msgs := server.requests[2].Messages
+	if !strings.Contains(msgs[2].Content, `{"tool":"read_file","arguments":{"path":"go.mod"}}`) || len(msgs[2].ToolCalls) != 0 {
+		t.Errorf("assistant message = %+v, want the call as JSON text", msgs[2])
+	}
+	if msgs[3].Role != "user" || msgs[3].Content != "Result of the read_file tool:\nmodule agentic-poc" {
+		t.Errorf("tool result = %+v, want a user message", msgs[3])
+	}
+}
+
+func TestParsePromptToolCall(t *testing.T) {
+	tools := []ToolDefinition{ollamaReadFile, {Name: "calculator"}}
+	tests := []struct {
+		name     string
+		text     string
+		wantTool string
+		wantArgs string
+		wantRest string
+	}{
+		{name: "bare object", text: `{"tool": "read_file", "arguments": {"path": "a.go"}}`, wantTool: "read_file", wantArgs: `{"path":"a.go"}`},
+		{name: "name and parameters", text: `{"name": "calculator", "parameters": {"a": 1}}`, wantTool: "calculator", wantArgs: `{"a":1}`},
+		{name: "no arguments", text: `{"tool": "calculator"}`, wantTool: "calculator", wantArgs: `{}`},
+		{name: "fenced with text", text: "I'll read it.\n```json\n{\"tool\": \"read_file\", \"arguments\": {\"path\": \"x\"}}\n```\nThen answer.", wantTool: "read_file", wantArgs: `{"path":"x"}`, wantRest: "I'll read it.\nThen answer."},
+		{name: "earlier braces skipped", text: `Use {curly} braces: {"tool": "calculator", "arguments": {}}`, wantTool: "calculator", wantArgs: `{}`, wantRest: "Use {curly} braces:"},
+		{name: "unknown tool", text: `{"tool": "shell", "arguments": {"cmd": "ls"}}`},
+		{name: "plain text", text: "The answer is 42."},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			call, rest, ok := parsePromptToolCall(tt.text, tools)
+			if ok != (tt.wantTool != "") {
+				t.Fatalf("ok = %v, call = %+v", ok, call)
+			}
+			if !ok {
+				if rest != tt.text {
+					t.Errorf("rest = %q, want the text unchanged", rest)
+				}
+				return
+			}
+			args, _ := json.Marshal(call.Arguments)
+			if call.Name != tt.wantTool || string(args) != tt.wantArgs {
+				t.Errorf("call = %s %s, want %s %s", call.Name, args, tt.wantTool, tt.wantArgs)
+			}
+			if rest != tt.wantRest {
+				t.Errorf("rest = %q, want %q", rest, tt.wantRest)
+			}
+		})
+	}
+}
+
+func TestOllamaBuildRequest_ToolChoice(t *testing.T) {
+	tools := []ToolDefinition{ollamaReadFile, {Name: "finish_plan"}}
+	tests := []struct {
+		name       string
+		choice     *ToolChoice
+		wantTools  []string
+		wantSystem string
+		wantErr    string
+	}{
+		{name: "auto", choice: nil, wantTools: []string{"read_file", "finish_plan"}},
+		{name: "none", choice: &ToolChoice{Mode: ToolChoiceNone}},
+		{name: "any", choice: &ToolChoice{Mode: ToolChoiceAny}, wantTools: []string{"read_file", "finish_plan"}, wantSystem: "You must call one of the tools"},
+		{name: "forced", choice: ForceTool("finish_plan"), wantTools: []string{"finish_plan"}, wantSystem: "You must call the finish_plan tool"},
+		{name: "unknown", choice: ForceTool("shell"), wantErr: `unknown tool "shell"`},
+	}
+
+	p := NewOllamaProvider()
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			req, err := p.buildRequest("m", GenerateRequest{
+				Messages:   []Message{{Role: "user", Content: "Plan"}},
+				Tools:      tools,
+				ToolChoice: tt.choice,
+			}, false)
+			if tt.wantErr != "" {
+				if err == nil || !strings.Contains(err.Error(), tt.wantErr) {
+					t.Errorf("error = %v, want one containing %q", err, tt.wantErr)
+				}
+				return
+			}
+			if err != nil {
+				t.Fatal(err)
+			}
+
+			var names []string
+			for _, tool := range req.Tools {
+				names = append(names, tool.Function.Name)
+			}
+			if strings.Join(names, ",") != strings.Join(tt.wantTools, ",") {
+				t.Errorf("tools = %v, want %v", names, tt.wantTools)
+			}
+			hasSystem := req.Messages[0].Role == "system"
+			if tt.wantSystem == "" && hasSystem {
+				t.Errorf("unexpected system prompt %q", req.Messages[0].Content)
+			}
+			if tt.wantSystem != "" && (!hasSystem || !strings.Contains(req.Messages[0].Content, tt.wantSystem)) {
+				t.Errorf("messages = %+v, want a system prompt containing %q", req.Messages, tt.wantSystem)
+			}
+		})
+	}
+}
+
+func TestOllamaParseResponse_StopAndThinking(t *testing.T) {
+	p := NewOllamaProvider()
+	resp, err := p.parseResponse([]byte(`{"message":{"role":"assistant","content":"cut","thinking":"Hmm."},"done":true,"done_reason":"length"}`), nil)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if !resp.Truncated() {
+		t.Errorf("stop reason = %q, want max tokens", resp.StopReason)
+	}
+	if len(resp.Thinking) != 1 || resp.Thinking[0].Text != "Hmm." {
+		t.Errorf("thinking = %+v", resp.Thinking)
+	}
+
+	req, _ := p.buildRequest("m", GenerateRequest{ThinkingBudget: 2048}, false)
+	if !req.Think {
+		t.Error("a thinking budget should enable think")
+	}
+}
+
+func TestOllamaProviderGenerate_ErrorResponses(t *testing.T) {
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		w.WriteHeader(http.StatusNotFound)
+		w.Write([]byte(`{"error":"model \"missing\" not found, try pulling it first"}`))
+	}))
+	defer server.Close()
+
+	p := NewOllamaProvider(WithOllamaBaseURL(server.URL), WithOllamaModel("missing"))
+	_, err := p.Generate(context.Background(), GenerateRequest{Messages: []Message{{Role: "user", Content: "Hi"}}})
+
+	var apiErr *APIError
+	if !errors.As(err, &apiErr) || apiErr.StatusCode != http.StatusNotFound {
+		t.Fatalf("error = %v, want an APIError with status 404", err)
+	}
+	if !strings.Contains(err.Error(), "ollama:") || !strings.Contains(err.Error(), "try pulling it first") {
+		t.Errorf("error = %q", err)
+	}
+	if IsTransient(err) {
+		t.Error("a missing model is not transient")
+	}
+}
@@ -14,3 +14,13 @@ type LLMProvider interface {
 	// Name returns the name of the provider (e.g., "claude", "gemini", "openai").
 	Name() string
 }
+
+// ModelSwitcher is implemented by providers whose model can be changed
+// after they are created, for example from an interactive session.
+type ModelSwitcher interface {
+	// Model returns the model used for new requests.
+	Model() string
+
+	// SetModel changes the model used for new requests.
+	SetModel(model string)
+}
@@ -0,0 +1,311 @@
+package provider
+
+import (
+	"context"
+	"crypto/sha256"
+	"encoding/hex"
+	"encoding/json"
+	"fmt"
+	"log"
+	"os"
+	"path/filepath"
+	"sort"
+	"strings"
+	"sync"
+	"time"
+)
+
+// Default limits of a CachingProvider.
+const (
+	// DefaultResponseCacheTTL is how long a cached response is reused.
+	DefaultResponseCacheTTL = 24 * time.Hour
+	// DefaultResponseCacheMaxBytes is the size of the cache directory above
+	// which the least recently used responses are evicted.
+	DefaultResponseCacheMaxBytes = 100 << 20
+)
+
+// CachingProvider is an LLMProvider decorator that stores responses on
+// disk and returns them for identical requests, so re-running the same
+// prompt does not pay for the same LLM calls again.
+//
+// Requests are keyed by a hash of the request, the wrapped provider's name
+// and model, and the agent role in the context. Only deterministic
+// requests are cached, that is with a temperature of 0 and no extended
+// thinking, unless WithCacheNonDeterministic is set. Failed requests and
+// responses from a Router's fallback are never cached. A cache hit reports
+// zero usage, since no tokens were spent.
+type CachingProvider struct {
+	next        LLMProvider
+	dir         string
+	ttl         time.Duration
+	maxBytes    int64
+	temperature *float64 // Temperature of requests that do not set one; nil if unknown
+	force       bool     // Cache non-deterministic requests too
+	now         func() time.Time
+
+	evictMu sync.Mutex
+}
+
+// CacheOption configures a CachingProvider.
+type CacheOption func(*CachingProvider)
+
+// WithCacheTTL sets how long a cached response is reused. Values below 1
+// are ignored.
+func WithCacheTTL(ttl time.Duration) CacheOption {
+	return func(c *CachingProvider) {
+		if ttl > 0 {
+			c.ttl = ttl
+		}
+	}
+}
+
+// WithCacheMaxBytes sets the size of the cache directory above which the
+// least recently used responses are evicted. Values below 1 are ignored.
+func WithCacheMaxBytes(n int64) CacheOption {
+	return func(c *CachingProvider) {
+		if n > 0 {
+			c.maxBytes = n
+		}
+	}
+}
+
+// WithProviderTemperature tells the cache the temperature the wrapped
+// provider uses for requests that do not set one. Without it, such
+// requests are assumed to be non-deterministic.
+func WithProviderTemperature(t float64) CacheOption {
+	return func(c *CachingProvider) {
+		c.temperature = &t
+	}
+}
+
+// WithCacheNonDeterministic caches requests even if their sampling is not
+// deterministic, so a re-run replays the first answer instead of sampling
+// a new one.
+func WithCacheNonDeterministic() CacheOption {
+	return func(c *CachingProvider) {
+		c.force = true
+	}
+}
+
+// DefaultResponseCacheDir returns the default cache directory, e.g.
+// ~/.cache/agentic-poc/responses on Linux.
+func DefaultResponseCacheDir() (string, error) {
+	dir, err := os.UserCacheDir()
+	if err != nil {
+		return "", err
+	}
+	return filepath.Join(dir, "agentic-poc", "responses"), nil
+}
+
+// NewCachingProvider wraps next with a response cache stored in dir, which
+// is created if needed.
+func NewCachingProvider(next LLMProvider, dir string, opts ...CacheOption) (*CachingProvider, error) {
+	if err := os.MkdirAll(dir, 0755); err != nil {
+		return nil, fmt.Errorf("failed to create response cache directory: %w", err)
+	}
+
+	c := &CachingProvider{
+		next:     next,
+		dir:      dir,
+		ttl:      DefaultResponseCacheTTL,
+		maxBytes: DefaultResponseCacheMaxBytes,
+		now:      time.Now,
+	}
+	for _, opt := range opts {
+		opt(c)
+	}
+	return c, nil
+}
+
+// Name returns the name of the wrapped provider.
+func (c *CachingProvider) Name() string {
+	return c.next.Name()
+}
+
+// Model returns the model of the wrapped provider, if it can switch
+// models.
+func (c *CachingProvider) Model() string {
+	if switcher, ok := c.next.(ModelSwitcher); ok {
+		return switcher.Model()
+	}
+	return ""
+}
+
+// SetModel changes the model of the wrapped provider, if it can switch
+// models. Responses cached for the old model are not reused.
+func (c *CachingProvider) SetModel(model string) {
+	if switcher, ok := c.next.(ModelSwitcher); ok {
+		switcher.SetModel(model)
+	}
+}
+
+// cacheEntry is the format of a cached response on disk.
+type cacheEntry struct {
+	Created  time.Time   `json:"created"`
+	Response LLMResponse `json:"response"`
+}
+
+// Generate returns the cached response to an identical request if there
+// is one that has not expired, and otherwise asks the wrapped provider and
+// caches its response.
+func (c *CachingProvider) Generate(ctx context.Context, req GenerateRequest) (*LLMResponse, error) {
+	if !c.cacheable(req) {
+		return c.next.Generate(ctx, req)
+	}
+
+	key, err := c.key(ctx, req)
+	if err != nil {
+		log.Printf("[Cache] Not caching request: %v", err)
+		return c.next.Generate(ctx, req)
+	}
+	path := filepath.Join(c.dir, key+".json")
+
+	if resp, ok := c.load(path); ok {
+		return resp, nil
+	}
+
+	resp, err := c.next.Generate(ctx, req)
+	if err != nil {
+		return nil, err
+	}
+	if resp.Fallback {
+		// The key names the backend chosen for the request, not the one
+		// that answered it
+		log.Printf("[Cache] Not caching a response from the fallback provider")
+		return resp, nil
+	}
+	if err := c.store(path, resp); err != nil {
+		log.Printf("[Cache] Failed to store response: %v", err)
+	}
+	return resp, nil
+}
+
+// cacheable reports whether the response to req may be cached.
+func (c *CachingProvider) cacheable(req GenerateRequest) bool {
+	if c.force {
+		return true
+	}
+	temperature := c.temperature
+	if req.Temperature != nil {
+		temperature = req.Temperature
+	}
+	return temperature != nil && *temperature == 0 && req.ThinkingBudget == 0
+}
+
+// key returns the cache key of a request: a hash of its canonical JSON
+// form, with the provider, model, role and default temperature. The
+// prompt cache policy does not change the response, so it is left out.
+func (c *CachingProvider) key(ctx context.Context, req GenerateRequest) (string, error) {
+	req.Cache = nil
+	data, err := json.Marshal(struct {
+		Provider    string          `json:"provider"`
+		Model       string          `json:"model"`
+		Role        string          `json:"role"`
+		Temperature *float64        `json:"temperature"`
+		Request     GenerateRequest `json:"request"`
+	}{c.next.Name(), c.Model(), RoleFromContext(ctx), c.temperature, req})
+	if err != nil {
+		return "", err
+	}
+	sum := sha256.Sum256(data)
+	return hex.EncodeToString(sum[:]), nil
+}
+
+// load returns the response cached at path, if it has not expired. An
+// expired or unreadable entry is removed.
+func (c *CachingProvider) load(path string) (*LLMResponse, bool) {
+	data, err := os.ReadFile(path)
+	if err != nil {
+		return nil, false
+	}
+
+	var entry cacheEntry
+	if err := json.Unmarshal(data, &entry); err != nil || c.now().Sub(entry.Created) >= c.ttl {
+		os.Remove(path)
+		return nil, false
+	}
+
+	// The modification time records the last use, for eviction
+	now := c.now()
+	os.Chtimes(path, now, now)
+
+	log.Printf("[Cache] Reusing response from %s", entry.Created.Format(time.RFC3339))
+	resp := entry.Response
+	resp.Usage = Usage{}
+	return &resp, true
+}
+
+// store writes resp to path, then evicts the least recently used entries
+// if the cache has grown past its size limit.
+func (c *CachingProvider) store(path string, resp *LLMResponse) error {
+	data, err := json.Marshal(cacheEntry{Created: c.now(), Response: *resp})
+	if err != nil {
+		return err
+	}
+
+	// Write to a temporary file first so concurrent readers never see a
+	// partial entry
+	tmp, err := os.CreateTemp(c.dir, "tmp-*")
+	if err != nil {
+		return err
+	}
+	if _, err := tmp.Write(data); err != nil {
+		tmp.Close()
+		os.Remove(tmp.Name())
+		return err
+	}
+	if err := tmp.Close(); err != nil {
+		os.Remove(tmp.Name())
+		return err
+	}
+	if err := os.Rename(tmp.Name(), path); err != nil {
+		os.Remove(tmp.Name())
+		return err
+	}
+	now := c.now()
+	os.Chtimes(path, now, now)
+
+	return c.evict()
+}
+
+// evict removes the least recently used entries until the cache fits in
+// its size limit.
+func (c *CachingProvider) evict() error {
+	c.evictMu.Lock()
+	defer c.evictMu.Unlock()
+
+	dirEntries, err := os.ReadDir(c.dir)
+	if err != nil {
+		return err
+	}
+
+	type file struct {
+		path    string
+		size    int64
+		modTime time.Time
+	}
+	var files []file
+	var total int64
+	for _, e := range dirEntries {
+		if e.IsDir() || !strings.HasSuffix(e.Name(), ".json") {
+			continue
+		}
+		info, err := e.Info()
+		if err != nil {
+			continue
+		}
+		files = append(files, file{filepath.Join(c.dir, e.Name()), info.Size(), info.ModTime()})
+		total += info.Size()
+	}
+
+	sort.Slice(files, func(i, j int) bool { return files[i].modTime.Before(files[j].modTime) })
+	for _, f := range files {
+		if total <= c.maxBytes {
+			break
+		}
+		if err := os.Remove(f.path); err == nil {
+			total -= f.size
+		}
+	}
+	return nil
+}
@@ -0,0 +1,226 @@
+package provider
+
+import (
+	"context"
+	"errors"
+	"os"
+	"path/filepath"
+	"testing"
+	"time"
+)
+
+// countingProvider answers every request with the number of calls so far.
+type countingProvider struct {
+	model string
+	calls int
+	err   error
+}
+
+func (p *countingProvider) Generate(ctx context.Context, req GenerateRequest) (*LLMResponse, error) {
+	p.calls++
+	if p.err != nil {
+		return nil, p.err
+	}
+	return &LLMResponse{
+		Text:       string(rune('0' + p.calls)),
+		StopReason: StopEndTurn,
+		Usage:      Usage{InputTokens: 10, OutputTokens: 2},
+	}, nil
+}
+
+func (p *countingProvider) Name() string      { return "counting" }
+func (p *countingProvider) Model() string     { return p.model }
+func (p *countingProvider) SetModel(m string) { p.model = m }
+
+func TestCachingProvider_Hits(t *testing.T) {
+	next := &countingProvider{model: "m1"}
+	cache, err := NewCachingProvider(next, t.TempDir(), WithProviderTemperature(0))
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	req := GenerateRequest{
+		SystemPrompt: "Plan.",
+		Messages:     []Message{{Role: "user", Content: "Build a CLI"}},
+		Tools:        []ToolDefinition{{Name: "finish_plan"}},
+	}
+	first, err := cache.Generate(context.Background(), req)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if first.Usage.InputTokens != 10 {
+		t.Errorf("a miss should report the provider's usage, got %+v", first.Usage)
+	}
+
+	// The prompt cache policy does not change the key
+	withPolicy := req
+	withPolicy.Cache = &CachePolicy{System: true}
+	second, err := cache.Generate(context.Background(), withPolicy)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if next.calls != 1 || second.Text != first.Text || second.StopReason != StopEndTurn {
+		t.Errorf("calls = %d, second = %+v; want the cached response", next.calls, second)
+	}
+	if second.Usage != (Usage{}) {
+		t.Errorf("a hit should report no usage, got %+v", second.Usage)
+	}
+
+	// Anything that can change the answer is a different key
+	misses := []struct {
+		name string
+		ctx  context.Context
+		req  GenerateRequest
+		prep func()
+	}{
+		{name: "message", ctx: context.Background(), req: GenerateRequest{Messages: []Message{{Role: "user", Content: "Build a GUI"}}}},
+		{name: "max tokens", ctx: context.Background(), req: func() GenerateRequest { r := req; r.MaxTokens = 10; return r }()},
+		{name: "role", ctx: WithRole(context.Background(), "architect"), req: req},
+		{name: "model", ctx: context.Background(), req: req, prep: func() { next.SetModel("m2") }},
+	}
+	for i, tt := range misses {
+		if tt.prep != nil {
+			tt.prep()
+		}
+		if _, err := cache.Generate(tt.ctx, tt.req); err != nil {
+			t.Fatal(err)
+		}
+		if next.calls != i+2 {
+			t.Errorf("%s: calls = %d, want a miss", tt.name, next.calls)
+		}
+	}
+}
+
+func TestCachingProvider_Cacheable(t *testing.T) {
+	zero, warm := 0.0, 0.7
+	tests := []struct {
+		name    string
+		opts    []CacheOption
+		req     GenerateRequest
+		wantHit bool
+	}{
+		{name: "unknown provider temperature", wantHit: false},
+		{name: "zero provider temperature", opts: []CacheOption{WithProviderTemperature(0)}, wantHit: true},
+		{name: "zero request temperature", req: GenerateRequest{Temperature: &zero}, wantHit: true},
+		{name: "request overrides provider", opts: []CacheOption{WithProviderTemperature(0)}, req: GenerateRequest{Temperature: &warm}, wantHit: false},
+		{name: "thinking", req: GenerateRequest{Temperature: &zero, ThinkingBudget: 1024}, wantHit: false},
+		{name: "forced", opts: []CacheOption{WithCacheNonDeterministic()}, req: GenerateRequest{Temperature: &warm}, wantHit: true},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			next := &countingProvider{}
+			cache, err := NewCachingProvider(next, t.TempDir(), tt.opts...)
+			if err != nil {
+				t.Fatal(err)
+			}
+			tt.req.Messages = []Message{{Role: "user", Content: "Hi"}}
+			cache.Generate(context.Background(), tt.req)
+			cache.Generate(context.Background(), tt.req)
+
+			if hit := next.calls == 1; hit != tt.wantHit {
+				t.Errorf("calls = %d, want hit %v", next.calls, tt.wantHit)
+			}
+		})
+	}
+}
+
+func TestCachingProvider_ErrorsNotCached(t *testing.T) {
+	next := &countingProvider{err: errors.New("overloaded")}
+	cache, _ := NewCachingProvider(next, t.TempDir(), WithCacheNonDeterministic())
+	req := GenerateRequest{Messages: []Message{{Role: "user", Content: "Hi"}}}
+
+	if _, err := cache.Generate(context.Background(), req); err == nil {
+		t.Fatal("expected the provider's error")
+	}
+	next.err = nil
+	if _, err := cache.Generate(context.Background(), req); err != nil {
+		t.Fatal(err)
+	}
+	if next.calls != 2 {
+		t.Errorf("calls = %d, want the failed request retried", next.calls)
+	}
+}
+
+func TestCachingProvider_FallbackNotCached(t *testing.T) {
+	primary := &stubProvider{name: "primary", err: overloaded}
+	fallback := &stubProvider{name: "fallback"}
+	cache, _ := NewCachingProvider(NewRouter(primary, WithFallback(fallback)), t.TempDir(), WithProviderTemperature(0))
+	req := GenerateRequest{Messages: []Message{{Role: "user", Content: "Hi"}}}
+
+	if _, err := cache.Generate(context.Background(), req); err != nil {
+		t.Fatal(err)
+	}
+
+	// Once the primary recovers, its answer is used and cached
+	primary.err = nil
+	for i := 0; i < 2; i++ {
+		resp, err := cache.Generate(context.Background(), req)
+		if err != nil {
+			t.Fatal(err)
+		}
+		if resp.Text != "primary" {
+			t.Errorf("response %d from %q, want the primary's", i, resp.Text)
+		}
+	}
+	if primary.calls != 2 || fallback.calls != 1 {
+		t.Errorf("primary called %d times, fallback %d; want 2 and 1", primary.calls, fallback.calls)
+	}
+}
+
+func TestCachingProvider_TTL(t *testing.T) {
+	next := &countingProvider{}
+	dir := t.TempDir()
+	cache, _ := NewCachingProvider(next, dir, WithCacheNonDeterministic(), WithCacheTTL(time.Hour))
+	now := time.Date(2026, 1, 1, 12, 0, 0, 0, time.UTC)
+	cache.now = func() time.Time { return now }
+	req := GenerateRequest{Messages: []Message{{Role: "user", Content: "Hi"}}}
+
+	cache.Generate(context.Background(), req)
+	now = now.Add(59 * time.Minute)
+	cache.Generate(context.Background(), req)
+	if next.calls != 1 {
+		t.Fatalf("calls = %d, want a hit within the TTL", next.calls)
+	}
+
+	now = now.Add(time.Minute)
+	resp, _ := cache.Generate(context.Background(), req)
+	if next.calls != 2 || resp.Text != "2" {
+		t.Errorf("calls = %d, response %q; want a fresh response after the TTL", next.calls, resp.Text)
+	}
+}
+
+func TestCachingProvider_Eviction(t *testing.T) {
+	next := &countingProvider{}
+	dir := t.TempDir()
+	cache, _ := NewCachingProvider(next, dir, WithCacheNonDeterministic())
+	now := time.Date(2026, 1, 1, 12, 0, 0, 0, time.UTC)
+	cache.now = func() time.Time { now = now.Add(time.Second); return now }
+
+	request := func(content string) GenerateRequest {
+		return GenerateRequest{Messages: []Message{{Role: "user", Content: content}}}
+	}
+	cache.Generate(context.Background(), request("a"))
+	cache.Generate(context.Background(), request("b"))
+
+	// Make room for exactly two entries, then use "a" so "b" is the least
+	// recently used when "c" is added
+	entries, _ := filepath.Glob(filepath.Join(dir, "*.json"))
+	info, _ := os.Stat(entries[0])
+	cache.maxBytes = 2*info.Size() + info.Size()/2
+	cache.Generate(context.Background(), request("a"))
+	cache.Generate(context.Background(), request("c"))
+
+	if entries, _ := filepath.Glob(filepath.Join(dir, "*.json")); len(entries) != 2 {
+		t.Fatalf("cache holds %d entries, want 2", len(entries))
+	}
+	calls := next.calls
+	cache.Generate(context.Background(), request("a"))
+	if next.calls != calls {
+		t.Error("the recently used entry should have been kept")
+	}
+	cache.Generate(context.Background(), request("b"))
+	if next.calls != calls+1 {
+		t.Error("the least recently used entry should have been evicted")
+	}
+}
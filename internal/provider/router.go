@@ -0,0 +1,230 @@
+package provider
+
+import (
+	"context"
+	"fmt"
+	"log"
+	"sync"
+	"time"
+)
+
+// Default circuit breaker settings of a Router.
+const (
+	// DefaultBreakerThreshold is the number of consecutive transient
+	// failures after which a backend is skipped.
+	DefaultBreakerThreshold = 3
+	// DefaultBreakerCooldown is how long a backend is skipped before it is
+	// tried again.
+	DefaultBreakerCooldown = 30 * time.Second
+)
+
+// roleKey is the context key for the role of the agent making a request.
+type roleKey struct{}
+
+// WithRole returns a context whose requests are made on behalf of the
+// agent role, such as "architect" or "coder". A Router uses it to pick a
+// backend; other providers ignore it.
+func WithRole(ctx context.Context, role string) context.Context {
+	return context.WithValue(ctx, roleKey{}, role)
+}
+
+// RoleFromContext returns the agent role set with WithRole, or "" if none.
+func RoleFromContext(ctx context.Context) string {
+	role, _ := ctx.Value(roleKey{}).(string)
+	return role
+}
+
+// RouteMatch reports whether a request should go to a route's backend.
+type RouteMatch func(ctx context.Context, req GenerateRequest) bool
+
+// MatchRole matches requests made on behalf of the given agent role.
+func MatchRole(role string) RouteMatch {
+	return func(ctx context.Context, req GenerateRequest) bool {
+		return RoleFromContext(ctx) == role
+	}
+}
+
+// route is a backend with the requests it serves.
+type route struct {
+	name    string
+	match   RouteMatch
+	backend *backend
+}
+
+// backend is a provider with its circuit breaker state.
+type backend struct {
+	provider LLMProvider
+
+	mu        sync.Mutex
+	failures  int       // Consecutive transient failures
+	openUntil time.Time // Skipped until then once failures reach the threshold
+}
+
+// available reports whether the backend's circuit is closed, or its
+// cooldown has expired and it may be tried again.
+func (b *backend) available(now time.Time) bool {
+	b.mu.Lock()
+	defer b.mu.Unlock()
+	return !now.Before(b.openUntil)
+}
+
+// record updates the circuit breaker after a request. It returns true if
+// the failure opened the circuit.
+func (b *backend) record(failed bool, now time.Time, threshold int, cooldown time.Duration) bool {
+	b.mu.Lock()
+	defer b.mu.Unlock()
+	if !failed {
+		b.failures = 0
+		b.openUntil = time.Time{}
+		return false
+	}
+	b.failures++
+	if b.failures < threshold {
+		return false
+	}
+	b.openUntil = now.Add(cooldown)
+	return true
+}
+
+// Router is an LLMProvider that sends each request to one of several
+// backends, chosen by the first route that matches the request, or to a
+// default backend. If a fallback is set, requests that fail with a
+// transient error (see IsTransient) are retried on the fallback, and a
+// backend that fails repeatedly is skipped for a cooldown period: its
+// circuit opens after a number of consecutive failures, and the first
+// request after the cooldown tries it again.
+type Router struct {
+	def       *backend
+	routes    []route
+	fallback  LLMProvider
+	threshold int
+	cooldown  time.Duration
+	now       func() time.Time
+}
+
+// RouterOption configures a Router.
+type RouterOption func(*Router)
+
+// WithRoute sends requests that match to p. Routes are tried in the order
+// they were added. Each route keeps its own circuit breaker, even if
+// several routes share a provider.
+func WithRoute(name string, match RouteMatch, p LLMProvider) RouterOption {
+	return func(r *Router) {
+		r.routes = append(r.routes, route{name: name, match: match, backend: &backend{provider: p}})
+	}
+}
+
+// WithRoleRoute sends requests made on behalf of an agent role to p.
+func WithRoleRoute(role string, p LLMProvider) RouterOption {
+	return WithRoute(role, MatchRole(role), p)
+}
+
+// WithFallback retries requests that fail with a transient error on p.
+func WithFallback(p LLMProvider) RouterOption {
+	return func(r *Router) {
+		r.fallback = p
+	}
+}
+
+// WithCircuitBreaker sets the number of consecutive transient failures
+// after which a backend is skipped in favor of the fallback, and for how
+// long.
+func WithCircuitBreaker(threshold int, cooldown time.Duration) RouterOption {
+	return func(r *Router) {
+		if threshold > 0 {
+			r.threshold = threshold
+		}
+		if cooldown > 0 {
+			r.cooldown = cooldown
+		}
+	}
+}
+
+// NewRouter creates a Router that sends requests no route matches to def.
+func NewRouter(def LLMProvider, opts ...RouterOption) *Router {
+	r := &Router{
+		def:       &backend{provider: def},
+		threshold: DefaultBreakerThreshold,
+		cooldown:  DefaultBreakerCooldown,
+		now:       time.Now,
+	}
+	for _, opt := range opts {
+		opt(r)
+	}
+	return r
+}
+
+// Name returns the name of the default backend.
+func (r *Router) Name() string {
+	return r.def.provider.Name()
+}
+
+// Generate sends the request to the backend chosen for it, falling back
+// on a transient failure if a fallback is set.
+func (r *Router) Generate(ctx context.Context, req GenerateRequest) (*LLMResponse, error) {
+	name, b := r.pick(ctx, req)
+	if r.fallback == nil {
+		return b.provider.Generate(ctx, req)
+	}
+
+	if !b.available(r.now()) {
+		return r.generateFallback(ctx, req)
+	}
+
+	resp, err := b.provider.Generate(ctx, req)
+	if err != nil && (ctx.Err() != nil || !IsTransient(err)) {
+		return nil, err
+	}
+	if b.record(err != nil, r.now(), r.threshold, r.cooldown) {
+		log.Printf("[Router] %s backend failed %d times in a row; using the fallback for %v", name, r.threshold, r.cooldown)
+	}
+	if err == nil {
+		return resp, nil
+	}
+
+	log.Printf("[Router] %s backend failed, retrying with the fallback: %v", name, err)
+	resp, fallbackErr := r.generateFallback(ctx, req)
+	if fallbackErr != nil {
+		return nil, fmt.Errorf("%w (fallback also failed: %v)", err, fallbackErr)
+	}
+	return resp, nil
+}
+
+// generateFallback sends the request to the fallback and marks its
+// response as such.
+func (r *Router) generateFallback(ctx context.Context, req GenerateRequest) (*LLMResponse, error) {
+	resp, err := r.fallback.Generate(ctx, req)
+	if err != nil {
+		return nil, err
+	}
+	resp.Fallback = true
+	return resp, nil
+}
+
+// pick returns the name and backend of the first route that matches the
+// request, or the default backend.
+func (r *Router) pick(ctx context.Context, req GenerateRequest) (string, *backend) {
+	for _, rt := range r.routes {
+		if rt.match(ctx, req) {
+			return rt.name, rt.backend
+		}
+	}
+	return "default", r.def
+}
+
+// Model returns the model of the default backend, if it can switch
+// models.
+func (r *Router) Model() string {
+	if switcher, ok := r.def.provider.(ModelSwitcher); ok {
+		return switcher.Model()
+	}
+	return ""
+}
+
+// SetModel changes the model of the default backend, if it can switch
+// models. Routed backends keep their models.
+func (r *Router) SetModel(model string) {
+	if switcher, ok := r.def.provider.(ModelSwitcher); ok {
+		switcher.SetModel(model)
+	}
+}
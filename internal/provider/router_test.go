@@ -0,0 +1,195 @@
+package provider
+
+import (
+	"context"
+	"errors"
+	"strings"
+	"testing"
+	"time"
+)
+
+// stubProvider returns a fixed response or error and counts its calls.
+type stubProvider struct {
+	name  string
+	err   error
+	calls int
+}
+
+func (p *stubProvider) Generate(ctx context.Context, req GenerateRequest) (*LLMResponse, error) {
+	p.calls++
+	if p.err != nil {
+		return nil, p.err
+	}
+	return &LLMResponse{Text: p.name}, nil
+}
+
+func (p *stubProvider) Name() string {
+	return p.name
+}
+
+var overloaded = &APIError{Provider: "claude", StatusCode: 529, Type: "overloaded_error", Message: "Overloaded"}
+
+func TestRouter_Routes(t *testing.T) {
+	def := &stubProvider{name: "default"}
+	planner := &stubProvider{name: "planner"}
+	vision := &stubProvider{name: "vision"}
+	hasImage := func(ctx context.Context, req GenerateRequest) bool {
+		for _, msg := range req.Messages {
+			for _, block := range msg.Blocks {
+				if block.Type == ContentImage {
+					return true
+				}
+			}
+		}
+		return false
+	}
+	router := NewRouter(def,
+		WithRoleRoute("architect", planner),
+		WithRoute("images", hasImage, vision),
+	)
+
+	imageReq := GenerateRequest{Messages: []Message{{Role: "user", Blocks: []ContentBlock{ImageBlock("image/png", "iVBORw0=")}}}}
+	tests := []struct {
+		name string
+		ctx  context.Context
+		req  GenerateRequest
+		want string
+	}{
+		{name: "no role", ctx: context.Background(), want: "default"},
+		{name: "other role", ctx: WithRole(context.Background(), "coder"), want: "default"},
+		{name: "architect", ctx: WithRole(context.Background(), "architect"), want: "planner"},
+		{name: "request trait", ctx: context.Background(), req: imageReq, want: "vision"},
+		{name: "first match wins", ctx: WithRole(context.Background(), "architect"), req: imageReq, want: "planner"},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			resp, err := router.Generate(tt.ctx, tt.req)
+			if err != nil {
+				t.Fatalf("unexpected error: %v", err)
+			}
+			if resp.Text != tt.want {
+				t.Errorf("routed to %q, want %q", resp.Text, tt.want)
+			}
+		})
+	}
+
+	if router.Name() != "default" {
+		t.Errorf("Name() = %q, want the default backend's", router.Name())
+	}
+}
+
+func TestRouter_Fallback(t *testing.T) {
+	tests := []struct {
+		name         string
+		err          error
+		fallbackErr  error
+		wantText     string
+		wantErr      string
+		wantFallback int
+	}{
+		{name: "success", wantText: "primary"},
+		{name: "overloaded", err: overloaded, wantText: "fallback", wantFallback: 1},
+		{name: "server error", err: &APIError{Provider: "claude", StatusCode: 500}, wantText: "fallback", wantFallback: 1},
+		{name: "bad request is not retried", err: &APIError{Provider: "claude", StatusCode: 400, Message: "bad"}, wantErr: "bad request"},
+		{
+			name:         "fallback fails too",
+			err:          overloaded,
+			fallbackErr:  errors.New("fallback down"),
+			wantErr:      "claude: overloaded: Overloaded (fallback also failed: fallback down)",
+			wantFallback: 1,
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			primary := &stubProvider{name: "primary", err: tt.err}
+			fallback := &stubProvider{name: "fallback", err: tt.fallbackErr}
+			router := NewRouter(primary, WithFallback(fallback))
+
+			resp, err := router.Generate(context.Background(), GenerateRequest{})
+			if tt.wantErr != "" {
+				if err == nil || !strings.Contains(err.Error(), tt.wantErr) {
+					t.Errorf("error = %v, want one containing %q", err, tt.wantErr)
+				}
+				if tt.err != nil && !errors.Is(err, tt.err) {
+					t.Error("the primary's error should be wrapped")
+				}
+			} else if err != nil {
+				t.Fatalf("unexpected error: %v", err)
+			} else if resp.Text != tt.wantText || resp.Fallback != (tt.wantFallback > 0) {
+				t.Errorf("response from %q (fallback %v), want %q", resp.Text, resp.Fallback, tt.wantText)
+			}
+			if fallback.calls != tt.wantFallback {
+				t.Errorf("fallback called %d times, want %d", fallback.calls, tt.wantFallback)
+			}
+		})
+	}
+}
+
+func TestRouter_NoFallbackAfterCancel(t *testing.T) {
+	ctx, cancel := context.WithCancel(context.Background())
+	cancel()
+	primary := &stubProvider{name: "primary", err: overloaded}
+	fallback := &stubProvider{name: "fallback"}
+
+	if _, err := NewRouter(primary, WithFallback(fallback)).Generate(ctx, GenerateRequest{}); err == nil {
+		t.Fatal("expected an error")
+	}
+	if fallback.calls != 0 {
+		t.Error("a cancelled request should not fall back")
+	}
+}
+
+func TestRouter_CircuitBreaker(t *testing.T) {
+	primary := &stubProvider{name: "primary", err: overloaded}
+	fallback := &stubProvider{name: "fallback"}
+	router := NewRouter(primary, WithFallback(fallback), WithCircuitBreaker(2, time.Minute))
+	now := time.Date(2026, 1, 1, 12, 0, 0, 0, time.UTC)
+	router.now = func() time.Time { return now }
+
+	generate := func() {
+		t.Helper()
+		if _, err := router.Generate(context.Background(), GenerateRequest{}); err != nil {
+			t.Fatalf("unexpected error: %v", err)
+		}
+	}
+
+	// Two failures open the circuit; the primary is then skipped
+	generate()
+	generate()
+	generate()
+	if primary.calls != 2 || fallback.calls != 3 {
+		t.Fatalf("calls = %d primary, %d fallback; want 2 and 3", primary.calls, fallback.calls)
+	}
+
+	// After the cooldown the primary is tried again, and one more failure
+	// reopens the circuit
+	now = now.Add(time.Minute)
+	generate()
+	generate()
+	if primary.calls != 3 || fallback.calls != 5 {
+		t.Fatalf("calls = %d primary, %d fallback; want 3 and 5", primary.calls, fallback.calls)
+	}
+
+	// A success closes it
+	now = now.Add(time.Minute)
+	primary.err = nil
+	generate()
+	primary.err = overloaded
+	generate()
+	if primary.calls != 5 || fallback.calls != 6 {
+		t.Errorf("calls = %d primary, %d fallback; want 5 and 6", primary.calls, fallback.calls)
+	}
+}
+
+func TestRouter_ModelSwitcher(t *testing.T) {
+	def, _ := NewClaudeProviderWithKey("test-api-key", WithModel("model-a"))
+	router := NewRouter(def, WithRoleRoute("architect", &stubProvider{name: "planner"}))
+
+	var switcher ModelSwitcher = router
+	switcher.SetModel("model-b")
+	if switcher.Model() != "model-b" || def.Model() != "model-b" {
+		t.Errorf("model = %q, want the default backend switched to model-b", def.Model())
+	}
+}
@@ -1,13 +1,73 @@
 // Package provider defines the LLM provider abstraction and core data types.
 package provider
 
+import "fmt"
+
 // Message represents a single message in a conversation.
 type Message struct {
-	Role       string     `json:"role"`
-	Content    string     `json:"content"`
-	ToolCallID string     `json:"tool_call_id,omitempty"`
-	ToolName   string     `json:"tool_name,omitempty"`
-	ToolCalls  []ToolCall `json:"tool_calls,omitempty"` // For assistant messages with tool use
+	Role       string         `json:"role"`
+	Content    string         `json:"content"`
+	Blocks     []ContentBlock `json:"blocks,omitempty"` // Rich content; when set, replaces Content, which keeps the text form
+	ToolCallID string         `json:"tool_call_id,omitempty"`
+	ToolName   string         `json:"tool_name,omitempty"`
+	ToolCalls  []ToolCall     `json:"tool_calls,omitempty"` // For assistant messages with tool use
+	ToolError  *ToolError     `json:"tool_error,omitempty"` // For tool results; nil if the call succeeded
+	Thinking   []Thinking     `json:"thinking,omitempty"`   // For assistant messages; sent back unchanged
+}
+
+// Thinking is a block of the model's extended thinking. Providers that
+// sign thinking require it to be sent back unchanged with the tool calls
+// it led to, so it is kept on the assistant message.
+type Thinking struct {
+	Text      string `json:"text,omitempty"`      // The reasoning; empty if redacted
+	Signature string `json:"signature,omitempty"` // Verifies the text was not changed
+	Redacted  string `json:"redacted,omitempty"`  // Encrypted reasoning the provider withheld
+}
+
+// ContentType identifies the kind of data held by a ContentBlock.
+type ContentType string
+
+const (
+	// ContentText is plain text.
+	ContentText ContentType = "text"
+	// ContentImage is a base64-encoded image.
+	ContentImage ContentType = "image"
+	// ContentAudio is base64-encoded audio.
+	ContentAudio ContentType = "audio"
+	// ContentResource is a reference to (or embedded copy of) a resource identified by URI.
+	ContentResource ContentType = "resource"
+	// ContentStructured is an arbitrary JSON value.
+	ContentStructured ContentType = "structured"
+	// ContentDocument is a base64-encoded document, such as a PDF.
+	ContentDocument ContentType = "document"
+)
+
+// ContentBlock is a single typed piece of content, such as text or an image.
+// Which fields are set depends on Type.
+type ContentBlock struct {
+	Type       ContentType `json:"type"`
+	Text       string      `json:"text,omitempty"`       // Text; optional inline text for resources
+	MimeType   string      `json:"mime_type,omitempty"`  // Image, audio, document and resource
+	Data       string      `json:"data,omitempty"`       // Base64 payload for image, audio, document and binary resources
+	URI        string      `json:"uri,omitempty"`        // Resource
+	Name       string      `json:"name,omitempty"`       // Resource and document
+	Structured interface{} `json:"structured,omitempty"` // Structured
+}
+
+// TextBlock creates a text ContentBlock.
+func TextBlock(text string) ContentBlock {
+	return ContentBlock{Type: ContentText, Text: text}
+}
+
+// ImageBlock creates an image ContentBlock from base64-encoded data.
+func ImageBlock(mimeType, data string) ContentBlock {
+	return ContentBlock{Type: ContentImage, MimeType: mimeType, Data: data}
+}
+
+// DocumentBlock creates a document ContentBlock from base64-encoded data.
+// The name, usually a file name, identifies the document to the model.
+func DocumentBlock(mimeType, name, data string) ContentBlock {
+	return ContentBlock{Type: ContentDocument, MimeType: mimeType, Name: name, Data: data}
 }
 
 // ToolCall represents a request from the LLM to execute a tool.
@@ -18,16 +78,122 @@ type ToolCall struct {
 }
 
 // ToolResult represents the result of executing a tool.
+// Output always holds the text form of the result. Tools that produce
+// images or other rich data also set Content, which providers that support
+// it send in place of Output.
 type ToolResult struct {
-	Success bool   `json:"success"`
-	Output  string `json:"output"`
-	Error   string `json:"error,omitempty"`
+	Success   bool           `json:"success"`
+	Output    string         `json:"output"`
+	Error     string         `json:"error,omitempty"`
+	ErrorKind ToolErrorKind  `json:"error_kind,omitempty"` // Why the call failed; internal if unset
+	Retryable bool           `json:"retryable,omitempty"`  // Whether repeating the call may succeed
+	Content   []ContentBlock `json:"content,omitempty"`
+}
+
+// Err returns the failure of an unsuccessful result as a ToolError, or nil
+// if the call succeeded.
+func (r *ToolResult) Err() *ToolError {
+	if r.Success {
+		return nil
+	}
+	kind := r.ErrorKind
+	if kind == "" {
+		kind = ToolErrorInternal
+	}
+	return &ToolError{Kind: kind, Message: r.Error, Retryable: r.Retryable}
+}
+
+// ToolErrorKind classifies why a tool call failed.
+type ToolErrorKind string
+
+const (
+	// ToolErrorValidation means the arguments were rejected; fix them and retry.
+	ToolErrorValidation ToolErrorKind = "validation"
+	// ToolErrorNotFound means the tool or a resource it needed does not exist.
+	ToolErrorNotFound ToolErrorKind = "not_found"
+	// ToolErrorPermission means the tool was not allowed to do what was asked.
+	ToolErrorPermission ToolErrorKind = "permission"
+	// ToolErrorTimeout means the call ran out of time.
+	ToolErrorTimeout ToolErrorKind = "timeout"
+	// ToolErrorExecution means the operation cannot succeed with these
+	// arguments, such as a division by zero; repeating the call won't help.
+	ToolErrorExecution ToolErrorKind = "execution"
+	// ToolErrorInternal is any other failure.
+	ToolErrorInternal ToolErrorKind = "internal"
+)
+
+// ToolError describes a failed tool call. It implements error so that tool
+// code can return it to classify a failure.
+type ToolError struct {
+	Kind      ToolErrorKind `json:"kind"`
+	Message   string        `json:"message"`
+	Retryable bool          `json:"retryable,omitempty"`
+}
+
+// Error returns the error message.
+func (e *ToolError) Error() string {
+	return e.Message
+}
+
+// Describe renders the error for the model, with its kind and whether a
+// retry may help, e.g. "validation error (retryable): path: is required".
+func (e *ToolError) Describe() string {
+	hint := ""
+	if e.Retryable {
+		hint = " (retryable)"
+	}
+	return fmt.Sprintf("%s error%s: %s", e.Kind, hint, e.Message)
 }
 
 // LLMResponse represents a response from an LLM provider.
 type LLMResponse struct {
-	Text      string     `json:"text"`
-	ToolCalls []ToolCall `json:"tool_calls,omitempty"`
+	Text         string     `json:"text"`
+	ToolCalls    []ToolCall `json:"tool_calls,omitempty"`
+	Thinking     []Thinking `json:"thinking,omitempty"` // Extended thinking, if it was enabled
+	Usage        Usage      `json:"usage"`
+	StopReason   StopReason `json:"stop_reason,omitempty"`   // Empty if the provider does not report one
+	StopSequence string     `json:"stop_sequence,omitempty"` // The stop sequence matched, for StopSequenceMatched
+	Fallback     bool       `json:"fallback,omitempty"`      // Served by a Router's fallback instead of the backend chosen for the request
+}
+
+// StopReason says why the model stopped generating a response.
+type StopReason string
+
+const (
+	// StopEndTurn means the model finished its turn.
+	StopEndTurn StopReason = "end_turn"
+	// StopMaxTokens means the response was cut off at the output token limit.
+	StopMaxTokens StopReason = "max_tokens"
+	// StopToolUse means the model stopped to wait for tool results.
+	StopToolUse StopReason = "tool_use"
+	// StopSequenceMatched means the model produced one of the request's stop sequences.
+	StopSequenceMatched StopReason = "stop_sequence"
+)
+
+// Truncated reports whether the response was cut off at the output token
+// limit, so its text may be incomplete and its tool calls malformed.
+func (r *LLMResponse) Truncated() bool {
+	return r.StopReason == StopMaxTokens
+}
+
+// Usage counts the tokens consumed by one or more LLM calls.
+// InputTokens excludes the input tokens written to or read from the
+// prompt cache, which are counted separately.
+type Usage struct {
+	InputTokens      int `json:"input_tokens"`
+	OutputTokens     int `json:"output_tokens"`
+	CacheWriteTokens int `json:"cache_write_tokens,omitempty"` // Input tokens added to the prompt cache
+	CacheReadTokens  int `json:"cache_read_tokens,omitempty"`  // Input tokens served from the prompt cache
+}
+
+// Add returns the sum of u and other.
+func (u Usage) Add(other Usage) Usage {
+	return Usage{
+		InputTokens:      u.InputTokens + other.InputTokens,
+		OutputTokens:     u.OutputTokens + other.OutputTokens,
+		CacheWriteTokens: u.CacheWriteTokens + other.CacheWriteTokens,
+		CacheReadTokens:  u.CacheReadTokens + other.CacheReadTokens,
+	}
 }
 
 // HasToolCalls returns true if the response contains tool calls.
@@ -43,8 +209,55 @@ type ToolDefinition struct {
 }
 
 // GenerateRequest represents a request to generate a response from an LLM.
+// Sampling fields left unset use the provider's defaults.
 type GenerateRequest struct {
-	Messages     []Message        `json:"messages"`
-	Tools        []ToolDefinition `json:"tools,omitempty"`
-	SystemPrompt string           `json:"system_prompt,omitempty"`
+	Messages      []Message        `json:"messages"`
+	Tools         []ToolDefinition `json:"tools,omitempty"`
+	SystemPrompt  string           `json:"system_prompt,omitempty"`
+	MaxTokens     int              `json:"max_tokens,omitempty"` // 0 uses the provider default
+	Temperature   *float64         `json:"temperature,omitempty"`
+	TopP          *float64         `json:"top_p,omitempty"`
+	StopSequences []string         `json:"stop_sequences,omitempty"`
+	ToolChoice    *ToolChoice      `json:"tool_choice,omitempty"` // Nil lets the model decide
+	Cache         *CachePolicy     `json:"cache,omitempty"`       // Nil caches nothing
+
+	// ThinkingBudget enables extended thinking with up to this many tokens
+	// of reasoning before the answer; 0 disables it. Providers without
+	// extended thinking ignore it.
+	ThinkingBudget int `json:"thinking_budget,omitempty"`
+}
+
+// CachePolicy marks the parts of a request that a provider with prompt
+// caching should cache, so later requests sharing the same prefix are
+// cheaper. Providers without prompt caching ignore it. A cached prefix
+// only matches if it is identical, so tools must be sent in a stable order.
+type CachePolicy struct {
+	System   bool `json:"system,omitempty"`   // Cache the system prompt
+	Tools    bool `json:"tools,omitempty"`    // Cache the tool definitions
+	Messages int  `json:"messages,omitempty"` // Add breakpoints to the last N messages
+}
+
+// ToolChoiceMode controls whether and which tools the model must call.
+type ToolChoiceMode string
+
+const (
+	// ToolChoiceAuto lets the model decide whether to call a tool.
+	ToolChoiceAuto ToolChoiceMode = "auto"
+	// ToolChoiceAny requires the model to call at least one tool.
+	ToolChoiceAny ToolChoiceMode = "any"
+	// ToolChoiceNone prevents the model from calling tools.
+	ToolChoiceNone ToolChoiceMode = "none"
+	// ToolChoiceTool requires the model to call the tool named in ToolChoice.Name.
+	ToolChoiceTool ToolChoiceMode = "tool"
+)
+
+// ToolChoice tells the model how to use the tools of a request.
+type ToolChoice struct {
+	Mode ToolChoiceMode `json:"mode"`
+	Name string         `json:"name,omitempty"` // For ToolChoiceTool
+}
+
+// ForceTool returns a ToolChoice that requires the model to call the named tool.
+func ForceTool(name string) *ToolChoice {
+	return &ToolChoice{Mode: ToolChoiceTool, Name: name}
 }
@@ -0,0 +1,38 @@
+package tool
+
+// Annotations are hints about a tool's behavior, advertised to MCP clients
+// so they can decide which calls need user approval. They describe the
+// tool's intent and are not enforced.
+type Annotations struct {
+	Title           string `json:"title,omitempty"`
+	ReadOnlyHint    bool   `json:"readOnlyHint"`    // The tool does not modify its environment
+	DestructiveHint bool   `json:"destructiveHint"` // Changes may delete or overwrite data
+	IdempotentHint  bool   `json:"idempotentHint"`  // Repeating a call with the same arguments has no further effect
+	OpenWorldHint   bool   `json:"openWorldHint"`   // The tool interacts with external systems
+}
+
+// DefaultAnnotations returns the hints MCP assumes for a tool that declares
+// none: it may be destructive and may reach external systems.
+func DefaultAnnotations() Annotations {
+	return Annotations{DestructiveHint: true, OpenWorldHint: true}
+}
+
+// Annotated is implemented by tools that describe their behavior.
+type Annotated interface {
+	Annotations() Annotations
+}
+
+// AnnotationsOf returns the annotations of t, if it has any.
+func AnnotationsOf(t Tool) (Annotations, bool) {
+	if a, ok := t.(Annotated); ok {
+		return a.Annotations(), true
+	}
+	return Annotations{}, false
+}
+
+// IsReadOnly reports whether t declares itself read-only. Tools without
+// annotations are assumed to have side effects.
+func IsReadOnly(t Tool) bool {
+	a, ok := AnnotationsOf(t)
+	return ok && a.ReadOnlyHint
+}
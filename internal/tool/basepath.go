@@ -0,0 +1,35 @@
+package tool
+
+import (
+	"fmt"
+	"path/filepath"
+
+	"agentic-poc/internal/provider"
+)
+
+// resolveInBase joins path to basePath and rejects results outside
+// basePath, so tools cannot be used to reach arbitrary files.
+func resolveInBase(basePath, path string) (string, error) {
+	// Clean the path to prevent directory traversal attacks
+	fullPath := filepath.Clean(filepath.Join(basePath, path))
+
+	if basePath == "" {
+		return fullPath, nil
+	}
+
+	absBase, err := filepath.Abs(basePath)
+	if err != nil {
+		return "", fmt.Errorf("failed to resolve base path: %v", err)
+	}
+	absPath, err := filepath.Abs(fullPath)
+	if err != nil {
+		return "", fmt.Errorf("failed to resolve file path: %v", err)
+	}
+
+	// Check that the resolved path starts with the base path
+	rel, err := filepath.Rel(absBase, absPath)
+	if err != nil || len(rel) > 0 && rel[0] == '.' {
+		return "", Errorf(provider.ToolErrorPermission, "path escapes base directory")
+	}
+	return fullPath, nil
+}
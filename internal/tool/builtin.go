@@ -0,0 +1,34 @@
+package tool
+
+import (
+	"fmt"
+	"sort"
+	"strings"
+)
+
+// builtinFactories creates the built-in tools that can be exposed by name,
+// e.g. from the MCP server's -tools flag. File tools are rooted at basePath.
+var builtinFactories = map[string]func(basePath string) Tool{
+	"calculator": func(string) Tool { return NewCalculatorTool() },
+	"read_file":  func(basePath string) Tool { return NewFileReaderTool(basePath) },
+	"write_file": func(basePath string) Tool { return NewFileWriterTool(basePath) },
+}
+
+// BuiltinNames returns the names accepted by NewBuiltinTool, sorted.
+func BuiltinNames() []string {
+	names := make([]string, 0, len(builtinFactories))
+	for name := range builtinFactories {
+		names = append(names, name)
+	}
+	sort.Strings(names)
+	return names
+}
+
+// NewBuiltinTool creates the built-in tool with the given name.
+func NewBuiltinTool(name, basePath string) (Tool, error) {
+	factory, ok := builtinFactories[name]
+	if !ok {
+		return nil, fmt.Errorf("unknown tool %q (available: %s)", name, strings.Join(BuiltinNames(), ", "))
+	}
+	return factory(basePath), nil
+}
@@ -0,0 +1,51 @@
+package tool
+
+import (
+	"strings"
+	"testing"
+)
+
+func TestNewBuiltinTool(t *testing.T) {
+	for _, name := range BuiltinNames() {
+		tl, err := NewBuiltinTool(name, t.TempDir())
+		if err != nil {
+			t.Fatalf("NewBuiltinTool(%q) error = %v", name, err)
+		}
+		if tl.Name() != name {
+			t.Errorf("NewBuiltinTool(%q) created %q", name, tl.Name())
+		}
+		if _, ok := AnnotationsOf(tl); !ok {
+			t.Errorf("built-in tool %q should declare annotations", name)
+		}
+	}
+
+	if _, err := NewBuiltinTool("rm_rf", "."); err == nil || !strings.Contains(err.Error(), "available") {
+		t.Errorf("expected error listing available tools, got %v", err)
+	}
+}
+
+func TestIsReadOnly(t *testing.T) {
+	tests := []struct {
+		name string
+		tool Tool
+		want bool
+	}{
+		{name: "calculator", tool: NewCalculatorTool(), want: true},
+		{name: "read_file", tool: NewFileReaderTool("."), want: true},
+		{name: "write_file", tool: NewFileWriterTool("."), want: false},
+		{name: "unannotated", tool: unannotatedTool{NewCalculatorTool()}, want: false},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if got := IsReadOnly(tt.tool); got != tt.want {
+				t.Errorf("IsReadOnly() = %v, want %v", got, tt.want)
+			}
+		})
+	}
+}
+
+// unannotatedTool hides the annotations of the tool it wraps.
+type unannotatedTool struct {
+	Tool
+}
@@ -8,112 +8,49 @@ import (
 )
 
 // CalculatorTool performs basic arithmetic operations.
-type CalculatorTool struct{}
-
-// NewCalculatorTool creates a new CalculatorTool instance.
-func NewCalculatorTool() *CalculatorTool {
-	return &CalculatorTool{}
-}
-
-// Name returns the tool's identifier.
-func (c *CalculatorTool) Name() string {
-	return "calculator"
+type CalculatorTool struct {
+	*TypedTool[calculatorArgs, string]
 }
 
-// Description returns what the tool does.
-func (c *CalculatorTool) Description() string {
-	return "Performs basic arithmetic operations: add, subtract, multiply, divide"
+// calculatorArgs are the arguments of the calculator tool.
+type calculatorArgs struct {
+	Operation string  `json:"operation" jsonschema:"description=The arithmetic operation to perform,enum=add,enum=subtract,enum=multiply,enum=divide"`
+	A         float64 `json:"a" jsonschema:"description=The first operand"`
+	B         float64 `json:"b" jsonschema:"description=The second operand"`
 }
 
-// Parameters returns the JSON Schema for the tool's input.
-func (c *CalculatorTool) Parameters() map[string]interface{} {
-	return map[string]interface{}{
-		"type": "object",
-		"properties": map[string]interface{}{
-			"operation": map[string]interface{}{
-				"type":        "string",
-				"enum":        []string{"add", "subtract", "multiply", "divide"},
-				"description": "The arithmetic operation to perform",
-			},
-			"a": map[string]interface{}{
-				"type":        "number",
-				"description": "The first operand",
-			},
-			"b": map[string]interface{}{
-				"type":        "number",
-				"description": "The second operand",
-			},
-		},
-		"required": []string{"operation", "a", "b"},
+// NewCalculatorTool creates a new CalculatorTool instance.
+func NewCalculatorTool() *CalculatorTool {
+	return &CalculatorTool{
+		TypedTool: NewTyped("calculator",
+			"Performs basic arithmetic operations: add, subtract, multiply, divide",
+			calculate),
 	}
 }
 
-// Execute performs the arithmetic operation.
-func (c *CalculatorTool) Execute(ctx context.Context, args map[string]interface{}) (*provider.ToolResult, error) {
-	operation, ok := args["operation"].(string)
-	if !ok {
-		return &provider.ToolResult{
-			Success: false,
-			Error:   "missing or invalid 'operation' argument",
-		}, nil
-	}
-
-	a, err := toFloat64(args["a"])
-	if err != nil {
-		return &provider.ToolResult{
-			Success: false,
-			Error:   fmt.Sprintf("invalid 'a' argument: %v", err),
-		}, nil
-	}
-
-	b, err := toFloat64(args["b"])
-	if err != nil {
-		return &provider.ToolResult{
-			Success: false,
-			Error:   fmt.Sprintf("invalid 'b' argument: %v", err),
-		}, nil
-	}
+// Annotations describes the tool's behavior for MCP clients.
+func (c *CalculatorTool) Annotations() Annotations {
+	return Annotations{ReadOnlyHint: true, IdempotentHint: true}
+}
 
+// calculate performs the arithmetic operation.
+func calculate(ctx context.Context, args calculatorArgs) (string, error) {
 	var result float64
-	switch operation {
+	switch args.Operation {
 	case "add":
-		result = a + b
+		result = args.A + args.B
 	case "subtract":
-		result = a - b
+		result = args.A - args.B
 	case "multiply":
-		result = a * b
+		result = args.A * args.B
 	case "divide":
-		if b == 0 {
-			return &provider.ToolResult{
-				Success: false,
-				Error:   "division by zero",
-			}, nil
+		if args.B == 0 {
+			return "", Errorf(provider.ToolErrorExecution, "division by zero")
 		}
-		result = a / b
+		result = args.A / args.B
 	default:
-		return &provider.ToolResult{
-			Success: false,
-			Error:   fmt.Sprintf("unknown operation: %s", operation),
-		}, nil
+		return "", Errorf(provider.ToolErrorValidation, "unknown operation: %s", args.Operation)
 	}
 
-	return &provider.ToolResult{
-		Success: true,
-		Output:  fmt.Sprintf("%v", result),
-	}, nil
-}
-
-// toFloat64 converts an interface{} to float64.
-// Handles both float64 and int types that may come from JSON parsing.
-func toFloat64(v interface{}) (float64, error) {
-	switch n := v.(type) {
-	case float64:
-		return n, nil
-	case int:
-		return float64(n), nil
-	case int64:
-		return float64(n), nil
-	default:
-		return 0, fmt.Errorf("expected number, got %T", v)
-	}
+	return fmt.Sprintf("%v", result), nil
 }
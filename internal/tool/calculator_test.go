@@ -3,6 +3,8 @@ package tool
 import (
 	"context"
 	"testing"
+
+	"agentic-poc/internal/provider"
 )
 
 func TestCalculatorTool_Name(t *testing.T) {
@@ -173,6 +175,18 @@ func TestCalculatorTool_Execute(t *testing.T) {
 	}
 }
 
+func TestCalculatorTool_DivideByZero(t *testing.T) {
+	result, err := NewCalculatorTool().Execute(context.Background(), map[string]interface{}{"operation": "divide", "a": 10.0, "b": 0.0})
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+
+	// The same operands will always fail, so the model must not retry them
+	if result.Success || result.ErrorKind != provider.ToolErrorExecution || result.Retryable {
+		t.Errorf("result = %+v, want a non-retryable execution error", result)
+	}
+}
+
 func TestCalculatorTool_ImplementsInterface(t *testing.T) {
 	var _ Tool = (*CalculatorTool)(nil)
 }
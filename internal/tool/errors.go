@@ -0,0 +1,56 @@
+package tool
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"os"
+
+	"agentic-poc/internal/provider"
+)
+
+// Errorf returns an error of the given kind for a tool to return, either
+// from a typed tool function or through ErrorResult.
+func Errorf(kind provider.ToolErrorKind, format string, args ...interface{}) error {
+	return &provider.ToolError{
+		Kind:      kind,
+		Message:   fmt.Sprintf(format, args...),
+		Retryable: kind == provider.ToolErrorValidation || kind == provider.ToolErrorTimeout,
+	}
+}
+
+// ErrorResult converts err into a failed ToolResult. A *provider.ToolError
+// keeps its kind; other errors are classified by what they wrap, so
+// os.ErrNotExist becomes not_found and context.DeadlineExceeded a
+// retryable timeout.
+func ErrorResult(err error) *provider.ToolResult {
+	toolErr := classifyError(err)
+	return &provider.ToolResult{
+		Success:   false,
+		Error:     toolErr.Message,
+		ErrorKind: toolErr.Kind,
+		Retryable: toolErr.Retryable,
+	}
+}
+
+// classifyError returns err as a ToolError.
+func classifyError(err error) *provider.ToolError {
+	var toolErr *provider.ToolError
+	if errors.As(err, &toolErr) {
+		// Keep the full message, which may add context around the ToolError
+		return &provider.ToolError{Kind: toolErr.Kind, Message: err.Error(), Retryable: toolErr.Retryable}
+	}
+
+	kind := provider.ToolErrorInternal
+	retryable := false
+	switch {
+	case errors.Is(err, os.ErrNotExist):
+		kind = provider.ToolErrorNotFound
+	case errors.Is(err, os.ErrPermission):
+		kind = provider.ToolErrorPermission
+	case errors.Is(err, context.DeadlineExceeded):
+		kind = provider.ToolErrorTimeout
+		retryable = true
+	}
+	return &provider.ToolError{Kind: kind, Message: err.Error(), Retryable: retryable}
+}
@@ -0,0 +1,58 @@
+package tool
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"os"
+	"testing"
+
+	"agentic-poc/internal/provider"
+)
+
+func TestErrorResult(t *testing.T) {
+	tests := []struct {
+		name      string
+		err       error
+		wantKind  provider.ToolErrorKind
+		wantRetry bool
+		wantMsg   string
+	}{
+		{"explicit kind", Errorf(provider.ToolErrorPermission, "no access to %s", "x"), provider.ToolErrorPermission, false, "no access to x"},
+		{"validation is retryable", Errorf(provider.ToolErrorValidation, "bad"), provider.ToolErrorValidation, true, "bad"},
+		{"wrapped tool error keeps kind", fmt.Errorf("reading: %w", Errorf(provider.ToolErrorNotFound, "gone")), provider.ToolErrorNotFound, false, "reading: gone"},
+		{"not exist", fmt.Errorf("open: %w", os.ErrNotExist), provider.ToolErrorNotFound, false, "open: file does not exist"},
+		{"permission", os.ErrPermission, provider.ToolErrorPermission, false, "permission denied"},
+		{"deadline", context.DeadlineExceeded, provider.ToolErrorTimeout, true, "context deadline exceeded"},
+		{"anything else", errors.New("boom"), provider.ToolErrorInternal, false, "boom"},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			result := ErrorResult(tt.err)
+			if result.Success || result.ErrorKind != tt.wantKind || result.Retryable != tt.wantRetry || result.Error != tt.wantMsg {
+				t.Errorf("ErrorResult() = %+v, want kind %q retryable %v message %q", result, tt.wantKind, tt.wantRetry, tt.wantMsg)
+			}
+		})
+	}
+}
+
+func TestBuiltinToolErrorKinds(t *testing.T) {
+	dir := t.TempDir()
+	ctx := context.Background()
+
+	result, _ := NewFileReaderTool(dir).Execute(ctx, map[string]interface{}{"path": "missing.txt"})
+	if result.ErrorKind != provider.ToolErrorNotFound {
+		t.Errorf("missing file: kind = %q, want not_found", result.ErrorKind)
+	}
+
+	result, _ = NewFileWriterTool(dir).Execute(ctx, map[string]interface{}{"path": "../escape.txt", "content": "x"})
+	if result.ErrorKind != provider.ToolErrorPermission {
+		t.Errorf("path escape: kind = %q, want permission", result.ErrorKind)
+	}
+
+	result, _ = NewCalculatorTool().Execute(ctx, map[string]interface{}{"operation": "add"})
+	if result.ErrorKind != provider.ToolErrorValidation || !result.Retryable {
+		t.Errorf("missing operands: got kind %q retryable %v, want retryable validation", result.ErrorKind, result.Retryable)
+	}
+}
@@ -3,113 +3,70 @@ package tool
 import (
 	"context"
 	"fmt"
+	"net/http"
 	"os"
-	"path/filepath"
 
 	"agentic-poc/internal/provider"
 )
 
-// FileReaderTool reads content from files.
+// FileReaderTool reads content from files. Text files are returned as
+// text; images and PDFs are returned as image and document content blocks
+// the model can look at.
 type FileReaderTool struct {
+	*TypedTool[readFileArgs, *provider.ToolResult]
 	basePath string
 }
 
+// readFileArgs are the arguments of the read_file tool.
+type readFileArgs struct {
+	Path string `json:"path" jsonschema:"description=The path to the file to read (relative to base path)"`
+}
+
 // NewFileReaderTool creates a new FileReaderTool with the given base path.
 // All file paths will be resolved relative to basePath for security.
 func NewFileReaderTool(basePath string) *FileReaderTool {
-	return &FileReaderTool{basePath: basePath}
-}
-
-// Name returns the tool's identifier.
-func (f *FileReaderTool) Name() string {
-	return "read_file"
+	f := &FileReaderTool{basePath: basePath}
+	f.TypedTool = NewTyped("read_file", "Reads the content of a file at the specified path", f.read)
+	return f
 }
 
-// Description returns what the tool does.
-func (f *FileReaderTool) Description() string {
-	return "Reads the content of a file at the specified path"
+// Annotations describes the tool's behavior for MCP clients.
+func (f *FileReaderTool) Annotations() Annotations {
+	return Annotations{ReadOnlyHint: true, IdempotentHint: true}
 }
 
-// Parameters returns the JSON Schema for the tool's input.
-func (f *FileReaderTool) Parameters() map[string]interface{} {
-	return map[string]interface{}{
-		"type": "object",
-		"properties": map[string]interface{}{
-			"path": map[string]interface{}{
-				"type":        "string",
-				"description": "The path to the file to read (relative to base path)",
-			},
-		},
-		"required": []string{"path"},
-	}
-}
-
-// Execute reads the file and returns its content.
-func (f *FileReaderTool) Execute(ctx context.Context, args map[string]interface{}) (*provider.ToolResult, error) {
-	pathArg, ok := args["path"].(string)
-	if !ok {
-		return &provider.ToolResult{
-			Success: false,
-			Error:   "missing or invalid 'path' argument",
-		}, nil
-	}
-
-	// Resolve the full path
-	fullPath := filepath.Join(f.basePath, pathArg)
-
-	// Clean the path to prevent directory traversal attacks
-	fullPath = filepath.Clean(fullPath)
-
-	// Verify the path is still within basePath
-	if f.basePath != "" {
-		absBase, err := filepath.Abs(f.basePath)
-		if err != nil {
-			return &provider.ToolResult{
-				Success: false,
-				Error:   fmt.Sprintf("failed to resolve base path: %v", err),
-			}, nil
-		}
-		absPath, err := filepath.Abs(fullPath)
-		if err != nil {
-			return &provider.ToolResult{
-				Success: false,
-				Error:   fmt.Sprintf("failed to resolve file path: %v", err),
-			}, nil
-		}
-
-		// Check that the resolved path starts with the base path
-		rel, err := filepath.Rel(absBase, absPath)
-		if err != nil || len(rel) > 0 && rel[0] == '.' {
-			return &provider.ToolResult{
-				Success: false,
-				Error:   "path escapes base directory",
-			}, nil
-		}
+// read reads the file and returns its content.
+func (f *FileReaderTool) read(ctx context.Context, args readFileArgs) (*provider.ToolResult, error) {
+	fullPath, err := resolveInBase(f.basePath, args.Path)
+	if err != nil {
+		return nil, err
 	}
 
-	// Read the file
 	content, err := os.ReadFile(fullPath)
 	if err != nil {
 		if os.IsNotExist(err) {
-			return &provider.ToolResult{
-				Success: false,
-				Error:   fmt.Sprintf("file not found: %s", pathArg),
-			}, nil
+			return nil, Errorf(provider.ToolErrorNotFound, "file not found: %s", args.Path)
 		}
 		if os.IsPermission(err) {
-			return &provider.ToolResult{
-				Success: false,
-				Error:   fmt.Sprintf("permission denied: %s", pathArg),
-			}, nil
+			return nil, Errorf(provider.ToolErrorPermission, "permission denied: %s", args.Path)
 		}
+		return nil, fmt.Errorf("failed to read file: %v", err)
+	}
+
+	if block, ok := provider.MediaBlock(args.Path, content); ok {
+		if len(content) > provider.MaxMediaSize {
+			return nil, Errorf(provider.ToolErrorValidation, "%s is too large to send to the model (%d bytes, limit %d)", args.Path, len(content), provider.MaxMediaSize)
+		}
+		summary := fmt.Sprintf("%s (%s, %d bytes)", args.Path, block.MimeType, len(content))
 		return &provider.ToolResult{
-			Success: false,
-			Error:   fmt.Sprintf("failed to read file: %v", err),
+			Success: true,
+			Output:  summary,
+			Content: []provider.ContentBlock{provider.TextBlock(summary), block},
 		}, nil
 	}
+	if http.DetectContentType(content) == "application/octet-stream" {
+		return nil, Errorf(provider.ToolErrorValidation, "%s is a binary file; only text, images and PDFs can be read", args.Path)
+	}
 
-	return &provider.ToolResult{
-		Success: true,
-		Output:  string(content),
-	}, nil
+	return &provider.ToolResult{Success: true, Output: string(content)}, nil
 }
@@ -4,7 +4,10 @@ import (
 	"context"
 	"os"
 	"path/filepath"
+	"strings"
 	"testing"
+
+	"agentic-poc/internal/provider"
 )
 
 func TestFileReaderTool_Name(t *testing.T) {
@@ -222,3 +225,59 @@ func TestFileReaderTool_Execute_EmptyFile(t *testing.T) {
 func TestFileReaderTool_ImplementsInterface(t *testing.T) {
 	var _ Tool = (*FileReaderTool)(nil)
 }
+
+func TestFileReaderTool_Execute_MediaAndBinaryFiles(t *testing.T) {
+	tmpDir := t.TempDir()
+	files := map[string][]byte{
+		"chart.png": []byte("\x89PNG\r\n\x1a\n\x00\x00\x00\rIHDR"),
+		"spec.pdf":  []byte("%PDF-1.4\n"),
+		"app.bin":   {0x7f, 'E', 'L', 'F', 0x00, 0x01},
+		"large.png": append([]byte("\x89PNG\r\n\x1a\n"), make([]byte, provider.MaxMediaSize)...),
+	}
+	for name, data := range files {
+		if err := os.WriteFile(filepath.Join(tmpDir, name), data, 0644); err != nil {
+			t.Fatal(err)
+		}
+	}
+
+	tests := []struct {
+		path      string
+		wantBlock provider.ContentType
+		wantErr   string
+	}{
+		{path: "chart.png", wantBlock: provider.ContentImage},
+		{path: "spec.pdf", wantBlock: provider.ContentDocument},
+		{path: "app.bin", wantErr: "binary file"},
+		{path: "large.png", wantErr: "too large"},
+	}
+
+	reader := NewFileReaderTool(tmpDir)
+	for _, tt := range tests {
+		t.Run(tt.path, func(t *testing.T) {
+			result, err := reader.Execute(context.Background(), map[string]interface{}{"path": tt.path})
+			if err != nil {
+				t.Fatalf("unexpected error: %v", err)
+			}
+
+			if tt.wantErr != "" {
+				if result.Success || !strings.Contains(result.Error, tt.wantErr) {
+					t.Errorf("result = %+v, want a failure containing %q", result, tt.wantErr)
+				}
+				if result.ErrorKind != provider.ToolErrorValidation {
+					t.Errorf("error kind = %q, want validation", result.ErrorKind)
+				}
+				return
+			}
+
+			if !result.Success {
+				t.Fatalf("unexpected failure: %s", result.Error)
+			}
+			if len(result.Content) != 2 || result.Content[1].Type != tt.wantBlock {
+				t.Fatalf("content = %+v, want a caption and a %s block", result.Content, tt.wantBlock)
+			}
+			if !strings.Contains(result.Output, tt.path) || result.Content[0].Text != result.Output {
+				t.Errorf("output = %q, want a caption naming the file", result.Output)
+			}
+		})
+	}
+}
@@ -0,0 +1,41 @@
+package tool
+
+import "context"
+
+// ProgressFunc receives human-readable progress updates from a running tool.
+type ProgressFunc func(message string)
+
+// progressKey is the context key for the ProgressFunc.
+type progressKey struct{}
+
+// WithProgress returns a context whose tools report progress to fn.
+// The MCP server uses this to turn reports into notifications/progress.
+func WithProgress(ctx context.Context, fn ProgressFunc) context.Context {
+	return context.WithValue(ctx, progressKey{}, fn)
+}
+
+// WithProgressPrefix returns a context that forwards progress reports to the
+// reporter in ctx with prefix prepended. It is used to attribute updates
+// from nested work, such as an agent run inside a workflow.
+func WithProgressPrefix(ctx context.Context, prefix string) context.Context {
+	if _, ok := ctx.Value(progressKey{}).(ProgressFunc); !ok {
+		return ctx
+	}
+	return WithProgress(ctx, func(message string) {
+		ReportProgress(ctx, prefix+message)
+	})
+}
+
+// ProgressReporter returns the reporter in ctx, or nil if there is none.
+func ProgressReporter(ctx context.Context) ProgressFunc {
+	fn, _ := ctx.Value(progressKey{}).(ProgressFunc)
+	return fn
+}
+
+// ReportProgress sends a progress update to the reporter in ctx, if any.
+// Tools can call it unconditionally.
+func ReportProgress(ctx context.Context, message string) {
+	if fn, ok := ctx.Value(progressKey{}).(ProgressFunc); ok && fn != nil {
+		fn(message)
+	}
+}
@@ -0,0 +1,29 @@
+package tool
+
+import (
+	"context"
+	"reflect"
+	"testing"
+)
+
+func TestReportProgress_NoReporter(t *testing.T) {
+	// Must not panic when nobody is listening
+	ReportProgress(context.Background(), "ignored")
+	ReportProgress(WithProgressPrefix(context.Background(), "x: "), "ignored")
+}
+
+func TestReportProgress_Prefix(t *testing.T) {
+	var got []string
+	ctx := WithProgress(context.Background(), func(message string) {
+		got = append(got, message)
+	})
+
+	ReportProgress(ctx, "start")
+	nested := WithProgressPrefix(WithProgressPrefix(ctx, "workflow: "), "coder: ")
+	ReportProgress(nested, "writing file")
+
+	want := []string{"start", "workflow: coder: writing file"}
+	if !reflect.DeepEqual(got, want) {
+		t.Errorf("got %v, want %v", got, want)
+	}
+}
@@ -0,0 +1,365 @@
+package tool
+
+import (
+	"encoding/json"
+	"fmt"
+	"math"
+	"reflect"
+	"sort"
+	"strings"
+)
+
+// FieldError is a single schema violation at a path in the arguments,
+// such as "items[2].name".
+type FieldError struct {
+	Path    string
+	Message string
+}
+
+// String formats the error as "path: message".
+func (e FieldError) String() string {
+	return e.Path + ": " + e.Message
+}
+
+// ValidationError lists every way a set of arguments violates a schema.
+type ValidationError struct {
+	Errors []FieldError
+}
+
+// Error joins the field errors into a single message.
+func (e *ValidationError) Error() string {
+	msgs := make([]string, len(e.Errors))
+	for i, fe := range e.Errors {
+		msgs[i] = fe.String()
+	}
+	return strings.Join(msgs, "; ")
+}
+
+// ValidateArguments checks args against a JSON Schema, such as the one
+// returned by Tool.Parameters. It supports the subset of draft 2020-12
+// that tool schemas use: type, properties, required,
+// additionalProperties, items, enum, const, minimum, maximum,
+// exclusiveMinimum, exclusiveMaximum, minLength, maxLength, minItems
+// and maxItems. Other keywords are ignored.
+//
+// It returns nil or a *ValidationError naming every violation.
+func ValidateArguments(schema map[string]interface{}, args map[string]interface{}) error {
+	if args == nil {
+		args = map[string]interface{}{}
+	}
+
+	v := &validator{}
+	v.validate("arguments", schema, args)
+	if len(v.errors) == 0 {
+		return nil
+	}
+	return &ValidationError{Errors: v.errors}
+}
+
+// validator collects errors while walking a value and its schema.
+type validator struct {
+	errors []FieldError
+}
+
+// fail records a violation at path.
+func (v *validator) fail(path, format string, args ...interface{}) {
+	v.errors = append(v.errors, FieldError{Path: path, Message: fmt.Sprintf(format, args...)})
+}
+
+// validate checks value against schema. A type mismatch stops further
+// checks at that path, since they would only repeat the same problem.
+func (v *validator) validate(path string, schema map[string]interface{}, value interface{}) {
+	if schema == nil {
+		return
+	}
+
+	if types := stringList(schema["type"]); len(types) > 0 {
+		if !matchesAnyType(value, types) {
+			v.fail(path, "expected %s, got %s", strings.Join(types, " or "), typeName(value))
+			return
+		}
+	}
+
+	if enum, ok := schema["enum"]; ok {
+		values := anyList(enum)
+		if !containsValue(values, value) {
+			v.fail(path, "must be one of %s, got %s", formatValue(values), formatValue(value))
+		}
+	}
+	if c, ok := schema["const"]; ok && !equalValues(c, value) {
+		v.fail(path, "must be %s, got %s", formatValue(c), formatValue(value))
+	}
+
+	if n, ok := toFloat(value); ok {
+		v.validateNumber(path, schema, n)
+	}
+	if s, ok := value.(string); ok {
+		v.validateString(path, schema, s)
+	}
+	if items, ok := toList(value); ok {
+		v.validateArray(path, schema, items)
+	}
+	if obj, ok := toObject(value); ok {
+		v.validateObject(path, schema, obj)
+	}
+}
+
+// validateNumber checks the numeric range keywords.
+func (v *validator) validateNumber(path string, schema map[string]interface{}, n float64) {
+	if min, ok := toFloat(schema["minimum"]); ok && n < min {
+		v.fail(path, "must be >= %g, got %g", min, n)
+	}
+	if max, ok := toFloat(schema["maximum"]); ok && n > max {
+		v.fail(path, "must be <= %g, got %g", max, n)
+	}
+	if min, ok := toFloat(schema["exclusiveMinimum"]); ok && n <= min {
+		v.fail(path, "must be > %g, got %g", min, n)
+	}
+	if max, ok := toFloat(schema["exclusiveMaximum"]); ok && n >= max {
+		v.fail(path, "must be < %g, got %g", max, n)
+	}
+}
+
+// validateString checks the string length keywords, counted in characters.
+func (v *validator) validateString(path string, schema map[string]interface{}, s string) {
+	length := len([]rune(s))
+	if min, ok := toFloat(schema["minLength"]); ok && float64(length) < min {
+		v.fail(path, "must be at least %g characters long, got %d", min, length)
+	}
+	if max, ok := toFloat(schema["maxLength"]); ok && float64(length) > max {
+		v.fail(path, "must be at most %g characters long, got %d", max, length)
+	}
+}
+
+// validateArray checks the array size keywords and each item.
+func (v *validator) validateArray(path string, schema map[string]interface{}, items []interface{}) {
+	if min, ok := toFloat(schema["minItems"]); ok && float64(len(items)) < min {
+		v.fail(path, "must have at least %g items, got %d", min, len(items))
+	}
+	if max, ok := toFloat(schema["maxItems"]); ok && float64(len(items)) > max {
+		v.fail(path, "must have at most %g items, got %d", max, len(items))
+	}
+
+	itemSchema, _ := toObject(schema["items"])
+	if itemSchema == nil {
+		return
+	}
+	for i, item := range items {
+		v.validate(fmt.Sprintf("%s[%d]", path, i), itemSchema, item)
+	}
+}
+
+// validateObject checks required properties, each known property, and
+// additionalProperties. Properties are visited in sorted order so the
+// error message is stable.
+func (v *validator) validateObject(path string, schema map[string]interface{}, obj map[string]interface{}) {
+	for _, name := range stringList(schema["required"]) {
+		if _, ok := obj[name]; !ok {
+			v.fail(joinPath(path, name), "is required")
+		}
+	}
+
+	properties, _ := toObject(schema["properties"])
+	names := make([]string, 0, len(obj))
+	for name := range obj {
+		names = append(names, name)
+	}
+	sort.Strings(names)
+
+	for _, name := range names {
+		propPath := joinPath(path, name)
+		if raw, known := properties[name]; known {
+			propSchema, _ := toObject(raw)
+			v.validate(propPath, propSchema, obj[name])
+			continue
+		}
+
+		switch additional := schema["additionalProperties"].(type) {
+		case bool:
+			if !additional {
+				v.fail(propPath, "is not an allowed property")
+			}
+		case map[string]interface{}:
+			v.validate(propPath, additional, obj[name])
+		}
+	}
+}
+
+// joinPath appends a property name to a path. The root "arguments"
+// prefix is dropped so top-level fields read naturally, e.g. "path".
+func joinPath(path, name string) string {
+	if path == "arguments" {
+		return name
+	}
+	return path + "." + name
+}
+
+// matchesAnyType reports whether value is an instance of one of the JSON
+// Schema types.
+func matchesAnyType(value interface{}, types []string) bool {
+	for _, t := range types {
+		if matchesType(value, t) {
+			return true
+		}
+	}
+	return false
+}
+
+// matchesType reports whether value is an instance of the JSON Schema type t.
+func matchesType(value interface{}, t string) bool {
+	switch t {
+	case "null":
+		return value == nil
+	case "boolean":
+		_, ok := value.(bool)
+		return ok
+	case "string":
+		_, ok := value.(string)
+		return ok
+	case "number":
+		_, ok := toFloat(value)
+		return ok
+	case "integer":
+		n, ok := toFloat(value)
+		return ok && n == math.Trunc(n) && !math.IsInf(n, 0)
+	case "array":
+		_, ok := toList(value)
+		return ok
+	case "object":
+		_, ok := toObject(value)
+		return ok
+	default:
+		// Unknown types are not ours to reject
+		return true
+	}
+}
+
+// typeName returns the JSON type of value for error messages.
+func typeName(value interface{}) string {
+	switch {
+	case value == nil:
+		return "null"
+	case matchesType(value, "boolean"):
+		return "boolean"
+	case matchesType(value, "string"):
+		return "string"
+	case matchesType(value, "integer"):
+		return "integer"
+	case matchesType(value, "number"):
+		return "number"
+	case matchesType(value, "array"):
+		return "array"
+	case matchesType(value, "object"):
+		return "object"
+	default:
+		return fmt.Sprintf("%T", value)
+	}
+}
+
+// toFloat converts any Go or JSON-decoded number to float64.
+func toFloat(value interface{}) (float64, bool) {
+	switch n := value.(type) {
+	case float64:
+		return n, true
+	case json.Number:
+		f, err := n.Float64()
+		return f, err == nil
+	case bool, string, nil:
+		return 0, false
+	}
+
+	rv := reflect.ValueOf(value)
+	switch rv.Kind() {
+	case reflect.Int, reflect.Int8, reflect.Int16, reflect.Int32, reflect.Int64:
+		return float64(rv.Int()), true
+	case reflect.Uint, reflect.Uint8, reflect.Uint16, reflect.Uint32, reflect.Uint64:
+		return float64(rv.Uint()), true
+	case reflect.Float32, reflect.Float64:
+		return rv.Float(), true
+	default:
+		return 0, false
+	}
+}
+
+// toList converts any slice to []interface{}. Byte slices are not arrays.
+func toList(value interface{}) ([]interface{}, bool) {
+	if list, ok := value.([]interface{}); ok {
+		return list, true
+	}
+	rv := reflect.ValueOf(value)
+	if rv.Kind() != reflect.Slice && rv.Kind() != reflect.Array || rv.Type().Elem().Kind() == reflect.Uint8 {
+		return nil, false
+	}
+	list := make([]interface{}, rv.Len())
+	for i := range list {
+		list[i] = rv.Index(i).Interface()
+	}
+	return list, true
+}
+
+// toObject converts a map with string keys to map[string]interface{}.
+func toObject(value interface{}) (map[string]interface{}, bool) {
+	if obj, ok := value.(map[string]interface{}); ok {
+		return obj, true
+	}
+	rv := reflect.ValueOf(value)
+	if rv.Kind() != reflect.Map || rv.Type().Key().Kind() != reflect.String {
+		return nil, false
+	}
+	obj := make(map[string]interface{}, rv.Len())
+	iter := rv.MapRange()
+	for iter.Next() {
+		obj[iter.Key().String()] = iter.Value().Interface()
+	}
+	return obj, true
+}
+
+// stringList reads a keyword that is a string or a list of strings, such
+// as "type" and "required".
+func stringList(value interface{}) []string {
+	if s, ok := value.(string); ok {
+		return []string{s}
+	}
+	items, _ := toList(value)
+	list := make([]string, 0, len(items))
+	for _, item := range items {
+		if s, ok := item.(string); ok {
+			list = append(list, s)
+		}
+	}
+	return list
+}
+
+// anyList reads a keyword that is a list of arbitrary values, such as "enum".
+func anyList(value interface{}) []interface{} {
+	list, _ := toList(value)
+	return list
+}
+
+// containsValue reports whether values contains value.
+func containsValue(values []interface{}, value interface{}) bool {
+	for _, candidate := range values {
+		if equalValues(candidate, value) {
+			return true
+		}
+	}
+	return false
+}
+
+// equalValues compares two JSON values, treating all numeric types alike.
+func equalValues(a, b interface{}) bool {
+	if x, ok := toFloat(a); ok {
+		y, ok := toFloat(b)
+		return ok && x == y
+	}
+	return reflect.DeepEqual(a, b)
+}
+
+// formatValue renders a value as JSON for error messages.
+func formatValue(value interface{}) string {
+	data, err := json.Marshal(value)
+	if err != nil {
+		return fmt.Sprint(value)
+	}
+	return string(data)
+}
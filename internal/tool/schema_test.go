@@ -0,0 +1,179 @@
+package tool
+
+import (
+	"errors"
+	"strings"
+	"testing"
+
+	"github.com/leanovate/gopter"
+	"github.com/leanovate/gopter/gen"
+	"github.com/leanovate/gopter/prop"
+)
+
+// todoSchema exercises nested objects, arrays and every supported keyword.
+var todoSchema = map[string]interface{}{
+	"type": "object",
+	"properties": map[string]interface{}{
+		"title":    map[string]interface{}{"type": "string", "minLength": 1, "maxLength": 10},
+		"priority": map[string]interface{}{"type": "integer", "minimum": 1, "maximum": 5},
+		"ratio":    map[string]interface{}{"type": "number", "exclusiveMinimum": 0, "exclusiveMaximum": 1},
+		"status":   map[string]interface{}{"type": "string", "enum": []string{"open", "done"}},
+		"note":     map[string]interface{}{"type": []interface{}{"string", "null"}},
+		"tags": map[string]interface{}{
+			"type":     "array",
+			"items":    map[string]interface{}{"type": "string"},
+			"minItems": 1,
+			"maxItems": 3,
+		},
+		"owner": map[string]interface{}{
+			"type": "object",
+			"properties": map[string]interface{}{
+				"name": map[string]interface{}{"type": "string"},
+			},
+			"required":             []string{"name"},
+			"additionalProperties": false,
+		},
+	},
+	"required": []string{"title"},
+}
+
+func TestValidateArguments(t *testing.T) {
+	tests := []struct {
+		name string
+		args map[string]interface{}
+		want []string // Expected "path: message" errors, nil if valid
+	}{
+		{
+			name: "valid",
+			args: map[string]interface{}{
+				"title": "ship", "priority": float64(3), "ratio": 0.5, "status": "open",
+				"note": nil, "tags": []interface{}{"a"}, "owner": map[string]interface{}{"name": "sam"},
+			},
+		},
+		{
+			name: "Go-typed values are accepted",
+			args: map[string]interface{}{"title": "ship", "priority": 2, "tags": []string{"a", "b"}},
+		},
+		{
+			name: "missing required",
+			args: map[string]interface{}{},
+			want: []string{"title: is required"},
+		},
+		{
+			name: "wrong type",
+			args: map[string]interface{}{"title": 42.0},
+			want: []string{"title: expected string, got integer"},
+		},
+		{
+			name: "integer with fraction",
+			args: map[string]interface{}{"title": "x", "priority": 2.5},
+			want: []string{"priority: expected integer, got number"},
+		},
+		{
+			name: "range",
+			args: map[string]interface{}{"title": "x", "priority": 9.0, "ratio": 1.0},
+			want: []string{"priority: must be <= 5, got 9", "ratio: must be < 1, got 1"},
+		},
+		{
+			name: "string length",
+			args: map[string]interface{}{"title": "much too long"},
+			want: []string{"title: must be at most 10 characters long, got 13"},
+		},
+		{
+			name: "enum",
+			args: map[string]interface{}{"title": "x", "status": "closed"},
+			want: []string{`status: must be one of ["open","done"], got "closed"`},
+		},
+		{
+			name: "nullable",
+			args: map[string]interface{}{"title": "x", "note": 1.0},
+			want: []string{"note: expected string or null, got integer"},
+		},
+		{
+			name: "array items and size",
+			args: map[string]interface{}{"title": "x", "tags": []interface{}{"a", 2.0, "c", "d"}},
+			want: []string{"tags: must have at most 3 items, got 4", "tags[1]: expected string, got integer"},
+		},
+		{
+			name: "nested object",
+			args: map[string]interface{}{"title": "x", "owner": map[string]interface{}{"email": "a@b"}},
+			want: []string{"owner.name: is required", "owner.email: is not an allowed property"},
+		},
+		{
+			name: "unknown top-level properties allowed by default",
+			args: map[string]interface{}{"title": "x", "extra": true},
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			err := ValidateArguments(todoSchema, tt.args)
+			if tt.want == nil {
+				if err != nil {
+					t.Fatalf("expected no error, got %v", err)
+				}
+				return
+			}
+
+			var verr *ValidationError
+			if !errors.As(err, &verr) {
+				t.Fatalf("expected *ValidationError, got %v", err)
+			}
+			got := make([]string, len(verr.Errors))
+			for i, fe := range verr.Errors {
+				got[i] = fe.String()
+			}
+			if strings.Join(got, "\n") != strings.Join(tt.want, "\n") {
+				t.Errorf("errors =\n  %s\nwant\n  %s", strings.Join(got, "\n  "), strings.Join(tt.want, "\n  "))
+			}
+		})
+	}
+}
+
+func TestValidateArguments_NilArgs(t *testing.T) {
+	schema := map[string]interface{}{"type": "object", "required": []string{"path"}}
+	if err := ValidateArguments(schema, nil); err == nil || err.Error() != "path: is required" {
+		t.Errorf("expected missing path error, got %v", err)
+	}
+}
+
+func TestValidateArguments_BuiltinSchemas(t *testing.T) {
+	// Every built-in tool must accept its own well-formed arguments
+	calc := NewCalculatorTool()
+	args := map[string]interface{}{"operation": "add", "a": 1.0, "b": 2.0}
+	if err := ValidateArguments(calc.Parameters(), args); err != nil {
+		t.Errorf("calculator: %v", err)
+	}
+
+	args["operation"] = "modulo"
+	if err := ValidateArguments(calc.Parameters(), args); err == nil {
+		t.Error("calculator: expected enum violation for modulo")
+	}
+}
+
+// TestValidateArgumentsProperty_NumericRange checks that a value is accepted
+// by minimum/maximum exactly when it lies within the bounds.
+func TestValidateArgumentsProperty_NumericRange(t *testing.T) {
+	parameters := gopter.DefaultTestParameters()
+	parameters.MinSuccessfulTests = 100
+	properties := gopter.NewProperties(parameters)
+
+	properties.Property("minimum/maximum accept exactly the values in range", prop.ForAll(
+		func(min, max, value float64) bool {
+			schema := map[string]interface{}{
+				"type": "object",
+				"properties": map[string]interface{}{
+					"n": map[string]interface{}{"type": "number", "minimum": min, "maximum": max},
+				},
+			}
+			err := ValidateArguments(schema, map[string]interface{}{"n": value})
+			inRange := value >= min && value <= max
+			return (err == nil) == inRange
+		},
+		gen.Float64Range(-100, 100),
+		gen.Float64Range(-100, 100),
+		gen.Float64Range(-150, 150),
+	))
+
+	properties.TestingRun(t)
+}
@@ -0,0 +1,231 @@
+package tool
+
+import (
+	"fmt"
+	"reflect"
+	"strconv"
+	"strings"
+)
+
+// SchemaFor derives a JSON Schema from a Go type, typically the argument
+// struct of a typed tool.
+//
+// Struct fields are named by their json tag and are required unless the
+// tag has omitempty or the field is a pointer. Fields tagged json:"-" and
+// unexported fields are skipped; embedded structs are flattened as
+// encoding/json does. Constraints come from the jsonschema tag, a
+// comma-separated list of key=value pairs:
+//
+//	Op string `json:"op" jsonschema:"description=What to do, in a word,enum=add,enum=sub"`
+//
+// Supported keys are description, enum (repeat it for each value), minimum,
+// maximum, exclusiveMinimum, exclusiveMaximum, minLength, maxLength,
+// minItems and maxItems. A comma only ends a value when it is followed by
+// one of these keys, so descriptions may contain commas.
+//
+// It panics on types that have no JSON Schema equivalent, such as channels
+// and functions, or on malformed tags; both are programming errors.
+func SchemaFor[T any]() map[string]interface{} {
+	var zero T
+	return schemaForType(reflect.TypeOf(&zero).Elem(), map[reflect.Type]bool{})
+}
+
+// schemaForType builds the schema for t. visiting holds the struct types on
+// the current path, to reject recursive types.
+func schemaForType(t reflect.Type, visiting map[reflect.Type]bool) map[string]interface{} {
+	for t.Kind() == reflect.Pointer {
+		t = t.Elem()
+	}
+
+	switch t.Kind() {
+	case reflect.String:
+		return map[string]interface{}{"type": "string"}
+	case reflect.Bool:
+		return map[string]interface{}{"type": "boolean"}
+	case reflect.Int, reflect.Int8, reflect.Int16, reflect.Int32, reflect.Int64,
+		reflect.Uint, reflect.Uint8, reflect.Uint16, reflect.Uint32, reflect.Uint64:
+		return map[string]interface{}{"type": "integer"}
+	case reflect.Float32, reflect.Float64:
+		return map[string]interface{}{"type": "number"}
+	case reflect.Interface:
+		// Any JSON value
+		return map[string]interface{}{}
+	case reflect.Slice, reflect.Array:
+		if t.Elem().Kind() == reflect.Uint8 {
+			// encoding/json sends []byte as a base64 string
+			return map[string]interface{}{"type": "string"}
+		}
+		return map[string]interface{}{
+			"type":  "array",
+			"items": schemaForType(t.Elem(), visiting),
+		}
+	case reflect.Map:
+		if t.Key().Kind() != reflect.String {
+			panic(fmt.Sprintf("tool schema: map key must be a string, got %s", t))
+		}
+		schema := map[string]interface{}{"type": "object"}
+		if t.Elem().Kind() != reflect.Interface {
+			schema["additionalProperties"] = schemaForType(t.Elem(), visiting)
+		}
+		return schema
+	case reflect.Struct:
+		return structSchema(t, visiting)
+	default:
+		panic(fmt.Sprintf("tool schema: unsupported type %s", t))
+	}
+}
+
+// structSchema builds an object schema from the exported fields of t.
+func structSchema(t reflect.Type, visiting map[reflect.Type]bool) map[string]interface{} {
+	if visiting[t] {
+		panic(fmt.Sprintf("tool schema: recursive type %s", t))
+	}
+	visiting[t] = true
+	defer delete(visiting, t)
+
+	properties := map[string]interface{}{}
+	required := []string{}
+	addStructFields(t, properties, &required, visiting)
+
+	schema := map[string]interface{}{
+		"type":       "object",
+		"properties": properties,
+	}
+	if len(required) > 0 {
+		schema["required"] = required
+	}
+	return schema
+}
+
+// addStructFields adds the properties for t's fields, flattening embedded
+// structs without a json name.
+func addStructFields(t reflect.Type, properties map[string]interface{}, required *[]string, visiting map[reflect.Type]bool) {
+	for i := 0; i < t.NumField(); i++ {
+		field := t.Field(i)
+		name, omitEmpty, skip := jsonFieldName(field)
+		if skip {
+			continue
+		}
+
+		if field.Anonymous && name == "" {
+			embedded := field.Type
+			if embedded.Kind() == reflect.Pointer {
+				embedded = embedded.Elem()
+			}
+			if embedded.Kind() == reflect.Struct {
+				addStructFields(embedded, properties, required, visiting)
+				continue
+			}
+		}
+		if !field.IsExported() {
+			continue
+		}
+		if name == "" {
+			name = field.Name
+		}
+
+		schema := schemaForType(field.Type, visiting)
+		applySchemaTag(schema, field)
+		properties[name] = schema
+
+		if !omitEmpty && field.Type.Kind() != reflect.Pointer {
+			*required = append(*required, name)
+		}
+	}
+}
+
+// jsonFieldName returns the name from a field's json tag and whether it
+// has omitempty. skip is true for fields tagged json:"-".
+func jsonFieldName(field reflect.StructField) (name string, omitEmpty, skip bool) {
+	tag, ok := field.Tag.Lookup("json")
+	if !ok {
+		return "", false, false
+	}
+	if tag == "-" {
+		return "", false, true
+	}
+	name, opts, _ := strings.Cut(tag, ",")
+	for _, opt := range strings.Split(opts, ",") {
+		if opt == "omitempty" {
+			omitEmpty = true
+		}
+	}
+	return name, omitEmpty, false
+}
+
+// schemaTagKeys are the keys accepted in a jsonschema tag.
+var schemaTagKeys = map[string]bool{
+	"description": true, "enum": true,
+	"minimum": true, "maximum": true, "exclusiveMinimum": true, "exclusiveMaximum": true,
+	"minLength": true, "maxLength": true, "minItems": true, "maxItems": true,
+}
+
+// applySchemaTag adds the constraints from a field's jsonschema tag.
+func applySchemaTag(schema map[string]interface{}, field reflect.StructField) {
+	tag := field.Tag.Get("jsonschema")
+	if tag == "" {
+		return
+	}
+
+	var enum []interface{}
+	for _, pair := range splitSchemaTag(tag) {
+		key, value, _ := strings.Cut(pair, "=")
+		switch key {
+		case "description":
+			schema["description"] = value
+		case "enum":
+			enum = append(enum, enumValue(value, schema["type"], field))
+		default:
+			n, err := strconv.ParseFloat(value, 64)
+			if err != nil {
+				panic(fmt.Sprintf("tool schema: field %s: %s must be a number, got %q", field.Name, key, value))
+			}
+			schema[key] = n
+		}
+	}
+	if enum != nil {
+		schema["enum"] = enum
+	}
+}
+
+// splitSchemaTag splits a jsonschema tag into key=value pairs. A comma that
+// is not followed by a known key is part of the preceding value.
+func splitSchemaTag(tag string) []string {
+	var pairs []string
+	for _, part := range strings.Split(tag, ",") {
+		key, _, hasValue := strings.Cut(part, "=")
+		if hasValue && schemaTagKeys[key] || len(pairs) == 0 {
+			pairs = append(pairs, part)
+			continue
+		}
+		pairs[len(pairs)-1] += "," + part
+	}
+
+	for _, pair := range pairs {
+		key, _, _ := strings.Cut(pair, "=")
+		if !schemaTagKeys[key] {
+			panic(fmt.Sprintf("tool schema: unknown jsonschema tag key %q", key))
+		}
+	}
+	return pairs
+}
+
+// enumValue converts an enum tag value to the field's JSON type.
+func enumValue(value string, schemaType interface{}, field reflect.StructField) interface{} {
+	switch schemaType {
+	case "integer", "number":
+		n, err := strconv.ParseFloat(value, 64)
+		if err != nil {
+			panic(fmt.Sprintf("tool schema: field %s: enum value %q is not a number", field.Name, value))
+		}
+		return n
+	case "boolean":
+		b, err := strconv.ParseBool(value)
+		if err != nil {
+			panic(fmt.Sprintf("tool schema: field %s: enum value %q is not a boolean", field.Name, value))
+		}
+		return b
+	default:
+		return value
+	}
+}
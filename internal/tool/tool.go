@@ -23,6 +23,13 @@ type Tool interface {
 	Execute(ctx context.Context, args map[string]interface{}) (*provider.ToolResult, error)
 }
 
+// Provider supplies a set of tools that may change over time, such as
+// tools discovered from MCP servers. Agents and MCP servers re-read it
+// rather than copying the tools once.
+type Provider interface {
+	GetTools() []Tool
+}
+
 // ToDefinition converts a Tool to a ToolDefinition for use in LLM requests.
 func ToDefinition(t Tool) provider.ToolDefinition {
 	return provider.ToolDefinition{
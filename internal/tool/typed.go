@@ -0,0 +1,133 @@
+package tool
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+
+	"agentic-poc/internal/provider"
+)
+
+// TypedFunc implements a typed tool: it receives the decoded arguments and
+// returns a result or an error to report to the model.
+type TypedFunc[In, Out any] func(ctx context.Context, in In) (Out, error)
+
+// TypedTool is a Tool whose arguments and result are Go types. Its schema
+// is derived from In with SchemaFor, so the schema and the code that reads
+// the arguments cannot drift apart.
+type TypedTool[In, Out any] struct {
+	name        string
+	description string
+	schema      map[string]interface{}
+	fn          TypedFunc[In, Out]
+}
+
+// NewTyped creates a tool from a function. In is usually a struct describing
+// the arguments (see SchemaFor for the supported tags).
+//
+// An error returned by fn becomes a failed ToolResult with the error's
+// message, classified by ErrorResult; return an error from Errorf to set
+// the kind explicitly. The result is converted according to Out:
+//   - *provider.ToolResult is returned as-is
+//   - string becomes the Output
+//   - anything else is marshaled to JSON for the Output and also attached
+//     as a structured content block
+//
+// NewTyped panics if no schema can be derived from In.
+func NewTyped[In, Out any](name, description string, fn TypedFunc[In, Out]) *TypedTool[In, Out] {
+	return &TypedTool[In, Out]{
+		name:        name,
+		description: description,
+		schema:      SchemaFor[In](),
+		fn:          fn,
+	}
+}
+
+// Name returns the tool's identifier.
+func (t *TypedTool[In, Out]) Name() string {
+	return t.name
+}
+
+// Description returns what the tool does.
+func (t *TypedTool[In, Out]) Description() string {
+	return t.description
+}
+
+// Parameters returns the JSON Schema derived from In.
+func (t *TypedTool[In, Out]) Parameters() map[string]interface{} {
+	return t.schema
+}
+
+// Execute validates and decodes the arguments, calls the tool function,
+// and converts its result.
+func (t *TypedTool[In, Out]) Execute(ctx context.Context, args map[string]interface{}) (*provider.ToolResult, error) {
+	if err := ValidateArguments(t.schema, args); err != nil {
+		return ErrorResult(Errorf(provider.ToolErrorValidation, "invalid arguments: %v", err)), nil
+	}
+
+	in, err := decodeArguments[In](args)
+	if err != nil {
+		return ErrorResult(Errorf(provider.ToolErrorValidation, "invalid arguments: %v", err)), nil
+	}
+
+	out, err := t.fn(ctx, in)
+	if err != nil {
+		return ErrorResult(err), nil
+	}
+
+	return typedResult(out)
+}
+
+// decodeArguments converts the argument map to In by way of JSON, so the
+// usual json tags and types apply.
+func decodeArguments[In any](args map[string]interface{}) (In, error) {
+	var in In
+	if args == nil {
+		args = map[string]interface{}{}
+	}
+
+	data, err := json.Marshal(args)
+	if err != nil {
+		return in, fmt.Errorf("arguments are not valid JSON: %w", err)
+	}
+
+	if err := json.Unmarshal(data, &in); err != nil {
+		var typeErr *json.UnmarshalTypeError
+		if errors.As(err, &typeErr) && typeErr.Field != "" {
+			return in, fmt.Errorf("%s: expected %s, got %s", typeErr.Field, typeErr.Type, typeErr.Value)
+		}
+		return in, err
+	}
+	return in, nil
+}
+
+// typedResult converts a tool function's result into a ToolResult.
+func typedResult(out interface{}) (*provider.ToolResult, error) {
+	switch v := out.(type) {
+	case *provider.ToolResult:
+		if v == nil {
+			return &provider.ToolResult{Success: true}, nil
+		}
+		return v, nil
+	case string:
+		return &provider.ToolResult{Success: true, Output: v}, nil
+	}
+
+	data, err := json.Marshal(out)
+	if err != nil {
+		return &provider.ToolResult{
+			Success: false,
+			Error:   fmt.Sprintf("failed to encode result: %v", err),
+		}, nil
+	}
+
+	return &provider.ToolResult{
+		Success: true,
+		Output:  string(data),
+		Content: []provider.ContentBlock{
+			provider.TextBlock(string(data)),
+			{Type: provider.ContentStructured, Structured: out},
+		},
+	}, nil
+}
@@ -0,0 +1,157 @@
+package tool
+
+import (
+	"context"
+	"errors"
+	"reflect"
+	"strings"
+	"testing"
+
+	"agentic-poc/internal/provider"
+)
+
+type schemaBase struct {
+	ID string `json:"id"`
+}
+
+type schemaItem struct {
+	Name string `json:"name"`
+}
+
+type schemaArgs struct {
+	schemaBase
+	Mode     string            `json:"mode" jsonschema:"description=How to run, e.g. fast or slow,enum=fast,enum=slow"`
+	Count    int               `json:"count,omitempty" jsonschema:"minimum=1,maximum=10"`
+	Level    float64           `json:"level" jsonschema:"enum=0.5,enum=1"`
+	Note     *string           `json:"note"`
+	Items    []schemaItem      `json:"items" jsonschema:"minItems=1"`
+	Labels   map[string]string `json:"labels,omitempty"`
+	Extra    interface{}       `json:"extra,omitempty"`
+	Ignored  string            `json:"-"`
+	internal string
+}
+
+func TestSchemaFor(t *testing.T) {
+	got := SchemaFor[schemaArgs]()
+
+	want := map[string]interface{}{
+		"type": "object",
+		"properties": map[string]interface{}{
+			"id": map[string]interface{}{"type": "string"},
+			"mode": map[string]interface{}{
+				"type":        "string",
+				"description": "How to run, e.g. fast or slow",
+				"enum":        []interface{}{"fast", "slow"},
+			},
+			"count": map[string]interface{}{"type": "integer", "minimum": 1.0, "maximum": 10.0},
+			"level": map[string]interface{}{"type": "number", "enum": []interface{}{0.5, 1.0}},
+			"note":  map[string]interface{}{"type": "string"},
+			"items": map[string]interface{}{
+				"type":     "array",
+				"minItems": 1.0,
+				"items": map[string]interface{}{
+					"type":       "object",
+					"properties": map[string]interface{}{"name": map[string]interface{}{"type": "string"}},
+					"required":   []string{"name"},
+				},
+			},
+			"labels": map[string]interface{}{
+				"type":                 "object",
+				"additionalProperties": map[string]interface{}{"type": "string"},
+			},
+			"extra": map[string]interface{}{},
+		},
+		"required": []string{"id", "mode", "level", "items"},
+	}
+
+	if !reflect.DeepEqual(got, want) {
+		t.Errorf("SchemaFor() =\n%#v\nwant\n%#v", got, want)
+	}
+}
+
+type recursiveArgs struct {
+	Children []recursiveArgs `json:"children"`
+}
+
+func TestSchemaFor_Panics(t *testing.T) {
+	tests := []struct {
+		name   string
+		schema func()
+	}{
+		{"recursive type", func() { SchemaFor[recursiveArgs]() }},
+		{"unsupported type", func() { SchemaFor[struct{ C chan int }]() }},
+		{"unknown tag key", func() {
+			SchemaFor[struct {
+				A string `json:"a" jsonschema:"format=email"`
+			}]()
+		}},
+		{"non-numeric bound", func() {
+			SchemaFor[struct {
+				A int `json:"a" jsonschema:"minimum=one"`
+			}]()
+		}},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			defer func() {
+				if recover() == nil {
+					t.Error("expected panic")
+				}
+			}()
+			tt.schema()
+		})
+	}
+}
+
+type greetArgs struct {
+	Name  string `json:"name" jsonschema:"minLength=1"`
+	Times int    `json:"times,omitempty" jsonschema:"minimum=1"`
+}
+
+type greeting struct {
+	Text string `json:"text"`
+}
+
+func TestTypedTool_Execute(t *testing.T) {
+	greet := NewTyped("greet", "Greets someone", func(ctx context.Context, in greetArgs) (greeting, error) {
+		if in.Name == "nobody" {
+			return greeting{}, errors.New("cannot greet nobody")
+		}
+		return greeting{Text: strings.Repeat("hi "+in.Name+" ", max(in.Times, 1))}, nil
+	})
+	ctx := context.Background()
+
+	result, _ := greet.Execute(ctx, map[string]interface{}{"name": "sam", "times": 2.0})
+	if !result.Success || result.Output != `{"text":"hi sam hi sam "}` {
+		t.Fatalf("unexpected result: %+v", result)
+	}
+	if len(result.Content) != 2 || result.Content[1].Structured != (greeting{Text: "hi sam hi sam "}) {
+		t.Errorf("expected text and structured content, got %+v", result.Content)
+	}
+
+	result, _ = greet.Execute(ctx, map[string]interface{}{"name": "nobody"})
+	if result.Success || result.Error != "cannot greet nobody" {
+		t.Errorf("expected the function's error, got %+v", result)
+	}
+
+	result, _ = greet.Execute(ctx, map[string]interface{}{"times": 0.0})
+	if result.Success || result.Error != "invalid arguments: name: is required; times: must be >= 1, got 0" {
+		t.Errorf("expected validation errors, got %+v", result)
+	}
+}
+
+func TestTypedTool_ResultPassthrough(t *testing.T) {
+	image := provider.ImageBlock("image/png", "iVBORw0=")
+	snap := NewTyped("snap", "Takes a picture", func(ctx context.Context, in struct{}) (*provider.ToolResult, error) {
+		return &provider.ToolResult{Success: true, Output: "took it", Content: []provider.ContentBlock{image}}, nil
+	})
+
+	result, _ := snap.Execute(context.Background(), nil)
+	if !result.Success || result.Output != "took it" || len(result.Content) != 1 {
+		t.Errorf("expected the tool's own result, got %+v", result)
+	}
+	if params := snap.Parameters(); params["type"] != "object" {
+		t.Errorf("expected an object schema for an empty struct, got %v", params)
+	}
+}